@@ -0,0 +1,569 @@
+package ansible
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	groupAll       = "all"
+	groupUngrouped = "ungrouped"
+	hostVariable   = "ansible_host"
+)
+
+// Inventory holds the hosts of an ansible inventory along with their variables and group membership
+// Groups are flattened so that a group lists every host of its child groups as well
+type Inventory struct {
+	Hosts  map[string]map[string]string
+	Groups map[string][]string
+}
+
+// NewInventory creates an empty inventory
+func NewInventory() *Inventory {
+	return &Inventory{Hosts: map[string]map[string]string{}, Groups: map[string][]string{}}
+}
+
+// ReadInventoryFile reads an INI or YAML inventory, picking the format from the file extension
+func ReadInventoryFile(fileName string) (*Inventory, error) {
+	inventoryData, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	extension := strings.ToLower(filepath.Ext(fileName))
+	if extension == ".yml" || extension == ".yaml" {
+		return ParseYAML(inventoryData)
+	}
+
+	return ParseINI(inventoryData)
+}
+
+// Address returns the address ansible would connect to for a host and whether it was set explicitly
+func (inventory *Inventory) Address(hostName string) (string, bool) {
+	address, exists := inventory.Hosts[hostName][hostVariable]
+	if !exists || address == "" {
+		return hostName, false
+	}
+
+	return address, true
+}
+
+// HostNames returns the sorted names of all hosts in the inventory
+func (inventory *Inventory) HostNames() []string {
+	hostNames := make([]string, 0, len(inventory.Hosts))
+	for hostName := range inventory.Hosts {
+		hostNames = append(hostNames, hostName)
+	}
+
+	sort.Strings(hostNames)
+	return hostNames
+}
+
+// GroupNames returns the sorted names of all groups except the implicit all and ungrouped groups
+func (inventory *Inventory) GroupNames() []string {
+	groupNames := make([]string, 0, len(inventory.Groups))
+	for groupName := range inventory.Groups {
+		if groupName != groupAll && groupName != groupUngrouped {
+			groupNames = append(groupNames, groupName)
+		}
+	}
+
+	sort.Strings(groupNames)
+	return groupNames
+}
+
+// AddHost adds a host to the inventory, merging its variables with any already known
+func (inventory *Inventory) AddHost(hostName string, vars map[string]string) {
+	if _, exists := inventory.Hosts[hostName]; !exists {
+		inventory.Hosts[hostName] = map[string]string{}
+	}
+
+	for name, value := range vars {
+		inventory.Hosts[hostName][name] = value
+	}
+}
+
+// AddToGroup adds a host to a group if it is not already a member
+func (inventory *Inventory) AddToGroup(groupName, hostName string) {
+	for _, member := range inventory.Groups[groupName] {
+		if member == hostName {
+			return
+		}
+	}
+
+	inventory.Groups[groupName] = append(inventory.Groups[groupName], hostName)
+}
+
+// ParseINI parses an INI style inventory
+func ParseINI(data []byte) (*Inventory, error) {
+	inventory := NewInventory()
+	children := map[string][]string{}
+	groupVars := map[string]map[string]string{}
+	groupName := groupUngrouped
+	sectionType := "hosts"
+	for index, rawLine := range strings.Split(string(data), "\n") {
+		line := strings.TrimSpace(rawLine)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("Invalid section header on line %d: %s", index+1, line)
+			}
+
+			groupName, sectionType = parseSectionHeader(line[1 : len(line)-1])
+			if _, known := sectionLineKinds[sectionType]; !known {
+				return nil, fmt.Errorf("Invalid section type on line %d: %s", index+1, sectionType)
+			}
+
+			if _, exists := inventory.Groups[groupName]; !exists {
+				inventory.Groups[groupName] = []string{}
+			}
+
+			continue
+		}
+
+		err := parseINISectionLine(inventory, children, groupVars, groupName, sectionType, line)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s on line %d: %v", sectionLineKinds[sectionType], index+1, err)
+		}
+	}
+
+	flattenChildren(inventory, children)
+	applyGroupVars(inventory, groupVars, children)
+	return inventory, nil
+}
+
+// sectionLineKinds names the lines each section type holds
+var sectionLineKinds = map[string]string{"hosts": "host", "children": "child group", "vars": "variable"}
+
+// parseINISectionLine adds a line to the inventory as a host, child group or variable depending on the section it is in
+func parseINISectionLine(
+	inventory *Inventory,
+	children map[string][]string,
+	groupVars map[string]map[string]string,
+	groupName,
+	sectionType,
+	line string,
+) error {
+	switch sectionType {
+	case "hosts":
+		return parseINIHostLine(inventory, groupName, line)
+	case "children":
+		children[groupName] = append(children[groupName], strings.Fields(line)[0])
+	case "vars":
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("expected key=value but found %s", line)
+		}
+
+		if groupVars[groupName] == nil {
+			groupVars[groupName] = map[string]string{}
+		}
+
+		groupVars[groupName][strings.TrimSpace(parts[0])] = strings.Trim(strings.TrimSpace(parts[1]), `"'`)
+	}
+
+	return nil
+}
+
+func parseSectionHeader(header string) (string, string) {
+	parts := strings.SplitN(header, ":", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+
+	return parts[0], "hosts"
+}
+
+func parseINIHostLine(inventory *Inventory, groupName, line string) error {
+	fields := strings.Fields(line)
+	vars := map[string]string{}
+	for _, field := range fields[1:] {
+		if strings.HasPrefix(field, "#") {
+			break
+		}
+
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("expected key=value but found %s", field)
+		}
+
+		vars[parts[0]] = strings.Trim(parts[1], `"'`)
+	}
+
+	hostNames, err := expandHostPattern(fields[0])
+	if err != nil {
+		return err
+	}
+
+	for _, hostName := range hostNames {
+		inventory.AddHost(hostName, vars)
+		inventory.AddToGroup(groupName, hostName)
+	}
+
+	return nil
+}
+
+var hostRangeRegex = regexp.MustCompile(`\[([0-9]+):([0-9]+)\]`)
+
+// expandHostPattern expands numeric ranges such as www[01:03].example.com
+func expandHostPattern(pattern string) ([]string, error) {
+	match := hostRangeRegex.FindStringSubmatchIndex(pattern)
+	if match == nil {
+		return []string{pattern}, nil
+	}
+
+	startString := pattern[match[2]:match[3]]
+	start, _ := strconv.Atoi(startString)
+	end, _ := strconv.Atoi(pattern[match[4]:match[5]])
+	if end < start {
+		return nil, fmt.Errorf("invalid range in %s", pattern)
+	}
+
+	hostNames := make([]string, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		number := strconv.Itoa(i)
+		if len(startString) > 1 && strings.HasPrefix(startString, "0") {
+			number = fmt.Sprintf("%0*d", len(startString), i)
+		}
+
+		expanded, err := expandHostPattern(pattern[:match[0]] + number + pattern[match[1]:])
+		if err != nil {
+			return nil, err
+		}
+
+		hostNames = append(hostNames, expanded...)
+	}
+
+	return hostNames, nil
+}
+
+// ParseYAML parses a YAML style inventory
+func ParseYAML(data []byte) (*Inventory, error) {
+	var document interface{}
+	err := yaml.Unmarshal(data, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := NewInventory()
+	if document == nil {
+		return inventory, nil
+	}
+
+	groups, isMapping := yamlMapping(document)
+	if !isMapping {
+		return nil, fmt.Errorf("Inventory must be a mapping of groups")
+	}
+
+	children := map[string][]string{}
+	groupVars := map[string]map[string]string{}
+	for groupName, group := range groups {
+		err = parseYAMLGroup(inventory, children, groupVars, groupName, group)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	flattenChildren(inventory, children)
+	applyGroupVars(inventory, groupVars, children)
+	return inventory, nil
+}
+
+func parseYAMLGroup(inventory *Inventory, children map[string][]string, groupVars map[string]map[string]string, groupName string, group interface{}) error {
+	if _, exists := inventory.Groups[groupName]; !exists {
+		inventory.Groups[groupName] = []string{}
+	}
+
+	if group == nil {
+		return nil
+	}
+
+	groupData, isMapping := yamlMapping(group)
+	if !isMapping {
+		return fmt.Errorf("Group %s must be a mapping", groupName)
+	}
+
+	hosts, _ := yamlMapping(groupData["hosts"])
+	for hostName, hostVars := range hosts {
+		inventory.AddHost(hostName, yamlVars(hostVars))
+		inventory.AddToGroup(groupName, hostName)
+	}
+
+	if vars := yamlVars(groupData["vars"]); len(vars) > 0 {
+		if groupVars[groupName] == nil {
+			groupVars[groupName] = map[string]string{}
+		}
+
+		for name, value := range vars {
+			groupVars[groupName][name] = value
+		}
+	}
+
+	childGroups, _ := yamlMapping(groupData["children"])
+	for childName, child := range childGroups {
+		children[groupName] = append(children[groupName], childName)
+		err := parseYAMLGroup(inventory, children, groupVars, childName, child)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// yamlVars reads the scalar variables of a host or group, other values are ignored
+func yamlVars(value interface{}) map[string]string {
+	vars := map[string]string{}
+	if varsData, isMapping := yamlMapping(value); isMapping {
+		for name, item := range varsData {
+			if itemString, isScalar := yamlScalar(item); isScalar {
+				vars[name] = itemString
+			}
+		}
+	}
+
+	return vars
+}
+
+// yamlMapping converts a decoded YAML mapping to one with string keys, an empty value like "hosts:" is an empty mapping
+func yamlMapping(value interface{}) (map[string]interface{}, bool) {
+	if value == nil {
+		return map[string]interface{}{}, true
+	}
+
+	decoded, isMapping := value.(map[interface{}]interface{})
+	if !isMapping {
+		return nil, false
+	}
+
+	mapping := make(map[string]interface{}, len(decoded))
+	for key, item := range decoded {
+		mapping[fmt.Sprint(key)] = item
+	}
+
+	return mapping, true
+}
+
+// yamlScalar formats a YAML scalar like 22 or true the way it was written in the inventory
+func yamlScalar(value interface{}) (string, bool) {
+	switch value.(type) {
+	case string, int, int64, uint64, float64, bool:
+		return fmt.Sprint(value), true
+	}
+
+	return "", false
+}
+
+// flattenChildren adds the hosts of every child group to its parents
+func flattenChildren(inventory *Inventory, children map[string][]string) {
+	var collect func(groupName string, seen map[string]bool) []string
+	collect = func(groupName string, seen map[string]bool) []string {
+		if seen[groupName] {
+			return nil
+		}
+
+		seen[groupName] = true
+		hostNames := append([]string{}, inventory.Groups[groupName]...)
+		childNames := append([]string{}, children[groupName]...)
+		sort.Strings(childNames)
+		for _, childName := range childNames {
+			hostNames = append(hostNames, collect(childName, seen)...)
+		}
+
+		return hostNames
+	}
+
+	flattened := map[string][]string{}
+	for groupName := range inventory.Groups {
+		flattened[groupName] = collect(groupName, map[string]bool{})
+	}
+
+	for groupName, hostNames := range flattened {
+		inventory.Groups[groupName] = []string{}
+		for _, hostName := range hostNames {
+			inventory.AddToGroup(groupName, hostName)
+		}
+	}
+}
+
+// applyGroupVars gives hosts the variables of their groups that they do not set themselves
+// As in ansible a child group wins over its parents, groups at the same depth are merged by name and all is the weakest
+func applyGroupVars(inventory *Inventory, groupVars map[string]map[string]string, children map[string][]string) {
+	depths := groupDepths(children)
+	groupNames := make([]string, 0, len(groupVars))
+	for groupName := range groupVars {
+		groupNames = append(groupNames, groupName)
+	}
+
+	// The strongest group goes first, every later group only fills in what is still missing
+	sort.Slice(groupNames, func(i, j int) bool {
+		if depths(groupNames[i]) != depths(groupNames[j]) {
+			return depths(groupNames[i]) > depths(groupNames[j])
+		}
+
+		return groupNames[i] > groupNames[j]
+	})
+
+	for _, groupName := range groupNames {
+		members := inventory.Groups[groupName]
+		if groupName == groupAll {
+			members = inventory.HostNames()
+		}
+
+		for _, hostName := range members {
+			for name, value := range groupVars[groupName] {
+				if _, exists := inventory.Hosts[hostName][name]; !exists {
+					inventory.Hosts[hostName][name] = value
+				}
+			}
+		}
+	}
+}
+
+// groupDepths returns how far a group is from all, a group no other group lists as a child is a direct child of all
+func groupDepths(children map[string][]string) func(groupName string) int {
+	parents := map[string][]string{}
+	for parentName, childNames := range children {
+		for _, childName := range childNames {
+			parents[childName] = append(parents[childName], parentName)
+		}
+	}
+
+	var depth func(groupName string, seen map[string]bool) int
+	depth = func(groupName string, seen map[string]bool) int {
+		if groupName == groupAll || seen[groupName] {
+			return 0
+		}
+
+		seen[groupName] = true
+		defer delete(seen, groupName)
+		deepest := 0
+		for _, parentName := range parents[groupName] {
+			if parentDepth := depth(parentName, seen); parentDepth > deepest {
+				deepest = parentDepth
+			}
+		}
+
+		return deepest + 1
+	}
+
+	return func(groupName string) int {
+		return depth(groupName, map[string]bool{})
+	}
+}
+
+// ungroupedHosts returns the hosts that are not a member of any named group
+func (inventory *Inventory) ungroupedHosts() []string {
+	grouped := map[string]bool{}
+	for _, groupName := range inventory.GroupNames() {
+		for _, hostName := range inventory.Groups[groupName] {
+			grouped[hostName] = true
+		}
+	}
+
+	ungrouped := []string{}
+	for _, hostName := range inventory.HostNames() {
+		if !grouped[hostName] {
+			ungrouped = append(ungrouped, hostName)
+		}
+	}
+
+	return ungrouped
+}
+
+func sortedMembers(members []string) []string {
+	sorted := append([]string{}, members...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func sortedVarNames(vars map[string]string) []string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// WriteINI writes the inventory in INI format
+func (inventory *Inventory) WriteINI(writer io.Writer) error {
+	lines := []string{}
+	for _, hostName := range inventory.ungroupedHosts() {
+		lines = append(lines, inventory.iniHostLine(hostName))
+	}
+
+	for _, groupName := range inventory.GroupNames() {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+
+		lines = append(lines, fmt.Sprintf("[%s]", groupName))
+		for _, hostName := range sortedMembers(inventory.Groups[groupName]) {
+			lines = append(lines, inventory.iniHostLine(hostName))
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	_, err := fmt.Fprintln(writer, strings.Join(lines, "\n"))
+	return err
+}
+
+func (inventory *Inventory) iniHostLine(hostName string) string {
+	fields := []string{hostName}
+	vars := inventory.Hosts[hostName]
+	for _, name := range sortedVarNames(vars) {
+		fields = append(fields, fmt.Sprintf("%s=%s", name, vars[name]))
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// yamlGroup is how a group is written in a YAML inventory, hosts map to their variables
+type yamlGroup struct {
+	Hosts    map[string]map[string]string `yaml:"hosts,omitempty"`
+	Children map[string]yamlGroup         `yaml:"children,omitempty"`
+}
+
+// WriteYAML writes the inventory in YAML format
+func (inventory *Inventory) WriteYAML(writer io.Writer) error {
+	all := yamlGroup{Hosts: inventory.yamlHosts(inventory.ungroupedHosts())}
+	if groupNames := inventory.GroupNames(); len(groupNames) > 0 {
+		all.Children = make(map[string]yamlGroup, len(groupNames))
+		for _, groupName := range groupNames {
+			all.Children[groupName] = yamlGroup{Hosts: inventory.yamlHosts(inventory.Groups[groupName])}
+		}
+	}
+
+	inventoryData, err := yaml.Marshal(map[string]yamlGroup{groupAll: all})
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(inventoryData)
+	return err
+}
+
+func (inventory *Inventory) yamlHosts(hostNames []string) map[string]map[string]string {
+	hosts := make(map[string]map[string]string, len(hostNames))
+	for _, hostName := range hostNames {
+		hosts[hostName] = inventory.Hosts[hostName]
+	}
+
+	return hosts
+}
@@ -0,0 +1,348 @@
+package ansible
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testINIInventory = `# ops inventory
+mail.example.com ansible_host=10.0.0.1
+
+[webservers]
+www[01:02].example.com ansible_port=22
+api.example.com ansible_host="10.0.0.4"
+
+[dbservers]
+db.example.com ansible_host=10.0.0.5 # primary
+
+[prod:children]
+webservers
+dbservers
+
+[prod:vars]
+ntp_server=ntp.example.com
+`
+
+const testYAMLInventory = `all:
+  hosts:
+    mail.example.com:
+      ansible_host: 10.0.0.1
+  children:
+    prod:
+      vars:
+        ntp_server: ntp.example.com
+      children:
+        webservers:
+          hosts:
+            www01.example.com:
+              ansible_port: 22
+            www02.example.com:
+              ansible_port: 22
+            api.example.com:
+              ansible_host: 10.0.0.4
+        dbservers:
+          hosts:
+            db.example.com:
+              ansible_host: 10.0.0.5
+`
+
+func expectedInventory() *Inventory {
+	return &Inventory{
+		Hosts: map[string]map[string]string{
+			"mail.example.com":  {"ansible_host": "10.0.0.1"},
+			"www01.example.com": {"ansible_port": "22", "ntp_server": "ntp.example.com"},
+			"www02.example.com": {"ansible_port": "22", "ntp_server": "ntp.example.com"},
+			"api.example.com":   {"ansible_host": "10.0.0.4", "ntp_server": "ntp.example.com"},
+			"db.example.com":    {"ansible_host": "10.0.0.5", "ntp_server": "ntp.example.com"},
+		},
+		Groups: map[string][]string{},
+	}
+}
+
+func TestParseINI(t *testing.T) {
+	inventory, err := ParseINI([]byte(testINIInventory))
+	assert.Nil(t, err)
+
+	expected := expectedInventory()
+	assert.Equal(t, expected.Hosts, inventory.Hosts)
+	assert.Equal(t, []string{"dbservers", "prod", "webservers"}, inventory.GroupNames())
+	assert.Equal(t, []string{"mail.example.com"}, inventory.Groups["ungrouped"])
+	assert.Equal(t, []string{"www01.example.com", "www02.example.com", "api.example.com"}, inventory.Groups["webservers"])
+	assert.Equal(t, []string{"db.example.com", "www01.example.com", "www02.example.com", "api.example.com"}, inventory.Groups["prod"])
+}
+
+func TestParseYAML(t *testing.T) {
+	inventory, err := ParseYAML([]byte(testYAMLInventory))
+	assert.Nil(t, err)
+
+	expected := expectedInventory()
+	assert.Equal(t, expected.Hosts, inventory.Hosts)
+	assert.Equal(t, []string{"dbservers", "prod", "webservers"}, inventory.GroupNames())
+	assert.Equal(t, []string{"db.example.com"}, inventory.Groups["dbservers"])
+	assert.Equal(t, 3, len(inventory.Groups["webservers"]))
+	assert.Equal(t, 4, len(inventory.Groups["prod"]))
+	assert.Equal(t, 5, len(inventory.Groups["all"]))
+}
+
+func TestParseYAMLAnchorsAndQuotes(t *testing.T) {
+	inventory, err := ParseYAML([]byte(`---
+all:
+  vars: &defaults
+    ansible_port: 22
+  hosts:
+    "web\x2d1.example.com": {ansible_host: "10.0.0.1", <<: *defaults}
+    web-2.example.com:
+      <<: *defaults
+      ansible_host: '10.0.0.2'
+      ansible_ssh_common_args: >-
+        -o StrictHostKeyChecking=no
+        -o ProxyCommand="ssh bastion"
+`))
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		map[string]map[string]string{
+			"web-1.example.com": {"ansible_host": "10.0.0.1", "ansible_port": "22"},
+			"web-2.example.com": {
+				"ansible_host":            "10.0.0.2",
+				"ansible_port":            "22",
+				"ansible_ssh_common_args": `-o StrictHostKeyChecking=no -o ProxyCommand="ssh bastion"`,
+			},
+		},
+		inventory.Hosts,
+	)
+}
+
+func TestParseINIGroupVars(t *testing.T) {
+	inventory, err := ParseINI([]byte(`[web]
+web1.example.com
+web2.example.com ansible_host=10.0.0.2
+
+[canary]
+web1.example.com
+
+[web:children]
+canary
+
+[prod:children]
+web
+
+[all:vars]
+ansible_user=deploy
+
+[prod:vars]
+ansible_host = "10.0.0.9"
+ansible_user=ops
+
+[web:vars]
+ansible_host=10.0.0.1
+
+[canary:vars]
+ansible_host='10.0.0.8'
+`))
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		map[string]map[string]string{
+			"web1.example.com": {"ansible_host": "10.0.0.8", "ansible_user": "ops"},
+			"web2.example.com": {"ansible_host": "10.0.0.2", "ansible_user": "ops"},
+		},
+		inventory.Hosts,
+	)
+}
+
+func TestParseYAMLGroupVars(t *testing.T) {
+	inventory, err := ParseYAML([]byte(`all:
+  vars:
+    ansible_user: deploy
+  children:
+    web:
+      vars:
+        ansible_host: 10.0.0.1
+      hosts:
+        web1.example.com:
+        web2.example.com:
+          ansible_host: 10.0.0.2
+`))
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		map[string]map[string]string{
+			"web1.example.com": {"ansible_host": "10.0.0.1", "ansible_user": "deploy"},
+			"web2.example.com": {"ansible_host": "10.0.0.2", "ansible_user": "deploy"},
+		},
+		inventory.Hosts,
+	)
+}
+
+func TestParseYAMLInvalid(t *testing.T) {
+	_, err := ParseYAML([]byte("all:\n  hosts: [a\n"))
+	assert.NotNil(t, err)
+}
+
+func TestParseYAMLEmpty(t *testing.T) {
+	inventory, err := ParseYAML([]byte(""))
+	assert.Nil(t, err)
+	assert.Equal(t, NewInventory(), inventory)
+}
+
+func TestParseYAMLNotAMapping(t *testing.T) {
+	_, err := ParseYAML([]byte("- all\n"))
+	assert.EqualError(t, err, "Inventory must be a mapping of groups")
+}
+
+func TestParseYAMLBadGroup(t *testing.T) {
+	_, err := ParseYAML([]byte("all:\n  children:\n    web: [a]\n"))
+	assert.EqualError(t, err, "Group web must be a mapping")
+}
+
+func TestParseINIBadHost(t *testing.T) {
+	_, err := ParseINI([]byte("[web]\nfoo.example.com ansible_host\n"))
+	assert.EqualError(t, err, "Invalid host on line 2: expected key=value but found ansible_host")
+}
+
+func TestParseINIBadRange(t *testing.T) {
+	_, err := ParseINI([]byte("www[3:1].example.com\n"))
+	assert.EqualError(t, err, "Invalid host on line 1: invalid range in www[3:1].example.com")
+}
+
+func TestParseINIBadSection(t *testing.T) {
+	_, err := ParseINI([]byte("[web\n"))
+	assert.EqualError(t, err, "Invalid section header on line 1: [web")
+}
+
+func TestParseINIBadVariable(t *testing.T) {
+	_, err := ParseINI([]byte("[web:vars]\nansible_host\n"))
+	assert.EqualError(t, err, "Invalid variable on line 2: expected key=value but found ansible_host")
+}
+
+func TestParseINIBadSectionType(t *testing.T) {
+	_, err := ParseINI([]byte("[web:hostvars]\nfoo.example.com\n"))
+	assert.EqualError(t, err, "Invalid section type on line 1: hostvars")
+}
+
+func TestAddress(t *testing.T) {
+	inventory, err := ParseINI([]byte(testINIInventory))
+	assert.Nil(t, err)
+
+	address, explicit := inventory.Address("api.example.com")
+	assert.Equal(t, "10.0.0.4", address)
+	assert.True(t, explicit)
+
+	address, explicit = inventory.Address("www01.example.com")
+	assert.Equal(t, "www01.example.com", address)
+	assert.False(t, explicit)
+}
+
+func TestReadInventoryFile(t *testing.T) {
+	for _, testCase := range []struct{ extension, inventory string }{{".ini", testINIInventory}, {".yml", testYAMLInventory}} {
+		inventoryFile, err := ioutil.TempFile("/tmp", "inventory")
+		assert.Nil(t, err)
+		fileName := inventoryFile.Name() + testCase.extension
+		assert.Nil(t, os.Rename(inventoryFile.Name(), fileName))
+		assert.Nil(t, ioutil.WriteFile(fileName, []byte(testCase.inventory), 0644))
+
+		inventory, err := ReadInventoryFile(fileName)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedInventory().Hosts, inventory.Hosts)
+		assert.Nil(t, os.Remove(fileName))
+	}
+}
+
+func TestReadInventoryFileMissingFile(t *testing.T) {
+	_, err := ReadInventoryFile("/tmp/doesntexist.ini")
+	assert.EqualError(t, err, "open /tmp/doesntexist.ini: no such file or directory")
+}
+
+func buildExportInventory() *Inventory {
+	inventory := NewInventory()
+	inventory.AddHost("solo.example.com", map[string]string{"ansible_host": "10.0.0.1"})
+	inventory.AddHost("api.example.com", map[string]string{"ansible_host": "10.0.0.4"})
+	inventory.AddHost("v6.example.com", map[string]string{"ansible_host": "::1"})
+	inventory.AddToGroup("web", "v6.example.com")
+	inventory.AddToGroup("web", "api.example.com")
+	inventory.AddToGroup("web", "api.example.com")
+	return inventory
+}
+
+func TestWriteINI(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.Nil(t, buildExportInventory().WriteINI(&buffer))
+
+	expected := "solo.example.com ansible_host=10.0.0.1\n\n[web]\napi.example.com ansible_host=10.0.0.4\nv6.example.com ansible_host=::1\n"
+	assert.Equal(t, expected, buffer.String())
+
+	roundTrip, err := ParseINI(buffer.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, buildExportInventory().Hosts, roundTrip.Hosts)
+	assert.Equal(t, []string{"api.example.com", "v6.example.com"}, roundTrip.Groups["web"])
+}
+
+func TestWriteYAML(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.Nil(t, buildExportInventory().WriteYAML(&buffer))
+
+	expected := `all:
+  hosts:
+    solo.example.com:
+      ansible_host: 10.0.0.1
+  children:
+    web:
+      hosts:
+        api.example.com:
+          ansible_host: 10.0.0.4
+        v6.example.com:
+          ansible_host: ::1
+`
+	assert.Equal(t, expected, buffer.String())
+
+	roundTrip, err := ParseYAML(buffer.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, buildExportInventory().Hosts, roundTrip.Hosts)
+	assert.Equal(t, []string{"web"}, roundTrip.GroupNames())
+	assert.Equal(t, 2, len(roundTrip.Groups["web"]))
+}
+
+func TestWriteYAMLQuotesKeywords(t *testing.T) {
+	inventory := NewInventory()
+	inventory.AddHost("yes", map[string]string{"ansible_host": "10.0.0.1", "ansible_port": "0755"})
+	inventory.AddHost("null", map[string]string{"ansible_host": "10.0.0.2"})
+	inventory.AddHost("1e3", map[string]string{"ansible_host": "10.0.0.3", "ansible_become": "true"})
+	inventory.AddToGroup("no", "yes")
+	inventory.AddToGroup("no", "null")
+
+	var buffer bytes.Buffer
+	assert.Nil(t, inventory.WriteYAML(&buffer))
+
+	expected := `all:
+  hosts:
+    "1e3":
+      ansible_become: "true"
+      ansible_host: 10.0.0.3
+  children:
+    "no":
+      hosts:
+        "null":
+          ansible_host: 10.0.0.2
+        "yes":
+          ansible_host: 10.0.0.1
+          ansible_port: "0755"
+`
+	assert.Equal(t, expected, buffer.String())
+
+	roundTrip, err := ParseYAML(buffer.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, inventory.Hosts, roundTrip.Hosts)
+	assert.Equal(t, []string{"no"}, roundTrip.GroupNames())
+	assert.Equal(t, []string{"null", "yes"}, sortedMembers(roundTrip.Groups["no"]))
+}
+
+func TestWriteEmptyINI(t *testing.T) {
+	var buffer bytes.Buffer
+	assert.Nil(t, NewInventory().WriteINI(&buffer))
+	assert.Equal(t, "", buffer.String())
+}
@@ -0,0 +1,130 @@
+package awsUtil
+
+import (
+	"io"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// DefaultGroupAddressKinds imports the private address of each group member without a suffix
+const DefaultGroupAddressKinds = "private:"
+
+// AutoScalingGroup is an Auto Scaling group and its in service instances, ordered by launch time
+type AutoScalingGroup struct {
+	Name      string
+	ARN       string
+	Region    string
+	Tags      map[string]string
+	Instances []GroupInstance
+}
+
+// GroupInstance is an instance of an Auto Scaling group and its addresses of each kind
+type GroupInstance struct {
+	ID        string
+	Zone      string
+	Addresses map[string][]string
+}
+
+// ReadAllAutoScalingGroups gets the Auto Scaling groups with the given names in all regions, every group is read when none are given
+// Only instances that are in service are included
+func (util *AwsUtil) ReadAllAutoScalingGroups(names []string, warnings io.Writer) ([]AutoScalingGroup, error) {
+	results, err := util.scanRegions(warnings, func(sess *session.Session) (interface{}, error) {
+		return readAutoScalingGroups(sess, names)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []AutoScalingGroup{}
+	for _, result := range results {
+		groups = append(groups, result.([]AutoScalingGroup)...)
+	}
+
+	return groups, nil
+}
+
+func readAutoScalingGroups(sess *session.Session, names []string) ([]AutoScalingGroup, error) {
+	params := &autoscaling.DescribeAutoScalingGroupsInput{}
+	if len(names) != 0 {
+		params.AutoScalingGroupNames = aws.StringSlice(names)
+	}
+
+	descriptions := []*autoscaling.Group{}
+	err := autoscaling.New(sess).DescribeAutoScalingGroupsPages(params, func(resp *autoscaling.DescribeAutoScalingGroupsOutput, lastPage bool) bool {
+		descriptions = append(descriptions, resp.AutoScalingGroups...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	instanceIDs := []string{}
+	for _, description := range descriptions {
+		for _, instance := range description.Instances {
+			if aws.StringValue(instance.LifecycleState) == autoscaling.LifecycleStateInService {
+				instanceIDs = append(instanceIDs, aws.StringValue(instance.InstanceId))
+			}
+		}
+	}
+
+	instances, err := describeInstances(sess, instanceIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]AutoScalingGroup, 0, len(descriptions))
+	for _, description := range descriptions {
+		group := AutoScalingGroup{
+			Name:   aws.StringValue(description.AutoScalingGroupName),
+			ARN:    aws.StringValue(description.AutoScalingGroupARN),
+			Region: aws.StringValue(sess.Config.Region),
+			Tags:   map[string]string{},
+		}
+
+		for _, tag := range description.Tags {
+			group.Tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+		}
+
+		members := []*ec2.Instance{}
+		for _, groupInstance := range description.Instances {
+			instance, exists := instances[aws.StringValue(groupInstance.InstanceId)]
+			if exists && aws.StringValue(groupInstance.LifecycleState) == autoscaling.LifecycleStateInService {
+				members = append(members, instance)
+			}
+		}
+
+		sort.Slice(members, func(i, j int) bool {
+			iLaunched, jLaunched := aws.TimeValue(members[i].LaunchTime), aws.TimeValue(members[j].LaunchTime)
+			if !iLaunched.Equal(jLaunched) {
+				return iLaunched.Before(jLaunched)
+			}
+
+			return aws.StringValue(members[i].InstanceId) < aws.StringValue(members[j].InstanceId)
+		})
+
+		for _, member := range members {
+			groupInstance := GroupInstance{ID: aws.StringValue(member.InstanceId), Addresses: map[string][]string{}}
+			if member.Placement != nil {
+				groupInstance.Zone = aws.StringValue(member.Placement.AvailabilityZone)
+			}
+
+			for _, kind := range InstanceAddressKinds {
+				groupInstance.Addresses[kind] = addressesOfKind(member, kind)
+			}
+
+			group.Instances = append(group.Instances, groupInstance)
+		}
+
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups, nil
+}
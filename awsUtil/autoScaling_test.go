@@ -0,0 +1,42 @@
+package awsUtil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadAllAutoScalingGroups(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	util := newTestAwsUtil(t, server, "us-east-1")
+	groups, err := util.ReadAllAutoScalingGroups([]string{"web-us-east-1"}, new(bytes.Buffer))
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]AutoScalingGroup{
+			{
+				Name:   "web-us-east-1",
+				ARN:    "arn:aws:autoscaling:us-east-1:1:autoScalingGroup:1:autoScalingGroupName/web-us-east-1",
+				Region: "us-east-1",
+				Tags:   map[string]string{"env": "prod"},
+				Instances: []GroupInstance{
+					{
+						ID: "i-us-east-1",
+						Addresses: map[string][]string{
+							PublicAddress:    {"54.0.0.1"},
+							PrivateAddress:   {"10.0.0.1"},
+							SecondaryAddress: {},
+							IPv6Address:      {},
+							ElasticAddress:   {},
+						},
+					},
+				},
+			},
+		},
+		groups,
+	)
+	assert.Equal(t, []string{"us-east-1 DescribeAutoScalingGroups", "us-east-1 DescribeInstances", "us-east-1 DescribeRegions"}, server.sortedRequests())
+}
@@ -4,19 +4,33 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
 	"text/template"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
-	"github.com/aws/aws-sdk-go/service/elb"
-	"github.com/go-ini/ini"
 )
 
+// DefaultConcurrency is how many regions are scanned at the same time
+const DefaultConcurrency = 4
+
+// regionsRegion is the region asked for the list of regions
+const regionsRegion = "us-east-1"
+
 // AwsUtil handles connecting to AWS and retrieving host data
 type AwsUtil struct {
 	profileName string
+	regions     RegionFilter
+	endpoint    string
+	base        *session.Session
+	sessions    map[string]*session.Session
+	mutex       sync.Mutex
 }
 
 // NewAwsUtil creates a new awsUtil with a given profileName
@@ -26,142 +40,185 @@ func NewAwsUtil(profileName string) *AwsUtil {
 	return util
 }
 
-// ReadAllLoadBalancers gets the load balancer information for all regions
-func (util *AwsUtil) ReadAllLoadBalancers() (map[string]string, error) {
-	loadBalancerHostNames := make(map[string]string)
-	regions, err := util.getRegions()
+// ReadAllLoadBalancers gets the load balancers matching the filter in all regions
+func (util *AwsUtil) ReadAllLoadBalancers(filter LoadBalancerFilter, warnings io.Writer) ([]LoadBalancer, error) {
+	results, err := util.scanRegions(warnings, func(sess *session.Session) (interface{}, error) {
+		return readLoadBalancers(sess, filter)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	for _, region := range regions {
-		regionLbs, err := util.readLoadBalancers(region)
-		if err != nil {
-			return nil, err
-		}
-
-		for name, address := range regionLbs {
-			loadBalancerHostNames[name] = address
-		}
+	loadBalancers := []LoadBalancer{}
+	for _, result := range results {
+		loadBalancers = append(loadBalancers, result.([]LoadBalancer)...)
 	}
 
-	return loadBalancerHostNames, nil
+	return loadBalancers, nil
 }
 
-// ReadAllInstances gets the instance information for all regions
-func (util *AwsUtil) ReadAllInstances(templ *template.Template) (map[string]string, error) {
-	instanceIPs := make(map[string]string)
-	regions, err := util.getRegions()
+// ReadAllInstances gets the addresses of each kind for the instances in all regions, only reading instances that match the filter
+// The addresses are sorted by name and instances whose names collide with another instance are reported as warnings
+func (util *AwsUtil) ReadAllInstances(templ *template.Template, filter InstanceFilter, kinds []AddressKind, warnings io.Writer) ([]InstanceAddress, error) {
+	results, err := util.scanRegions(warnings, func(sess *session.Session) (interface{}, error) {
+		return readInstances(sess, templ, filter, kinds)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	for _, region := range regions {
-		regionInstances, err := util.readInstances(region, templ)
-		if err != nil {
-			return nil, err
+	all := newNamedInstances()
+	for _, result := range results {
+		regionInstances := result.(*namedInstances)
+		all.warnings = append(all.warnings, regionInstances.warnings...)
+
+		names := make([]string, 0, len(regionInstances.addresses))
+		for name := range regionInstances.addresses {
+			names = append(names, name)
 		}
 
-		for name, address := range regionInstances {
-			instanceIPs[name] = address
+		sort.Strings(names)
+		for _, name := range names {
+			all.add(regionInstances.addresses[name])
 		}
 	}
 
-	return instanceIPs, nil
+	for _, warning := range all.warnings {
+		fmt.Fprintln(warnings, warning)
+	}
+
+	addresses := make([]InstanceAddress, 0, len(all.addresses))
+	for _, address := range all.addresses {
+		addresses = append(addresses, address)
+	}
+
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Name < addresses[j].Name
+	})
+
+	return addresses, nil
 }
 
-// ListAllProfiles lists all available aws credential profiles
+// ListAllProfiles lists the profiles of the shared credentials and config files, including role and SSO profiles
 func (util *AwsUtil) ListAllProfiles() ([]string, error) {
-	scp := credentials.SharedCredentialsProvider{}
-	_, err := scp.Retrieve()
+	shared, err := loadSharedProfiles()
 	if err != nil {
 		return nil, err
 	}
 
-	config, err := ini.Load(scp.Filename)
+	return shared.names(), nil
+}
+
+// scanRegions calls scan for every region matching the filter, at most DefaultConcurrency at a time
+// The results of the regions that succeed are returned in region order
+// Regions that fail are reported as warnings, an error is only returned when every region fails
+func (util *AwsUtil) scanRegions(warnings io.Writer, scan func(sess *session.Session) (interface{}, error)) ([]interface{}, error) {
+	regions, err := util.getRegions()
 	if err != nil {
 		return nil, err
 	}
 
-	return config.SectionStrings(), nil
-}
+	results := make([]interface{}, len(regions))
+	errs := make([]error, len(regions))
+	semaphore := make(chan struct{}, DefaultConcurrency)
+	var wait sync.WaitGroup
+	for index, region := range regions {
+		sess, err := util.session(region)
+		if err != nil {
+			errs[index] = err
+			continue
+		}
 
-func (util *AwsUtil) readLoadBalancers(region string) (map[string]string, error) {
-	sess, err := session.NewSessionWithOptions(session.Options{
-		Config:  aws.Config{Region: aws.String(region)},
-		Profile: util.profileName,
-	})
-	if err != nil {
-		return nil, errors.New("Failed to create session")
+		wait.Add(1)
+		go func(index int, sess *session.Session) {
+			defer wait.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			results[index], errs[index] = scan(sess)
+		}(index, sess)
 	}
 
-	svc := elb.New(sess)
+	wait.Wait()
 
-	params := &elb.DescribeLoadBalancersInput{}
-	loadBalancers := make([]*elb.LoadBalancerDescription, 0, 10)
-	err = svc.DescribeLoadBalancersPages(params, func(resp *elb.DescribeLoadBalancersOutput, lastPage bool) bool {
-		loadBalancers = append(loadBalancers, resp.LoadBalancerDescriptions...)
-		return lastPage
-	})
-	if err != nil {
-		return nil, err
+	succeeded := make([]interface{}, 0, len(regions))
+	for index, region := range regions {
+		if errs[index] != nil {
+			fmt.Fprintf(warnings, "Warning: %v, skipping region %s\n", errs[index], region)
+			continue
+		}
+
+		succeeded = append(succeeded, results[index])
 	}
 
-	loadBalancerHostNames := make(map[string]string)
-	for _, loadBalancer := range loadBalancers {
-		loadBalancerHostNames[*loadBalancer.LoadBalancerName] = *loadBalancer.DNSName
+	if len(succeeded) == 0 {
+		return nil, errs[0]
 	}
 
-	return loadBalancerHostNames, nil
+	return succeeded, nil
 }
 
-func (util *AwsUtil) readInstances(region string, templ *template.Template) (map[string]string, error) {
-	sess, err := session.NewSessionWithOptions(session.Options{
-		Config:  aws.Config{Region: aws.String(region)},
-		Profile: util.profileName,
-	})
+func readInstances(sess *session.Session, templ *template.Template, filter InstanceFilter, kinds []AddressKind) (*namedInstances, error) {
+	svc := ec2.New(sess)
+
+	// Each region names instances with its own copy of the template because the tag function is replaced for every instance
+	regionTempl, err := templ.Clone()
 	if err != nil {
-		return nil, errors.New("Failed to create session")
+		return nil, err
 	}
 
-	svc := ec2.New(sess)
-
-	params := &ec2.DescribeInstancesInput{}
+	params := &ec2.DescribeInstancesInput{Filters: filter.ec2Filters()}
 	reservations := make([]*ec2.Reservation, 0, 10)
 	err = svc.DescribeInstancesPages(params, func(resp *ec2.DescribeInstancesOutput, lastPage bool) bool {
 		reservations = append(reservations, resp.Reservations...)
 
-		return lastPage
+		return true
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	instanceIPs := parseReservations(reservations, templ)
-	return instanceIPs, nil
+	named := parseReservations(reservations, regionTempl, kinds)
+	for name, address := range named.addresses {
+		address.Region = aws.StringValue(sess.Config.Region)
+		named.addresses[name] = address
+	}
+
+	return named, nil
 }
 
-func parseReservations(reservations []*ec2.Reservation, templ *template.Template) map[string]string {
-	instanceIPs := make(map[string]string)
+// parseReservations names the addresses of each kind for every instance
+// Instances that can't be named are skipped and collisions keep the first instance, both are reported as warnings
+func parseReservations(reservations []*ec2.Reservation, templ *template.Template, kinds []AddressKind) *namedInstances {
+	named := newNamedInstances()
 	for _, reservation := range reservations {
 		instances := reservation.Instances
 		for _, instance := range instances {
+			instanceID := aws.StringValue(instance.InstanceId)
 			name, err := parseInstance(instance, templ)
-			if err == nil {
-				publicIP, err := getPublicIP(instance)
-				if err == nil {
-					instanceIPs[name] = publicIP
-				}
+			if err != nil {
+				named.warn("Warning: Unable to name instance %s: %v, skipping", instanceID, err)
+				continue
+			}
+
+			if name == "" {
+				named.warn("Warning: Instance %s has an empty name, skipping", instanceID)
+				continue
+			}
 
-				privateIP, err := getPrivateIP(instance)
-				if err == nil {
-					instanceIPs[fmt.Sprintf("%s-private", name)] = privateIP
+			tags := instanceTags(instance)
+			primary := true
+			for _, kind := range kinds {
+				addresses := addressesOfKind(instance, kind.Kind)
+				for index, addressName := range kindAddressNames(name, kind, addresses) {
+					named.add(InstanceAddress{Name: addressName, IP: addresses[index], InstanceID: instanceID, Tags: tags, Primary: primary})
+					primary = false
 				}
 			}
 		}
 	}
 
-	return instanceIPs
+	return named
 }
 
 func getPublicIP(instance *ec2.Instance) (string, error) {
@@ -182,7 +239,7 @@ func getPrivateIP(instance *ec2.Instance) (string, error) {
 
 func parseInstance(instance *ec2.Instance, templ *template.Template) (string, error) {
 	var buffer bytes.Buffer
-	err := templ.Execute(&buffer, instance)
+	err := templ.Funcs(instanceFuncs(instance)).Execute(&buffer, instance)
 	if err != nil {
 		return "", err
 	}
@@ -190,11 +247,9 @@ func parseInstance(instance *ec2.Instance, templ *template.Template) (string, er
 	return buffer.String(), nil
 }
 
+// getRegions lists the regions that match the region filter
 func (util *AwsUtil) getRegions() ([]string, error) {
-	sess, err := session.NewSessionWithOptions(session.Options{
-		Config:  aws.Config{Region: aws.String("us-east-1")},
-		Profile: util.profileName,
-	})
+	sess, err := util.session(regionsRegion)
 	if err != nil {
 		return nil, err
 	}
@@ -208,13 +263,105 @@ func (util *AwsUtil) getRegions() ([]string, error) {
 	regions := resultRegions.Regions
 	regionNames := make([]string, 0, len(regions))
 	for _, region := range regions {
-		regionNames = append(regionNames, *region.RegionName)
+		if util.regions.Matches(*region.RegionName) {
+			regionNames = append(regionNames, *region.RegionName)
+		}
+	}
+
+	if len(regionNames) == 0 {
+		return nil, fmt.Errorf("No regions match %s", util.regions)
 	}
 
 	return regionNames, nil
 }
 
+// session creates the session for a region the first time it is needed and reuses it after that
+// Every region copies the same base session, so the profile is only read once and its credentials are shared
+func (util *AwsUtil) session(region string) (*session.Session, error) {
+	util.mutex.Lock()
+	defer util.mutex.Unlock()
+
+	if sess, exists := util.sessions[region]; exists {
+		return sess, nil
+	}
+
+	if util.base == nil {
+		base, err := util.newBaseSession()
+		if err != nil {
+			return nil, err
+		}
+
+		util.base = base
+	}
+
+	if util.sessions == nil {
+		util.sessions = map[string]*session.Session{}
+	}
+
+	sess := util.base.Copy(&aws.Config{Region: aws.String(region)})
+	util.sessions[region] = sess
+	return sess, nil
+}
+
+// newBaseSession loads the profile and creates the credentials that every region uses
+// Credentials cache what they retrieve and lock while retrieving, so a role is assumed and an MFA code is asked for once
+func (util *AwsUtil) newBaseSession() (*session.Session, error) {
+	// The base session gets its own client because loading AWS_CA_BUNDLE changes the client's transport
+	config := aws.Config{Region: aws.String(regionsRegion), HTTPClient: &http.Client{}}
+	if util.endpoint != "" {
+		config.Endpoint = aws.String(util.endpoint)
+	}
+
+	shared, err := loadSharedProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	sso, isSSO, err := shared.ssoProfile(util.profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if isSSO {
+		config.Credentials = credentials.NewCredentials(&ssoCredentials{settings: sso, endpoint: util.endpoint, client: config.HTTPClient})
+	}
+
+	// The config file is loaded so role_arn and source_profile profiles assume their role, asking for MFA codes on stdin
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:                  config,
+		Profile:                 util.profileName,
+		SharedConfigState:       session.SharedConfigEnable,
+		AssumeRoleTokenProvider: stscreds.StdinTokenProvider,
+	})
+	if err != nil {
+		return nil, errors.New("Failed to create session")
+	}
+
+	return sess, nil
+}
+
+// resetSessions forgets the sessions created with the previous profile or endpoint
+func (util *AwsUtil) resetSessions() {
+	util.mutex.Lock()
+	defer util.mutex.Unlock()
+
+	util.base = nil
+	util.sessions = nil
+}
+
 // SetProfile sets the aws credential profile to use
 func (util *AwsUtil) SetProfile(profile string) {
 	util.profileName = profile
+	util.resetSessions()
+}
+
+// SetRegions sets which regions are scanned
+func (util *AwsUtil) SetRegions(regions RegionFilter) {
+	util.regions = regions
+}
+
+// SetEndpoint sends every AWS request to endpoint instead of the AWS default, an empty endpoint restores the default
+func (util *AwsUtil) SetEndpoint(endpoint string) {
+	util.endpoint = endpoint
+	util.resetSessions()
 }
@@ -1,15 +1,34 @@
 package awsUtil
 
-import "text/template"
+import (
+	"io"
+	"text/template"
+)
 
 // AwsInterface defines a simple way to interact with AWS
 type AwsInterface interface {
-	// ReadAllLoadBalancers gets the load balancer information for all regions
-	ReadAllLoadBalancers() (map[string]string, error)
-	// ReadAllInstances gets the instance information for all regions
-	ReadAllInstances(templ *template.Template) (map[string]string, error)
+	// ReadAllLoadBalancers gets the load balancers matching the filter in all regions, writing regions that fail to warnings
+	ReadAllLoadBalancers(filter LoadBalancerFilter, warnings io.Writer) ([]LoadBalancer, error)
+	// ReadAllInstances gets the named instance addresses for all regions, writing regions that fail and name collisions to warnings
+	ReadAllInstances(templ *template.Template, filter InstanceFilter, kinds []AddressKind, warnings io.Writer) ([]InstanceAddress, error)
+	// ReadAllDBInstances gets the RDS instances in all regions, writing regions that fail to warnings
+	ReadAllDBInstances(warnings io.Writer) ([]DBInstance, error)
+	// ReadAllCacheClusters gets the ElastiCache clusters and their nodes in all regions, writing regions that fail to warnings
+	ReadAllCacheClusters(warnings io.Writer) ([]CacheCluster, error)
+	// ReadAllTasks gets the running ECS tasks of the clusters, or every cluster, in all regions, writing regions that fail to warnings
+	ReadAllTasks(clusters []string, warnings io.Writer) ([]Task, error)
+	// ReadAllAutoScalingGroups gets the named Auto Scaling groups, or every group, in all regions, writing regions that fail to warnings
+	ReadAllAutoScalingGroups(names []string, warnings io.Writer) ([]AutoScalingGroup, error)
+	// ListHostedZones lists the Route53 hosted zones of the account, without their records
+	ListHostedZones() ([]HostedZone, error)
+	// ReadHostedZone reads the A and AAAA records of the Route53 hosted zone with the given ID or name
+	ReadHostedZone(zone string) (HostedZone, error)
 	// ListAllProfiles lists all available aws credential profiles
 	ListAllProfiles() ([]string, error)
 	// SetProfile sets the aws credential profile to use
 	SetProfile(string)
+	// SetRegions sets which regions are scanned
+	SetRegions(RegionFilter)
+	// SetEndpoint sends every AWS request to endpoint instead of the AWS default
+	SetEndpoint(string)
 }
@@ -0,0 +1,579 @@
+package awsUtil
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRegionsResponse = `<DescribeRegionsResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+  <requestId>1</requestId>
+  <regionInfo>
+    <item><regionName>us-east-1</regionName></item>
+    <item><regionName>us-west-2</regionName></item>
+    <item><regionName>eu-west-1</regionName></item>
+  </regionInfo>
+</DescribeRegionsResponse>`
+
+const testInstancesResponse = `<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+  <requestId>1</requestId>
+  <reservationSet>
+    <item>
+      <reservationId>r-1</reservationId>
+      <instancesSet>
+        <item>
+          <instanceId>i-%[1]s</instanceId><privateIpAddress>10.0.0.1</privateIpAddress><ipAddress>54.0.0.1</ipAddress>
+          <tagSet><item><key>Name</key><value>Web Server</value></item><item><key>env</key><value>prod</value></item></tagSet>
+        </item>
+      </instancesSet>
+    </item>
+  </reservationSet>
+</DescribeInstancesResponse>`
+
+const testLoadBalancersResponse = `<DescribeLoadBalancersResponse xmlns="http://elasticloadbalancing.amazonaws.com/doc/2012-06-01/">
+  <DescribeLoadBalancersResult>
+    <LoadBalancerDescriptions>
+      <member><LoadBalancerName>web-%[1]s</LoadBalancerName><DNSName>web.%[1]s.elb.example.com</DNSName><Scheme>internet-facing</Scheme></member>
+    </LoadBalancerDescriptions>
+  </DescribeLoadBalancersResult>
+  <ResponseMetadata><RequestId>1</RequestId></ResponseMetadata>
+</DescribeLoadBalancersResponse>`
+
+const testLoadBalancerTagsResponse = `<DescribeTagsResponse xmlns="http://elasticloadbalancing.amazonaws.com/doc/2012-06-01/">
+  <DescribeTagsResult>
+    <TagDescriptions>
+      <member><LoadBalancerName>web-%[1]s</LoadBalancerName><Tags><member><Key>env</Key><Value>prod</Value></member></Tags></member>
+    </TagDescriptions>
+  </DescribeTagsResult>
+  <ResponseMetadata><RequestId>1</RequestId></ResponseMetadata>
+</DescribeTagsResponse>`
+
+const testLoadBalancersV2Response = `<DescribeLoadBalancersResponse xmlns="http://elasticloadbalancing.amazonaws.com/doc/2015-12-01/">
+  <DescribeLoadBalancersResult>
+    <LoadBalancers>
+      <member>
+        <LoadBalancerArn>arn:aws:elasticloadbalancing:%[1]s:1:loadbalancer/app/api/1</LoadBalancerArn>
+        <LoadBalancerName>api-%[1]s</LoadBalancerName>
+        <DNSName>api.%[1]s.elb.example.com</DNSName>
+        <Scheme>internal</Scheme>
+        <Type>application</Type>
+        <AvailabilityZones><member><ZoneName>%[1]sa</ZoneName></member></AvailabilityZones>
+      </member>
+      <member>
+        <LoadBalancerArn>arn:aws:elasticloadbalancing:%[1]s:1:loadbalancer/net/nlb/1</LoadBalancerArn>
+        <LoadBalancerName>nlb-%[1]s</LoadBalancerName>
+        <DNSName>nlb.%[1]s.elb.example.com</DNSName>
+        <Scheme>internet-facing</Scheme>
+        <Type>network</Type>
+        <AvailabilityZones>
+          <member><ZoneName>%[1]sb</ZoneName><LoadBalancerAddresses><member><IpAddress>52.0.0.2</IpAddress></member></LoadBalancerAddresses></member>
+          <member><ZoneName>%[1]sa</ZoneName><LoadBalancerAddresses><member><IpAddress>52.0.0.1</IpAddress></member></LoadBalancerAddresses></member>
+        </AvailabilityZones>
+      </member>
+    </LoadBalancers>
+  </DescribeLoadBalancersResult>
+  <ResponseMetadata><RequestId>1</RequestId></ResponseMetadata>
+</DescribeLoadBalancersResponse>`
+
+const testLoadBalancerV2TagsResponse = `<DescribeTagsResponse xmlns="http://elasticloadbalancing.amazonaws.com/doc/2015-12-01/">
+  <DescribeTagsResult>
+    <TagDescriptions>
+      <member>
+        <ResourceArn>arn:aws:elasticloadbalancing:%[1]s:1:loadbalancer/app/api/1</ResourceArn>
+        <Tags><member><Key>env</Key><Value>staging</Value></member></Tags>
+      </member>
+      <member>
+        <ResourceArn>arn:aws:elasticloadbalancing:%[1]s:1:loadbalancer/net/nlb/1</ResourceArn>
+        <Tags><member><Key>env</Key><Value>prod</Value></member></Tags>
+      </member>
+    </TagDescriptions>
+  </DescribeTagsResult>
+  <ResponseMetadata><RequestId>1</RequestId></ResponseMetadata>
+</DescribeTagsResponse>`
+
+const testEc2ErrorResponse = `<Response>
+  <Errors><Error><Code>UnauthorizedOperation</Code><Message>denied in %s</Message></Error></Errors>
+  <RequestID>1</RequestID>
+</Response>`
+
+const testQueryErrorResponse = `<ErrorResponse>
+  <Error><Code>AccessDenied</Code><Message>denied in %s</Message></Error>
+  <RequestId>1</RequestId>
+</ErrorResponse>`
+
+const testHostedZonesResponse = `<ListHostedZonesResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">
+  <HostedZones>
+    <HostedZone>
+      <Id>/hostedzone/Z1</Id><Name>example.com.</Name><CallerReference>1</CallerReference><Config><PrivateZone>false</PrivateZone></Config>
+    </HostedZone>
+    <HostedZone>
+      <Id>/hostedzone/Z2</Id><Name>internal.com.</Name><CallerReference>2</CallerReference><Config><PrivateZone>true</PrivateZone></Config>
+    </HostedZone>
+    <HostedZone>
+      <Id>/hostedzone/Z3</Id><Name>internal.com.</Name><CallerReference>3</CallerReference><Config><PrivateZone>false</PrivateZone></Config>
+    </HostedZone>
+  </HostedZones>
+  <IsTruncated>false</IsTruncated>
+  <MaxItems>100</MaxItems>
+</ListHostedZonesResponse>`
+
+const testRecordSetsResponse = `<ListResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">
+  <ResourceRecordSets>
+    <ResourceRecordSet>
+      <Name>example.com.</Name><Type>NS</Type><TTL>172800</TTL>
+      <ResourceRecords><ResourceRecord><Value>ns-1.example.net.</Value></ResourceRecord></ResourceRecords>
+    </ResourceRecordSet>
+    <ResourceRecordSet>
+      <Name>Web.example.com.</Name><Type>A</Type><TTL>300</TTL>
+      <ResourceRecords>
+        <ResourceRecord><Value>10.0.0.1</Value></ResourceRecord>
+        <ResourceRecord><Value>10.0.0.2</Value></ResourceRecord>
+      </ResourceRecords>
+    </ResourceRecordSet>
+    <ResourceRecordSet>
+      <Name>web.example.com.</Name><Type>AAAA</Type><TTL>300</TTL>
+      <ResourceRecords><ResourceRecord><Value>2001:db8::1</Value></ResourceRecord></ResourceRecords>
+    </ResourceRecordSet>
+    <ResourceRecordSet>
+      <Name>www.example.com.</Name><Type>A</Type>
+      <AliasTarget><HostedZoneId>Z1</HostedZoneId><DNSName>web.example.com.</DNSName><EvaluateTargetHealth>false</EvaluateTargetHealth></AliasTarget>
+    </ResourceRecordSet>
+    <ResourceRecordSet>
+      <Name>\052.example.com.</Name><Type>A</Type><SetIdentifier>east</SetIdentifier><TTL>300</TTL>
+      <ResourceRecords><ResourceRecord><Value>10.0.0.9</Value></ResourceRecord></ResourceRecords>
+    </ResourceRecordSet>
+  </ResourceRecordSets>
+  <IsTruncated>false</IsTruncated>
+  <MaxItems>100</MaxItems>
+</ListResourceRecordSetsResponse>`
+
+const testDBInstancesResponse = `<DescribeDBInstancesResponse xmlns="http://rds.amazonaws.com/doc/2014-10-31/">
+  <DescribeDBInstancesResult>
+    <DBInstances>
+      <DBInstance>
+        <DBInstanceIdentifier>orders-%[1]s</DBInstanceIdentifier>
+        <DBInstanceArn>arn:aws:rds:%[1]s:1:db:orders-%[1]s</DBInstanceArn>
+        <Engine>postgres</Engine>
+        <DBInstanceStatus>available</DBInstanceStatus>
+        <Endpoint><Address>orders.%[1]s.rds.example.com</Address><Port>5432</Port></Endpoint>
+      </DBInstance>
+      <DBInstance>
+        <DBInstanceIdentifier>new-%[1]s</DBInstanceIdentifier>
+        <Engine>mysql</Engine>
+        <DBInstanceStatus>creating</DBInstanceStatus>
+      </DBInstance>
+    </DBInstances>
+  </DescribeDBInstancesResult>
+  <ResponseMetadata><RequestId>1</RequestId></ResponseMetadata>
+</DescribeDBInstancesResponse>`
+
+const testCacheClustersResponse = `<DescribeCacheClustersResponse xmlns="http://elasticache.amazonaws.com/doc/2015-02-02/">
+  <DescribeCacheClustersResult>
+    <CacheClusters>
+      <CacheCluster>
+        <CacheClusterId>sessions-%[1]s</CacheClusterId>
+        <ReplicationGroupId>sessions</ReplicationGroupId>
+        <Engine>redis</Engine>
+        <CacheClusterStatus>available</CacheClusterStatus>
+        <CacheNodes>
+          <CacheNode>
+            <CacheNodeId>0002</CacheNodeId><CustomerAvailabilityZone>%[1]sb</CustomerAvailabilityZone>
+            <Endpoint><Address>b.%[1]s.cache.example.com</Address><Port>6379</Port></Endpoint>
+          </CacheNode>
+          <CacheNode>
+            <CacheNodeId>0001</CacheNodeId><CustomerAvailabilityZone>%[1]sa</CustomerAvailabilityZone>
+            <Endpoint><Address>a.%[1]s.cache.example.com</Address><Port>6379</Port></Endpoint>
+          </CacheNode>
+          <CacheNode><CacheNodeId>0003</CacheNodeId><CustomerAvailabilityZone>%[1]sa</CustomerAvailabilityZone></CacheNode>
+        </CacheNodes>
+      </CacheCluster>
+    </CacheClusters>
+  </DescribeCacheClustersResult>
+  <ResponseMetadata><RequestId>1</RequestId></ResponseMetadata>
+</DescribeCacheClustersResponse>`
+
+const testAutoScalingGroupsResponse = `<DescribeAutoScalingGroupsResponse xmlns="http://autoscaling.amazonaws.com/doc/2011-01-01/">
+  <DescribeAutoScalingGroupsResult>
+    <AutoScalingGroups>
+      <member>
+        <AutoScalingGroupName>web-%[1]s</AutoScalingGroupName>
+        <AutoScalingGroupARN>arn:aws:autoscaling:%[1]s:1:autoScalingGroup:1:autoScalingGroupName/web-%[1]s</AutoScalingGroupARN>
+        <Instances>
+          <member><InstanceId>i-%[1]s</InstanceId><AvailabilityZone>%[1]sa</AvailabilityZone><LifecycleState>InService</LifecycleState></member>
+          <member><InstanceId>i-old</InstanceId><AvailabilityZone>%[1]sa</AvailabilityZone><LifecycleState>Terminating</LifecycleState></member>
+        </Instances>
+        <Tags><member><Key>env</Key><Value>prod</Value></member></Tags>
+      </member>
+    </AutoScalingGroups>
+  </DescribeAutoScalingGroupsResult>
+  <ResponseMetadata><RequestId>1</RequestId></ResponseMetadata>
+</DescribeAutoScalingGroupsResponse>`
+
+// testEcsResponses answers each ECS action, tasks/t2 runs on a container instance that isn't an EC2 instance
+var testEcsResponses = map[string]string{
+	"ListClusters": `{"clusterArns": ["arn:aws:ecs:%[1]s:1:cluster/web"]}`,
+	"ListTasks":    `{"taskArns": ["arn:aws:ecs:%[1]s:1:task/t1", "arn:aws:ecs:%[1]s:1:task/t2", "arn:aws:ecs:%[1]s:1:task/t3"]}`,
+	"DescribeTasks": `{"tasks": [
+		{"taskArn": "arn:aws:ecs:%[1]s:1:task/t1", "clusterArn": "arn:aws:ecs:%[1]s:1:cluster/web", "group": "service:api",
+		 "taskDefinitionArn": "arn:aws:ecs:%[1]s:1:task-definition/api:3", "containerInstanceArn": "arn:aws:ecs:%[1]s:1:container-instance/c1"},
+		{"taskArn": "arn:aws:ecs:%[1]s:1:task/t2", "clusterArn": "arn:aws:ecs:%[1]s:1:cluster/web", "group": "family:batch",
+		 "taskDefinitionArn": "arn:aws:ecs:%[1]s:1:task-definition/batch:1", "containerInstanceArn": "arn:aws:ecs:%[1]s:1:container-instance/c2"},
+		{"taskArn": "arn:aws:ecs:%[1]s:1:task/t3", "clusterArn": "arn:aws:ecs:%[1]s:1:cluster/web",
+		 "taskDefinitionArn": "arn:aws:ecs:%[1]s:1:task-definition/cron:7", "containerInstanceArn": "arn:aws:ecs:%[1]s:1:container-instance/c1"}
+	]}`,
+	"DescribeContainerInstances": `{"containerInstances": [
+		{"containerInstanceArn": "arn:aws:ecs:%[1]s:1:container-instance/c1", "ec2InstanceId": "i-%[1]s"},
+		{"containerInstanceArn": "arn:aws:ecs:%[1]s:1:container-instance/c2"}
+	]}`,
+}
+
+// ecsTargetPrefix is the prefix of the X-Amz-Target header of every ECS request
+const ecsTargetPrefix = "AmazonEC2ContainerServiceV20141113."
+
+var testInstanceTags = map[string]string{"Name": "Web Server", "env": "prod"}
+
+var defaultKinds = []AddressKind{{Kind: PublicAddress}, {Kind: PrivateAddress, Suffix: "-private"}}
+
+var credentialRegion = regexp.MustCompile(`Credential=[^/]+/[^/]+/([^/]+)/`)
+
+// testAwsServer answers EC2, ELB, RDS, ElastiCache, ECS, Auto Scaling and Route53 requests, using the region each request was signed for
+type testAwsServer struct {
+	*httptest.Server
+	failingRegions map[string]bool
+	mutex          sync.Mutex
+	requests       []string
+	filters        []string
+}
+
+func newTestAwsServer(failingRegions ...string) *testAwsServer {
+	server := &testAwsServer{failingRegions: map[string]bool{}}
+	for _, region := range failingRegions {
+		server.failingRegions[region] = true
+	}
+
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		region := credentialRegion.FindStringSubmatch(r.Header.Get("Authorization"))[1]
+		if strings.HasPrefix(r.URL.Path, route53Path) {
+			server.serveRoute53(w, r, region)
+			return
+		}
+
+		action := r.Form.Get("Action")
+		if target := r.Header.Get("X-Amz-Target"); strings.HasPrefix(target, ecsTargetPrefix) {
+			action = strings.TrimPrefix(target, ecsTargetPrefix)
+		}
+
+		if r.Form.Get("Version") == "2015-12-01" {
+			action += "V2"
+		}
+
+		server.mutex.Lock()
+		server.requests = append(server.requests, fmt.Sprintf("%s %s", region, action))
+		if filter := r.Form.Get("Filter.1.Name"); filter != "" {
+			server.filters = append(server.filters, fmt.Sprintf("%s=%s", filter, r.Form.Get("Filter.1.Value.1")))
+		}
+		server.mutex.Unlock()
+
+		if action == "DescribeRegions" {
+			fmt.Fprint(w, testRegionsResponse)
+			return
+		}
+
+		if server.failingRegions[region] {
+			w.WriteHeader(http.StatusForbidden)
+			if action == "DescribeInstances" {
+				fmt.Fprintf(w, testEc2ErrorResponse, region)
+			} else {
+				fmt.Fprintf(w, testQueryErrorResponse, region)
+			}
+
+			return
+		}
+
+		switch action {
+		case "DescribeInstances":
+			fmt.Fprintf(w, testInstancesResponse, region)
+		case "DescribeLoadBalancers":
+			fmt.Fprintf(w, testLoadBalancersResponse, region)
+		case "DescribeTags":
+			fmt.Fprintf(w, testLoadBalancerTagsResponse, region)
+		case "DescribeLoadBalancersV2":
+			fmt.Fprintf(w, testLoadBalancersV2Response, region)
+		case "DescribeTagsV2":
+			fmt.Fprintf(w, testLoadBalancerV2TagsResponse, region)
+		case "DescribeDBInstances":
+			fmt.Fprintf(w, testDBInstancesResponse, region)
+		case "DescribeCacheClusters":
+			fmt.Fprintf(w, testCacheClustersResponse, region)
+		case "DescribeAutoScalingGroups":
+			fmt.Fprintf(w, testAutoScalingGroupsResponse, region)
+		case "ListClusters", "ListTasks", "DescribeTasks", "DescribeContainerInstances":
+			fmt.Fprintf(w, testEcsResponses[action], region)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+
+	return server
+}
+
+// route53Path is the prefix of every Route53 API path
+const route53Path = "/2013-04-01/hostedzone"
+
+func (server *testAwsServer) serveRoute53(w http.ResponseWriter, r *http.Request, region string) {
+	server.mutex.Lock()
+	server.requests = append(server.requests, fmt.Sprintf("%s %s", region, r.URL.Path))
+	server.mutex.Unlock()
+
+	switch r.URL.Path {
+	case route53Path:
+		fmt.Fprint(w, testHostedZonesResponse)
+	case route53Path + "/Z1/rrset":
+		fmt.Fprint(w, testRecordSetsResponse)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (server *testAwsServer) sortedRequests() []string {
+	sort.Strings(server.requests)
+	return server.requests
+}
+
+func newTestAwsUtil(t *testing.T, server *testAwsServer, regions string) *AwsUtil {
+	assert.Nil(t, os.Setenv("AWS_ACCESS_KEY_ID", "AKID"))
+	assert.Nil(t, os.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET"))
+	assert.Nil(t, os.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/notafile"))
+	assert.Nil(t, os.Setenv("AWS_CONFIG_FILE", "/notafile"))
+
+	filter, err := ParseRegionFilter(regions)
+	assert.Nil(t, err)
+
+	util := new(AwsUtil)
+	util.SetEndpoint(server.URL)
+	util.SetRegions(filter)
+	return util
+}
+
+func instanceIPs(addresses []InstanceAddress) map[string]string {
+	IPs := make(map[string]string, len(addresses))
+	for _, address := range addresses {
+		IPs[address.Name] = address.IP
+	}
+
+	return IPs
+}
+
+func TestReadAllInstances(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	warnings := new(bytes.Buffer)
+	util := newTestAwsUtil(t, server, "")
+	instances, err := util.ReadAllInstances(template.Must(template.New("").Parse("{{.InstanceId}}")), nil, defaultKinds, warnings)
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]InstanceAddress{
+			{Name: "i-eu-west-1", IP: "54.0.0.1", InstanceID: "i-eu-west-1", Region: "eu-west-1", Tags: testInstanceTags, Primary: true},
+			{Name: "i-eu-west-1-private", IP: "10.0.0.1", InstanceID: "i-eu-west-1", Region: "eu-west-1", Tags: testInstanceTags},
+			{Name: "i-us-east-1", IP: "54.0.0.1", InstanceID: "i-us-east-1", Region: "us-east-1", Tags: testInstanceTags, Primary: true},
+			{Name: "i-us-east-1-private", IP: "10.0.0.1", InstanceID: "i-us-east-1", Region: "us-east-1", Tags: testInstanceTags},
+			{Name: "i-us-west-2", IP: "54.0.0.1", InstanceID: "i-us-west-2", Region: "us-west-2", Tags: testInstanceTags, Primary: true},
+			{Name: "i-us-west-2-private", IP: "10.0.0.1", InstanceID: "i-us-west-2", Region: "us-west-2", Tags: testInstanceTags},
+		},
+		instances,
+	)
+	assert.Equal(t, "", warnings.String())
+	assert.Equal(
+		t,
+		[]string{"eu-west-1 DescribeInstances", "us-east-1 DescribeInstances", "us-east-1 DescribeRegions", "us-west-2 DescribeInstances"},
+		server.sortedRequests(),
+	)
+	assert.Equal(t, 3, len(util.sessions))
+}
+
+func TestReadAllInstancesTemplateFilterAndCollisions(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	templ, err := InstanceTemplate(`{{tag "Name" | slug}}`)
+	assert.Nil(t, err)
+
+	filter, err := ParseInstanceFilter("tag:env=prod", "")
+	assert.Nil(t, err)
+
+	warnings := new(bytes.Buffer)
+	util := newTestAwsUtil(t, server, "us-*")
+	instances, err := util.ReadAllInstances(templ, filter, defaultKinds, warnings)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"web-server": "54.0.0.1", "web-server-private": "10.0.0.1"}, instanceIPs(instances))
+	assert.Equal(
+		t,
+		"Warning: Instances i-us-east-1 and i-us-west-2 are both named web-server, keeping i-us-east-1\n"+
+			"Warning: Instances i-us-east-1 and i-us-west-2 are both named web-server-private, keeping i-us-east-1\n",
+		warnings.String(),
+	)
+	assert.Equal(t, []string{"tag:env=prod", "tag:env=prod"}, server.filters)
+}
+
+func TestReadAllInstancesRegionFilter(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	util := newTestAwsUtil(t, server, "us-*,!us-east-1")
+	instances, err := util.ReadAllInstances(template.Must(template.New("").Parse("{{.InstanceId}}")), nil, defaultKinds, new(bytes.Buffer))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"i-us-west-2": "54.0.0.1", "i-us-west-2-private": "10.0.0.1"}, instanceIPs(instances))
+	assert.Equal(t, []string{"us-east-1 DescribeRegions", "us-west-2 DescribeInstances"}, server.sortedRequests())
+}
+
+func TestReadAllInstancesNoMatchingRegions(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	util := newTestAwsUtil(t, server, "ap-*,!ap-south-1")
+	_, err := util.ReadAllInstances(template.Must(template.New("").Parse("{{.InstanceId}}")), nil, defaultKinds, new(bytes.Buffer))
+	assert.EqualError(t, err, "No regions match ap-*,!ap-south-1")
+}
+
+func TestReadAllLoadBalancers(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	warnings := new(bytes.Buffer)
+	util := newTestAwsUtil(t, server, "us-east-1")
+	loadBalancers, err := util.ReadAllLoadBalancers(LoadBalancerFilter{}, warnings)
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]LoadBalancer{
+			{
+				ID:      "web-us-east-1",
+				Name:    "web-us-east-1",
+				Type:    Classic,
+				Scheme:  "internet-facing",
+				DNSName: "web.us-east-1.elb.example.com",
+				Region:  "us-east-1",
+				Tags:    map[string]string{"env": "prod"},
+			},
+			{
+				ID:      "arn:aws:elasticloadbalancing:us-east-1:1:loadbalancer/app/api/1",
+				Name:    "api-us-east-1",
+				Type:    Application,
+				Scheme:  "internal",
+				DNSName: "api.us-east-1.elb.example.com",
+				Region:  "us-east-1",
+				Tags:    map[string]string{"env": "staging"},
+			},
+			{
+				ID:        "arn:aws:elasticloadbalancing:us-east-1:1:loadbalancer/net/nlb/1",
+				Name:      "nlb-us-east-1",
+				Type:      Network,
+				Scheme:    "internet-facing",
+				DNSName:   "nlb.us-east-1.elb.example.com",
+				Region:    "us-east-1",
+				Tags:      map[string]string{"env": "prod"},
+				Addresses: []ZoneAddress{{Zone: "us-east-1a", IP: "52.0.0.1"}, {Zone: "us-east-1b", IP: "52.0.0.2"}},
+			},
+		},
+		loadBalancers,
+	)
+	assert.Equal(t, "", warnings.String())
+}
+
+func TestReadAllLoadBalancersFilter(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	util := newTestAwsUtil(t, server, "us-east-1")
+	loadBalancers, err := util.ReadAllLoadBalancers(LoadBalancerFilter{Types: []string{Network}, Tags: map[string]string{"env": "prod"}}, new(bytes.Buffer))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(loadBalancers))
+	assert.Equal(t, "nlb-us-east-1", loadBalancers[0].Name)
+	assert.Equal(t, []string{"us-east-1 DescribeLoadBalancersV2", "us-east-1 DescribeRegions", "us-east-1 DescribeTagsV2"}, server.sortedRequests())
+}
+
+func TestReadAllLoadBalancersFailingRegion(t *testing.T) {
+	server := newTestAwsServer("eu-west-1")
+	defer server.Close()
+
+	warnings := new(bytes.Buffer)
+	util := newTestAwsUtil(t, server, "")
+	loadBalancers, err := util.ReadAllLoadBalancers(LoadBalancerFilter{Types: []string{Classic}}, warnings)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(loadBalancers))
+	assert.Equal(t, "web-us-east-1", loadBalancers[0].Name)
+	assert.Equal(t, "web-us-west-2", loadBalancers[1].Name)
+	assert.True(t, strings.HasPrefix(warnings.String(), "Warning: AccessDenied: denied in eu-west-1"))
+	assert.True(t, strings.HasSuffix(warnings.String(), ", skipping region eu-west-1\n"))
+}
+
+func TestReadAllLoadBalancersEveryRegionFails(t *testing.T) {
+	server := newTestAwsServer("eu-west-1", "us-west-2")
+	defer server.Close()
+
+	warnings := new(bytes.Buffer)
+	util := newTestAwsUtil(t, server, "!us-east-1")
+	_, err := util.ReadAllLoadBalancers(LoadBalancerFilter{}, warnings)
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "AccessDenied: denied in us-west-2"))
+	assert.Equal(t, 2, strings.Count(warnings.String(), "Warning: "))
+}
+
+func TestReadAllInstancesFailingRegion(t *testing.T) {
+	server := newTestAwsServer("us-west-2")
+	defer server.Close()
+
+	warnings := new(bytes.Buffer)
+	util := newTestAwsUtil(t, server, "us-*")
+	instances, err := util.ReadAllInstances(template.Must(template.New("").Parse("{{.InstanceId}}")), nil, defaultKinds, warnings)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"i-us-east-1": "54.0.0.1", "i-us-east-1-private": "10.0.0.1"}, instanceIPs(instances))
+	assert.True(t, strings.HasPrefix(warnings.String(), "Warning: UnauthorizedOperation: denied in us-west-2"))
+}
+
+func TestSetProfileResetsSessions(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	util := newTestAwsUtil(t, server, "")
+	_, err := util.session("us-east-1")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(util.sessions))
+
+	util.SetProfile("other")
+	assert.Nil(t, util.sessions)
+	assert.Nil(t, util.base)
+}
+
+func TestSessionsShareCredentials(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	util := newTestAwsUtil(t, server, "")
+	east, err := util.session("us-east-1")
+	assert.Nil(t, err)
+
+	// The profile is not read again for the other regions
+	home := setupSharedFiles(t, "", "[profile")
+	defer func() { assert.Nil(t, os.RemoveAll(home)) }()
+	west, err := util.session("us-west-2")
+	assert.Nil(t, err)
+
+	assert.Equal(t, "us-east-1", *east.Config.Region)
+	assert.Equal(t, "us-west-2", *west.Config.Region)
+	assert.True(t, east.Config.Credentials == west.Config.Credentials)
+	assert.True(t, east.Config.HTTPClient == west.Config.HTTPClient)
+}
@@ -0,0 +1,196 @@
+package awsUtil
+
+import (
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ecs"
+)
+
+// maxDescribeTasks is the most tasks or container instances one ECS describe request accepts
+const maxDescribeTasks = 100
+
+// Task is a running ECS task, IP is the private IP of the container instance it runs on
+// Service is the service that started the task, or the task definition family of tasks started without a service
+type Task struct {
+	ID             string
+	ARN            string
+	Cluster        string
+	Service        string
+	TaskDefinition string
+	InstanceID     string
+	IP             string
+	Region         string
+}
+
+// ReadAllTasks gets the running ECS tasks of the clusters in all regions, every cluster is read when none are given
+func (util *AwsUtil) ReadAllTasks(clusters []string, warnings io.Writer) ([]Task, error) {
+	results, err := util.scanRegions(warnings, func(sess *session.Session) (interface{}, error) {
+		return readTasks(sess, clusters)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []Task{}
+	for _, result := range results {
+		tasks = append(tasks, result.([]Task)...)
+	}
+
+	return tasks, nil
+}
+
+func readTasks(sess *session.Session, clusters []string) ([]Task, error) {
+	svc := ecs.New(sess)
+	if len(clusters) == 0 {
+		err := svc.ListClustersPages(&ecs.ListClustersInput{}, func(resp *ecs.ListClustersOutput, lastPage bool) bool {
+			clusters = append(clusters, aws.StringValueSlice(resp.ClusterArns)...)
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tasks := []Task{}
+	for _, cluster := range clusters {
+		clusterTasks, err := readClusterTasks(sess, svc, cluster)
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = append(tasks, clusterTasks...)
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Cluster != tasks[j].Cluster {
+			return tasks[i].Cluster < tasks[j].Cluster
+		}
+
+		if tasks[i].Service != tasks[j].Service {
+			return tasks[i].Service < tasks[j].Service
+		}
+
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	return tasks, nil
+}
+
+// readClusterTasks reads the running tasks of a cluster and finds the EC2 instance each one runs on
+func readClusterTasks(sess *session.Session, svc *ecs.ECS, cluster string) ([]Task, error) {
+	taskARNs := []*string{}
+	params := &ecs.ListTasksInput{Cluster: aws.String(cluster), DesiredStatus: aws.String(ecs.DesiredStatusRunning)}
+	err := svc.ListTasksPages(params, func(resp *ecs.ListTasksOutput, lastPage bool) bool {
+		taskARNs = append(taskARNs, resp.TaskArns...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ecsTasks := make([]*ecs.Task, 0, len(taskARNs))
+	containerInstanceARNs := []*string{}
+	for start := 0; start < len(taskARNs); start += maxDescribeTasks {
+		end := start + maxDescribeTasks
+		if end > len(taskARNs) {
+			end = len(taskARNs)
+		}
+
+		output, err := svc.DescribeTasks(&ecs.DescribeTasksInput{Cluster: aws.String(cluster), Tasks: taskARNs[start:end]})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, task := range output.Tasks {
+			ecsTasks = append(ecsTasks, task)
+			if task.ContainerInstanceArn != nil && indexOf(containerInstanceARNs, *task.ContainerInstanceArn) == -1 {
+				containerInstanceARNs = append(containerInstanceARNs, task.ContainerInstanceArn)
+			}
+		}
+	}
+
+	instanceIDs := map[string]string{}
+	for start := 0; start < len(containerInstanceARNs); start += maxDescribeTasks {
+		end := start + maxDescribeTasks
+		if end > len(containerInstanceARNs) {
+			end = len(containerInstanceARNs)
+		}
+
+		output, err := svc.DescribeContainerInstances(&ecs.DescribeContainerInstancesInput{
+			Cluster:            aws.String(cluster),
+			ContainerInstances: containerInstanceARNs[start:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, containerInstance := range output.ContainerInstances {
+			instanceIDs[aws.StringValue(containerInstance.ContainerInstanceArn)] = aws.StringValue(containerInstance.Ec2InstanceId)
+		}
+	}
+
+	ec2IDs := make([]string, 0, len(instanceIDs))
+	for _, instanceID := range instanceIDs {
+		if instanceID != "" && !contains(ec2IDs, instanceID) {
+			ec2IDs = append(ec2IDs, instanceID)
+		}
+	}
+
+	sort.Strings(ec2IDs)
+	instances, err := describeInstances(sess, ec2IDs)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]Task, 0, len(ecsTasks))
+	for _, ecsTask := range ecsTasks {
+		task := Task{
+			ID:             resourceName(aws.StringValue(ecsTask.TaskArn)),
+			ARN:            aws.StringValue(ecsTask.TaskArn),
+			Cluster:        resourceName(aws.StringValue(ecsTask.ClusterArn)),
+			Service:        taskService(ecsTask),
+			TaskDefinition: resourceName(aws.StringValue(ecsTask.TaskDefinitionArn)),
+			InstanceID:     instanceIDs[aws.StringValue(ecsTask.ContainerInstanceArn)],
+			Region:         aws.StringValue(sess.Config.Region),
+		}
+		if task.Cluster == "" {
+			task.Cluster = resourceName(cluster)
+		}
+
+		if instance, exists := instances[task.InstanceID]; exists {
+			task.IP, _ = getPrivateIP(instance)
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+// taskService is the service in a task's group like service:web, or the family of tasks started without a service
+func taskService(task *ecs.Task) string {
+	group := aws.StringValue(task.Group)
+	if index := strings.Index(group, ":"); index != -1 {
+		return group[index+1:]
+	}
+
+	if group != "" {
+		return group
+	}
+
+	family := resourceName(aws.StringValue(task.TaskDefinitionArn))
+	if index := strings.LastIndex(family, ":"); index != -1 {
+		return family[:index]
+	}
+
+	return family
+}
+
+// resourceName is the part of an ARN after the last slash, names are returned unchanged
+func resourceName(ARN string) string {
+	return ARN[strings.LastIndex(ARN, "/")+1:]
+}
@@ -0,0 +1,79 @@
+package awsUtil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadAllTasks(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	util := newTestAwsUtil(t, server, "us-east-1")
+	tasks, err := util.ReadAllTasks(nil, new(bytes.Buffer))
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]Task{
+			{
+				ID:             "t1",
+				ARN:            "arn:aws:ecs:us-east-1:1:task/t1",
+				Cluster:        "web",
+				Service:        "api",
+				TaskDefinition: "api:3",
+				InstanceID:     "i-us-east-1",
+				IP:             "10.0.0.1",
+				Region:         "us-east-1",
+			},
+			{
+				ID:             "t2",
+				ARN:            "arn:aws:ecs:us-east-1:1:task/t2",
+				Cluster:        "web",
+				Service:        "batch",
+				TaskDefinition: "batch:1",
+				Region:         "us-east-1",
+			},
+			{
+				ID:             "t3",
+				ARN:            "arn:aws:ecs:us-east-1:1:task/t3",
+				Cluster:        "web",
+				Service:        "cron",
+				TaskDefinition: "cron:7",
+				InstanceID:     "i-us-east-1",
+				IP:             "10.0.0.1",
+				Region:         "us-east-1",
+			},
+		},
+		tasks,
+	)
+	assert.Equal(
+		t,
+		[]string{
+			"us-east-1 DescribeContainerInstances",
+			"us-east-1 DescribeInstances",
+			"us-east-1 DescribeRegions",
+			"us-east-1 DescribeTasks",
+			"us-east-1 ListClusters",
+			"us-east-1 ListTasks",
+		},
+		server.sortedRequests(),
+	)
+}
+
+func TestReadAllTasksClusters(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	util := newTestAwsUtil(t, server, "us-east-1")
+	tasks, err := util.ReadAllTasks([]string{"web"}, new(bytes.Buffer))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(tasks))
+	assert.NotContains(t, server.sortedRequests(), "us-east-1 ListClusters")
+}
+
+func TestResourceName(t *testing.T) {
+	assert.Equal(t, "web", resourceName("arn:aws:ecs:us-east-1:1:cluster/web"))
+	assert.Equal(t, "web", resourceName("web"))
+}
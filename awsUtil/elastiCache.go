@@ -0,0 +1,92 @@
+package awsUtil
+
+import (
+	"io"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/elasticache"
+)
+
+// CacheCluster is an ElastiCache cluster and its nodes
+// ReplicationGroup is the Redis replication group the cluster is part of, if any
+type CacheCluster struct {
+	ID               string
+	ReplicationGroup string
+	Engine           string
+	Status           string
+	Region           string
+	Nodes            []CacheNode
+}
+
+// CacheNode is a node of an ElastiCache cluster, Endpoint is the DNS name clients connect to
+type CacheNode struct {
+	ID       string
+	Zone     string
+	Endpoint string
+	Port     int64
+}
+
+// ReadAllCacheClusters gets the ElastiCache clusters in all regions with the nodes that have an endpoint
+func (util *AwsUtil) ReadAllCacheClusters(warnings io.Writer) ([]CacheCluster, error) {
+	results, err := util.scanRegions(warnings, func(sess *session.Session) (interface{}, error) {
+		return readCacheClusters(sess)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := []CacheCluster{}
+	for _, result := range results {
+		clusters = append(clusters, result.([]CacheCluster)...)
+	}
+
+	return clusters, nil
+}
+
+func readCacheClusters(sess *session.Session) ([]CacheCluster, error) {
+	clusters := []CacheCluster{}
+	params := &elasticache.DescribeCacheClustersInput{ShowCacheNodeInfo: aws.Bool(true)}
+	err := elasticache.New(sess).DescribeCacheClustersPages(params, func(resp *elasticache.DescribeCacheClustersOutput, lastPage bool) bool {
+		for _, cacheCluster := range resp.CacheClusters {
+			cluster := CacheCluster{
+				ID:               aws.StringValue(cacheCluster.CacheClusterId),
+				ReplicationGroup: aws.StringValue(cacheCluster.ReplicationGroupId),
+				Engine:           aws.StringValue(cacheCluster.Engine),
+				Status:           aws.StringValue(cacheCluster.CacheClusterStatus),
+				Region:           aws.StringValue(sess.Config.Region),
+			}
+
+			for _, node := range cacheCluster.CacheNodes {
+				if node.Endpoint == nil || node.Endpoint.Address == nil {
+					continue
+				}
+
+				cluster.Nodes = append(cluster.Nodes, CacheNode{
+					ID:       aws.StringValue(node.CacheNodeId),
+					Zone:     aws.StringValue(node.CustomerAvailabilityZone),
+					Endpoint: *node.Endpoint.Address,
+					Port:     aws.Int64Value(node.Endpoint.Port),
+				})
+			}
+
+			sort.Slice(cluster.Nodes, func(i, j int) bool {
+				return cluster.Nodes[i].ID < cluster.Nodes[j].ID
+			})
+
+			clusters = append(clusters, cluster)
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].ID < clusters[j].ID
+	})
+
+	return clusters, nil
+}
@@ -0,0 +1,34 @@
+package awsUtil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadAllCacheClusters(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	util := newTestAwsUtil(t, server, "us-east-1")
+	clusters, err := util.ReadAllCacheClusters(new(bytes.Buffer))
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]CacheCluster{
+			{
+				ID:               "sessions-us-east-1",
+				ReplicationGroup: "sessions",
+				Engine:           "redis",
+				Status:           "available",
+				Region:           "us-east-1",
+				Nodes: []CacheNode{
+					{ID: "0001", Zone: "us-east-1a", Endpoint: "a.us-east-1.cache.example.com", Port: 6379},
+					{ID: "0002", Zone: "us-east-1b", Endpoint: "b.us-east-1.cache.example.com", Port: 6379},
+				},
+			},
+		},
+		clusters,
+	)
+}
@@ -0,0 +1,98 @@
+package awsUtil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	// HostnamesTag lists the comma separated hostnames an instance is an option for
+	HostnamesTag = "hostbuilder:hostnames"
+	// OptionTag names the option an instance adds to its hostnames
+	OptionTag = "hostbuilder:option"
+)
+
+// HostMapping adds an instance as an option on hostnames, Option defaults to the instance name
+type HostMapping struct {
+	Hostnames []string `json:"hostnames"`
+	Option    string   `json:"option,omitempty"`
+}
+
+// HostMap maps patterns matching instance names, where * matches any characters, to the hosts they are options for
+type HostMap map[string]HostMapping
+
+// LoadHostMap reads a host map from a JSON file, an empty file name gives an empty map
+func LoadHostMap(fileName string) (HostMap, error) {
+	hostMap := HostMap{}
+	if fileName == "" {
+		return hostMap, nil
+	}
+
+	mapJSON, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(mapJSON, &hostMap)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse %s: %v", fileName, err)
+	}
+
+	for pattern := range hostMap {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("Invalid pattern %s in %s", pattern, fileName)
+		}
+	}
+
+	return hostMap, nil
+}
+
+// instanceHosts lists the hostnames an instance address is an option for and the name of the option
+// The hostnames come from the instance's tags and every mapping whose pattern matches the address name
+// The option tag wins over the option of the first matching mapping, in pattern order
+func instanceHosts(address InstanceAddress, hostMap HostMap) ([]string, string) {
+	hostnames := []string{}
+	add := func(hostname string) {
+		hostname = strings.TrimSpace(hostname)
+		if hostname != "" && !contains(hostnames, hostname) {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+
+	if tagged, exists := address.Tags[HostnamesTag]; exists {
+		for _, hostname := range strings.Split(tagged, ",") {
+			add(hostname)
+		}
+	}
+
+	option := strings.TrimSpace(address.Tags[OptionTag])
+	patterns := make([]string, 0, len(hostMap))
+	for pattern := range hostMap {
+		patterns = append(patterns, pattern)
+	}
+
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, address.Name); !matched {
+			continue
+		}
+
+		for _, hostname := range hostMap[pattern].Hostnames {
+			add(hostname)
+		}
+
+		if option == "" {
+			option = hostMap[pattern].Option
+		}
+	}
+
+	if option == "" {
+		option = address.Name
+	}
+
+	return hostnames, option
+}
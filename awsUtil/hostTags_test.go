@@ -0,0 +1,56 @@
+package awsUtil
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstanceHosts(t *testing.T) {
+	hostMap := HostMap{
+		"web-*":  {Hostnames: []string{"www.example.com", "api.example.com"}, Option: "staging"},
+		"web-1*": {Hostnames: []string{"one.example.com"}, Option: "first"},
+		"db-*":   {Hostnames: []string{"db.example.com"}},
+	}
+
+	hostnames, option := instanceHosts(InstanceAddress{Name: "web-1", Tags: map[string]string{HostnamesTag: "api.example.com, tagged.example.com,"}}, hostMap)
+	assert.Equal(t, []string{"api.example.com", "tagged.example.com", "www.example.com", "one.example.com"}, hostnames)
+	assert.Equal(t, "staging", option)
+
+	hostnames, option = instanceHosts(InstanceAddress{Name: "web-2", Tags: map[string]string{OptionTag: "canary"}}, hostMap)
+	assert.Equal(t, []string{"www.example.com", "api.example.com"}, hostnames)
+	assert.Equal(t, "canary", option)
+
+	hostnames, option = instanceHosts(InstanceAddress{Name: "db-1"}, hostMap)
+	assert.Equal(t, []string{"db.example.com"}, hostnames)
+	assert.Equal(t, "db-1", option)
+
+	hostnames, _ = instanceHosts(InstanceAddress{Name: "cache-1"}, hostMap)
+	assert.Equal(t, []string{}, hostnames)
+}
+
+func TestLoadHostMap(t *testing.T) {
+	mapFile, err := ioutil.TempFile("/tmp", "hostMap")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.Remove(mapFile.Name())) }()
+
+	_, err = mapFile.WriteString(`{"web-*": {"hostnames": ["www.example.com"], "option": "staging"}}`)
+	assert.Nil(t, err)
+	hostMap, err := LoadHostMap(mapFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, HostMap{"web-*": {Hostnames: []string{"www.example.com"}, Option: "staging"}}, hostMap)
+
+	hostMap, err = LoadHostMap("")
+	assert.Nil(t, err)
+	assert.Equal(t, HostMap{}, hostMap)
+
+	assert.Nil(t, ioutil.WriteFile(mapFile.Name(), []byte(`{"[": {}}`), 0644))
+	_, err = LoadHostMap(mapFile.Name())
+	assert.EqualError(t, err, "Invalid pattern [ in "+mapFile.Name())
+
+	assert.Nil(t, ioutil.WriteFile(mapFile.Name(), []byte(`[`), 0644))
+	_, err = LoadHostMap(mapFile.Name())
+	assert.EqualError(t, err, "Unable to parse "+mapFile.Name()+": unexpected end of JSON input")
+}
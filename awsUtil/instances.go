@@ -0,0 +1,290 @@
+package awsUtil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/guywithnose/hostBuilder/provider"
+)
+
+// InstanceStates lists the states an instance can be in
+var InstanceStates = []string{"pending", "running", "shutting-down", "terminated", "stopping", "stopped"}
+
+// InstanceFilter maps DescribeInstances filter names like tag:env to the values they accept
+type InstanceFilter map[string][]string
+
+// ParseInstanceFilter parses space separated filters like tag:env=staging,production and a comma separated list of states
+func ParseInstanceFilter(filters, states string) (InstanceFilter, error) {
+	filter := InstanceFilter{}
+	for _, field := range strings.Fields(filters) {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("Invalid filter %s, expected name=value", field)
+		}
+
+		filter[parts[0]] = append(filter[parts[0]], strings.Split(parts[1], ",")...)
+	}
+
+	if states != "" {
+		for _, state := range strings.Split(states, ",") {
+			if !contains(InstanceStates, state) {
+				return nil, fmt.Errorf("Invalid instance state %s", state)
+			}
+
+			filter["instance-state-name"] = append(filter["instance-state-name"], state)
+		}
+	}
+
+	return filter, nil
+}
+
+// ec2Filters converts the filter into DescribeInstances filters, sorted by name
+func (filter InstanceFilter) ec2Filters() []*ec2.Filter {
+	if len(filter) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(filter))
+	for name := range filter {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	filters := make([]*ec2.Filter, 0, len(names))
+	for _, name := range names {
+		filters = append(filters, &ec2.Filter{Name: aws.String(name), Values: aws.StringSlice(filter[name])})
+	}
+
+	return filters
+}
+
+// InstanceTemplate parses a template for naming instances
+// On top of the provider.TemplateFuncs, {{tag "Name"}} gives the value of one of the instance's tags
+func InstanceTemplate(templateString string) (*template.Template, error) {
+	return template.New("").Funcs(provider.TemplateFuncs).Funcs(instanceFuncs(nil)).Parse(templateString)
+}
+
+func instanceFuncs(instance *ec2.Instance) template.FuncMap {
+	return template.FuncMap{
+		"tag": func(key string) string {
+			if instance == nil {
+				return ""
+			}
+
+			for _, tag := range instance.Tags {
+				if aws.StringValue(tag.Key) == key {
+					return aws.StringValue(tag.Value)
+				}
+			}
+
+			return ""
+		},
+	}
+}
+
+// InstanceAddress is an address named after an instance
+// Primary is set on the first address found for the instance, which is the one its tagged hosts use
+type InstanceAddress struct {
+	Name       string
+	IP         string
+	InstanceID string
+	Region     string
+	Tags       map[string]string
+	Primary    bool
+}
+
+// instanceTags gives the tags of an instance by key
+func instanceTags(instance *ec2.Instance) map[string]string {
+	tags := make(map[string]string, len(instance.Tags))
+	for _, tag := range instance.Tags {
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+
+	return tags
+}
+
+// namedInstances keeps the first instance address given each name, reporting the instances that collide with it
+type namedInstances struct {
+	addresses map[string]InstanceAddress
+	warnings  []string
+}
+
+func newNamedInstances() *namedInstances {
+	return &namedInstances{addresses: map[string]InstanceAddress{}}
+}
+
+func (named *namedInstances) add(address InstanceAddress) {
+	existing, exists := named.addresses[address.Name]
+	if !exists {
+		named.addresses[address.Name] = address
+		return
+	}
+
+	if existing.InstanceID != address.InstanceID {
+		named.warnings = append(
+			named.warnings,
+			fmt.Sprintf("Warning: Instances %s and %s are both named %s, keeping %s", existing.InstanceID, address.InstanceID, address.Name, existing.InstanceID),
+		)
+	}
+}
+
+func (named *namedInstances) warn(format string, args ...interface{}) {
+	named.warnings = append(named.warnings, fmt.Sprintf(format, args...))
+}
+
+const (
+	// PublicAddress is the public IP of the instance
+	PublicAddress = "public"
+	// PrivateAddress is the primary private IP of the instance
+	PrivateAddress = "private"
+	// SecondaryAddress is every secondary private IP on the instance's network interfaces
+	SecondaryAddress = "secondary"
+	// IPv6Address is every IPv6 address on the instance's network interfaces
+	IPv6Address = "ipv6"
+	// ElasticAddress is every Elastic IP associated with the instance's network interfaces
+	ElasticAddress = "elastic"
+)
+
+// InstanceAddressKinds lists the kinds of instance addresses that can be imported
+var InstanceAddressKinds = []string{PublicAddress, PrivateAddress, SecondaryAddress, IPv6Address, ElasticAddress}
+
+// DefaultAddressKinds imports the public address under the instance name and the private address with a -private suffix
+const DefaultAddressKinds = "public,private"
+
+var defaultSuffixes = map[string]string{
+	PublicAddress:    "",
+	PrivateAddress:   "-private",
+	SecondaryAddress: "-secondary",
+	IPv6Address:      "-ipv6",
+	ElasticAddress:   "-eip",
+}
+
+// amazonOwner owns the public IPs AWS assigns automatically, any other owner means an Elastic IP
+const amazonOwner = "amazon"
+
+// AddressKind selects a kind of instance address to import and the suffix added to the instance name for it
+type AddressKind struct {
+	Kind   string
+	Suffix string
+}
+
+// ParseAddressKinds parses a comma separated list of address kinds, each optionally followed by :{suffix}
+func ParseAddressKinds(kinds string) ([]AddressKind, error) {
+	if kinds == "" {
+		kinds = DefaultAddressKinds
+	}
+
+	addressKinds := []AddressKind{}
+	suffixes := map[string]string{}
+	for _, field := range strings.Split(kinds, ",") {
+		parts := strings.SplitN(field, ":", 2)
+		suffix, valid := defaultSuffixes[parts[0]]
+		if !valid {
+			return nil, fmt.Errorf("Invalid address kind %s", parts[0])
+		}
+
+		if len(parts) == 2 {
+			suffix = parts[1]
+		}
+
+		if other, exists := suffixes[suffix]; exists {
+			return nil, fmt.Errorf("Address kinds %s and %s both use the suffix %q", other, parts[0], suffix)
+		}
+
+		suffixes[suffix] = parts[0]
+		addressKinds = append(addressKinds, AddressKind{Kind: parts[0], Suffix: suffix})
+	}
+
+	return addressKinds, nil
+}
+
+// addressesOfKind lists the instance's addresses of a kind
+func addressesOfKind(instance *ec2.Instance, kind string) []string {
+	addresses := []string{}
+	switch kind {
+	case PublicAddress:
+		if publicIP, err := getPublicIP(instance); err == nil {
+			addresses = append(addresses, publicIP)
+		}
+	case PrivateAddress:
+		if privateIP, err := getPrivateIP(instance); err == nil {
+			addresses = append(addresses, privateIP)
+		}
+	}
+
+	for _, networkInterface := range instance.NetworkInterfaces {
+		switch kind {
+		case SecondaryAddress:
+			for _, address := range networkInterface.PrivateIpAddresses {
+				if !aws.BoolValue(address.Primary) && address.PrivateIpAddress != nil {
+					addresses = append(addresses, *address.PrivateIpAddress)
+				}
+			}
+		case IPv6Address:
+			for _, address := range networkInterface.Ipv6Addresses {
+				if address.Ipv6Address != nil {
+					addresses = append(addresses, *address.Ipv6Address)
+				}
+			}
+		case ElasticAddress:
+			for _, address := range networkInterface.PrivateIpAddresses {
+				association := address.Association
+				if association != nil && association.PublicIp != nil && aws.StringValue(association.IpOwnerId) != amazonOwner {
+					addresses = append(addresses, *association.PublicIp)
+				}
+			}
+		}
+	}
+
+	return addresses
+}
+
+// kindAddressNames names each address of a kind, numbering every address after the first
+func kindAddressNames(name string, kind AddressKind, addresses []string) []string {
+	names := make([]string, 0, len(addresses))
+	for index := range addresses {
+		if index == 0 {
+			names = append(names, name+kind.Suffix)
+		} else {
+			names = append(names, fmt.Sprintf("%s%s-%d", name, kind.Suffix, index+1))
+		}
+	}
+
+	return names
+}
+
+// maxInstanceIDs is the most instance IDs read by one DescribeInstances request
+const maxInstanceIDs = 100
+
+// describeInstances reads the instances with the given IDs, keyed by ID
+func describeInstances(sess *session.Session, instanceIDs []string) (map[string]*ec2.Instance, error) {
+	svc := ec2.New(sess)
+	instances := make(map[string]*ec2.Instance, len(instanceIDs))
+	for start := 0; start < len(instanceIDs); start += maxInstanceIDs {
+		end := start + maxInstanceIDs
+		if end > len(instanceIDs) {
+			end = len(instanceIDs)
+		}
+
+		params := &ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice(instanceIDs[start:end])}
+		err := svc.DescribeInstancesPages(params, func(resp *ec2.DescribeInstancesOutput, lastPage bool) bool {
+			for _, reservation := range resp.Reservations {
+				for _, instance := range reservation.Instances {
+					instances[aws.StringValue(instance.InstanceId)] = instance
+				}
+			}
+
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return instances, nil
+}
@@ -0,0 +1,199 @@
+package awsUtil
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInstanceFilter(t *testing.T) {
+	filter, err := ParseInstanceFilter("tag:env=staging,production instance-type=t2.micro", "running,stopped")
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		InstanceFilter{
+			"tag:env":             {"staging", "production"},
+			"instance-type":       {"t2.micro"},
+			"instance-state-name": {"running", "stopped"},
+		},
+		filter,
+	)
+	assert.Equal(
+		t,
+		[]*ec2.Filter{
+			{Name: aws.String("instance-state-name"), Values: aws.StringSlice([]string{"running", "stopped"})},
+			{Name: aws.String("instance-type"), Values: aws.StringSlice([]string{"t2.micro"})},
+			{Name: aws.String("tag:env"), Values: aws.StringSlice([]string{"staging", "production"})},
+		},
+		filter.ec2Filters(),
+	)
+}
+
+func TestParseInstanceFilterEmpty(t *testing.T) {
+	filter, err := ParseInstanceFilter("", "")
+	assert.Nil(t, err)
+	assert.Nil(t, filter.ec2Filters())
+}
+
+func TestParseInstanceFilterInvalidFilter(t *testing.T) {
+	_, err := ParseInstanceFilter("tag:env", "")
+	assert.EqualError(t, err, "Invalid filter tag:env, expected name=value")
+}
+
+func TestParseInstanceFilterInvalidState(t *testing.T) {
+	_, err := ParseInstanceFilter("", "running,asleep")
+	assert.EqualError(t, err, "Invalid instance state asleep")
+}
+
+func TestParseReservations(t *testing.T) {
+	templ, err := InstanceTemplate(`{{tag "Name" | lower | replace " " "_"}}`)
+	assert.Nil(t, err)
+
+	reservations := []*ec2.Reservation{
+		{
+			Instances: []*ec2.Instance{
+				{
+					InstanceId:       aws.String("i-1"),
+					PublicIpAddress:  aws.String("54.0.0.1"),
+					PrivateIpAddress: aws.String("10.0.0.1"),
+					Tags:             []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("Web Server")}},
+				},
+				{InstanceId: aws.String("i-2"), PrivateIpAddress: aws.String("10.0.0.2")},
+				{
+					InstanceId:       aws.String("i-3"),
+					PrivateIpAddress: aws.String("10.0.0.3"),
+					Tags:             []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("web server")}},
+				},
+			},
+		},
+	}
+
+	named := parseReservations(reservations, templ, defaultKinds)
+	assert.Equal(
+		t,
+		map[string]InstanceAddress{
+			"web_server":         {Name: "web_server", IP: "54.0.0.1", InstanceID: "i-1", Tags: map[string]string{"Name": "Web Server"}, Primary: true},
+			"web_server-private": {Name: "web_server-private", IP: "10.0.0.1", InstanceID: "i-1", Tags: map[string]string{"Name": "Web Server"}},
+		},
+		named.addresses,
+	)
+	assert.Equal(
+		t,
+		[]string{
+			"Warning: Instance i-2 has an empty name, skipping",
+			"Warning: Instances i-1 and i-3 are both named web_server-private, keeping i-1",
+		},
+		named.warnings,
+	)
+}
+
+func TestParseReservationsTemplateError(t *testing.T) {
+	templ, err := InstanceTemplate(`{{.Missing}}`)
+	assert.Nil(t, err)
+
+	named := parseReservations([]*ec2.Reservation{{Instances: []*ec2.Instance{{InstanceId: aws.String("i-1")}}}}, templ, defaultKinds)
+	assert.Equal(t, map[string]InstanceAddress{}, named.addresses)
+	assert.Equal(
+		t,
+		[]string{"Warning: Unable to name instance i-1: template: :1:2: executing \"\" at <.Missing>: can't evaluate field Missing in type *ec2.Instance, skipping"},
+		named.warnings,
+	)
+}
+
+func TestParseAddressKinds(t *testing.T) {
+	kinds, err := ParseAddressKinds("private:,ipv6,elastic:-public,secondary")
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]AddressKind{
+			{Kind: PrivateAddress},
+			{Kind: IPv6Address, Suffix: "-ipv6"},
+			{Kind: ElasticAddress, Suffix: "-public"},
+			{Kind: SecondaryAddress, Suffix: "-secondary"},
+		},
+		kinds,
+	)
+}
+
+func TestParseAddressKindsDefault(t *testing.T) {
+	kinds, err := ParseAddressKinds("")
+	assert.Nil(t, err)
+	assert.Equal(t, defaultKinds, kinds)
+}
+
+func TestParseAddressKindsInvalid(t *testing.T) {
+	_, err := ParseAddressKinds("public,floating")
+	assert.EqualError(t, err, "Invalid address kind floating")
+}
+
+func TestParseAddressKindsDuplicateSuffix(t *testing.T) {
+	_, err := ParseAddressKinds("public,elastic:")
+	assert.EqualError(t, err, "Address kinds public and elastic both use the suffix \"\"")
+}
+
+func TestParseReservationsAddressKinds(t *testing.T) {
+	templ, err := InstanceTemplate("{{.InstanceId}}")
+	assert.Nil(t, err)
+
+	kinds, err := ParseAddressKinds("public,private,secondary,ipv6,elastic")
+	assert.Nil(t, err)
+
+	reservations := []*ec2.Reservation{
+		{
+			Instances: []*ec2.Instance{
+				{
+					InstanceId:       aws.String("i-1"),
+					PublicIpAddress:  aws.String("54.0.0.1"),
+					PrivateIpAddress: aws.String("10.0.0.1"),
+					NetworkInterfaces: []*ec2.InstanceNetworkInterface{
+						{
+							PrivateIpAddresses: []*ec2.InstancePrivateIpAddress{
+								{
+									Primary:          aws.Bool(true),
+									PrivateIpAddress: aws.String("10.0.0.1"),
+									Association:      &ec2.InstanceNetworkInterfaceAssociation{IpOwnerId: aws.String("amazon"), PublicIp: aws.String("54.0.0.1")},
+								},
+								{
+									Primary:          aws.Bool(false),
+									PrivateIpAddress: aws.String("10.0.0.2"),
+									Association:      &ec2.InstanceNetworkInterfaceAssociation{IpOwnerId: aws.String("123456789012"), PublicIp: aws.String("3.0.0.1")},
+								},
+							},
+							Ipv6Addresses: []*ec2.InstanceIpv6Address{{Ipv6Address: aws.String("2600::1")}},
+						},
+						{
+							PrivateIpAddresses: []*ec2.InstancePrivateIpAddress{
+								{Primary: aws.Bool(true), PrivateIpAddress: aws.String("10.1.0.1")},
+								{Primary: aws.Bool(false), PrivateIpAddress: aws.String("10.1.0.2")},
+							},
+							Ipv6Addresses: []*ec2.InstanceIpv6Address{{Ipv6Address: aws.String("2600::2")}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	named := parseReservations(reservations, templ, kinds)
+	IPs := map[string]string{}
+	for name, address := range named.addresses {
+		IPs[name] = address.IP
+	}
+
+	assert.Equal(
+		t,
+		map[string]string{
+			"i-1":             "54.0.0.1",
+			"i-1-private":     "10.0.0.1",
+			"i-1-secondary":   "10.0.0.2",
+			"i-1-secondary-2": "10.1.0.2",
+			"i-1-ipv6":        "2600::1",
+			"i-1-ipv6-2":      "2600::2",
+			"i-1-eip":         "3.0.0.1",
+		},
+		IPs,
+	)
+	assert.Nil(t, named.warnings)
+}
@@ -0,0 +1,283 @@
+package awsUtil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+)
+
+const (
+	// Classic is the type of load balancers from the original ELB API
+	Classic = "classic"
+	// Application is the type of application load balancers
+	Application = "application"
+	// Network is the type of network load balancers
+	Network = "network"
+)
+
+// LoadBalancerTypes lists the load balancer types that can be imported
+var LoadBalancerTypes = []string{Classic, Application, Network}
+
+// LoadBalancerSchemes lists the schemes a load balancer can have
+var LoadBalancerSchemes = []string{"internet-facing", "internal"}
+
+// maxTagRequest is the most load balancers the DescribeTags APIs accept at once
+const maxTagRequest = 20
+
+// LoadBalancer describes a classic, application or network load balancer
+// ID is the ARN of application and network load balancers and the name of classic load balancers
+// Addresses holds the static address of each availability zone for network load balancers that have them
+type LoadBalancer struct {
+	ID        string
+	Name      string
+	Type      string
+	Scheme    string
+	DNSName   string
+	Region    string
+	Tags      map[string]string
+	Addresses []ZoneAddress
+}
+
+// ZoneAddress is the static address of a load balancer in an availability zone
+type ZoneAddress struct {
+	Zone string
+	IP   string
+}
+
+// LoadBalancerFilter chooses which load balancers are read, empty fields match every load balancer
+// A tag with an empty value matches any load balancer that has the tag
+type LoadBalancerFilter struct {
+	Types  []string
+	Scheme string
+	Tags   map[string]string
+}
+
+// ParseLoadBalancerFilter parses comma separated types, a scheme and comma separated key=value tags
+func ParseLoadBalancerFilter(types, scheme, tags string) (LoadBalancerFilter, error) {
+	filter := LoadBalancerFilter{Scheme: scheme}
+	if types != "" {
+		filter.Types = strings.Split(types, ",")
+		for _, loadBalancerType := range filter.Types {
+			if !contains(LoadBalancerTypes, loadBalancerType) {
+				return LoadBalancerFilter{}, fmt.Errorf("Invalid load balancer type %s", loadBalancerType)
+			}
+		}
+	}
+
+	if scheme != "" && !contains(LoadBalancerSchemes, scheme) {
+		return LoadBalancerFilter{}, fmt.Errorf("Invalid load balancer scheme %s", scheme)
+	}
+
+	if tags != "" {
+		filter.Tags = map[string]string{}
+		for _, tag := range strings.Split(tags, ",") {
+			parts := strings.SplitN(tag, "=", 2)
+			if parts[0] == "" {
+				return LoadBalancerFilter{}, fmt.Errorf("Invalid tag %s", tag)
+			}
+
+			filter.Tags[parts[0]] = ""
+			if len(parts) == 2 {
+				filter.Tags[parts[0]] = parts[1]
+			}
+		}
+	}
+
+	return filter, nil
+}
+
+// includesType reports whether load balancers of a type can match the filter
+func (filter LoadBalancerFilter) includesType(loadBalancerType string) bool {
+	return len(filter.Types) == 0 || contains(filter.Types, loadBalancerType)
+}
+
+// Matches reports whether a load balancer matches the filter
+func (filter LoadBalancerFilter) Matches(loadBalancer LoadBalancer) bool {
+	if !filter.includesType(loadBalancer.Type) {
+		return false
+	}
+
+	if filter.Scheme != "" && loadBalancer.Scheme != filter.Scheme {
+		return false
+	}
+
+	for key, value := range filter.Tags {
+		tagValue, exists := loadBalancer.Tags[key]
+		if !exists || (value != "" && tagValue != value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// readLoadBalancers reads the load balancers in a region from both the classic and elbv2 APIs
+func readLoadBalancers(sess *session.Session, filter LoadBalancerFilter) ([]LoadBalancer, error) {
+	loadBalancers := []LoadBalancer{}
+	if filter.includesType(Classic) {
+		classic, err := readClassicLoadBalancers(elb.New(sess))
+		if err != nil {
+			return nil, err
+		}
+
+		loadBalancers = append(loadBalancers, classic...)
+	}
+
+	if filter.includesType(Application) || filter.includesType(Network) {
+		current, err := readCurrentLoadBalancers(elbv2.New(sess))
+		if err != nil {
+			return nil, err
+		}
+
+		loadBalancers = append(loadBalancers, current...)
+	}
+
+	matching := make([]LoadBalancer, 0, len(loadBalancers))
+	for _, loadBalancer := range loadBalancers {
+		loadBalancer.Region = aws.StringValue(sess.Config.Region)
+		if filter.Matches(loadBalancer) {
+			matching = append(matching, loadBalancer)
+		}
+	}
+
+	return matching, nil
+}
+
+func readClassicLoadBalancers(svc *elb.ELB) ([]LoadBalancer, error) {
+	descriptions := make([]*elb.LoadBalancerDescription, 0, 10)
+	err := svc.DescribeLoadBalancersPages(&elb.DescribeLoadBalancersInput{}, func(resp *elb.DescribeLoadBalancersOutput, lastPage bool) bool {
+		descriptions = append(descriptions, resp.LoadBalancerDescriptions...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loadBalancers := make([]LoadBalancer, 0, len(descriptions))
+	names := make([]*string, 0, len(descriptions))
+	for _, description := range descriptions {
+		loadBalancers = append(loadBalancers, LoadBalancer{
+			ID:      aws.StringValue(description.LoadBalancerName),
+			Name:    aws.StringValue(description.LoadBalancerName),
+			Type:    Classic,
+			Scheme:  aws.StringValue(description.Scheme),
+			DNSName: aws.StringValue(description.DNSName),
+			Tags:    map[string]string{},
+		})
+		names = append(names, description.LoadBalancerName)
+	}
+
+	for start := 0; start < len(names); start += maxTagRequest {
+		end := start + maxTagRequest
+		if end > len(names) {
+			end = len(names)
+		}
+
+		output, err := svc.DescribeTags(&elb.DescribeTagsInput{LoadBalancerNames: names[start:end]})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, description := range output.TagDescriptions {
+			index := indexOf(names, aws.StringValue(description.LoadBalancerName))
+			if index == -1 {
+				continue
+			}
+
+			for _, tag := range description.Tags {
+				loadBalancers[index].Tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+		}
+	}
+
+	return loadBalancers, nil
+}
+
+func readCurrentLoadBalancers(svc *elbv2.ELBV2) ([]LoadBalancer, error) {
+	descriptions := make([]*elbv2.LoadBalancer, 0, 10)
+	err := svc.DescribeLoadBalancersPages(&elbv2.DescribeLoadBalancersInput{}, func(resp *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+		descriptions = append(descriptions, resp.LoadBalancers...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loadBalancers := make([]LoadBalancer, 0, len(descriptions))
+	ARNs := make([]*string, 0, len(descriptions))
+	for _, description := range descriptions {
+		loadBalancer := LoadBalancer{
+			ID:      aws.StringValue(description.LoadBalancerArn),
+			Name:    aws.StringValue(description.LoadBalancerName),
+			Type:    aws.StringValue(description.Type),
+			Scheme:  aws.StringValue(description.Scheme),
+			DNSName: aws.StringValue(description.DNSName),
+			Tags:    map[string]string{},
+		}
+
+		for _, zone := range description.AvailabilityZones {
+			for _, address := range zone.LoadBalancerAddresses {
+				if address.IpAddress != nil {
+					loadBalancer.Addresses = append(loadBalancer.Addresses, ZoneAddress{Zone: aws.StringValue(zone.ZoneName), IP: *address.IpAddress})
+				}
+			}
+		}
+
+		sort.Slice(loadBalancer.Addresses, func(i, j int) bool {
+			return loadBalancer.Addresses[i].Zone < loadBalancer.Addresses[j].Zone
+		})
+
+		loadBalancers = append(loadBalancers, loadBalancer)
+		ARNs = append(ARNs, description.LoadBalancerArn)
+	}
+
+	for start := 0; start < len(ARNs); start += maxTagRequest {
+		end := start + maxTagRequest
+		if end > len(ARNs) {
+			end = len(ARNs)
+		}
+
+		output, err := svc.DescribeTags(&elbv2.DescribeTagsInput{ResourceArns: ARNs[start:end]})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, description := range output.TagDescriptions {
+			index := indexOf(ARNs, aws.StringValue(description.ResourceArn))
+			if index == -1 {
+				continue
+			}
+
+			for _, tag := range description.Tags {
+				loadBalancers[index].Tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+			}
+		}
+	}
+
+	return loadBalancers, nil
+}
+
+func indexOf(values []*string, value string) int {
+	for index, candidate := range values {
+		if aws.StringValue(candidate) == value {
+			return index
+		}
+	}
+
+	return -1
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,54 @@
+package awsUtil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLoadBalancerFilter(t *testing.T) {
+	filter, err := ParseLoadBalancerFilter("application,network", "internal", "env=prod,team")
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		LoadBalancerFilter{Types: []string{Application, Network}, Scheme: "internal", Tags: map[string]string{"env": "prod", "team": ""}},
+		filter,
+	)
+}
+
+func TestParseLoadBalancerFilterEmpty(t *testing.T) {
+	filter, err := ParseLoadBalancerFilter("", "", "")
+	assert.Nil(t, err)
+	assert.Equal(t, LoadBalancerFilter{}, filter)
+	assert.True(t, filter.Matches(LoadBalancer{Type: Classic}))
+}
+
+func TestParseLoadBalancerFilterInvalidType(t *testing.T) {
+	_, err := ParseLoadBalancerFilter("gateway", "", "")
+	assert.EqualError(t, err, "Invalid load balancer type gateway")
+}
+
+func TestParseLoadBalancerFilterInvalidScheme(t *testing.T) {
+	_, err := ParseLoadBalancerFilter("", "public", "")
+	assert.EqualError(t, err, "Invalid load balancer scheme public")
+}
+
+func TestParseLoadBalancerFilterInvalidTag(t *testing.T) {
+	_, err := ParseLoadBalancerFilter("", "", "=prod")
+	assert.EqualError(t, err, "Invalid tag =prod")
+}
+
+func TestLoadBalancerFilterMatches(t *testing.T) {
+	filter := LoadBalancerFilter{Types: []string{Network}, Scheme: "internal", Tags: map[string]string{"env": "prod", "team": ""}}
+	loadBalancer := LoadBalancer{Type: Network, Scheme: "internal", Tags: map[string]string{"env": "prod", "team": "web"}}
+	assert.True(t, filter.Matches(loadBalancer))
+
+	loadBalancer.Tags["env"] = "staging"
+	assert.False(t, filter.Matches(loadBalancer))
+
+	loadBalancer.Tags = map[string]string{"env": "prod"}
+	assert.False(t, filter.Matches(loadBalancer))
+
+	assert.False(t, filter.Matches(LoadBalancer{Type: Network, Scheme: "internet-facing", Tags: map[string]string{"env": "prod", "team": "web"}}))
+	assert.False(t, filter.Matches(LoadBalancer{Type: Application, Scheme: "internal", Tags: map[string]string{"env": "prod", "team": "web"}}))
+}
@@ -0,0 +1,330 @@
+package awsUtil
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/go-ini/ini"
+)
+
+const (
+	// profilePrefix starts the name of every profile section in the config file except default
+	profilePrefix = "profile "
+	// ssoSessionPrefix starts the name of the sections the config file shares SSO settings between profiles with
+	ssoSessionPrefix = "sso-session "
+	// defaultProfile is the profile used when none is given
+	defaultProfile = "default"
+)
+
+// sharedProfiles are the profiles defined in the shared credentials and config files
+type sharedProfiles struct {
+	// profiles maps each profile name to the keys of its sections, keys in the config file win over the credentials file
+	profiles    map[string]map[string]string
+	ssoSessions map[string]map[string]string
+}
+
+// credentialsFilename is the shared credentials file, AWS_SHARED_CREDENTIALS_FILE overrides ~/.aws/credentials
+func credentialsFilename() string {
+	if filename := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); filename != "" {
+		return filename
+	}
+
+	return defaults.SharedCredentialsFilename()
+}
+
+// configFilename is the shared config file, AWS_CONFIG_FILE overrides ~/.aws/config
+func configFilename() string {
+	if filename := os.Getenv("AWS_CONFIG_FILE"); filename != "" {
+		return filename
+	}
+
+	return defaults.SharedConfigFilename()
+}
+
+// loadSharedProfiles reads the profiles in the shared credentials and config files, files that don't exist are skipped
+func loadSharedProfiles() (*sharedProfiles, error) {
+	shared := &sharedProfiles{profiles: map[string]map[string]string{}, ssoSessions: map[string]map[string]string{}}
+	credentialsFile, err := loadIniFile(credentialsFilename())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, section := range credentialsFile.Sections() {
+		if section.Name() != ini.DEFAULT_SECTION {
+			shared.addProfile(section.Name(), section.KeysHash())
+		}
+	}
+
+	configFile, err := loadIniFile(configFilename())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, section := range configFile.Sections() {
+		name := section.Name()
+		switch {
+		case name == defaultProfile:
+			shared.addProfile(name, section.KeysHash())
+		case strings.HasPrefix(name, profilePrefix):
+			shared.addProfile(strings.TrimSpace(strings.TrimPrefix(name, profilePrefix)), section.KeysHash())
+		case strings.HasPrefix(name, ssoSessionPrefix):
+			shared.ssoSessions[strings.TrimSpace(strings.TrimPrefix(name, ssoSessionPrefix))] = section.KeysHash()
+		}
+	}
+
+	return shared, nil
+}
+
+func loadIniFile(filename string) (*ini.File, error) {
+	contents, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return ini.Empty(), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := ini.Load(contents)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse %s: %v", filename, err)
+	}
+
+	return file, nil
+}
+
+func (shared *sharedProfiles) addProfile(name string, keys map[string]string) {
+	if shared.profiles[name] == nil {
+		shared.profiles[name] = map[string]string{}
+	}
+
+	for key, value := range keys {
+		shared.profiles[name][key] = value
+	}
+}
+
+// names lists the profiles sorted by name
+func (shared *sharedProfiles) names() []string {
+	names := make([]string, 0, len(shared.profiles))
+	for name := range shared.profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// ssoProfile finds the SSO settings of a profile, ok is false for profiles that don't use SSO
+// Profiles either name an sso-session section with sso_session or set sso_start_url and sso_region themselves
+func (shared *sharedProfiles) ssoProfile(name string) (ssoSettings, bool, error) {
+	profile := shared.profiles[name]
+	if profile["sso_session"] == "" && profile["sso_start_url"] == "" {
+		return ssoSettings{}, false, nil
+	}
+
+	settings := ssoSettings{
+		profile:   name,
+		startURL:  profile["sso_start_url"],
+		region:    profile["sso_region"],
+		accountID: profile["sso_account_id"],
+		roleName:  profile["sso_role_name"],
+		cacheKey:  profile["sso_start_url"],
+	}
+
+	if sessionName := profile["sso_session"]; sessionName != "" {
+		session, exists := shared.ssoSessions[sessionName]
+		if !exists {
+			return ssoSettings{}, true, fmt.Errorf("Profile %s uses the sso-session %s, which does not exist", name, sessionName)
+		}
+
+		settings.startURL = session["sso_start_url"]
+		settings.region = session["sso_region"]
+		settings.cacheKey = sessionName
+	}
+
+	if settings.startURL == "" || settings.region == "" || settings.accountID == "" || settings.roleName == "" {
+		return ssoSettings{}, true, fmt.Errorf("Profile %s needs sso_start_url, sso_region, sso_account_id and sso_role_name to use SSO", name)
+	}
+
+	return settings, true, nil
+}
+
+// ssoSettings are what an SSO profile needs to exchange the token cached by aws sso login for role credentials
+type ssoSettings struct {
+	profile   string
+	startURL  string
+	region    string
+	accountID string
+	roleName  string
+	cacheKey  string
+}
+
+// ssoCredentials provides the role credentials of an SSO profile, the AWS SDK in this build can't read SSO profiles itself
+type ssoCredentials struct {
+	credentials.Expiry
+	settings ssoSettings
+	// endpoint replaces the SSO portal, like the endpoint setting replaces every other AWS endpoint
+	endpoint string
+	client   *http.Client
+}
+
+type ssoToken struct {
+	AccessToken string    `json:"accessToken"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+type ssoRoleCredentials struct {
+	RoleCredentials struct {
+		AccessKeyID     string `json:"accessKeyId"`
+		SecretAccessKey string `json:"secretAccessKey"`
+		SessionToken    string `json:"sessionToken"`
+		Expiration      int64  `json:"expiration"`
+	} `json:"roleCredentials"`
+}
+
+// Retrieve exchanges the cached SSO token for the credentials of the profile's role
+func (provider *ssoCredentials) Retrieve() (credentials.Value, error) {
+	token, err := provider.cachedToken()
+	if err != nil {
+		return credentials.Value{}, err
+	}
+
+	endpoint := provider.endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://portal.sso.%s.amazonaws.com", provider.settings.region)
+	}
+
+	query := url.Values{"account_id": {provider.settings.accountID}, "role_name": {provider.settings.roleName}}
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/federation/credentials?%s", strings.TrimSuffix(endpoint, "/"), query.Encode()), nil)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+
+	request.Header.Set("x-amz-sso_bearer_token", token.AccessToken)
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode != http.StatusOK {
+		return credentials.Value{}, fmt.Errorf("Unable to get SSO credentials for profile %s: %s", provider.settings.profile, response.Status)
+	}
+
+	var roleCredentials ssoRoleCredentials
+	err = json.NewDecoder(response.Body).Decode(&roleCredentials)
+	if err != nil {
+		return credentials.Value{}, err
+	}
+
+	provider.SetExpiration(time.Unix(0, roleCredentials.RoleCredentials.Expiration*int64(time.Millisecond)), time.Minute)
+	return credentials.Value{
+		AccessKeyID:     roleCredentials.RoleCredentials.AccessKeyID,
+		SecretAccessKey: roleCredentials.RoleCredentials.SecretAccessKey,
+		SessionToken:    roleCredentials.RoleCredentials.SessionToken,
+		ProviderName:    "SSOProvider",
+	}, nil
+}
+
+// cachedToken reads the token aws sso login cached for the profile's start URL or sso-session
+func (provider *ssoCredentials) cachedToken() (ssoToken, error) {
+	cacheFile := filepath.Join(filepath.Dir(defaults.SharedConfigFilename()), "sso", "cache", ssoCacheName(provider.settings.cacheKey))
+	loginError := fmt.Errorf("No SSO session for profile %s, run aws sso login --profile %s", provider.settings.profile, provider.settings.profile)
+	contents, err := ioutil.ReadFile(cacheFile)
+	if err != nil {
+		return ssoToken{}, loginError
+	}
+
+	var token ssoToken
+	err = json.Unmarshal(contents, &token)
+	if err != nil || token.AccessToken == "" || token.ExpiresAt.Before(time.Now()) {
+		return ssoToken{}, loginError
+	}
+
+	return token, nil
+}
+
+// ssoCacheName is the name of the file aws sso login caches the token of a start URL or sso-session in
+func ssoCacheName(cacheKey string) string {
+	hash := sha1.Sum([]byte(cacheKey))
+	return hex.EncodeToString(hash[:]) + ".json"
+}
+
+// envProfile is the profile used when none is given, AWS_PROFILE or AWS_DEFAULT_PROFILE override default
+func envProfile() string {
+	for _, name := range []string{"AWS_PROFILE", "AWS_DEFAULT_PROFILE"} {
+		if profile := os.Getenv(name); profile != "" {
+			return profile
+		}
+	}
+
+	return defaultProfile
+}
+
+// selectProfiles lists the profiles chosen by a comma separated list of profile names and patterns like prod-*
+// Patterns and exclusions starting with ! are matched against the profiles of the shared files, names are used as they are
+func selectProfiles(util AwsInterface, profiles string) ([]string, error) {
+	if profiles == "" {
+		return []string{envProfile()}, nil
+	}
+
+	var names, include, exclude []string
+	for _, pattern := range strings.Split(profiles, ",") {
+		pattern = strings.TrimSpace(pattern)
+		excluded := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return nil, fmt.Errorf("Invalid profile %s", pattern)
+		}
+
+		switch {
+		case excluded:
+			exclude = append(exclude, pattern)
+		case strings.ContainsAny(pattern, "*?["):
+			include = append(include, pattern)
+		default:
+			names = append(names, pattern)
+		}
+	}
+
+	if len(include) != 0 || (len(exclude) != 0 && len(names) == 0) {
+		available, err := util.ListAllProfiles()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, profile := range available {
+			if len(include) == 0 || matchesAny(include, profile) {
+				names = append(names, profile)
+			}
+		}
+	}
+
+	selected := []string{}
+	seen := map[string]bool{}
+	for _, profile := range names {
+		if !seen[profile] && !matchesAny(exclude, profile) {
+			seen[profile] = true
+			selected = append(selected, profile)
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("No profiles match %s", profiles)
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,247 @@
+package awsUtil
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCredentialsFile = `[default]
+aws_access_key_id = AKID
+aws_secret_access_key = SECRET
+
+[dev]
+aws_access_key_id = AKID
+aws_secret_access_key = SECRET
+`
+
+const testConfigFile = `[default]
+region = us-east-1
+
+[profile prod]
+role_arn = arn:aws:iam::1:role/admin
+source_profile = default
+
+[profile sso]
+sso_session = corp
+sso_account_id = 1
+sso_role_name = admin
+
+[profile legacy-sso]
+sso_start_url = https://corp.awsapps.com/start
+sso_region = us-east-1
+sso_account_id = 2
+sso_role_name = readonly
+
+[profile broken-sso]
+sso_session = missing
+
+[sso-session corp]
+sso_start_url = https://corp.awsapps.com/start
+sso_region = eu-west-1
+
+[services local]
+ec2 =
+  endpoint_url = http://localhost
+`
+
+// setupSharedFiles writes the shared credentials and config files to a temporary home directory
+func setupSharedFiles(t *testing.T, credentials, config string) string {
+	home, err := ioutil.TempDir("/tmp", "home")
+	assert.Nil(t, err)
+	assert.Nil(t, os.Mkdir(filepath.Join(home, ".aws"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(home, ".aws", "credentials"), []byte(credentials), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(home, ".aws", "config"), []byte(config), 0644))
+	assert.Nil(t, os.Setenv("HOME", home))
+	assert.Nil(t, os.Unsetenv("AWS_SHARED_CREDENTIALS_FILE"))
+	assert.Nil(t, os.Unsetenv("AWS_CONFIG_FILE"))
+	return home
+}
+
+func TestListAllProfiles(t *testing.T) {
+	home := setupSharedFiles(t, testCredentialsFile, testConfigFile)
+	defer func() { assert.Nil(t, os.RemoveAll(home)) }()
+
+	profiles, err := NewAwsUtil("").ListAllProfiles()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"broken-sso", "default", "dev", "legacy-sso", "prod", "sso"}, profiles)
+}
+
+func TestListAllProfilesEnvFiles(t *testing.T) {
+	home := setupSharedFiles(t, "", "")
+	defer func() { assert.Nil(t, os.RemoveAll(home)) }()
+
+	credentialsFile := filepath.Join(home, "credentials")
+	assert.Nil(t, ioutil.WriteFile(credentialsFile, []byte("[ci]\n"), 0644))
+	assert.Nil(t, os.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile))
+	assert.Nil(t, os.Setenv("AWS_CONFIG_FILE", "/notafile"))
+
+	profiles, err := NewAwsUtil("").ListAllProfiles()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ci"}, profiles)
+}
+
+func TestListAllProfilesInvalidFile(t *testing.T) {
+	home := setupSharedFiles(t, "", "[profile")
+	defer func() { assert.Nil(t, os.RemoveAll(home)) }()
+
+	_, err := NewAwsUtil("").ListAllProfiles()
+	assert.Contains(t, fmt.Sprint(err), "Unable to parse "+filepath.Join(home, ".aws", "config"))
+}
+
+func TestSSOProfile(t *testing.T) {
+	home := setupSharedFiles(t, testCredentialsFile, testConfigFile)
+	defer func() { assert.Nil(t, os.RemoveAll(home)) }()
+
+	shared, err := loadSharedProfiles()
+	assert.Nil(t, err)
+
+	settings, isSSO, err := shared.ssoProfile("sso")
+	assert.Nil(t, err)
+	assert.True(t, isSSO)
+	assert.Equal(
+		t,
+		ssoSettings{profile: "sso", startURL: "https://corp.awsapps.com/start", region: "eu-west-1", accountID: "1", roleName: "admin", cacheKey: "corp"},
+		settings,
+	)
+
+	settings, isSSO, err = shared.ssoProfile("legacy-sso")
+	assert.Nil(t, err)
+	assert.True(t, isSSO)
+	assert.Equal(t, "https://corp.awsapps.com/start", settings.cacheKey)
+
+	_, isSSO, err = shared.ssoProfile("prod")
+	assert.Nil(t, err)
+	assert.False(t, isSSO)
+
+	_, _, err = shared.ssoProfile("broken-sso")
+	assert.EqualError(t, err, "Profile broken-sso uses the sso-session missing, which does not exist")
+}
+
+func TestSSOCredentials(t *testing.T) {
+	home := setupSharedFiles(t, testCredentialsFile, testConfigFile)
+	defer func() { assert.Nil(t, os.RemoveAll(home)) }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/federation/credentials", r.URL.Path)
+		assert.Equal(t, "account_id=1&role_name=admin", r.URL.RawQuery)
+		assert.Equal(t, "token", r.Header.Get("x-amz-sso_bearer_token"))
+		expiration := time.Now().Add(time.Hour).Unix() * 1000
+		fmt.Fprintf(w, `{"roleCredentials": {"accessKeyId": "SSOKEY", "secretAccessKey": "SSOSECRET", "sessionToken": "SESSION", "expiration": %d}}`, expiration)
+	}))
+	defer server.Close()
+
+	shared, err := loadSharedProfiles()
+	assert.Nil(t, err)
+	settings, _, err := shared.ssoProfile("sso")
+	assert.Nil(t, err)
+	credentials := &ssoCredentials{settings: settings, endpoint: server.URL, client: http.DefaultClient}
+	_, err = credentials.Retrieve()
+	assert.EqualError(t, err, "No SSO session for profile sso, run aws sso login --profile sso")
+
+	cacheDir := filepath.Join(home, ".aws", "sso", "cache")
+	assert.Nil(t, os.MkdirAll(cacheDir, 0755))
+	token := fmt.Sprintf(`{"accessToken": "token", "expiresAt": "%s"}`, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(cacheDir, ssoCacheName("corp")), []byte(token), 0644))
+	value, err := credentials.Retrieve()
+	assert.Nil(t, err)
+	assert.Equal(t, "SSOKEY", value.AccessKeyID)
+	assert.Equal(t, "SSOSECRET", value.SecretAccessKey)
+	assert.Equal(t, "SESSION", value.SessionToken)
+	assert.False(t, credentials.IsExpired())
+}
+
+func TestSSOCredentialsExpiredToken(t *testing.T) {
+	home := setupSharedFiles(t, testCredentialsFile, testConfigFile)
+	defer func() { assert.Nil(t, os.RemoveAll(home)) }()
+
+	cacheDir := filepath.Join(home, ".aws", "sso", "cache")
+	assert.Nil(t, os.MkdirAll(cacheDir, 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(cacheDir, ssoCacheName("corp")), []byte(`{"accessToken": "token", "expiresAt": "2017-11-01T12:00:00Z"}`), 0644))
+
+	credentials := &ssoCredentials{settings: ssoSettings{profile: "sso", cacheKey: "corp"}, client: http.DefaultClient}
+	_, err := credentials.Retrieve()
+	assert.EqualError(t, err, "No SSO session for profile sso, run aws sso login --profile sso")
+}
+
+func TestSelectProfiles(t *testing.T) {
+	util := new(AwsUtil)
+	home := setupSharedFiles(t, testCredentialsFile, testConfigFile)
+	defer func() { assert.Nil(t, os.RemoveAll(home)) }()
+
+	assert.Nil(t, os.Unsetenv("AWS_PROFILE"))
+	assert.Nil(t, os.Unsetenv("AWS_DEFAULT_PROFILE"))
+	profiles, err := selectProfiles(util, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"default"}, profiles)
+
+	assert.Nil(t, os.Setenv("AWS_PROFILE", "dev"))
+	defer func() { assert.Nil(t, os.Unsetenv("AWS_PROFILE")) }()
+	profiles, err = selectProfiles(util, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"dev"}, profiles)
+
+	profiles, err = selectProfiles(util, "other, dev,other")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"other", "dev"}, profiles)
+
+	profiles, err = selectProfiles(util, "*sso,!broken-*")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"legacy-sso", "sso"}, profiles)
+
+	profiles, err = selectProfiles(util, "!*sso")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"default", "dev", "prod"}, profiles)
+
+	_, err = selectProfiles(util, "qa-*")
+	assert.EqualError(t, err, "No profiles match qa-*")
+
+	_, err = selectProfiles(util, "dev,[")
+	assert.EqualError(t, err, "Invalid profile [")
+}
+
+func TestDiscoverMultipleProfiles(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	util := newTestAwsUtil(t, server, "us-east-1")
+	instancesProvider := NewInstancesProvider(util)
+	settings, err := provider.ApplyDefaults(instancesProvider, map[string]string{"profile": "dev,prod", "region": "us-east-1", "endpoint": server.URL})
+	assert.Nil(t, err)
+
+	warnings := new(bytes.Buffer)
+	addresses, err := instancesProvider.Discover(settings, warnings)
+	assert.Nil(t, err)
+	assert.Equal(t, "", warnings.String())
+
+	names := []string{}
+	for _, address := range addresses {
+		names = append(names, address.Name+" "+address.Metadata[provider.ProfileMetadata])
+	}
+
+	assert.Equal(t, []string{"dev-i-us-east-1 dev", "dev-i-us-east-1-private dev", "prod-i-us-east-1 prod", "prod-i-us-east-1-private prod"}, names)
+}
+
+func TestDiscoverMultipleProfilesFailing(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	util := newTestAwsUtil(t, server, "")
+	instancesProvider := NewInstancesProvider(util)
+	settings, err := provider.ApplyDefaults(instancesProvider, map[string]string{"profile": "dev,prod", "region": "[", "endpoint": server.URL})
+	assert.Nil(t, err)
+
+	warnings := new(bytes.Buffer)
+	_, err = instancesProvider.Discover(settings, warnings)
+	assert.EqualError(t, err, "Invalid region [")
+	assert.Equal(t, "Warning: Invalid region [, skipping profile dev\nWarning: Invalid region [, skipping profile prod\n", warnings.String())
+}
@@ -0,0 +1,1034 @@
+package awsUtil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/guywithnose/hostBuilder/provider"
+)
+
+var profileSetting = provider.Setting{
+	Name:   "profile",
+	Alias:  "p",
+	Usage:  "The comma separated AWS profiles to use, * matches any characters and a leading ! excludes a profile, AWS_PROFILE or default when empty",
+	EnvVar: "HOST_BUILDER_AWS_PROFILE",
+}
+
+var profilePrefixSetting = provider.Setting{
+	Name:    "profilePrefix",
+	Usage:   "The template prefixed to every name when importing from more than one profile",
+	Default: "{{.Profile}}-",
+	EnvVar:  "HOST_BUILDER_AWS_PROFILE_PREFIX",
+}
+
+var endpointSetting = provider.Setting{
+	Name:   "endpoint",
+	Usage:  "Send every AWS request to this endpoint instead of the AWS default",
+	EnvVar: "HOST_BUILDER_AWS_ENDPOINT",
+}
+
+// connectionSettings choose the accounts, regions and endpoint every regional AWS provider talks to
+var connectionSettings = []provider.Setting{
+	profileSetting,
+	profilePrefixSetting,
+	{
+		Name:   "region",
+		Alias:  "r",
+		Usage:  "The comma separated regions to scan, * matches any characters and a leading ! excludes a region",
+		EnvVar: "HOST_BUILDER_AWS_REGION",
+	},
+	endpointSetting,
+}
+
+// InstancesProvider discovers the public and private addresses of EC2 instances
+type InstancesProvider struct {
+	util AwsInterface
+}
+
+// NewInstancesProvider builds an instances provider that talks to AWS through util
+func NewInstancesProvider(util AwsInterface) *InstancesProvider {
+	return &InstancesProvider{util: util}
+}
+
+// Name is the name sources use to refer to the provider
+func (instancesProvider *InstancesProvider) Name() string {
+	return "awsInstances"
+}
+
+// Schema lists the settings the provider accepts
+func (instancesProvider *InstancesProvider) Schema() []provider.Setting {
+	return append(
+		append([]provider.Setting{}, connectionSettings...),
+		provider.Setting{
+			Name:    "template",
+			Alias:   "t",
+			Usage:   "The template to use for naming instance ips",
+			Default: "{{.InstanceId}}",
+			EnvVar:  "HOST_BUILDER_INSTANCE_TEMPLATE",
+		},
+		provider.Setting{Name: "filter", Usage: "Only import instances matching these space separated DescribeInstances filters like tag:env=staging,production"},
+		provider.Setting{Name: "state", Usage: "Only import instances in these comma separated states like running"},
+		provider.Setting{
+			Name:    "addresses",
+			Usage:   "The comma separated address kinds to import (public, private, secondary, ipv6, elastic), each optionally followed by :{name suffix}",
+			Default: DefaultAddressKinds,
+			EnvVar:  "HOST_BUILDER_INSTANCE_ADDRESSES",
+		},
+		provider.Setting{
+			Name:  "hostMap",
+			Usage: "A JSON file mapping instance name patterns to the hostnames they are options for, on top of the hostbuilder:hostnames tag",
+			File:  true,
+		},
+		provider.Setting{
+			Name:    "makeCurrent",
+			Usage:   "Make the option each instance adds to its hostnames their current option (true or false)",
+			Default: "false",
+		},
+	)
+}
+
+// Discover finds the addresses of the instances in every region of each profile
+func (instancesProvider *InstancesProvider) Discover(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	return discoverProfiles(instancesProvider.util, settings, warnings, instancesProvider.discoverProfile)
+}
+
+func (instancesProvider *InstancesProvider) discoverProfile(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	err := connect(instancesProvider.util, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	templ, err := InstanceTemplate(settings["template"])
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := ParseInstanceFilter(settings["filter"], settings["state"])
+	if err != nil {
+		return nil, err
+	}
+
+	kinds, err := ParseAddressKinds(settings["addresses"])
+	if err != nil {
+		return nil, err
+	}
+
+	hostMap, err := LoadHostMap(settings["hostMap"])
+	if err != nil {
+		return nil, err
+	}
+
+	makeCurrent, err := strconv.ParseBool(settings["makeCurrent"])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid makeCurrent %s, expected true or false", settings["makeCurrent"])
+	}
+
+	instances, err := instancesProvider.util.ReadAllInstances(templ, filter, kinds, warnings)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]provider.Address, 0, len(instances))
+	for _, instance := range instances {
+		metadata := map[string]string{
+			provider.ProfileMetadata:  settings["profile"],
+			provider.RegionMetadata:   instance.Region,
+			provider.ResourceMetadata: instance.InstanceID,
+		}
+		addresses = append(addresses, provider.Address{Name: instance.Name, IP: instance.IP, Metadata: metadata})
+		if !instance.Primary {
+			continue
+		}
+
+		hostnames, option := instanceHosts(instance, hostMap)
+		for _, hostname := range hostnames {
+			hostMetadata := map[string]string{provider.CurrentMetadata: strconv.FormatBool(makeCurrent)}
+			for key, value := range metadata {
+				hostMetadata[key] = value
+			}
+
+			addresses = append(addresses, provider.Address{Host: hostname, Name: option, IP: instance.IP, Metadata: hostMetadata})
+		}
+	}
+
+	return addresses, nil
+}
+
+// Complete suggests values for a setting
+func (instancesProvider *InstancesProvider) Complete(setting string, _ map[string]string) []string {
+	switch setting {
+	case "state":
+		return InstanceStates
+	case "addresses":
+		return InstanceAddressKinds
+	case "makeCurrent":
+		return []string{"true", "false"}
+	}
+
+	return completeProfile(instancesProvider.util, setting)
+}
+
+// LoadBalancersProvider discovers the addresses of classic, application and network load balancers
+type LoadBalancersProvider struct {
+	util AwsInterface
+}
+
+// NewLoadBalancersProvider builds a load balancers provider that talks to AWS through util
+func NewLoadBalancersProvider(util AwsInterface) *LoadBalancersProvider {
+	return &LoadBalancersProvider{util: util}
+}
+
+// Name is the name sources use to refer to the provider
+func (loadBalancersProvider *LoadBalancersProvider) Name() string {
+	return "awsLoadBalancers"
+}
+
+// Schema lists the settings the provider accepts
+func (loadBalancersProvider *LoadBalancersProvider) Schema() []provider.Setting {
+	return append(
+		append([]provider.Setting{}, connectionSettings...),
+		provider.Setting{
+			Name:    "template",
+			Alias:   "t",
+			Usage:   "The template to use for naming load balancer ips",
+			Default: "{{.Name}}",
+			EnvVar:  "HOST_BUILDER_LOAD_BALANCER_TEMPLATE",
+		},
+		provider.Setting{Name: "types", Usage: "The comma separated load balancer types to import (classic, application, network)"},
+		provider.Setting{Name: "scheme", Usage: "Only import load balancers with this scheme (internet-facing, internal)"},
+		provider.Setting{Name: "tags", Usage: "Only import load balancers with these comma separated key=value tags, a key without a value matches any value"},
+	)
+}
+
+// Discover finds the addresses of the load balancers in every region of each profile
+// Network load balancers with static addresses get an address for each availability zone named {name}-{zone},
+// every other load balancer gets the address its DNS name resolves to
+func (loadBalancersProvider *LoadBalancersProvider) Discover(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	return discoverProfiles(loadBalancersProvider.util, settings, warnings, loadBalancersProvider.discoverProfile)
+}
+
+func (loadBalancersProvider *LoadBalancersProvider) discoverProfile(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	err := connect(loadBalancersProvider.util, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	templ, err := template.New("").Parse(settings["template"])
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := ParseLoadBalancerFilter(settings["types"], settings["scheme"], settings["tags"])
+	if err != nil {
+		return nil, err
+	}
+
+	loadBalancers, err := loadBalancersProvider.util.ReadAllLoadBalancers(filter, warnings)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := []provider.Address{}
+	for _, loadBalancer := range loadBalancers {
+		name, err := provider.ExecuteTemplate(templ, loadBalancer)
+		if err != nil {
+			return nil, err
+		}
+
+		loadBalancerAddresses, err := addressLoadBalancer(name, loadBalancer, settings["profile"])
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, loadBalancerAddresses...)
+	}
+
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Name < addresses[j].Name
+	})
+
+	return addresses, nil
+}
+
+// Complete suggests values for a setting
+func (loadBalancersProvider *LoadBalancersProvider) Complete(setting string, _ map[string]string) []string {
+	switch setting {
+	case "types":
+		return LoadBalancerTypes
+	case "scheme":
+		return LoadBalancerSchemes
+	}
+
+	return completeProfile(loadBalancersProvider.util, setting)
+}
+
+// RDSProvider discovers the addresses RDS instance endpoints resolve to
+type RDSProvider struct {
+	util AwsInterface
+}
+
+// RDSEngines lists the engines an RDS instance can run
+var RDSEngines = []string{
+	"aurora",
+	"aurora-mysql",
+	"aurora-postgresql",
+	"mariadb",
+	"mysql",
+	"oracle-ee",
+	"oracle-se",
+	"oracle-se1",
+	"oracle-se2",
+	"postgres",
+	"sqlserver-ee",
+	"sqlserver-ex",
+	"sqlserver-se",
+	"sqlserver-web",
+}
+
+// NewRDSProvider builds an RDS provider that talks to AWS through util
+func NewRDSProvider(util AwsInterface) *RDSProvider {
+	return &RDSProvider{util: util}
+}
+
+// Name is the name sources use to refer to the provider
+func (rdsProvider *RDSProvider) Name() string {
+	return "awsRds"
+}
+
+// Schema lists the settings the provider accepts
+func (rdsProvider *RDSProvider) Schema() []provider.Setting {
+	return append(
+		append([]provider.Setting{}, connectionSettings...),
+		provider.Setting{
+			Name:    "template",
+			Alias:   "t",
+			Usage:   "The template to use for naming database ips",
+			Default: "{{.ID}}",
+			EnvVar:  "HOST_BUILDER_RDS_TEMPLATE",
+		},
+		provider.Setting{Name: "engines", Usage: "Only import instances running these comma separated engines like postgres,mysql"},
+	)
+}
+
+// Discover finds the addresses of the RDS instances in every region of each profile
+// Instances whose endpoint can't be resolved are skipped with a warning
+func (rdsProvider *RDSProvider) Discover(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	return discoverProfiles(rdsProvider.util, settings, warnings, rdsProvider.discoverProfile)
+}
+
+func (rdsProvider *RDSProvider) discoverProfile(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	err := connect(rdsProvider.util, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	templ, err := template.New("").Funcs(provider.TemplateFuncs).Parse(settings["template"])
+	if err != nil {
+		return nil, err
+	}
+
+	engines, err := parseList(settings["engines"], RDSEngines, "engine")
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := rdsProvider.util.ReadAllDBInstances(warnings)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := []provider.Address{}
+	for _, instance := range instances {
+		if len(engines) != 0 && !contains(engines, instance.Engine) {
+			continue
+		}
+
+		name, err := provider.ExecuteTemplate(templ, instance)
+		if err != nil {
+			return nil, err
+		}
+
+		IP, err := provider.ResolveAddress(instance.Endpoint)
+		if err != nil {
+			fmt.Fprintf(warnings, "Warning: %v, skipping %s\n", err, name)
+			continue
+		}
+
+		addresses = append(addresses, provider.Address{
+			Name: name,
+			IP:   IP,
+			Metadata: map[string]string{
+				"endpoint":                instance.Endpoint,
+				"engine":                  instance.Engine,
+				provider.ProfileMetadata:  settings["profile"],
+				provider.RegionMetadata:   instance.Region,
+				provider.ResourceMetadata: instance.ID,
+			},
+		})
+	}
+
+	sortAddresses(addresses)
+	return addresses, nil
+}
+
+// Complete suggests values for a setting
+func (rdsProvider *RDSProvider) Complete(setting string, _ map[string]string) []string {
+	if setting == "engines" {
+		return RDSEngines
+	}
+
+	return completeProfile(rdsProvider.util, setting)
+}
+
+// ElastiCacheProvider discovers the addresses ElastiCache node endpoints resolve to
+type ElastiCacheProvider struct {
+	util AwsInterface
+}
+
+// CacheEngines lists the engines an ElastiCache cluster can run
+var CacheEngines = []string{"memcached", "redis"}
+
+// NewElastiCacheProvider builds an ElastiCache provider that talks to AWS through util
+func NewElastiCacheProvider(util AwsInterface) *ElastiCacheProvider {
+	return &ElastiCacheProvider{util: util}
+}
+
+// Name is the name sources use to refer to the provider
+func (elastiCacheProvider *ElastiCacheProvider) Name() string {
+	return "awsElastiCache"
+}
+
+// Schema lists the settings the provider accepts
+func (elastiCacheProvider *ElastiCacheProvider) Schema() []provider.Setting {
+	return append(
+		append([]provider.Setting{}, connectionSettings...),
+		provider.Setting{
+			Name:    "template",
+			Alias:   "t",
+			Usage:   "The template to use for naming cache cluster ips",
+			Default: "{{.ID}}",
+			EnvVar:  "HOST_BUILDER_ELASTICACHE_TEMPLATE",
+		},
+		provider.Setting{Name: "engines", Usage: "Only import clusters running these comma separated engines (memcached, redis)"},
+	)
+}
+
+// Discover finds the addresses of the cache nodes in every region of each profile
+// A cluster with one node gets its address, a cluster with more nodes also gets an address for each node named {name}-{node ID}
+// Nodes whose endpoint can't be resolved are skipped with a warning
+func (elastiCacheProvider *ElastiCacheProvider) Discover(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	return discoverProfiles(elastiCacheProvider.util, settings, warnings, elastiCacheProvider.discoverProfile)
+}
+
+func (elastiCacheProvider *ElastiCacheProvider) discoverProfile(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	err := connect(elastiCacheProvider.util, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	templ, err := template.New("").Funcs(provider.TemplateFuncs).Parse(settings["template"])
+	if err != nil {
+		return nil, err
+	}
+
+	engines, err := parseList(settings["engines"], CacheEngines, "engine")
+	if err != nil {
+		return nil, err
+	}
+
+	clusters, err := elastiCacheProvider.util.ReadAllCacheClusters(warnings)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := []provider.Address{}
+	for _, cluster := range clusters {
+		if len(engines) != 0 && !contains(engines, cluster.Engine) {
+			continue
+		}
+
+		name, err := provider.ExecuteTemplate(templ, cluster)
+		if err != nil {
+			return nil, err
+		}
+
+		for index, node := range cluster.Nodes {
+			IP, err := provider.ResolveAddress(node.Endpoint)
+			if err != nil {
+				fmt.Fprintf(warnings, "Warning: %v, skipping node %s of %s\n", err, node.ID, name)
+				continue
+			}
+
+			metadata := map[string]string{
+				"endpoint":                node.Endpoint,
+				"engine":                  cluster.Engine,
+				"zone":                    node.Zone,
+				provider.ProfileMetadata:  settings["profile"],
+				provider.RegionMetadata:   cluster.Region,
+				provider.ResourceMetadata: cluster.ID,
+			}
+			if index == 0 {
+				addresses = append(addresses, provider.Address{Name: name, IP: IP, Metadata: metadata})
+			}
+
+			if len(cluster.Nodes) > 1 {
+				addresses = append(addresses, provider.Address{Name: fmt.Sprintf("%s-%s", name, node.ID), IP: IP, Metadata: metadata})
+			}
+		}
+	}
+
+	sortAddresses(addresses)
+	return addresses, nil
+}
+
+// Complete suggests values for a setting
+func (elastiCacheProvider *ElastiCacheProvider) Complete(setting string, _ map[string]string) []string {
+	if setting == "engines" {
+		return CacheEngines
+	}
+
+	return completeProfile(elastiCacheProvider.util, setting)
+}
+
+// ECSProvider discovers the addresses of running ECS tasks
+type ECSProvider struct {
+	util AwsInterface
+}
+
+// NewECSProvider builds an ECS provider that talks to AWS through util
+func NewECSProvider(util AwsInterface) *ECSProvider {
+	return &ECSProvider{util: util}
+}
+
+// Name is the name sources use to refer to the provider
+func (ecsProvider *ECSProvider) Name() string {
+	return "awsEcs"
+}
+
+// Schema lists the settings the provider accepts
+func (ecsProvider *ECSProvider) Schema() []provider.Setting {
+	return append(
+		append([]provider.Setting{}, connectionSettings...),
+		provider.Setting{
+			Name:    "template",
+			Alias:   "t",
+			Usage:   "The template to use for naming task ips",
+			Default: "{{.Cluster}}/{{.Service}}/{{.ID}}",
+			EnvVar:  "HOST_BUILDER_ECS_TEMPLATE",
+		},
+		provider.Setting{Name: "clusters", Usage: "Only import tasks from these comma separated clusters"},
+	)
+}
+
+// Discover finds the addresses of the running tasks in every region of each profile
+// Each task gets the private IP of the container instance it runs on, tasks without one are skipped with a warning
+func (ecsProvider *ECSProvider) Discover(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	return discoverProfiles(ecsProvider.util, settings, warnings, ecsProvider.discoverProfile)
+}
+
+func (ecsProvider *ECSProvider) discoverProfile(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	err := connect(ecsProvider.util, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	templ, err := template.New("").Funcs(provider.TemplateFuncs).Parse(settings["template"])
+	if err != nil {
+		return nil, err
+	}
+
+	clusters, err := parseList(settings["clusters"], nil, "cluster")
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := ecsProvider.util.ReadAllTasks(clusters, warnings)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := []provider.Address{}
+	for _, task := range tasks {
+		if task.IP == "" {
+			fmt.Fprintf(warnings, "Warning: Task %s of %s has no private IP, skipping\n", task.ID, task.Cluster)
+			continue
+		}
+
+		name, err := provider.ExecuteTemplate(templ, task)
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, provider.Address{
+			Name: name,
+			IP:   task.IP,
+			Metadata: map[string]string{
+				"cluster":                 task.Cluster,
+				"service":                 task.Service,
+				"taskDefinition":          task.TaskDefinition,
+				"instanceId":              task.InstanceID,
+				provider.ProfileMetadata:  settings["profile"],
+				provider.RegionMetadata:   task.Region,
+				provider.ResourceMetadata: task.ARN,
+			},
+		})
+	}
+
+	sortAddresses(addresses)
+	return addresses, nil
+}
+
+// Complete suggests values for a setting
+func (ecsProvider *ECSProvider) Complete(setting string, _ map[string]string) []string {
+	return completeProfile(ecsProvider.util, setting)
+}
+
+// AutoScalingProvider discovers the addresses of the instances in Auto Scaling groups
+type AutoScalingProvider struct {
+	util AwsInterface
+}
+
+// NewAutoScalingProvider builds an Auto Scaling provider that talks to AWS through util
+func NewAutoScalingProvider(util AwsInterface) *AutoScalingProvider {
+	return &AutoScalingProvider{util: util}
+}
+
+// Name is the name sources use to refer to the provider
+func (autoScalingProvider *AutoScalingProvider) Name() string {
+	return "awsAutoScaling"
+}
+
+// Schema lists the settings the provider accepts
+func (autoScalingProvider *AutoScalingProvider) Schema() []provider.Setting {
+	return append(
+		append([]provider.Setting{}, connectionSettings...),
+		provider.Setting{
+			Name:    "template",
+			Alias:   "t",
+			Usage:   "The template to use for naming groups, each instance is named {name}-{index}",
+			Default: "{{.Name}}",
+			EnvVar:  "HOST_BUILDER_ASG_TEMPLATE",
+		},
+		provider.Setting{Name: "groups", Usage: "Only import instances from these comma separated groups"},
+		provider.Setting{
+			Name:    "addresses",
+			Usage:   "The comma separated address kinds to import (public, private, secondary, ipv6, elastic), each optionally followed by :{name suffix}",
+			Default: DefaultGroupAddressKinds,
+			EnvVar:  "HOST_BUILDER_ASG_ADDRESSES",
+		},
+	)
+}
+
+// Discover finds the addresses of the in service instances of the groups in every region of each profile
+// Instances are numbered from 1 in the order they were launched, so {name}-1 is the oldest instance of the group
+func (autoScalingProvider *AutoScalingProvider) Discover(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	return discoverProfiles(autoScalingProvider.util, settings, warnings, autoScalingProvider.discoverProfile)
+}
+
+func (autoScalingProvider *AutoScalingProvider) discoverProfile(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	err := connect(autoScalingProvider.util, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	templ, err := template.New("").Funcs(provider.TemplateFuncs).Parse(settings["template"])
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := parseList(settings["groups"], nil, "group")
+	if err != nil {
+		return nil, err
+	}
+
+	kinds, err := ParseAddressKinds(settings["addresses"])
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := autoScalingProvider.util.ReadAllAutoScalingGroups(names, warnings)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := []provider.Address{}
+	for _, group := range groups {
+		name, err := provider.ExecuteTemplate(templ, group)
+		if err != nil {
+			return nil, err
+		}
+
+		for index, instance := range group.Instances {
+			for _, kind := range kinds {
+				instanceAddresses := instance.Addresses[kind.Kind]
+				for addressIndex, addressName := range kindAddressNames(fmt.Sprintf("%s-%d", name, index+1), kind, instanceAddresses) {
+					addresses = append(addresses, provider.Address{
+						Name: addressName,
+						IP:   instanceAddresses[addressIndex],
+						Metadata: map[string]string{
+							"group":                   group.Name,
+							"zone":                    instance.Zone,
+							provider.ProfileMetadata:  settings["profile"],
+							provider.RegionMetadata:   group.Region,
+							provider.ResourceMetadata: instance.ID,
+						},
+					})
+				}
+			}
+		}
+	}
+
+	sortAddresses(addresses)
+	return addresses, nil
+}
+
+// Complete suggests values for a setting
+func (autoScalingProvider *AutoScalingProvider) Complete(setting string, _ map[string]string) []string {
+	if setting == "addresses" {
+		return InstanceAddressKinds
+	}
+
+	return completeProfile(autoScalingProvider.util, setting)
+}
+
+// Route53Provider discovers the A and AAAA records of a hosted zone, adding them as options on the record names
+type Route53Provider struct {
+	util AwsInterface
+}
+
+// RecordAddress is the data available to the route53 option template
+type RecordAddress struct {
+	Zone    string
+	ZoneID  string
+	Private bool
+	Profile string
+	Name    string
+	Type    string
+}
+
+// NewRoute53Provider builds a route53 provider that talks to AWS through util
+func NewRoute53Provider(util AwsInterface) *Route53Provider {
+	return &Route53Provider{util: util}
+}
+
+// Name is the name sources use to refer to the provider
+func (route53Provider *Route53Provider) Name() string {
+	return "awsRoute53"
+}
+
+// Schema lists the settings the provider accepts
+func (route53Provider *Route53Provider) Schema() []provider.Setting {
+	return []provider.Setting{
+		profileSetting,
+		profilePrefixSetting,
+		endpointSetting,
+		{Name: "zone", Usage: "The name or ID of the hosted zone to import"},
+		{
+			Name:    "option",
+			Alias:   "o",
+			Usage:   "The template to use for naming the option added to each hostname",
+			Default: "{{.Zone}}",
+			EnvVar:  "HOST_BUILDER_ROUTE53_OPTION",
+		},
+		{Name: "types", Usage: "The comma separated record types to import (A, AAAA)", Default: route53.RRTypeA},
+	}
+}
+
+// Discover reads the records of the hosted zone in each profile
+// Alias records use the address of their target, looking it up in the zone before resolving it
+// Wildcard records and aliases that can't be resolved are skipped with a warning
+func (route53Provider *Route53Provider) Discover(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	return discoverProfiles(route53Provider.util, settings, warnings, route53Provider.discoverProfile)
+}
+
+func (route53Provider *Route53Provider) discoverProfile(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	if settings["zone"] == "" {
+		return nil, errors.New("A hosted zone is required")
+	}
+
+	recordTypes := strings.Split(settings["types"], ",")
+	for _, recordType := range recordTypes {
+		if !contains(RecordTypes, recordType) {
+			return nil, fmt.Errorf("Invalid record type %s", recordType)
+		}
+	}
+
+	templ, err := template.New("").Funcs(provider.TemplateFuncs).Parse(settings["option"])
+	if err != nil {
+		return nil, err
+	}
+
+	err = connect(route53Provider.util, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	zone, err := route53Provider.util.ReadHostedZone(settings["zone"])
+	if err != nil {
+		return nil, err
+	}
+
+	return recordAddresses(zone, recordTypes, templ, settings["profile"], warnings)
+}
+
+// Complete suggests values for a setting
+func (route53Provider *Route53Provider) Complete(setting string, settings map[string]string) []string {
+	switch setting {
+	case "types":
+		return RecordTypes
+	case "zone":
+		profiles, err := selectProfiles(route53Provider.util, settings["profile"])
+		if err != nil || connect(route53Provider.util, withProfile(settings, profiles[0])) != nil {
+			return nil
+		}
+
+		zones, err := route53Provider.util.ListHostedZones()
+		if err != nil {
+			return nil
+		}
+
+		names := make([]string, 0, len(zones))
+		for _, zone := range zones {
+			names = append(names, zone.Name)
+		}
+
+		return names
+	}
+
+	return completeProfile(route53Provider.util, setting)
+}
+
+func recordAddresses(zone HostedZone, recordTypes []string, templ *template.Template, profile string, warnings io.Writer) ([]provider.Address, error) {
+	sort.SliceStable(zone.Records, func(i, j int) bool {
+		if zone.Records[i].Name != zone.Records[j].Name {
+			return zone.Records[i].Name < zone.Records[j].Name
+		}
+
+		return zone.Records[i].Type < zone.Records[j].Type
+	})
+
+	values := map[string]string{}
+	for _, record := range zone.Records {
+		if _, exists := values[record.Name+" "+record.Type]; !exists && len(record.Values) != 0 {
+			values[record.Name+" "+record.Type] = record.Values[0]
+		}
+	}
+
+	addresses := []provider.Address{}
+	for _, record := range zone.Records {
+		if !contains(recordTypes, record.Type) {
+			continue
+		}
+
+		if strings.HasPrefix(record.Name, "*") {
+			fmt.Fprintf(warnings, "Warning: %s is a wildcard record, skipping\n", record.Name)
+			continue
+		}
+
+		IP, err := recordIP(record, values)
+		if err != nil {
+			fmt.Fprintf(warnings, "Warning: %v, skipping %s\n", err, record.Name)
+			continue
+		}
+
+		option, err := provider.ExecuteTemplate(templ, RecordAddress{
+			Zone:    zone.Name,
+			ZoneID:  zone.ID,
+			Private: zone.Private,
+			Profile: profile,
+			Name:    record.Name,
+			Type:    record.Type,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, provider.Address{
+			Host: record.Name,
+			Name: option,
+			IP:   IP,
+			Metadata: map[string]string{
+				"zone":                    zone.Name,
+				"type":                    record.Type,
+				provider.ProfileMetadata:  profile,
+				provider.ResourceMetadata: zone.ID,
+			},
+		})
+	}
+
+	return addresses, nil
+}
+
+// recordIP is the first value of a record, alias records use the record they target in the zone or resolve their target
+func recordIP(record Record, values map[string]string) (string, error) {
+	if record.AliasTarget == "" {
+		if len(record.Values) == 0 {
+			return "", errors.New("the record has no value")
+		}
+
+		return record.Values[0], nil
+	}
+
+	if IP, found := values[record.AliasTarget+" "+record.Type]; found {
+		return IP, nil
+	}
+
+	return provider.ResolveAddress(record.AliasTarget)
+}
+
+func addressLoadBalancer(name string, loadBalancer LoadBalancer, profile string) ([]provider.Address, error) {
+	metadata := func(zone string) map[string]string {
+		metadata := map[string]string{
+			"dnsName":                 loadBalancer.DNSName,
+			"type":                    loadBalancer.Type,
+			"scheme":                  loadBalancer.Scheme,
+			provider.ProfileMetadata:  profile,
+			provider.RegionMetadata:   loadBalancer.Region,
+			provider.ResourceMetadata: loadBalancer.ID,
+		}
+		if zone != "" {
+			metadata["zone"] = zone
+		}
+
+		return metadata
+	}
+
+	if len(loadBalancer.Addresses) == 0 {
+		IP, err := provider.ResolveAddress(loadBalancer.DNSName)
+		if err != nil {
+			return nil, err
+		}
+
+		return []provider.Address{{Name: name, IP: IP, Metadata: metadata("")}}, nil
+	}
+
+	addresses := []provider.Address{{Name: name, IP: loadBalancer.Addresses[0].IP, Metadata: metadata(loadBalancer.Addresses[0].Zone)}}
+	for _, address := range loadBalancer.Addresses {
+		addresses = append(addresses, provider.Address{Name: fmt.Sprintf("%s-%s", name, address.Zone), IP: address.IP, Metadata: metadata(address.Zone)})
+	}
+
+	return addresses, nil
+}
+
+// discoverProfiles calls discover with the settings of each profile the profile setting selects
+// With more than one profile every name is prefixed using the profilePrefix template,
+// profiles that fail are reported as warnings and an error is only returned when every profile fails
+func discoverProfiles(
+	util AwsInterface,
+	settings map[string]string,
+	warnings io.Writer,
+	discover func(settings map[string]string, warnings io.Writer) ([]provider.Address, error),
+) ([]provider.Address, error) {
+	profiles, err := selectProfiles(util, settings["profile"])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(profiles) == 1 {
+		return discover(withProfile(settings, profiles[0]), warnings)
+	}
+
+	prefixTempl, err := template.New("").Funcs(provider.TemplateFuncs).Parse(settings["profilePrefix"])
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := []provider.Address{}
+	var firstErr error
+	succeeded := 0
+	for _, profile := range profiles {
+		profileAddresses, err := discover(withProfile(settings, profile), warnings)
+		if err != nil {
+			fmt.Fprintf(warnings, "Warning: %v, skipping profile %s\n", err, profile)
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			continue
+		}
+
+		prefix, err := provider.ExecuteTemplate(prefixTempl, struct{ Profile string }{profile})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, address := range profileAddresses {
+			address.Name = prefix + address.Name
+			addresses = append(addresses, address)
+		}
+
+		succeeded++
+	}
+
+	if succeeded == 0 {
+		return nil, firstErr
+	}
+
+	return addresses, nil
+}
+
+// parseList splits a comma separated setting, checking every value is valid when valid values are given
+func parseList(list string, valid []string, kind string) ([]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	values := strings.Split(list, ",")
+	for _, value := range values {
+		if value == "" || (valid != nil && !contains(valid, value)) {
+			return nil, fmt.Errorf("Invalid %s %s", kind, value)
+		}
+	}
+
+	return values, nil
+}
+
+// sortAddresses sorts addresses by name
+func sortAddresses(addresses []provider.Address) {
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Name < addresses[j].Name
+	})
+}
+
+// withProfile copies the settings, choosing a single profile
+func withProfile(settings map[string]string, profile string) map[string]string {
+	profileSettings := make(map[string]string, len(settings))
+	for name, value := range settings {
+		profileSettings[name] = value
+	}
+
+	profileSettings[profileSetting.Name] = profile
+	return profileSettings
+}
+
+// connect points util at the profile, regions and endpoint in the settings
+func connect(util AwsInterface, settings map[string]string) error {
+	regions, err := ParseRegionFilter(settings["region"])
+	if err != nil {
+		return err
+	}
+
+	util.SetProfile(settings["profile"])
+	util.SetRegions(regions)
+	util.SetEndpoint(settings["endpoint"])
+	return nil
+}
+
+func completeProfile(util AwsInterface, setting string) []string {
+	if setting != profileSetting.Name {
+		return nil
+	}
+
+	profiles, err := util.ListAllProfiles()
+	if err != nil {
+		return nil
+	}
+
+	return profiles
+}
@@ -0,0 +1,73 @@
+package awsUtil
+
+import (
+	"io"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+// DBInstance is an RDS database instance, Endpoint is the DNS name clients connect to
+// Cluster is the Aurora cluster the instance belongs to, if any
+type DBInstance struct {
+	ID       string
+	ARN      string
+	Engine   string
+	Status   string
+	Cluster  string
+	Endpoint string
+	Port     int64
+	Region   string
+}
+
+// ReadAllDBInstances gets the RDS instances in all regions, instances without an endpoint yet are skipped
+func (util *AwsUtil) ReadAllDBInstances(warnings io.Writer) ([]DBInstance, error) {
+	results, err := util.scanRegions(warnings, func(sess *session.Session) (interface{}, error) {
+		return readDBInstances(sess)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	instances := []DBInstance{}
+	for _, result := range results {
+		instances = append(instances, result.([]DBInstance)...)
+	}
+
+	return instances, nil
+}
+
+func readDBInstances(sess *session.Session) ([]DBInstance, error) {
+	instances := []DBInstance{}
+	err := rds.New(sess).DescribeDBInstancesPages(&rds.DescribeDBInstancesInput{}, func(resp *rds.DescribeDBInstancesOutput, lastPage bool) bool {
+		for _, instance := range resp.DBInstances {
+			if instance.Endpoint == nil || instance.Endpoint.Address == nil {
+				continue
+			}
+
+			instances = append(instances, DBInstance{
+				ID:       aws.StringValue(instance.DBInstanceIdentifier),
+				ARN:      aws.StringValue(instance.DBInstanceArn),
+				Engine:   aws.StringValue(instance.Engine),
+				Status:   aws.StringValue(instance.DBInstanceStatus),
+				Cluster:  aws.StringValue(instance.DBClusterIdentifier),
+				Endpoint: *instance.Endpoint.Address,
+				Port:     aws.Int64Value(instance.Endpoint.Port),
+				Region:   aws.StringValue(sess.Config.Region),
+			})
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].ID < instances[j].ID
+	})
+
+	return instances, nil
+}
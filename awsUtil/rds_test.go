@@ -0,0 +1,42 @@
+package awsUtil
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadAllDBInstances(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	util := newTestAwsUtil(t, server, "us-*")
+	instances, err := util.ReadAllDBInstances(new(bytes.Buffer))
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]DBInstance{
+			{
+				ID:       "orders-us-east-1",
+				ARN:      "arn:aws:rds:us-east-1:1:db:orders-us-east-1",
+				Engine:   "postgres",
+				Status:   "available",
+				Endpoint: "orders.us-east-1.rds.example.com",
+				Port:     5432,
+				Region:   "us-east-1",
+			},
+			{
+				ID:       "orders-us-west-2",
+				ARN:      "arn:aws:rds:us-west-2:1:db:orders-us-west-2",
+				Engine:   "postgres",
+				Status:   "available",
+				Endpoint: "orders.us-west-2.rds.example.com",
+				Port:     5432,
+				Region:   "us-west-2",
+			},
+		},
+		instances,
+	)
+	assert.Equal(t, []string{"us-east-1 DescribeDBInstances", "us-east-1 DescribeRegions", "us-west-2 DescribeDBInstances"}, server.sortedRequests())
+}
@@ -0,0 +1,68 @@
+package awsUtil
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// RegionFilter chooses the regions that are scanned using shell patterns like us-*
+// An empty filter matches every region
+type RegionFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// ParseRegionFilter parses a comma separated list of region patterns, patterns starting with ! are excluded
+func ParseRegionFilter(filter string) (RegionFilter, error) {
+	regions := RegionFilter{}
+	if filter == "" {
+		return regions, nil
+	}
+
+	for _, pattern := range strings.Split(filter, ",") {
+		pattern = strings.TrimSpace(pattern)
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return RegionFilter{}, fmt.Errorf("Invalid region %s", pattern)
+		}
+
+		if exclude {
+			regions.Exclude = append(regions.Exclude, pattern)
+		} else {
+			regions.Include = append(regions.Include, pattern)
+		}
+	}
+
+	return regions, nil
+}
+
+// Matches reports whether region is included and not excluded
+func (regions RegionFilter) Matches(region string) bool {
+	if matchesAny(regions.Exclude, region) {
+		return false
+	}
+
+	return len(regions.Include) == 0 || matchesAny(regions.Include, region)
+}
+
+// String formats the filter the way ParseRegionFilter reads it
+func (regions RegionFilter) String() string {
+	patterns := append([]string{}, regions.Include...)
+	for _, pattern := range regions.Exclude {
+		patterns = append(patterns, "!"+pattern)
+	}
+
+	return strings.Join(patterns, ",")
+}
+
+func matchesAny(patterns []string, region string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, region); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,39 @@
+package awsUtil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRegionFilter(t *testing.T) {
+	regions, err := ParseRegionFilter("us-*, eu-west-1,!us-gov-*")
+	assert.Nil(t, err)
+	assert.Equal(t, RegionFilter{Include: []string{"us-*", "eu-west-1"}, Exclude: []string{"us-gov-*"}}, regions)
+	assert.Equal(t, "us-*,eu-west-1,!us-gov-*", regions.String())
+	assert.True(t, regions.Matches("us-east-1"))
+	assert.True(t, regions.Matches("eu-west-1"))
+	assert.False(t, regions.Matches("eu-west-2"))
+	assert.False(t, regions.Matches("us-gov-west-1"))
+}
+
+func TestParseRegionFilterEmpty(t *testing.T) {
+	regions, err := ParseRegionFilter("")
+	assert.Nil(t, err)
+	assert.True(t, regions.Matches("ap-south-1"))
+}
+
+func TestParseRegionFilterExcludeOnly(t *testing.T) {
+	regions, err := ParseRegionFilter("!ap-*")
+	assert.Nil(t, err)
+	assert.True(t, regions.Matches("us-east-1"))
+	assert.False(t, regions.Matches("ap-south-1"))
+}
+
+func TestParseRegionFilterInvalid(t *testing.T) {
+	_, err := ParseRegionFilter("us-[")
+	assert.EqualError(t, err, "Invalid region us-[")
+
+	_, err = ParseRegionFilter("us-east-1,!")
+	assert.EqualError(t, err, "Invalid region ")
+}
@@ -0,0 +1,223 @@
+package awsUtil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/private/protocol/json/jsonutil"
+	"github.com/aws/aws-sdk-go/service/route53"
+)
+
+// RecordTypes lists the record types that can be imported from a hosted zone
+var RecordTypes = []string{route53.RRTypeA, route53.RRTypeAaaa}
+
+// ChangeActions lists the actions a change batch can apply to the exported records
+var ChangeActions = []string{route53.ChangeActionUpsert, route53.ChangeActionCreate, route53.ChangeActionDelete}
+
+// hostedZonePrefix is the prefix Route53 gives hosted zone IDs in its responses
+const hostedZonePrefix = "/hostedzone/"
+
+// HostedZone is a Route53 hosted zone and the A and AAAA records in it
+// Records are only read by ReadHostedZone
+type HostedZone struct {
+	ID      string
+	Name    string
+	Private bool
+	Records []Record
+}
+
+// Record is an A or AAAA record set, AliasTarget is the DNS name of the target of alias records
+type Record struct {
+	Name          string
+	Type          string
+	Values        []string
+	AliasTarget   string
+	SetIdentifier string
+}
+
+// ListHostedZones lists the hosted zones of the account, without their records
+func (util *AwsUtil) ListHostedZones() ([]HostedZone, error) {
+	sess, err := util.session(regionsRegion)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := []HostedZone{}
+	err = route53.New(sess).ListHostedZonesPages(&route53.ListHostedZonesInput{}, func(resp *route53.ListHostedZonesOutput, lastPage bool) bool {
+		for _, zone := range resp.HostedZones {
+			hostedZone := HostedZone{
+				ID:   strings.TrimPrefix(aws.StringValue(zone.Id), hostedZonePrefix),
+				Name: recordName(aws.StringValue(zone.Name)),
+			}
+			if zone.Config != nil {
+				hostedZone.Private = aws.BoolValue(zone.Config.PrivateZone)
+			}
+
+			zones = append(zones, hostedZone)
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return zones, nil
+}
+
+// ReadHostedZone reads the A and AAAA records of the hosted zone with the given ID or name
+// A name shared by a public and a private zone is an error, the zone ID chooses between them
+func (util *AwsUtil) ReadHostedZone(zone string) (HostedZone, error) {
+	hostedZone, err := util.findHostedZone(zone)
+	if err != nil {
+		return HostedZone{}, err
+	}
+
+	sess, err := util.session(regionsRegion)
+	if err != nil {
+		return HostedZone{}, err
+	}
+
+	params := &route53.ListResourceRecordSetsInput{HostedZoneId: aws.String(hostedZone.ID)}
+	err = route53.New(sess).ListResourceRecordSetsPages(params, func(resp *route53.ListResourceRecordSetsOutput, lastPage bool) bool {
+		for _, recordSet := range resp.ResourceRecordSets {
+			if !contains(RecordTypes, aws.StringValue(recordSet.Type)) {
+				continue
+			}
+
+			hostedZone.Records = append(hostedZone.Records, parseRecordSet(recordSet))
+		}
+
+		return true
+	})
+	if err != nil {
+		return HostedZone{}, err
+	}
+
+	return hostedZone, nil
+}
+
+func (util *AwsUtil) findHostedZone(zone string) (HostedZone, error) {
+	zones, err := util.ListHostedZones()
+	if err != nil {
+		return HostedZone{}, err
+	}
+
+	matches := []HostedZone{}
+	for _, hostedZone := range zones {
+		if hostedZone.ID == strings.TrimPrefix(zone, hostedZonePrefix) || hostedZone.Name == recordName(zone) {
+			matches = append(matches, hostedZone)
+		}
+	}
+
+	if len(matches) == 0 {
+		return HostedZone{}, fmt.Errorf("No hosted zone matches %s", zone)
+	}
+
+	if len(matches) > 1 {
+		IDs := make([]string, 0, len(matches))
+		for _, hostedZone := range matches {
+			IDs = append(IDs, hostedZone.ID)
+		}
+
+		sort.Strings(IDs)
+		return HostedZone{}, fmt.Errorf("More than one hosted zone is named %s, use one of the zone IDs %s", zone, strings.Join(IDs, ", "))
+	}
+
+	return matches[0], nil
+}
+
+func parseRecordSet(recordSet *route53.ResourceRecordSet) Record {
+	record := Record{
+		Name:          recordName(aws.StringValue(recordSet.Name)),
+		Type:          aws.StringValue(recordSet.Type),
+		SetIdentifier: aws.StringValue(recordSet.SetIdentifier),
+	}
+
+	if recordSet.AliasTarget != nil {
+		record.AliasTarget = recordName(aws.StringValue(recordSet.AliasTarget.DNSName))
+	}
+
+	for _, resourceRecord := range recordSet.ResourceRecords {
+		record.Values = append(record.Values, aws.StringValue(resourceRecord.Value))
+	}
+
+	return record
+}
+
+// recordName lower cases a DNS name, removing the trailing dot and the octal escapes Route53 uses for characters like *
+func recordName(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	var buffer bytes.Buffer
+	for index := 0; index < len(name); index++ {
+		if name[index] == '\\' && index+3 < len(name) {
+			if code, err := strconv.ParseUint(name[index+1:index+4], 8, 8); err == nil {
+				buffer.WriteByte(byte(code))
+				index += 3
+				continue
+			}
+		}
+
+		buffer.WriteByte(name[index])
+	}
+
+	return buffer.String()
+}
+
+// ChangeBatch builds the JSON change batch that applies action to a record for each hostname, as accepted by
+// aws route53 change-resource-record-sets --change-batch
+// IPv4 addresses become A records and IPv6 addresses become AAAA records
+func ChangeBatch(action, comment string, ttl int64, addresses map[string]string) ([]byte, error) {
+	hostNames := make([]string, 0, len(addresses))
+	for hostName := range addresses {
+		hostNames = append(hostNames, hostName)
+	}
+
+	sort.Strings(hostNames)
+	batch := &route53.ChangeBatch{}
+	if comment != "" {
+		batch.Comment = aws.String(comment)
+	}
+
+	for _, hostName := range hostNames {
+		recordType := route53.RRTypeA
+		if IP := net.ParseIP(addresses[hostName]); IP != nil && IP.To4() == nil {
+			recordType = route53.RRTypeAaaa
+		}
+
+		batch.Changes = append(batch.Changes, &route53.Change{
+			Action: aws.String(action),
+			ResourceRecordSet: &route53.ResourceRecordSet{
+				Name:            aws.String(hostName),
+				Type:            aws.String(recordType),
+				TTL:             aws.Int64(ttl),
+				ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(addresses[hostName])}},
+			},
+		})
+	}
+
+	err := batch.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	batchJSON, err := jsonutil.BuildJSON(batch)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	err = json.Indent(&buffer, batchJSON, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	buffer.WriteString("\n")
+	return buffer.Bytes(), nil
+}
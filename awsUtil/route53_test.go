@@ -0,0 +1,156 @@
+package awsUtil
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListHostedZones(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	zones, err := newTestAwsUtil(t, server, "").ListHostedZones()
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]HostedZone{
+			{ID: "Z1", Name: "example.com"},
+			{ID: "Z2", Name: "internal.com", Private: true},
+			{ID: "Z3", Name: "internal.com"},
+		},
+		zones,
+	)
+	assert.Equal(t, []string{"us-east-1 /2013-04-01/hostedzone"}, server.sortedRequests())
+}
+
+func TestReadHostedZone(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	util := newTestAwsUtil(t, server, "")
+	expected := HostedZone{
+		ID:   "Z1",
+		Name: "example.com",
+		Records: []Record{
+			{Name: "web.example.com", Type: "A", Values: []string{"10.0.0.1", "10.0.0.2"}},
+			{Name: "web.example.com", Type: "AAAA", Values: []string{"2001:db8::1"}},
+			{Name: "www.example.com", Type: "A", AliasTarget: "web.example.com"},
+			{Name: "*.example.com", Type: "A", Values: []string{"10.0.0.9"}, SetIdentifier: "east"},
+		},
+	}
+
+	for _, zone := range []string{"example.com", "Example.com.", "Z1", "/hostedzone/Z1"} {
+		hostedZone, err := util.ReadHostedZone(zone)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, hostedZone)
+	}
+}
+
+func TestReadHostedZoneAmbiguousName(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	_, err := newTestAwsUtil(t, server, "").ReadHostedZone("internal.com")
+	assert.EqualError(t, err, "More than one hosted zone is named internal.com, use one of the zone IDs Z2, Z3")
+}
+
+func TestReadHostedZoneNotFound(t *testing.T) {
+	server := newTestAwsServer()
+	defer server.Close()
+
+	_, err := newTestAwsUtil(t, server, "").ReadHostedZone("other.com")
+	assert.EqualError(t, err, "No hosted zone matches other.com")
+}
+
+func TestRecordName(t *testing.T) {
+	assert.Equal(t, "*.example.com", recordName(`\052.Example.COM.`))
+	assert.Equal(t, "web.example.com", recordName("web.example.com"))
+	assert.Equal(t, `a\9.example.com`, recordName(`a\9.example.com`))
+}
+
+func TestRecordAddresses(t *testing.T) {
+	zone := HostedZone{
+		ID:   "Z1",
+		Name: "example.com",
+		Records: []Record{
+			{Name: "www.example.com", Type: "A", AliasTarget: "web.example.com"},
+			{Name: "web.example.com", Type: "AAAA", Values: []string{"2001:db8::1"}},
+			{Name: "web.example.com", Type: "A", Values: []string{"10.0.0.1", "10.0.0.2"}},
+			{Name: "*.example.com", Type: "A", Values: []string{"10.0.0.9"}},
+			{Name: "empty.example.com", Type: "A"},
+		},
+	}
+
+	templ := template.Must(template.New("").Parse("{{.Zone}}-{{.Type}}"))
+	warnings := new(bytes.Buffer)
+	addresses, err := recordAddresses(zone, RecordTypes, templ, "prod", warnings)
+	assert.Nil(t, err)
+	metadata := func(recordType string) map[string]string {
+		return map[string]string{"zone": "example.com", "type": recordType, provider.ProfileMetadata: "prod", provider.ResourceMetadata: "Z1"}
+	}
+
+	assert.Equal(
+		t,
+		[]provider.Address{
+			{Host: "web.example.com", Name: "example.com-A", IP: "10.0.0.1", Metadata: metadata("A")},
+			{Host: "web.example.com", Name: "example.com-AAAA", IP: "2001:db8::1", Metadata: metadata("AAAA")},
+			{Host: "www.example.com", Name: "example.com-A", IP: "10.0.0.1", Metadata: metadata("A")},
+		},
+		addresses,
+	)
+	assert.Equal(
+		t,
+		"Warning: *.example.com is a wildcard record, skipping\nWarning: the record has no value, skipping empty.example.com\n",
+		warnings.String(),
+	)
+}
+
+func TestChangeBatch(t *testing.T) {
+	batch, err := ChangeBatch("UPSERT", "release", 60, map[string]string{"web.com": "10.0.0.1", "api.com": "2001:db8::1"})
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		`{
+  "Changes": [
+    {
+      "Action": "UPSERT",
+      "ResourceRecordSet": {
+        "Name": "api.com",
+        "ResourceRecords": [
+          {
+            "Value": "2001:db8::1"
+          }
+        ],
+        "TTL": 60,
+        "Type": "AAAA"
+      }
+    },
+    {
+      "Action": "UPSERT",
+      "ResourceRecordSet": {
+        "Name": "web.com",
+        "ResourceRecords": [
+          {
+            "Value": "10.0.0.1"
+          }
+        ],
+        "TTL": 60,
+        "Type": "A"
+      }
+    }
+  ],
+  "Comment": "release"
+}
+`,
+		string(batch),
+	)
+}
+
+func TestChangeBatchEmpty(t *testing.T) {
+	_, err := ChangeBatch("UPSERT", "", 60, map[string]string{})
+	assert.NotNil(t, err)
+}
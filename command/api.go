@@ -0,0 +1,459 @@
+package command
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/hosts"
+	"github.com/urfave/cli"
+)
+
+// unixPrefix marks a --listen address as a unix socket path
+const unixPrefix = "unix:"
+
+// CmdAPI serves the configuration as JSON over HTTP on localhost or a unix socket until SIGINT or SIGTERM
+func CmdAPI(c *cli.Context) error {
+	if c.NArg() != 0 {
+		return cli.NewExitError("Usage: \"hostBuilder api [--listen {address}|unix:{path}]\"", 1)
+	}
+
+	if _, err := loadConfig(c); err != nil {
+		return err
+	}
+
+	listen := c.String("listen")
+	if !strings.HasPrefix(listen, unixPrefix) {
+		host, _, err := net.SplitHostPort(listen)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("Invalid listen address %s", listen), 1)
+		}
+
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return cli.NewExitError("The API only listens on localhost or a unix socket", 1)
+		}
+	}
+
+	token := c.String("token")
+	if token == "" {
+		var err error
+		token, err = randomToken()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(c.App.Writer, "Token: %s\n", token)
+	}
+
+	listener, err := listenAPI(listen)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.App.Writer, "Listening on %s\n", listen)
+	if c.Bool("dashboard") && !strings.HasPrefix(listen, unixPrefix) {
+		fmt.Fprintf(c.App.Writer, "Dashboard: http://%s/#token=%s\n", listen, token)
+	}
+
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	handler := newAPIHandler(c.GlobalString("config"), c.String("output"), c.Bool("oneLinePerIP"), token, c.App.ErrWriter)
+	if c.Bool("dashboard") {
+		handler.dashboard = newDashboardHandler()
+	}
+
+	return serveAPI(listener, handler, stop)
+}
+
+// listenAPI listens on a TCP address or on a unix socket that only the current user can use
+func listenAPI(listen string) (net.Listener, error) {
+	if !strings.HasPrefix(listen, unixPrefix) {
+		return net.Listen("tcp", listen)
+	}
+
+	// The umask makes the socket 0600 when it is created, a chmod afterwards leaves a moment where anyone can connect
+	previousUmask := syscall.Umask(0177)
+	defer syscall.Umask(previousUmask)
+	return net.Listen("unix", strings.TrimPrefix(listen, unixPrefix))
+}
+
+// serveAPI handles requests until stop is closed, requests in flight are finished first
+func serveAPI(listener net.Listener, handler http.Handler, stop <-chan struct{}) error {
+	server := &http.Server{Handler: handler}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-stop:
+		return server.Shutdown(context.Background())
+	}
+}
+
+func randomToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
+// apiHandler serves the REST endpoints that mirror the host, group, globalIP and build commands
+// Every response carries the ETag of the configuration, changes without an If-Match or with one that is out of date are refused
+// The dashboard, when there is one, is served without a token because it holds no configuration
+type apiHandler struct {
+	configFile   string
+	output       string
+	oneLinePerIP bool
+	token        string
+	errWriter    io.Writer
+	dashboard    http.Handler
+	mutex        sync.Mutex
+}
+
+// apiHost is a host as the API shows it, IP is the address of its current option
+type apiHost struct {
+	Current    string                       `json:"current"`
+	IP         string                       `json:"ip,omitempty"`
+	Options    map[string]string            `json:"options"`
+	Provenance map[string]config.Provenance `json:"provenance,omitempty"`
+}
+
+// apiRequest is the body of the requests that change the configuration
+type apiRequest struct {
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	Hostname string `json:"hostname"`
+	Option   string `json:"option"`
+	GlobalIP string `json:"globalIP"`
+	Force    bool   `json:"force"`
+}
+
+// apiError is an error with the HTTP status it is reported with
+type apiError struct {
+	status  int
+	message string
+}
+
+func (err apiError) Error() string {
+	return err.message
+}
+
+func newAPIHandler(configFile, output string, oneLinePerIP bool, token string, errWriter io.Writer) *apiHandler {
+	return &apiHandler{configFile: configFile, output: output, oneLinePerIP: oneLinePerIP, token: token, errWriter: errWriter}
+}
+
+func (h *apiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.dashboard != nil && r.Method == http.MethodGet && dashboardPaths[r.URL.Path] {
+		h.dashboard.ServeHTTP(w, r)
+		return
+	}
+
+	authorization := []byte(r.Header.Get("Authorization"))
+	if subtle.ConstantTimeCompare(authorization, []byte("Bearer "+h.token)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeAPIError(w, apiError{http.StatusUnauthorized, "Missing or invalid token"})
+		return
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	configJSON, err := ioutil.ReadFile(h.configFile)
+	if err != nil {
+		writeAPIError(w, apiError{http.StatusInternalServerError, err.Error()})
+		return
+	}
+
+	configData, err := config.ParseConfig(configJSON)
+	if err != nil {
+		writeAPIError(w, apiError{http.StatusInternalServerError, err.Error()})
+		return
+	}
+
+	etag := configETag(configJSON)
+	w.Header().Set("ETag", etag)
+	if r.Method == http.MethodGet {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		h.get(w, configData, strings.Split(strings.Trim(r.URL.Path, "/"), "/"))
+		return
+	}
+
+	if err = checkIfMatch(r.Header.Get("If-Match"), etag); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	var request apiRequest
+	if r.Method == http.MethodPost || r.Method == http.MethodPut {
+		if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeAPIError(w, apiError{http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err)})
+			return
+		}
+	}
+
+	before := hostCurrents(configData)
+	err = h.change(configData, r.Method, strings.Split(strings.Trim(r.URL.Path, "/"), "/"), request)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err = config.WriteConfig(h.configFile, configData); err != nil {
+		writeAPIError(w, apiError{http.StatusInternalServerError, err.Error()})
+		return
+	}
+
+	if configJSON, err = ioutil.ReadFile(h.configFile); err == nil {
+		w.Header().Set("ETag", configETag(configJSON))
+	}
+
+	// The change is saved, so a failing hook is only a warning, an error would make the client send it again with an old ETag
+	if err = runChangeHooks(configData, before, h.output, h.errWriter, h.errWriter); err != nil {
+		fmt.Fprintf(h.errWriter, "Warning: %v\n", err)
+		writeAPIJSON(w, map[string]string{"warning": err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// get answers a read of the path
+func (h *apiHandler) get(w http.ResponseWriter, configData *config.HostsConfig, path []string) {
+	switch {
+	case len(path) == 1 && path[0] == "hosts":
+		hostViews := map[string]apiHost{}
+		for _, hostName := range sortHostNames(configData) {
+			hostViews[hostName] = newAPIHost(configData, hostName)
+		}
+
+		writeAPIJSON(w, hostViews)
+	case len(path) == 2 && path[0] == "hosts":
+		if _, exists := configData.Hosts[path[1]]; !exists {
+			writeAPIError(w, apiError{http.StatusNotFound, fmt.Sprintf("Hostname %s does not exist", path[1])})
+			return
+		}
+
+		writeAPIJSON(w, newAPIHost(configData, path[1]))
+	case len(path) == 1 && path[0] == "globalIPs":
+		globalIPs := configData.GlobalIPs
+		if globalIPs == nil {
+			globalIPs = map[string]string{}
+		}
+
+		writeAPIJSON(w, globalIPs)
+	case len(path) == 1 && path[0] == "groups":
+		groups := configData.Groups
+		if groups == nil {
+			groups = map[string][]string{}
+		}
+
+		writeAPIJSON(w, groups)
+	case len(path) == 2 && path[0] == "groups":
+		if _, exists := configData.Groups[path[1]]; !exists {
+			writeAPIError(w, apiError{http.StatusNotFound, fmt.Sprintf("Group %s does not exist", path[1])})
+			return
+		}
+
+		writeAPIJSON(w, configData.Groups[path[1]])
+	case len(path) == 1 && path[0] == "build":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, hosts.FormatHostLines(configData, h.oneLinePerIP))
+	default:
+		writeAPIError(w, apiError{http.StatusNotFound, "Not found"})
+	}
+}
+
+// change applies a request to the configuration with the same validation as the matching command
+func (h *apiHandler) change(configData *config.HostsConfig, method string, path []string, request apiRequest) error {
+	switch {
+	case method == http.MethodPost && len(path) == 1 && path[0] == "hosts":
+		if err := requireFields(map[string]string{"hostname": request.Hostname, "globalIP": request.GlobalIP}); err != nil {
+			return err
+		}
+
+		return badRequest(addGlobalIPHost(configData, request.Hostname, request.GlobalIP))
+	case method == http.MethodPost && len(path) == 3 && path[0] == "hosts" && path[2] == "options":
+		if err := requireFields(map[string]string{"name": request.Name, "address": request.Address}); err != nil {
+			return err
+		}
+
+		return badRequest(addHost(configData, path[1], request.Address, request.Name, request.Force, h.errWriter))
+	case method == http.MethodDelete && len(path) == 2 && path[0] == "hosts":
+		return removeAPIHost(configData, path[1])
+	case method == http.MethodDelete && len(path) == 4 && path[0] == "hosts" && path[2] == "options":
+		return badRequest(removeHostOption(configData, path[1], path[3]))
+	case method == http.MethodPut && len(path) == 3 && path[0] == "hosts" && path[2] == "current":
+		if err := requireFields(map[string]string{"option": request.Option}); err != nil {
+			return err
+		}
+
+		return badRequest(setHost(configData, path[1], request.Option))
+	case method == http.MethodPost && len(path) == 1 && path[0] == "globalIPs":
+		if err := requireFields(map[string]string{"name": request.Name, "address": request.Address}); err != nil {
+			return err
+		}
+
+		address, err := resolveAddress(request.Address)
+		if err != nil {
+			return badRequest(err)
+		}
+
+		return badRequest(addGlobalIP(configData, request.Name, address, request.Force, h.errWriter))
+	case method == http.MethodDelete && len(path) == 2 && path[0] == "globalIPs":
+		return badRequest(removeGlobalIP(configData, path[1]))
+	case method == http.MethodPost && len(path) == 3 && path[0] == "groups" && path[2] == "hosts":
+		if err := requireFields(map[string]string{"hostname": request.Hostname}); err != nil {
+			return err
+		}
+
+		return badRequest(addGroupHost(configData, path[1], request.Hostname))
+	case method == http.MethodDelete && len(path) == 2 && path[0] == "groups":
+		if _, exists := configData.Groups[path[1]]; !exists {
+			return apiError{http.StatusNotFound, fmt.Sprintf("Group %s does not exist", path[1])}
+		}
+
+		delete(configData.Groups, path[1])
+		return nil
+	case method == http.MethodPut && len(path) == 3 && path[0] == "groups" && path[2] == "current":
+		if err := requireFields(map[string]string{"globalIP": request.GlobalIP}); err != nil {
+			return err
+		}
+
+		return badRequest(setGroup(configData, path[1], request.GlobalIP))
+	case method == http.MethodPost && len(path) == 1 && path[0] == "build":
+		if h.output == "" {
+			return apiError{http.StatusBadRequest, "The API was started without an output file"}
+		}
+
+		err := buildHostsFile(configData, h.output, h.oneLinePerIP, h.errWriter, h.errWriter)
+		if err != nil {
+			return apiError{http.StatusInternalServerError, err.Error()}
+		}
+
+		return nil
+	default:
+		return apiError{http.StatusNotFound, "Not found"}
+	}
+}
+
+func newAPIHost(configData *config.HostsConfig, hostName string) apiHost {
+	host := configData.Hosts[hostName]
+	IP, _ := hosts.CurrentIP(configData, hostName)
+	return apiHost{Current: host.Current, IP: IP, Options: host.Options, Provenance: host.Provenance}
+}
+
+// removeAPIHost removes a hostname and takes it out of the groups it is in, a group left empty is removed as well
+func removeAPIHost(configData *config.HostsConfig, hostName string) error {
+	if _, exists := configData.Hosts[hostName]; !exists {
+		return apiError{http.StatusNotFound, fmt.Sprintf("Hostname %s does not exist", hostName)}
+	}
+
+	delete(configData.Hosts, hostName)
+	for groupName, hostNames := range configData.Groups {
+		remaining := make([]string, 0, len(hostNames))
+		for _, groupHostName := range hostNames {
+			if groupHostName != hostName {
+				remaining = append(remaining, groupHostName)
+			}
+		}
+
+		if len(remaining) == 0 {
+			delete(configData.Groups, groupName)
+		} else {
+			configData.Groups[groupName] = remaining
+		}
+	}
+
+	return nil
+}
+
+// checkIfMatch requires changes to name the ETag of the configuration they were made against, so they can not overwrite a change they have not seen
+func checkIfMatch(match, etag string) error {
+	if match == "" {
+		return apiError{http.StatusPreconditionRequired, "Send the ETag of the configuration in If-Match"}
+	}
+
+	if match != "*" && match != etag {
+		return apiError{http.StatusPreconditionFailed, "The configuration has changed, reload it and try again"}
+	}
+
+	return nil
+}
+
+// requireFields refuses a request body that leaves out one of the fields, which the matching command takes as arguments
+func requireFields(fields map[string]string) error {
+	names := make([]string, 0, len(fields))
+	for name, value := range fields {
+		if value == "" {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	sort.Strings(names)
+	return apiError{http.StatusBadRequest, fmt.Sprintf("Missing %s", strings.Join(names, ", "))}
+}
+
+// badRequest reports a validation error of a command as a bad request
+func badRequest(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return apiError{http.StatusBadRequest, err.Error()}
+}
+
+func configETag(configJSON []byte) string {
+	sum := sha256.Sum256(configJSON)
+	return fmt.Sprintf("\"%s\"", hex.EncodeToString(sum[:16]))
+}
+
+func writeAPIJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeAPIError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if apiErr, ok := err.(apiError); ok {
+		status = apiErr.status
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
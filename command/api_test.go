@@ -0,0 +1,445 @@
+package command
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+const testToken = "secret"
+
+func TestAPIListHosts(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	response := apiRequestTo(t, newTestAPIHandler(configFileName, ""), "GET", "/hosts", "", nil)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+	assert.Equal(t, testConfigETag(t, configFileName), response.Header().Get("ETag"))
+	assert.JSONEq(
+		t,
+		`{
+			"bar": {"current": "ignore", "options": {}},
+			"baz.com": {"current": "baz", "ip": "10.0.0.4", "options": {"bazz": "10.0.0.7"}},
+			"goo": {"current": "foop", "ip": "10.0.0.8", "options": {"foop": "10.0.0.8"}}
+		}`,
+		response.Body.String(),
+	)
+}
+
+func TestAPIShowHost(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	response := apiRequestTo(t, newTestAPIHandler(configFileName, ""), "GET", "/hosts/goo", "", nil)
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"current": "foop", "ip": "10.0.0.8", "options": {"foop": "10.0.0.8"}}`, response.Body.String())
+}
+
+func TestAPIShowMissingHost(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	response := apiRequestTo(t, newTestAPIHandler(configFileName, ""), "GET", "/hosts/missing", "", nil)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.JSONEq(t, `{"error": "Hostname missing does not exist"}`, response.Body.String())
+}
+
+func TestAPIGlobalIPsAndGroups(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	handler := newTestAPIHandler(configFileName, "")
+	response := apiRequestTo(t, handler, "GET", "/globalIPs", "", nil)
+	assert.JSONEq(t, `{"baz": "10.0.0.4"}`, response.Body.String())
+
+	response = apiRequestTo(t, handler, "GET", "/groups", "", nil)
+	assert.JSONEq(t, `{"foo": ["baz.com", "goo"]}`, response.Body.String())
+
+	response = apiRequestTo(t, handler, "GET", "/groups/foo", "", nil)
+	assert.JSONEq(t, `["baz.com", "goo"]`, response.Body.String())
+
+	response = apiRequestTo(t, handler, "GET", "/groups/missing", "", nil)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.JSONEq(t, `{"error": "Group missing does not exist"}`, response.Body.String())
+}
+
+func TestAPINotModified(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	etag := testConfigETag(t, configFileName)
+	response := apiRequestTo(t, newTestAPIHandler(configFileName, ""), "GET", "/hosts", "", map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, response.Code)
+	assert.Equal(t, etag, response.Header().Get("ETag"))
+	assert.Equal(t, "", response.Body.String())
+}
+
+func TestAPISetHost(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	etag := testConfigETag(t, configFileName)
+	response := apiRequestTo(t, newTestAPIHandler(configFileName, ""), "PUT", "/hosts/goo/current", `{"option": "baz"}`, map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusNoContent, response.Code)
+	assert.Equal(t, testConfigETag(t, configFileName), response.Header().Get("ETag"))
+	assert.NotEqual(t, etag, response.Header().Get("ETag"))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "baz", configData.Hosts["goo"].Current)
+}
+
+func TestAPISetHostInvalidOption(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	response := apiRequestTo(t, newTestAPIHandler(configFileName, ""), "PUT", "/hosts/goo/current", `{"option": "missing"}`, ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "IPName missing does not exist"}`, response.Body.String())
+}
+
+func TestAPISetHostMissingOption(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	response := apiRequestTo(t, newTestAPIHandler(configFileName, ""), "PUT", "/hosts/goo/current", `{}`, ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "Missing option"}`, response.Body.String())
+}
+
+func TestAPIStaleETag(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	response := apiRequestTo(t, newTestAPIHandler(configFileName, ""), "PUT", "/hosts/goo/current", `{"option": "baz"}`, map[string]string{"If-Match": `"stale"`})
+	assert.Equal(t, http.StatusPreconditionFailed, response.Code)
+	assert.Equal(t, testConfigETag(t, configFileName), response.Header().Get("ETag"))
+	assert.JSONEq(t, `{"error": "The configuration has changed, reload it and try again"}`, response.Body.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "foop", configData.Hosts["goo"].Current)
+}
+
+func TestAPIMissingIfMatch(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	response := apiRequestTo(t, newTestAPIHandler(configFileName, ""), "PUT", "/hosts/goo/current", `{"option": "baz"}`, nil)
+	assert.Equal(t, http.StatusPreconditionRequired, response.Code)
+	assert.Equal(t, testConfigETag(t, configFileName), response.Header().Get("ETag"))
+	assert.JSONEq(t, `{"error": "Send the ETag of the configuration in If-Match"}`, response.Body.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "foop", configData.Hosts["goo"].Current)
+}
+
+func TestAPIInvalidBody(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	response := apiRequestTo(t, newTestAPIHandler(configFileName, ""), "PUT", "/hosts/goo/current", `{`, ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.Contains(t, response.Body.String(), "Invalid request body")
+}
+
+func TestAPIHostOptions(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	handler := newTestAPIHandler(configFileName, "")
+	response := apiRequestTo(t, handler, "POST", "/hosts/goo/options", `{"name": "local", "address": "127.0.0.1"}`, ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	response = apiRequestTo(t, handler, "POST", "/hosts/goo/options", `{"name": "local", "address": "127.0.0.2"}`, ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "IP goo already exists"}`, response.Body.String())
+
+	response = apiRequestTo(t, handler, "DELETE", "/hosts/goo/options/foop", "", ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"local": "127.0.0.1"}, configData.Hosts["goo"].Options)
+}
+
+func TestAPIRemoveHost(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	handler := newTestAPIHandler(configFileName, "")
+	response := apiRequestTo(t, handler, "DELETE", "/hosts/goo", "", ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	response = apiRequestTo(t, handler, "DELETE", "/hosts/goo", "", ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.JSONEq(t, `{"error": "Hostname goo does not exist"}`, response.Body.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	_, exists := configData.Hosts["goo"]
+	assert.False(t, exists)
+	assert.Equal(t, map[string][]string{"foo": {"baz.com"}}, configData.Groups)
+}
+
+func TestAPIRemoveLastHostOfGroup(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	handler := newTestAPIHandler(configFileName, "")
+	response := apiRequestTo(t, handler, "DELETE", "/hosts/goo", "", ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNoContent, response.Code)
+	response = apiRequestTo(t, handler, "DELETE", "/hosts/baz.com", "", ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Empty(t, configData.Groups)
+}
+
+func TestAPIAddGlobalIPHost(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	response := apiRequestTo(t, newTestAPIHandler(configFileName, ""), "POST", "/hosts", `{"hostname": "new.com", "globalIP": "baz"}`, ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "baz", configData.Hosts["new.com"].Current)
+}
+
+func TestAPIGlobalIPChanges(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	handler := newTestAPIHandler(configFileName, "")
+	response := apiRequestTo(t, handler, "POST", "/globalIPs", `{"name": "local", "address": "localhost"}`, ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	response = apiRequestTo(t, handler, "DELETE", "/globalIPs/baz", "", ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	response = apiRequestTo(t, handler, "DELETE", "/globalIPs/baz", "", ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "GlobalIP baz does not exist"}`, response.Body.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"local": "127.0.0.1"}, configData.GlobalIPs)
+}
+
+func TestAPIGroupChanges(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	handler := newTestAPIHandler(configFileName, "")
+	response := apiRequestTo(t, handler, "POST", "/groups/foo/hosts", `{"hostname": "bar"}`, ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	response = apiRequestTo(t, handler, "PUT", "/groups/foo/current", `{"globalIP": "baz"}`, ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	response = apiRequestTo(t, handler, "PUT", "/groups/foo/current", `{"globalIP": "missing"}`, ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "Global IP missing does not exist"}`, response.Body.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"baz.com", "goo", "bar"}, configData.Groups["foo"])
+	assert.Equal(t, "baz", configData.Hosts["bar"].Current)
+	assert.Equal(t, "baz", configData.Hosts["goo"].Current)
+}
+
+func TestAPIRemoveGroup(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	handler := newTestAPIHandler(configFileName, "")
+	response := apiRequestTo(t, handler, "DELETE", "/groups/foo", "", ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	response = apiRequestTo(t, handler, "DELETE", "/groups/foo", "", ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.JSONEq(t, `{"error": "Group foo does not exist"}`, response.Body.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Empty(t, configData.Groups)
+	assert.Equal(t, "foop", configData.Hosts["goo"].Current)
+}
+
+func TestAPIBuild(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	handler := newTestAPIHandler(configFileName, outputFileName)
+	response := apiRequestTo(t, handler, "GET", "/build", "", nil)
+	assert.Equal(t, http.StatusOK, response.Code)
+	preview := response.Body.String()
+	assert.Contains(t, preview, "10.0.0.8 goo\n")
+
+	response = apiRequestTo(t, handler, "POST", "/build", `{}`, ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNoContent, response.Code)
+
+	output, err := ioutil.ReadFile(outputFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, preview, string(output))
+}
+
+func TestAPIBuildNoOutput(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	response := apiRequestTo(t, newTestAPIHandler(configFileName, ""), "POST", "/build", `{}`, ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+	assert.JSONEq(t, `{"error": "The API was started without an output file"}`, response.Body.String())
+}
+
+func TestAPIUnauthorized(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	request := httptest.NewRequest("GET", "/hosts", nil)
+	request.Header.Set("Authorization", "Bearer wrong")
+	response := httptest.NewRecorder()
+	newTestAPIHandler(configFileName, "").ServeHTTP(response, request)
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+	assert.Equal(t, "Bearer", response.Header().Get("WWW-Authenticate"))
+	assert.JSONEq(t, `{"error": "Missing or invalid token"}`, response.Body.String())
+}
+
+func TestAPINotFound(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	handler := newTestAPIHandler(configFileName, "")
+	response := apiRequestTo(t, handler, "GET", "/missing", "", nil)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+
+	response = apiRequestTo(t, handler, "DELETE", "/hosts", "", ifMatch(t, configFileName))
+	assert.Equal(t, http.StatusNotFound, response.Code)
+	assert.JSONEq(t, `{"error": "Not found"}`, response.Body.String())
+}
+
+func TestAPIBadConfigFile(t *testing.T) {
+	response := apiRequestTo(t, newTestAPIHandler("/notafile", ""), "GET", "/hosts", "", nil)
+	assert.Equal(t, http.StatusInternalServerError, response.Code)
+}
+
+func TestServeAPI(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	listener, err := listenAPI("127.0.0.1:0")
+	assert.Nil(t, err)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- serveAPI(listener, newTestAPIHandler(configFileName, ""), stop)
+	}()
+
+	request, err := http.NewRequest("GET", "http://"+listener.Addr().String()+"/globalIPs", nil)
+	assert.Nil(t, err)
+	request.Header.Set("Authorization", "Bearer "+testToken)
+	response, err := http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+	body, err := ioutil.ReadAll(response.Body)
+	assert.Nil(t, err)
+	assert.Nil(t, response.Body.Close())
+	assert.JSONEq(t, `{"baz": "10.0.0.4"}`, string(body))
+
+	close(stop)
+	assert.Nil(t, <-done)
+}
+
+func TestListenAPIUnixSocket(t *testing.T) {
+	socketDir, err := ioutil.TempDir("/tmp", "api")
+	assert.Nil(t, err)
+	defer func() {
+		assert.Nil(t, os.RemoveAll(socketDir))
+	}()
+
+	listener, err := listenAPI("unix:" + socketDir + "/api.sock")
+	assert.Nil(t, err)
+	info, err := os.Stat(socketDir + "/api.sock")
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.Equal(t, "unix", listener.Addr().Network())
+	assert.Nil(t, listener.Close())
+
+	_, err = net.Dial("unix", socketDir+"/api.sock")
+	assert.NotNil(t, err)
+}
+
+func TestCmdAPIUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdAPI(c), "Usage: \"hostBuilder api [--listen {address}|unix:{path}]\"")
+}
+
+func TestCmdAPINoConfig(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdAPI(c), "You must specify a config file")
+}
+
+func TestCmdAPINotLocal(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("listen", "0.0.0.0:8053", "doc")
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdAPI(c), "The API only listens on localhost or a unix socket")
+}
+
+func TestCmdAPIInvalidListen(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("listen", "localhost", "doc")
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdAPI(c), "Invalid listen address localhost")
+}
+
+func newTestAPIHandler(configFileName, outputFileName string) http.Handler {
+	return newAPIHandler(configFileName, outputFileName, false, testToken, new(bytes.Buffer))
+}
+
+func apiRequestTo(t *testing.T, handler http.Handler, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	request.Header.Set("Authorization", "Bearer "+testToken)
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	return response
+}
+
+// ifMatch is the If-Match header of a change to the configuration as it is now
+func ifMatch(t *testing.T, configFileName string) map[string]string {
+	return map[string]string{"If-Match": testConfigETag(t, configFileName)}
+}
+
+func testConfigETag(t *testing.T, configFileName string) string {
+	configJSON, err := ioutil.ReadFile(configFileName)
+	assert.Nil(t, err)
+	return configETag(configJSON)
+}
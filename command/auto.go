@@ -0,0 +1,78 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/urfave/cli"
+)
+
+// CmdAuto switches hosts and groups with the first rule that matches the network this machine is on
+func CmdAuto(lookup networkLookup) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		if c.NArg() != 0 {
+			return cli.NewExitError("Usage: \"hostBuilder auto\"", 1)
+		}
+
+		configData, err := loadConfig(c)
+		if err != nil {
+			return err
+		}
+
+		if len(configData.Rules) == 0 {
+			return cli.NewExitError("There are no rules, add one with 'hostBuilder rule add'", 1)
+		}
+
+		rule, reasons := firstMatchingRule(configData, lookup, func(err error) {
+			fmt.Fprintf(c.App.ErrWriter, "Warning: %v\n", err)
+		})
+		if rule == nil {
+			fmt.Fprintln(c.App.Writer, "No rule matched")
+			return nil
+		}
+
+		fmt.Fprintf(c.App.Writer, "Rule %s matched: %s\n", rule.Name, strings.Join(reasons, ", "))
+
+		before := hostCurrents(configData)
+		err = applyRule(configData, *rule)
+		if err != nil {
+			return err
+		}
+
+		switched := 0
+		for _, hostName := range sortHostNames(configData) {
+			if current := configData.Hosts[hostName].Current; before[hostName] != current {
+				fmt.Fprintf(c.App.Writer, "%s: %s -> %s\n", hostName, before[hostName], current)
+				switched++
+			}
+		}
+
+		if switched == 0 {
+			fmt.Fprintln(c.App.Writer, "Nothing to switch")
+			return nil
+		}
+
+		if c.Bool("dryRun") {
+			return nil
+		}
+
+		err = config.WriteConfig(c.GlobalString("config"), configData)
+		if err != nil {
+			return err
+		}
+
+		warnChangeHooks(configData, before, "", c.App.Writer, c.App.ErrWriter)
+		return nil
+	}
+}
+
+// CompleteAuto handles bash autocompletion for the 'auto' command
+func CompleteAuto(c *cli.Context) {
+	for _, flag := range c.App.Command("auto").Flags {
+		name := strings.Split(flag.GetName(), ",")[0]
+		if !c.IsSet(name) {
+			fmt.Fprintf(c.App.Writer, "--%s\n", name)
+		}
+	}
+}
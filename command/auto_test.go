@@ -0,0 +1,171 @@
+package command
+
+import (
+	"bytes"
+	"flag"
+	"net"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdAuto(t *testing.T) {
+	configFileName, set := setupRuleConfigFile(t, []config.Rule{
+		{Name: "home", CIDR: "192.168.0.0/16", Hosts: map[string]string{"goo": hostIgnore}},
+		{Name: "office", CIDR: "10.1.0.0/16", Interface: "tun*", Groups: map[string]string{"foo": "baz"}, Hosts: map[string]string{"baz.com": "bazz"}},
+		{Name: "fallback", Hosts: map[string]string{"goo": hostIgnore}},
+	})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdAuto(officeNetwork)(c))
+	assert.Equal(t, "Rule office matched: 10.1.2.3 on eth0 is in 10.1.0.0/16, tun0 is up\nbaz.com: baz -> bazz\ngoo: foop -> baz\n", writer.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "bazz", configData.Hosts["baz.com"].Current)
+	assert.Equal(t, "baz", configData.Hosts["goo"].Current)
+}
+
+func TestCmdAutoDryRun(t *testing.T) {
+	configFileName, set := setupRuleConfigFile(t, []config.Rule{{Name: "office", Env: "LOCATION=office", Hosts: map[string]string{"goo": hostIgnore}}})
+	defer removeFile(t, configFileName)
+
+	set.Bool("dryRun", true, "doc")
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdAuto(officeNetwork)(c))
+	assert.Equal(t, "Rule office matched: LOCATION=office\ngoo: foop -> ignore\n", writer.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "foop", configData.Hosts["goo"].Current)
+}
+
+func TestCmdAutoNothingToSwitch(t *testing.T) {
+	configFileName, set := setupRuleConfigFile(t, []config.Rule{{Name: "office", Gateway: "10.1.0.1", Hosts: map[string]string{"goo": "foop"}}})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdAuto(officeNetwork)(c))
+	assert.Equal(t, "Rule office matched: the default gateway is 10.1.0.1\nNothing to switch\n", writer.String())
+}
+
+func TestCmdAutoNoMatch(t *testing.T) {
+	configFileName, set := setupRuleConfigFile(t, []config.Rule{
+		{Name: "vpn", Interface: "wg*", Hosts: map[string]string{"goo": hostIgnore}},
+		{Name: "home", Env: "LOCATION=home", Hosts: map[string]string{"goo": hostIgnore}},
+		{Name: "ci", Env: "CI", Hosts: map[string]string{"goo": hostIgnore}},
+	})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdAuto(officeNetwork)(c))
+	assert.Equal(t, "No rule matched\n", writer.String())
+}
+
+func TestCmdAutoSkipsUncheckableRules(t *testing.T) {
+	configFileName, set := setupRuleConfigFile(t, []config.Rule{
+		{Name: "office", Gateway: "10.1.0.1", Hosts: map[string]string{"goo": hostIgnore}},
+		{Name: "fallback", Hosts: map[string]string{"baz.com": "bazz"}},
+	})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	errWriter := new(bytes.Buffer)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdAuto(fakeNetwork{})(c))
+	assert.Equal(t, "Rule fallback matched: it has no conditions\nbaz.com: baz -> bazz\n", writer.String())
+	assert.Equal(t, "Warning: Unable to check rule office: no default route\n", errWriter.String())
+}
+
+func TestCmdAutoChangeHook(t *testing.T) {
+	configFileName, set := setupRuleConfigFile(t, []config.Rule{{Name: "office", Hosts: map[string]string{"goo": hostIgnore}}})
+	defer removeFile(t, configFileName)
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	configData.Hooks = []config.Hook{{Name: "switch", When: hookChange, Command: "echo \"switched $HOSTBUILDER_HOSTS\""}}
+	assert.Nil(t, config.WriteConfig(configFileName, configData))
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdAuto(officeNetwork)(c))
+	assert.Equal(t, "Rule office matched: it has no conditions\ngoo: foop -> ignore\nswitched goo\n", writer.String())
+}
+
+func TestCmdAutoChangeHookAbortKeepsChange(t *testing.T) {
+	configFileName, set := setupRuleConfigFile(t, []config.Rule{{Name: "office", Hosts: map[string]string{"goo": hostIgnore}}})
+	defer removeFile(t, configFileName)
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	configData.Hooks = []config.Hook{{Name: "reload", When: hookChange, Command: "exit 3"}}
+	assert.Nil(t, config.WriteConfig(configFileName, configData))
+
+	app, writer := appWithWriter()
+	errWriter := new(bytes.Buffer)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdAuto(officeNetwork)(c))
+	assert.Equal(t, "Rule office matched: it has no conditions\ngoo: foop -> ignore\n", writer.String())
+	assert.Equal(t, "Warning: Hook reload exited with status 3\n", errWriter.String())
+
+	configData, err = config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, hostIgnore, configData.Hosts["goo"].Current)
+}
+
+func TestCmdAutoMissingHost(t *testing.T) {
+	configFileName, set := setupRuleConfigFile(t, []config.Rule{{Name: "office", Hosts: map[string]string{"gone": hostIgnore}}})
+	defer removeFile(t, configFileName)
+
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAuto(officeNetwork)(c), "HostName gone does not exist")
+}
+
+func TestCmdAutoNoRules(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdAuto(officeNetwork)(c), "There are no rules, add one with 'hostBuilder rule add'")
+}
+
+func TestCmdAutoUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdAuto(officeNetwork)(c), "Usage: \"hostBuilder auto\"")
+}
+
+func TestMatchRule(t *testing.T) {
+	reasons, matched, err := matchRule(config.Rule{CIDR: "fe80::/10"}, officeNetwork)
+	assert.Nil(t, err)
+	assert.True(t, matched)
+	assert.Equal(t, []string{"fe80::1 on eth0 is in fe80::/10"}, reasons)
+
+	_, matched, err = matchRule(config.Rule{CIDR: "10.1.0.0/16", Gateway: "10.9.0.1"}, officeNetwork)
+	assert.Nil(t, err)
+	assert.False(t, matched)
+
+	_, matched, err = matchRule(config.Rule{Gateway: "10.1.0.1"}, fakeNetwork{gateway: net.ParseIP("10.1.0.1")})
+	assert.Nil(t, err)
+	assert.True(t, matched)
+}
+
+func TestCompleteAuto(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	app.Commands = []cli.Command{{Name: "auto", Flags: []cli.Flag{cli.BoolFlag{Name: "dryRun"}}}}
+	c := cli.NewContext(app, set, nil)
+	CompleteAuto(c)
+	assert.Equal(t, "--dryRun\n", writer.String())
+}
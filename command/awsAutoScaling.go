@@ -0,0 +1,16 @@
+package command
+
+import (
+	"github.com/guywithnose/hostBuilder/awsUtil"
+	"github.com/urfave/cli"
+)
+
+// CmdAwsAutoScaling adds Auto Scaling group instance addresses to the configuration
+func CmdAwsAutoScaling(util awsUtil.AwsInterface) func(*cli.Context) error {
+	return CmdProvider(awsUtil.NewAutoScalingProvider(util), "Usage: \"hostBuilder aws asg\"")
+}
+
+// CompleteAwsAutoScaling handles bash autocompletion for the 'aws asg' command
+func CompleteAwsAutoScaling(util awsUtil.AwsInterface) func(c *cli.Context) {
+	return CompleteProvider(awsUtil.NewAutoScalingProvider(util), "asg")
+}
@@ -0,0 +1,62 @@
+package command
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/awsUtil"
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdAwsAutoScaling(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("groups", "web", "doc")
+	set.String("addresses", "private:,public:-public", "doc")
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	util.groups = []awsUtil.AutoScalingGroup{
+		{
+			Name:   "web",
+			Region: "us-east-1",
+			Instances: []awsUtil.GroupInstance{
+				{ID: "i-1", Addresses: map[string][]string{awsUtil.PrivateAddress: {"10.0.0.1"}, awsUtil.PublicAddress: {"54.0.0.1"}}},
+				{ID: "i-2", Addresses: map[string][]string{awsUtil.PrivateAddress: {"10.0.0.2"}}},
+			},
+		},
+	}
+	assert.Nil(t, CmdAwsAutoScaling(util)(c))
+	assert.Equal(t, []string{"web"}, util.names)
+	assert.Equal(
+		t,
+		"Added global IP web-1 (10.0.0.1)\nAdded global IP web-1-public (54.0.0.1)\nAdded global IP web-2 (10.0.0.2)\n",
+		writer.String(),
+	)
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "i-2", configData.Provenance["web-2"].ResourceID)
+}
+
+func TestCmdAwsAutoScalingInvalidAddresses(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	set.String("addresses", "floating", "doc")
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsAutoScaling(new(awsTestUtil))(c), "Invalid address kind floating")
+}
+
+func TestCompleteAwsAutoScalingAddresses(t *testing.T) {
+	os.Args = []string{"aws", "asg", "--addresses", "--bash-completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteAwsAutoScaling(new(awsTestUtil))(c)
+
+	assert.Equal(t, "public\nprivate\nsecondary\nipv6\nelastic\n", writer.String())
+}
@@ -0,0 +1,16 @@
+package command
+
+import (
+	"github.com/guywithnose/hostBuilder/awsUtil"
+	"github.com/urfave/cli"
+)
+
+// CmdAwsEcs adds ECS task addresses to the configuration
+func CmdAwsEcs(util awsUtil.AwsInterface) func(*cli.Context) error {
+	return CmdProvider(awsUtil.NewECSProvider(util), "Usage: \"hostBuilder aws ecs\"")
+}
+
+// CompleteAwsEcs handles bash autocompletion for the 'aws ecs' command
+func CompleteAwsEcs(util awsUtil.AwsInterface) func(c *cli.Context) {
+	return CompleteProvider(awsUtil.NewECSProvider(util), "ecs")
+}
@@ -0,0 +1,44 @@
+package command
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/awsUtil"
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdAwsEcs(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("clusters", "web,batch", "doc")
+	app, writer := appWithWriter()
+	errWriter := new(bytes.Buffer)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	util.tasks = []awsUtil.Task{
+		{ID: "t1", ARN: "arn:aws:ecs:us-east-1:1:task/t1", Cluster: "web", Service: "api", InstanceID: "i-1", IP: "10.0.0.1", Region: "us-east-1"},
+		{ID: "t2", ARN: "arn:aws:ecs:us-east-1:1:task/t2", Cluster: "web", Service: "api", Region: "us-east-1"},
+	}
+	assert.Nil(t, CmdAwsEcs(util)(c))
+	assert.Equal(t, []string{"web", "batch"}, util.names)
+	assert.Equal(t, "Added global IP web/api/t1 (10.0.0.1)\n", writer.String())
+	assert.Equal(t, "Warning: Task t2 of web has no private IP, skipping\n", errWriter.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "arn:aws:ecs:us-east-1:1:task/t1", configData.Provenance["web/api/t1"].ResourceID)
+}
+
+func TestCmdAwsEcsInvalidClusters(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	set.String("clusters", "web,", "doc")
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsEcs(new(awsTestUtil))(c), "Invalid cluster ")
+}
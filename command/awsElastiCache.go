@@ -0,0 +1,16 @@
+package command
+
+import (
+	"github.com/guywithnose/hostBuilder/awsUtil"
+	"github.com/urfave/cli"
+)
+
+// CmdAwsElastiCache adds ElastiCache node addresses to the configuration
+func CmdAwsElastiCache(util awsUtil.AwsInterface) func(*cli.Context) error {
+	return CmdProvider(awsUtil.NewElastiCacheProvider(util), "Usage: \"hostBuilder aws elasticache\"")
+}
+
+// CompleteAwsElastiCache handles bash autocompletion for the 'aws elasticache' command
+func CompleteAwsElastiCache(util awsUtil.AwsInterface) func(c *cli.Context) {
+	return CompleteProvider(awsUtil.NewElastiCacheProvider(util), "elasticache")
+}
@@ -0,0 +1,68 @@
+package command
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/awsUtil"
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdAwsElastiCache(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("template", "{{.Engine}}-{{.ID}}", "doc")
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	util.cacheClusters = []awsUtil.CacheCluster{
+		{ID: "sessions", Engine: "redis", Region: "us-east-1", Nodes: []awsUtil.CacheNode{{ID: "0001", Endpoint: "localhost"}}},
+		{
+			ID:     "pages",
+			Engine: "memcached",
+			Region: "us-east-1",
+			Nodes:  []awsUtil.CacheNode{{ID: "0001", Zone: "us-east-1a", Endpoint: "localhost"}, {ID: "0002", Zone: "us-east-1b", Endpoint: "localhost"}},
+		},
+	}
+	assert.Nil(t, CmdAwsElastiCache(util)(c))
+	assert.Equal(
+		t,
+		"Added global IP memcached-pages (127.0.0.1)\n"+
+			"Added global IP memcached-pages-0001 (127.0.0.1)\n"+
+			"Added global IP memcached-pages-0002 (127.0.0.1)\n"+
+			"Added global IP redis-sessions (127.0.0.1)\n",
+		writer.String(),
+	)
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "pages", configData.Provenance["memcached-pages-0002"].ResourceID)
+}
+
+func TestCmdAwsElastiCacheEngines(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("engines", "redis", "doc")
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	util.cacheClusters = []awsUtil.CacheCluster{
+		{ID: "sessions", Engine: "redis", Nodes: []awsUtil.CacheNode{{ID: "0001", Endpoint: "localhost"}}},
+		{ID: "pages", Engine: "memcached", Nodes: []awsUtil.CacheNode{{ID: "0001", Endpoint: "localhost"}}},
+	}
+	assert.Nil(t, CmdAwsElastiCache(util)(c))
+	assert.Equal(t, "Added global IP sessions (127.0.0.1)\n", writer.String())
+}
+
+func TestCmdAwsElastiCacheAwsError(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	util.throwError = true
+	assert.EqualError(t, CmdAwsElastiCache(util)(c), "error")
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/guywithnose/hostBuilder/awsUtil"
 	"github.com/guywithnose/hostBuilder/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli"
@@ -14,18 +15,85 @@ func TestCmdAwsInstances(t *testing.T) {
 	configFileName, set := setupBaseConfigFile(t)
 	defer removeFile(t, configFileName)
 
-	c := cli.NewContext(nil, set, nil)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	util.instances = []awsUtil.InstanceAddress{
+		{Name: "bar", IP: "::1", InstanceID: "i-2", Region: "us-west-2"},
+		{Name: "foo", IP: "127.0.0.1", InstanceID: "i-1", Region: "us-east-1"},
+	}
+	assert.Nil(t, CmdAwsInstances(util)(c))
+	assert.Equal(t, "Added global IP bar (::1)\nAdded global IP foo (127.0.0.1)\n", writer.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]string{"foo": "127.0.0.1", "bar": "::1", "baz": "10.0.0.4"}, configData.GlobalIPs)
+	assert.Equal(
+		t,
+		config.Provenance{
+			Source: "awsInstances", Provider: "awsInstances", Profile: "default", Region: "us-east-1", ResourceID: "i-1",
+			ImportedAt: &testImportedAt, FetchedAt: &testImportedAt,
+		},
+		configData.Provenance["foo"],
+	)
+}
+
+func TestCmdAwsInstancesProfiles(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("profile", "dev,prod", "doc")
+	set.String("profilePrefix", "{{.Profile}}.", "doc")
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	util.instances = []awsUtil.InstanceAddress{{Name: "foo", IP: "127.0.0.1", InstanceID: "i-1", Region: "us-east-1"}}
+	assert.Nil(t, CmdAwsInstances(util)(c))
+	assert.Equal(t, "Added global IP dev.foo (127.0.0.1)\nAdded global IP prod.foo (127.0.0.1)\n", writer.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "prod", configData.Provenance["prod.foo"].Profile)
+}
+
+func TestCmdAwsInstancesHostTags(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("makeCurrent", "true", "doc")
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
 	util := new(awsTestUtil)
-	instanceHosts := map[string]string{"foo": "127.0.0.1", "bar": "::1"}
-	util.instances = instanceHosts
+	tags := map[string]string{awsUtil.HostnamesTag: "baz.com,api.example.com", awsUtil.OptionTag: "staging"}
+	util.instances = []awsUtil.InstanceAddress{
+		{Name: "web", IP: "54.0.0.1", InstanceID: "i-1", Region: "us-east-1", Tags: tags, Primary: true},
+		{Name: "web-private", IP: "10.0.0.1", InstanceID: "i-1", Region: "us-east-1", Tags: tags},
+	}
 	assert.Nil(t, CmdAwsInstances(util)(c))
+	assert.Equal(
+		t,
+		"Added global IP web (54.0.0.1)\n"+
+			"Added global IP web-private (10.0.0.1)\n"+
+			"Added host api.example.com (staging => 54.0.0.1)\n"+
+			"Added option to baz.com (staging => 54.0.0.1)\n"+
+			"Switched baz.com to staging\n",
+		writer.String(),
+	)
 
 	configData, err := config.LoadConfigFromFile(configFileName)
 	assert.Nil(t, err)
+	assert.Equal(t, "staging", configData.Hosts["baz.com"].Current)
+	assert.Equal(t, "54.0.0.1", configData.Hosts["baz.com"].Options["staging"])
+	assert.Equal(t, "i-1", configData.Hosts["api.example.com"].Provenance["staging"].ResourceID)
+}
 
-	expectedIPs := instanceHosts
-	expectedIPs["baz"] = "10.0.0.4"
-	assert.Equal(t, expectedIPs, configData.GlobalIPs)
+func TestCmdAwsInstancesInvalidMakeCurrent(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	set.String("makeCurrent", "maybe", "doc")
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsInstances(new(awsTestUtil))(c), "Invalid makeCurrent maybe, expected true or false")
 }
 
 func TestCmdAwsInstancesBadTemplate(t *testing.T) {
@@ -33,35 +101,33 @@ func TestCmdAwsInstancesBadTemplate(t *testing.T) {
 	defer removeFile(t, configFileName)
 
 	set.String("template", "{{.badTemplate", "doc")
-	c := cli.NewContext(nil, set, nil)
-	instanceHosts := map[string]string{}
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
 	util := new(awsTestUtil)
-	util.instances = instanceHosts
 	assert.EqualError(t, CmdAwsInstances(util)(c), "template: :1: unclosed action")
 }
 
 func TestCmdAwsInstancesNoConfig(t *testing.T) {
 	set := flag.NewFlagSet("test", 0)
-	c := cli.NewContext(nil, set, nil)
-	instanceHosts := map[string]string{}
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
 	util := new(awsTestUtil)
-	util.instances = instanceHosts
 	assert.EqualError(t, CmdAwsInstances(util)(c), "You must specify a config file")
 }
 
 func TestCmdAwsInstancesUsage(t *testing.T) {
 	set := flag.NewFlagSet("test", 0)
 	assert.Nil(t, set.Parse([]string{"foo"}))
-	c := cli.NewContext(nil, set, nil)
-	instanceHosts := map[string]string{}
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
 	util := new(awsTestUtil)
-	util.instances = instanceHosts
 	assert.EqualError(t, CmdAwsInstances(util)(c), "Usage: \"hostBuilder aws instances\"")
 }
 
 func TestCmdAwsInstancesAwsError(t *testing.T) {
 	set := flag.NewFlagSet("test", 0)
-	c := cli.NewContext(nil, set, nil)
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
 	util := new(awsTestUtil)
 	util.throwError = true
 	assert.EqualError(t, CmdAwsInstances(util)(c), "error")
@@ -117,3 +183,72 @@ func TestCompleteAwsInstancesAwsError(t *testing.T) {
 
 	assert.Equal(t, "", writer.String())
 }
+
+func TestCmdAwsInstancesRegions(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("region", "us-*,!us-gov-*", "doc")
+	set.String("endpoint", "http://localhost:4566", "doc")
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	assert.Nil(t, CmdAwsInstances(util)(c))
+	assert.Equal(t, awsUtil.RegionFilter{Include: []string{"us-*"}, Exclude: []string{"us-gov-*"}}, util.regions)
+	assert.Equal(t, "http://localhost:4566", util.endpoint)
+}
+
+func TestCmdAwsInstancesInvalidRegion(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("region", "us-[", "doc")
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsInstances(new(awsTestUtil))(c), "Invalid region us-[")
+}
+
+func TestCmdAwsInstancesFilter(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("filter", "tag:env=staging", "doc")
+	set.String("state", "running", "doc")
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	assert.Nil(t, CmdAwsInstances(util)(c))
+	assert.Equal(t, awsUtil.InstanceFilter{"tag:env": {"staging"}, "instance-state-name": {"running"}}, util.instanceFilter)
+}
+
+func TestCmdAwsInstancesInvalidState(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("state", "asleep", "doc")
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsInstances(new(awsTestUtil))(c), "Invalid instance state asleep")
+}
+
+func TestCmdAwsInstancesAddresses(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("addresses", "private:,elastic", "doc")
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	assert.Nil(t, CmdAwsInstances(util)(c))
+	assert.Equal(t, []awsUtil.AddressKind{{Kind: awsUtil.PrivateAddress}, {Kind: awsUtil.ElasticAddress, Suffix: "-eip"}}, util.kinds)
+}
+
+func TestCmdAwsInstancesInvalidAddresses(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("addresses", "floating", "doc")
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsInstances(new(awsTestUtil))(c), "Invalid address kind floating")
+}
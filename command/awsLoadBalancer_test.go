@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/guywithnose/hostBuilder/awsUtil"
 	"github.com/guywithnose/hostBuilder/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli"
@@ -14,10 +15,12 @@ func TestCmdAwsLoadBalancer(t *testing.T) {
 	configFileName, set := setupBaseConfigFile(t)
 	defer removeFile(t, configFileName)
 
-	c := cli.NewContext(nil, set, nil)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
 	util := new(awsTestUtil)
-	util.loadBalancers = map[string]string{"foo": "localhost4", "bar": "localhost6"}
+	util.loadBalancers = []awsUtil.LoadBalancer{{Name: "foo", DNSName: "localhost4"}, {Name: "bar", DNSName: "localhost6"}}
 	assert.Nil(t, CmdAwsLoadBalancer(util)(c))
+	assert.Equal(t, "Added global IP bar (::1)\nAdded global IP foo (127.0.0.1)\n", writer.String())
 
 	configData, err := config.LoadConfigFromFile(configFileName)
 	assert.Nil(t, err)
@@ -26,39 +29,86 @@ func TestCmdAwsLoadBalancer(t *testing.T) {
 	assert.Equal(t, expectedIPs, configData.GlobalIPs)
 }
 
+func TestCmdAwsLoadBalancerNetwork(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("template", "{{.Name}}.{{.Region}}", "doc")
+	set.String("types", "network", "doc")
+	set.String("scheme", "internal", "doc")
+	set.String("tags", "env=prod,team", "doc")
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	util.loadBalancers = []awsUtil.LoadBalancer{
+		{
+			Name:      "nlb",
+			Type:      awsUtil.Network,
+			Region:    "us-east-1",
+			Addresses: []awsUtil.ZoneAddress{{Zone: "us-east-1a", IP: "52.0.0.1"}, {Zone: "us-east-1b", IP: "52.0.0.2"}},
+		},
+	}
+	assert.Nil(t, CmdAwsLoadBalancer(util)(c))
+	assert.Equal(
+		t,
+		awsUtil.LoadBalancerFilter{Types: []string{awsUtil.Network}, Scheme: "internal", Tags: map[string]string{"env": "prod", "team": ""}},
+		util.filter,
+	)
+	assert.Equal(
+		t,
+		"Added global IP nlb.us-east-1 (52.0.0.1)\n"+
+			"Added global IP nlb.us-east-1-us-east-1a (52.0.0.1)\n"+
+			"Added global IP nlb.us-east-1-us-east-1b (52.0.0.2)\n",
+		writer.String(),
+	)
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "52.0.0.2", configData.GlobalIPs["nlb.us-east-1-us-east-1b"])
+}
+
+func TestCmdAwsLoadBalancerInvalidType(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("types", "gateway", "doc")
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsLoadBalancer(new(awsTestUtil))(c), "Invalid load balancer type gateway")
+}
+
 func TestCmdAwsLoadBalancerUnresolvedHostname(t *testing.T) {
 	configFileName, set := setupBaseConfigFile(t)
 	defer removeFile(t, configFileName)
 
-	c := cli.NewContext(nil, set, nil)
-	loadBalancers := map[string]string{"foo": "notahost"}
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
 	util := new(awsTestUtil)
-	util.loadBalancers = loadBalancers
+	util.loadBalancers = []awsUtil.LoadBalancer{{Name: "foo", DNSName: "notahost"}}
 	assert.EqualError(t, CmdAwsLoadBalancer(util)(c), "Unable to resolve notahost")
 }
 
 func TestCmdAwsLoadBalancerNoConfig(t *testing.T) {
 	set := flag.NewFlagSet("test", 0)
-	c := cli.NewContext(nil, set, nil)
-	loadBalancers := map[string]string{}
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
 	util := new(awsTestUtil)
-	util.loadBalancers = loadBalancers
 	assert.EqualError(t, CmdAwsLoadBalancer(util)(c), "You must specify a config file")
 }
 
 func TestCmdAwsLoadBalancerUsage(t *testing.T) {
 	set := flag.NewFlagSet("test", 0)
 	assert.Nil(t, set.Parse([]string{"foo"}))
-	c := cli.NewContext(nil, set, nil)
-	loadBalancers := map[string]string{}
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
 	util := new(awsTestUtil)
-	util.loadBalancers = loadBalancers
 	assert.EqualError(t, CmdAwsLoadBalancer(util)(c), "Usage: \"hostBuilder aws loadBalancers\"")
 }
 
 func TestCmdAwsLoadBalancerAwsError(t *testing.T) {
 	set := flag.NewFlagSet("test", 0)
-	c := cli.NewContext(nil, set, nil)
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
 	util := new(awsTestUtil)
 	util.throwError = true
 	assert.EqualError(t, CmdAwsLoadBalancer(util)(c), "error")
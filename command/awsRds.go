@@ -0,0 +1,16 @@
+package command
+
+import (
+	"github.com/guywithnose/hostBuilder/awsUtil"
+	"github.com/urfave/cli"
+)
+
+// CmdAwsRds adds RDS instance addresses to the configuration
+func CmdAwsRds(util awsUtil.AwsInterface) func(*cli.Context) error {
+	return CmdProvider(awsUtil.NewRDSProvider(util), "Usage: \"hostBuilder aws rds\"")
+}
+
+// CompleteAwsRds handles bash autocompletion for the 'aws rds' command
+func CompleteAwsRds(util awsUtil.AwsInterface) func(c *cli.Context) {
+	return CompleteProvider(awsUtil.NewRDSProvider(util), "rds")
+}
@@ -0,0 +1,70 @@
+package command
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/awsUtil"
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdAwsRds(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("engines", "postgres", "doc")
+	app, writer := appWithWriter()
+	errWriter := new(bytes.Buffer)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	util.dbInstances = []awsUtil.DBInstance{
+		{ID: "orders", Engine: "postgres", Endpoint: "localhost", Region: "us-east-1"},
+		{ID: "cache", Engine: "mysql", Endpoint: "localhost", Region: "us-east-1"},
+		{ID: "reports", Engine: "postgres", Endpoint: "notahost", Region: "us-east-1"},
+	}
+	assert.Nil(t, CmdAwsRds(util)(c))
+	assert.Equal(t, "Added global IP orders (127.0.0.1)\n", writer.String())
+	assert.Equal(t, "Warning: Unable to resolve notahost, skipping reports\n", errWriter.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		config.Provenance{
+			Source: "awsRds", Provider: "awsRds", Profile: "default", Region: "us-east-1", ResourceID: "orders",
+			ImportedAt: &testImportedAt, FetchedAt: &testImportedAt,
+		},
+		configData.Provenance["orders"],
+	)
+}
+
+func TestCmdAwsRdsInvalidEngine(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	set.String("engines", "postgres,dynamo", "doc")
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsRds(new(awsTestUtil))(c), "Invalid engine dynamo")
+}
+
+func TestCmdAwsRdsUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsRds(new(awsTestUtil))(c), "Usage: \"hostBuilder aws rds\"")
+}
+
+func TestCompleteAwsRdsEngines(t *testing.T) {
+	os.Args = []string{"aws", "rds", "--engines", "--bash-completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteAwsRds(new(awsTestUtil))(c)
+
+	assert.Contains(t, writer.String(), "aurora-postgresql\n")
+}
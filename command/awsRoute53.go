@@ -0,0 +1,162 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/guywithnose/hostBuilder/awsUtil"
+	"github.com/guywithnose/hostBuilder/hosts"
+	"github.com/urfave/cli"
+)
+
+// defaultRecordTTL is the TTL of exported records when none is given
+const defaultRecordTTL = 300
+
+// CmdAwsRoute53Import adds the records of a Route53 hosted zone as options on their hostnames
+func CmdAwsRoute53Import(util awsUtil.AwsInterface) func(c *cli.Context) error {
+	route53Provider := awsUtil.NewRoute53Provider(util)
+	return func(c *cli.Context) error {
+		if c.NArg() != 1 {
+			return cli.NewExitError("Usage: \"hostBuilder aws route53 import {zone}\"", 1)
+		}
+
+		settings, err := providerSettings(c, route53Provider)
+		if err != nil {
+			return err
+		}
+
+		settings["zone"] = c.Args().Get(0)
+		return importProvider(c, route53Provider, settings, fmt.Sprintf("%s:%s", route53Provider.Name(), settings["zone"]))
+	}
+}
+
+// CompleteAwsRoute53Import handles bash autocompletion for the 'aws route53 import' command
+func CompleteAwsRoute53Import(util awsUtil.AwsInterface) func(c *cli.Context) {
+	route53Provider := awsUtil.NewRoute53Provider(util)
+	return func(c *cli.Context) {
+		if c.NArg() != 0 || strings.HasPrefix(os.Args[len(os.Args)-2], "-") {
+			completeProviderCommand(c, "import", route53Provider)
+			return
+		}
+
+		settings := map[string]string{}
+		for _, setting := range route53Provider.Schema() {
+			settings[setting.Name] = c.String(setting.Name)
+		}
+
+		for _, zone := range route53Provider.Complete("zone", settings) {
+			fmt.Fprintln(c.App.Writer, zone)
+		}
+	}
+}
+
+// CmdAwsRoute53Export writes a Route53 change batch that publishes the current IP of every host in a group
+func CmdAwsRoute53Export(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Usage: \"hostBuilder aws route53 export {groupName}\"", 1)
+	}
+
+	action := strings.ToUpper(c.String("action"))
+	if action == "" {
+		action = awsUtil.ChangeActions[0]
+	}
+
+	if !stringInList(awsUtil.ChangeActions, action) {
+		return cli.NewExitError(fmt.Sprintf("Invalid action %s", c.String("action")), 1)
+	}
+
+	ttl := c.Int64("ttl")
+	if ttl == 0 {
+		ttl = defaultRecordTTL
+	}
+
+	if ttl < 0 {
+		return cli.NewExitError(fmt.Sprintf("Invalid TTL %d", ttl), 1)
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	groupName := c.Args().Get(0)
+	if _, exists := configData.Groups[groupName]; !exists {
+		return cli.NewExitError(fmt.Sprintf("Group %s does not exist", groupName), 1)
+	}
+
+	zone := strings.ToLower(strings.TrimSuffix(c.String("zone"), "."))
+	addresses := map[string]string{}
+	for _, hostName := range configData.Groups[groupName] {
+		if zone != "" && hostName != zone && !strings.HasSuffix(hostName, "."+zone) {
+			fmt.Fprintf(c.App.ErrWriter, "Warning: %s is not in %s, skipping\n", hostName, zone)
+			continue
+		}
+
+		IP, ok := hosts.CurrentIP(configData, hostName)
+		if !ok {
+			fmt.Fprintf(c.App.ErrWriter, "Warning: %s has no current IP, skipping\n", hostName)
+			continue
+		}
+
+		addresses[hostName] = IP
+	}
+
+	if len(addresses) == 0 {
+		return cli.NewExitError(fmt.Sprintf("Group %s has no current IPs to export", groupName), 1)
+	}
+
+	batch, err := awsUtil.ChangeBatch(action, c.String("comment"), ttl, addresses)
+	if err != nil {
+		return err
+	}
+
+	if outputFile := c.String("output"); outputFile != "" {
+		return ioutil.WriteFile(outputFile, batch, 0644)
+	}
+
+	_, err = c.App.Writer.Write(batch)
+	return err
+}
+
+// CompleteAwsRoute53Export handles bash autocompletion for the 'aws route53 export' command
+func CompleteAwsRoute53Export(c *cli.Context) {
+	lastParam := os.Args[len(os.Args)-2]
+	if lastParam == "--output" {
+		fmt.Fprintln(c.App.Writer, "fileCompletion")
+		return
+	}
+
+	if lastParam == "--action" {
+		fmt.Fprintln(c.App.Writer, strings.Join(awsUtil.ChangeActions, "\n"))
+		return
+	}
+
+	if c.NArg() == 0 {
+		configData, err := loadConfig(c)
+		if err != nil {
+			return
+		}
+
+		fmt.Fprintln(c.App.Writer, strings.Join(sortGroupNames(configData), "\n"))
+		return
+	}
+
+	for _, flag := range c.App.Command("export").Flags {
+		name := strings.Split(flag.GetName(), ",")[0]
+		if !c.IsSet(name) {
+			fmt.Fprintf(c.App.Writer, "--%s\n", name)
+		}
+	}
+}
+
+func stringInList(list []string, value string) bool {
+	for _, candidate := range list {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
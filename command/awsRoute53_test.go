@@ -0,0 +1,264 @@
+package command
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/awsUtil"
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func route53TestZones() []awsUtil.HostedZone {
+	return []awsUtil.HostedZone{
+		{
+			ID:   "Z1",
+			Name: "example.com",
+			Records: []awsUtil.Record{
+				{Name: "web.example.com", Type: "A", Values: []string{"10.0.0.1"}},
+				{Name: "web.example.com", Type: "AAAA", Values: []string{"2001:db8::1"}},
+				{Name: "baz.com", Type: "A", Values: []string{"10.0.0.2"}},
+				{Name: "*.example.com", Type: "A", Values: []string{"10.0.0.9"}},
+			},
+		},
+		{ID: "Z2", Name: "internal.com", Private: true},
+	}
+}
+
+func TestCmdAwsRoute53Import(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"example.com"}))
+	app, writer := appWithWriter()
+	errWriter := new(bytes.Buffer)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	util.zones = route53TestZones()
+	assert.Nil(t, CmdAwsRoute53Import(util)(c))
+	assert.Equal(t, "example.com", util.zone)
+	assert.Equal(t, "Added option to baz.com (example.com => 10.0.0.2)\nAdded host web.example.com (example.com => 10.0.0.1)\n", writer.String())
+	assert.Equal(t, "Warning: *.example.com is a wildcard record, skipping\n", errWriter.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"bazz": "10.0.0.7", "example.com": "10.0.0.2"}, configData.Hosts["baz.com"].Options)
+	assert.Equal(t, "baz", configData.Hosts["baz.com"].Current)
+	assert.Equal(
+		t,
+		config.Provenance{
+			Source: "awsRoute53:example.com", Provider: "awsRoute53", Profile: "default", ResourceID: "Z1",
+			ImportedAt: &testImportedAt, FetchedAt: &testImportedAt,
+		},
+		configData.Hosts["web.example.com"].Provenance["example.com"],
+	)
+}
+
+func TestCmdAwsRoute53ImportTypesAndOption(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("types", "AAAA", "doc")
+	set.String("option", "{{.Profile}}-{{.Type}}", "doc")
+	set.String("profile", "prod", "doc")
+	assert.Nil(t, set.Parse([]string{"Z1"}))
+	app, writer := appWithWriter()
+	app.ErrWriter = new(bytes.Buffer)
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	util.zones = route53TestZones()
+	assert.Nil(t, CmdAwsRoute53Import(util)(c))
+	assert.Equal(t, "Added host web.example.com (prod-AAAA => 2001:db8::1)\n", writer.String())
+}
+
+func TestCmdAwsRoute53ImportInvalidType(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("types", "CNAME", "doc")
+	assert.Nil(t, set.Parse([]string{"example.com"}))
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsRoute53Import(new(awsTestUtil))(c), "Invalid record type CNAME")
+}
+
+func TestCmdAwsRoute53ImportUnknownZone(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"other.com"}))
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	util.zones = route53TestZones()
+	assert.EqualError(t, CmdAwsRoute53Import(util)(c), "No hosted zone matches other.com")
+}
+
+func TestCmdAwsRoute53ImportUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsRoute53Import(new(awsTestUtil))(c), "Usage: \"hostBuilder aws route53 import {zone}\"")
+}
+
+func TestCompleteAwsRoute53ImportZone(t *testing.T) {
+	os.Args = []string{"aws", "route53", "import", "--bash-completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	util := new(awsTestUtil)
+	util.zones = route53TestZones()
+	CompleteAwsRoute53Import(util)(c)
+
+	assert.Equal(t, "example.com\ninternal.com\n", writer.String())
+}
+
+func TestCompleteAwsRoute53ImportTypes(t *testing.T) {
+	os.Args = []string{"aws", "route53", "import", "example.com", "--types", "--bash-completion"}
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"example.com"}))
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	app.Commands = []cli.Command{{Name: "import"}}
+	CompleteAwsRoute53Import(new(awsTestUtil))(c)
+
+	assert.Equal(t, "A\nAAAA\n", writer.String())
+}
+
+func TestCmdAwsRoute53Export(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("comment", "release", "doc")
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdAwsRoute53Export(c))
+	expected, err := awsUtil.ChangeBatch("UPSERT", "release", defaultRecordTTL, map[string]string{"baz.com": "10.0.0.4", "goo": "10.0.0.8"})
+	assert.Nil(t, err)
+	assert.Equal(t, string(expected), writer.String())
+}
+
+func TestCmdAwsRoute53ExportZoneAndOutput(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	outputFile, err := ioutil.TempFile("/tmp", "batch")
+	assert.Nil(t, err)
+	defer removeFile(t, outputFile.Name())
+
+	set.String("zone", "com.", "doc")
+	set.String("action", "delete", "doc")
+	set.Int64("ttl", 60, "doc")
+	set.String("output", outputFile.Name(), "doc")
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	app, errWriter := appWithErrWriter()
+	writer := new(bytes.Buffer)
+	app.Writer = writer
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdAwsRoute53Export(c))
+	assert.Equal(t, "", writer.String())
+	assert.Equal(t, "Warning: goo is not in com, skipping\n", errWriter.String())
+
+	expected, err := awsUtil.ChangeBatch("DELETE", "", 60, map[string]string{"baz.com": "10.0.0.4"})
+	assert.Nil(t, err)
+	batch, err := ioutil.ReadFile(outputFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, string(expected), string(batch))
+}
+
+func TestCmdAwsRoute53ExportNoCurrentIPs(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("zone", "example.com", "doc")
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	app, errWriter := appWithErrWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsRoute53Export(c), "Group foo has no current IPs to export")
+	assert.Equal(t, "Warning: baz.com is not in example.com, skipping\nWarning: goo is not in example.com, skipping\n", errWriter.String())
+}
+
+func TestCmdAwsRoute53ExportInvalidAction(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("action", "replace", "doc")
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsRoute53Export(c), "Invalid action replace")
+}
+
+func TestCmdAwsRoute53ExportInvalidTTL(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.Int64("ttl", -1, "doc")
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsRoute53Export(c), "Invalid TTL -1")
+}
+
+func TestCmdAwsRoute53ExportMissingGroup(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"bar"}))
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsRoute53Export(c), "Group bar does not exist")
+}
+
+func TestCmdAwsRoute53ExportUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdAwsRoute53Export(c), "Usage: \"hostBuilder aws route53 export {groupName}\"")
+}
+
+func TestCompleteAwsRoute53Export(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	os.Args = []string{"aws", "route53", "export", "--bash-completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteAwsRoute53Export(c)
+
+	assert.Equal(t, "foo\n", writer.String())
+}
+
+func TestCompleteAwsRoute53ExportAction(t *testing.T) {
+	os.Args = []string{"aws", "route53", "export", "foo", "--action", "--bash-completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteAwsRoute53Export(c)
+
+	assert.Equal(t, "UPSERT\nCREATE\nDELETE\n", writer.String())
+}
+
+func TestCompleteAwsRoute53ExportFlags(t *testing.T) {
+	os.Args = []string{"aws", "route53", "export", "foo", "--bash-completion"}
+	set := flag.NewFlagSet("test", 0)
+	set.String("zone", "", "doc")
+	assert.Nil(t, set.Parse([]string{"--zone", "example.com", "foo"}))
+	app, writer := appWithWriter()
+	app.Commands = []cli.Command{
+		{
+			Name:  "export",
+			Flags: []cli.Flag{cli.StringFlag{Name: "zone, z"}, cli.StringFlag{Name: "output, o"}},
+		},
+	}
+	c := cli.NewContext(app, set, nil)
+	CompleteAwsRoute53Export(c)
+
+	assert.Equal(t, "--output\n", writer.String())
+}
@@ -5,7 +5,6 @@ import (
 	"os"
 	"strings"
 
-	"github.com/guywithnose/hostBuilder/hosts"
 	"github.com/urfave/cli"
 )
 
@@ -21,7 +20,7 @@ func CmdBuild(c *cli.Context) error {
 		return err
 	}
 
-	return hosts.OutputHostLines(outputFile, configData, c.Bool("oneLinePerIP"))
+	return buildHostsFile(configData, outputFile, c.Bool("oneLinePerIP"), c.App.Writer, c.App.ErrWriter)
 }
 
 // CompleteBuild handles bash autocompletion for the 'build' command
@@ -1,8 +1,10 @@
 package command
 
 import (
+	"bytes"
 	"flag"
 	"io/ioutil"
+	"net"
 	"os"
 	"testing"
 
@@ -25,7 +27,7 @@ func TestCmdBuild(t *testing.T) {
 
 	set.String("config", configFile.Name(), "doc")
 	set.String("output", outputFile.Name(), "doc")
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	err = CmdBuild(c)
 	assert.Nil(t, err)
 
@@ -36,6 +38,52 @@ func TestCmdBuild(t *testing.T) {
 	assert.Equal(t, expectedHostsFile, string(hostsFile))
 }
 
+func TestCmdBuildFailover(t *testing.T) {
+	configFileName, set := setupFailoverConfigFile(t, config.Check{TCP: closedAddress(t)})
+	defer removeFile(t, configFileName)
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	set.String("output", outputFileName, "doc")
+	app, _ := appWithWriter()
+	errWriter := new(bytes.Buffer)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdBuild(c))
+	assert.Contains(t, errWriter.String(), "Warning: api.example.com is using dev, local is unhealthy: dial tcp ")
+
+	hostsFile, err := ioutil.ReadFile(outputFileName)
+	assert.Nil(t, err)
+	assert.Contains(t, string(hostsFile), "10.0.0.9 api.example.com\n")
+}
+
+func TestCmdBuildFailoverHealthy(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer func() { _ = listener.Close() }()
+
+	configFileName, set := setupFailoverConfigFile(t, config.Check{TCP: listener.Addr().String()})
+	defer removeFile(t, configFileName)
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	set.String("output", outputFileName, "doc")
+	app, _ := appWithWriter()
+	errWriter := new(bytes.Buffer)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdBuild(c))
+	assert.Equal(t, "", errWriter.String())
+
+	hostsFile, err := ioutil.ReadFile(outputFileName)
+	assert.Nil(t, err)
+	assert.Contains(t, string(hostsFile), "127.0.0.1 api.example.com\n")
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "dev", configData.Hosts["api.example.com"].Current)
+}
+
 func TestCmdBuildInvalidConfigFile(t *testing.T) {
 	outputFile, err := ioutil.TempFile("/tmp", "output")
 	assert.Nil(t, err)
@@ -45,7 +93,7 @@ func TestCmdBuildInvalidConfigFile(t *testing.T) {
 
 	set.String("config", "/doesntexist", "doc")
 	set.String("output", outputFile.Name(), "doc")
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	err = CmdBuild(c)
 	assert.EqualError(t, err, "open /doesntexist: no such file or directory")
 }
@@ -56,7 +104,7 @@ func TestCmdBuildNoConfig(t *testing.T) {
 	defer removeFile(t, outputFile.Name())
 	set := flag.NewFlagSet("test", 0)
 	set.String("output", outputFile.Name(), "doc")
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	err = CmdBuild(c)
 	assert.EqualError(t, err, "You must specify a config file")
 }
@@ -71,7 +119,7 @@ func TestCmdBuildNoOutput(t *testing.T) {
 	assert.Nil(t, err)
 
 	set.String("config", configFile.Name(), "doc")
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	err = CmdBuild(c)
 	assert.EqualError(t, err, "You must specify an output file")
 }
@@ -0,0 +1,133 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/urfave/cli"
+)
+
+// defaultCacheDir is where discovery results are cached, under XDG_CACHE_HOME or ~/.cache
+func defaultCacheDir() string {
+	if cacheHome := os.Getenv("XDG_CACHE_HOME"); cacheHome != "" {
+		return filepath.Join(cacheHome, Name)
+	}
+
+	if home := os.Getenv("HOME"); home != "" {
+		return filepath.Join(home, ".cache", Name)
+	}
+
+	return ""
+}
+
+// cachedDiscovery runs discover or reuses the addresses it found before with the same name and settings
+// Results younger than maxAge are reused and --offline only uses cached results, however old they are
+// Fresh results are cached and the time the addresses were fetched is returned with them
+func cachedDiscovery(
+	c *cli.Context,
+	name string,
+	settings map[string]string,
+	maxAge time.Duration,
+	discover func() ([]provider.Address, error),
+) ([]provider.Address, time.Time, error) {
+	cache := provider.Cache{Dir: c.GlobalString("cache")}
+	if c.GlobalBool("offline") {
+		if cache.Dir == "" {
+			return nil, time.Time{}, errors.New("A cache directory is required to work offline")
+		}
+
+		entry, ok, err := cache.Load(name, settings)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+
+		if !ok {
+			return nil, time.Time{}, fmt.Errorf("Nothing is cached for %s, run it once without --offline", name)
+		}
+
+		return entry.List(), entry.FetchedAt, nil
+	}
+
+	if cache.Dir != "" && maxAge > 0 {
+		entry, ok, err := cache.Load(name, settings)
+		if err == nil && ok && entry.Age(now()) < maxAge {
+			return entry.List(), entry.FetchedAt, nil
+		}
+	}
+
+	fetchedAt := now().UTC().Truncate(time.Second)
+	addresses, err := discover()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if cache.Dir != "" {
+		err = cache.Store(provider.NewCacheEntry(name, settings, fetchedAt, addresses))
+		if err != nil {
+			fmt.Fprintf(c.App.ErrWriter, "Warning: Unable to cache the results of %s: %v\n", name, err)
+		}
+	}
+
+	return addresses, fetchedAt, nil
+}
+
+// discoverProvider runs the provider with the settings, reusing results younger than --cacheTtl
+func discoverProvider(c *cli.Context, commandProvider provider.Provider, settings map[string]string) ([]provider.Address, time.Time, error) {
+	return cachedDiscovery(c, commandProvider.Name(), settings, c.GlobalDuration("cacheTtl"), func() ([]provider.Address, error) {
+		return commandProvider.Discover(settings, c.App.ErrWriter)
+	})
+}
+
+// commandSettings gives the value of every flag of the command except the flags that only change how results are merged
+// They key the cache of commands that discover addresses without a provider
+func commandSettings(c *cli.Context) map[string]string {
+	settings := map[string]string{}
+	for _, name := range c.FlagNames() {
+		if name == sourceFlag.Name || name == pruneFlag.Name || name == currentFlag.Name {
+			continue
+		}
+
+		if value := c.Generic(name); value != nil {
+			settings[name] = fmt.Sprint(value)
+		}
+	}
+
+	return settings
+}
+
+// provenanceNotes describes whether an imported value is stale and how long ago it was fetched
+func provenanceNotes(provenance config.Provenance) string {
+	notes := []string{}
+	if provenance.Stale {
+		notes = append(notes, "stale")
+	}
+
+	if provenance.FetchedAt != nil {
+		notes = append(notes, "fetched "+formatAge(*provenance.FetchedAt))
+	} else if provenance.ImportedAt != nil {
+		notes = append(notes, "imported "+formatAge(*provenance.ImportedAt))
+	}
+
+	return strings.Join(notes, ", ")
+}
+
+// formatAge describes how long ago a time was, rounded down to minutes, hours or days
+func formatAge(then time.Time) string {
+	age := now().Sub(then)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age/time.Minute))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age/time.Hour))
+	}
+
+	return fmt.Sprintf("%dd ago", int(age/(24*time.Hour)))
+}
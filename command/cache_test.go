@@ -0,0 +1,136 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func setupCacheDir(t *testing.T) string {
+	cacheDir, err := ioutil.TempDir("/tmp", "cache")
+	assert.Nil(t, err)
+	return cacheDir
+}
+
+func TestCmdSyncCached(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test"}})
+	defer removeFile(t, configFileName)
+
+	cacheDir := setupCacheDir(t)
+	defer func() { assert.Nil(t, os.RemoveAll(cacheDir)) }()
+
+	set.String("cache", cacheDir, "doc")
+	set.Duration("cacheTtl", time.Hour, "doc")
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	testProvider := &testProvider{addresses: []provider.Address{{Name: "foo", IP: "10.0.0.1"}}}
+	assert.Nil(t, CmdSync(provider.NewRegistry(testProvider))(c))
+
+	// The cached results are used while they are younger than the TTL, even though the provider would now fail
+	writer.Reset()
+	testProvider.throwError = true
+	assert.Nil(t, CmdSync(provider.NewRegistry(testProvider))(c))
+	assert.Equal(t, "east is up to date\n", writer.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, &testImportedAt, configData.Sources["east"].SyncedAt)
+}
+
+func TestCmdSyncOffline(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test"}})
+	defer removeFile(t, configFileName)
+
+	cacheDir := setupCacheDir(t)
+	defer func() { assert.Nil(t, os.RemoveAll(cacheDir)) }()
+
+	fetchedAt := testImportedAt.Add(-3 * time.Hour)
+	cache := provider.Cache{Dir: cacheDir}
+	settings := map[string]string{"region": "us-east-1", "file": ""}
+	assert.Nil(t, cache.Store(provider.NewCacheEntry("test", settings, fetchedAt, []provider.Address{{Name: "foo", IP: "10.0.0.1"}})))
+
+	set.String("cache", cacheDir, "doc")
+	set.Bool("offline", true, "doc")
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	testProvider := &testProvider{throwError: true}
+	assert.Nil(t, CmdSync(provider.NewRegistry(testProvider))(c))
+	assert.Equal(t, "Added global IP foo (10.0.0.1)\n", writer.String())
+	assert.Nil(t, testProvider.settings)
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, &fetchedAt, configData.Provenance["foo"].FetchedAt)
+	assert.Equal(t, &testImportedAt, configData.Provenance["foo"].ImportedAt)
+	assert.Equal(t, &fetchedAt, configData.Sources["east"].SyncedAt)
+}
+
+func TestCmdSyncOfflineNotCached(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test"}})
+	defer removeFile(t, configFileName)
+
+	cacheDir := setupCacheDir(t)
+	defer func() { assert.Nil(t, os.RemoveAll(cacheDir)) }()
+
+	set.String("cache", cacheDir, "doc")
+	set.Bool("offline", true, "doc")
+	app, errWriter := appWithErrWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdSync(provider.NewRegistry(&testProvider{}))(c), "1 of 1 sources failed to sync")
+	assert.Equal(t, "Failed to sync east: Nothing is cached for test, run it once without --offline\n", errWriter.String())
+}
+
+func TestCmdSyncOfflineNoCacheDir(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test"}})
+	defer removeFile(t, configFileName)
+
+	set.Bool("offline", true, "doc")
+	app, errWriter := appWithErrWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.NotNil(t, CmdSync(provider.NewRegistry(&testProvider{}))(c))
+	assert.Equal(t, "Failed to sync east: A cache directory is required to work offline\n", errWriter.String())
+}
+
+func TestCmdSyncMaxAge(t *testing.T) {
+	recent := testImportedAt.Add(-10 * time.Minute)
+	old := testImportedAt.Add(-2 * time.Hour)
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{
+		"east":  {Provider: "test", SyncedAt: &recent},
+		"west":  {Provider: "test", SyncedAt: &old},
+		"north": {Provider: "test"},
+	})
+	defer removeFile(t, configFileName)
+
+	set.Duration("maxAge", time.Hour, "doc")
+	assert.Nil(t, set.Set("maxAge", "1h"))
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdSync(provider.NewRegistry(&testProvider{}))(c))
+	assert.Equal(t, "east was synced 10m ago, skipping\nnorth is up to date\nwest is up to date\n", writer.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, &recent, configData.Sources["east"].SyncedAt)
+	assert.Equal(t, &testImportedAt, configData.Sources["west"].SyncedAt)
+}
+
+func TestProvenanceNotes(t *testing.T) {
+	fetchedAt := testImportedAt.Add(-26 * time.Hour)
+	assert.Equal(t, "", provenanceNotes(config.Provenance{}))
+	assert.Equal(t, "stale", provenanceNotes(config.Provenance{Stale: true}))
+	assert.Equal(t, "imported just now", provenanceNotes(config.Provenance{ImportedAt: &testImportedAt}))
+	assert.Equal(t, "stale, fetched 26h ago", provenanceNotes(config.Provenance{ImportedAt: &testImportedAt, FetchedAt: &fetchedAt, Stale: true}))
+}
+
+func TestFormatAge(t *testing.T) {
+	assert.Equal(t, "just now", formatAge(testImportedAt.Add(-59*time.Second)))
+	assert.Equal(t, "59m ago", formatAge(testImportedAt.Add(-59*time.Minute)))
+	assert.Equal(t, "47h ago", formatAge(testImportedAt.Add(-47*time.Hour)))
+	assert.Equal(t, "3d ago", formatAge(testImportedAt.Add(-72*time.Hour)))
+}
@@ -0,0 +1,97 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+)
+
+// CmdCheck runs the health checks of the hosts and reports every option and the one the hosts file uses
+func CmdCheck(c *cli.Context) error {
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	hostNames := []string(c.Args())
+	for _, hostName := range hostNames {
+		if _, exists := configData.Hosts[hostName]; !exists {
+			return cli.NewExitError(fmt.Sprintf("HostName %s does not exist", hostName), 1)
+		}
+	}
+
+	if len(hostNames) == 0 {
+		for _, hostName := range sortHostNames(configData) {
+			if len(checkedOptions(configData.Hosts[hostName])) != 0 {
+				hostNames = append(hostNames, hostName)
+			}
+		}
+	}
+
+	if len(hostNames) == 0 {
+		return cli.NewExitError("There are no health checks, add one with 'hostBuilder host healthCheck'", 1)
+	}
+
+	health := checkHealth(configData, hostNames)
+	selected, _ := selectHealthy(configData, health)
+	down := 0
+	w := tabwriter.NewWriter(c.App.Writer, 0, 0, 1, ' ', 0)
+	for _, hostName := range hostNames {
+		options := checkedOptions(configData.Hosts[hostName])
+		if len(options) == 0 {
+			options = []string{configData.Hosts[hostName].Current}
+		}
+
+		healthy := false
+		for _, option := range options {
+			result := health[hostName][option]
+			line := []string{hostName, option}
+			switch {
+			case !result.Checked:
+				line = append(line, "unchecked")
+			case result.healthy():
+				line = append(line, "healthy")
+			default:
+				line = append(line, "unhealthy", result.Err.Error())
+			}
+
+			if option == selected.Hosts[hostName].Current {
+				line = append(line, "(current)")
+			}
+
+			healthy = healthy || result.healthy()
+			fmt.Fprintln(w, strings.Join(line, "\t"))
+		}
+
+		if !healthy {
+			down++
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	if down != 0 {
+		return cli.NewExitError(fmt.Sprintf("%d of %d hosts have no healthy option", down, len(hostNames)), 1)
+	}
+
+	return nil
+}
+
+// CompleteCheck handles bash autocompletion for the 'check' command
+func CompleteCheck(c *cli.Context) {
+	configData, err := loadConfig(c)
+	if err != nil {
+		return
+	}
+
+	for _, hostName := range sortHostNames(configData) {
+		if len(checkedOptions(configData.Hosts[hostName])) != 0 && !argsContain(c, hostName) {
+			fmt.Fprintln(c.App.Writer, hostName)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package command
+
+import (
+	"flag"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer func() { _ = listener.Close() }()
+
+	configFileName, set := setupFailoverConfigFile(t, config.Check{TCP: listener.Addr().String()})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdCheck(c))
+	assert.Equal(t, "api.example.com local healthy (current)\napi.example.com dev   unchecked\n", writer.String())
+}
+
+func TestCmdCheckUnhealthy(t *testing.T) {
+	configFileName, set := setupFailoverConfigFile(t, config.Check{Command: "exit 1"})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdCheck(c))
+	assert.Equal(t, "api.example.com local unhealthy Exited with status 1\napi.example.com dev   unchecked (current)\n", writer.String())
+}
+
+func TestCmdCheckNoHealthyOption(t *testing.T) {
+	configFileName, set := setupFailoverConfigFile(t, config.Check{Command: "exit 1"})
+	defer removeFile(t, configFileName)
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	host := configData.Hosts["goo"]
+	host.Checks = map[string]config.Check{"foop": {Command: "exit 2"}}
+	configData.Hosts["goo"] = host
+	assert.Nil(t, config.WriteConfig(configFileName, configData))
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdCheck(c), "1 of 2 hosts have no healthy option")
+	assert.Equal(
+		t,
+		"api.example.com local unhealthy Exited with status 1\n"+
+			"api.example.com dev   unchecked (current)\n"+
+			"goo             foop  unhealthy Exited with status 2 (current)\n",
+		writer.String(),
+	)
+}
+
+func TestCmdCheckHostNames(t *testing.T) {
+	configFileName, set := setupFailoverConfigFile(t, config.Check{Command: "true"})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"goo"}))
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdCheck(c))
+	assert.Equal(t, "goo foop unchecked (current)\n", writer.String())
+
+	set = flag.NewFlagSet("test", 0)
+	set.String("config", configFileName, "doc")
+	assert.Nil(t, set.Parse([]string{"gone"}))
+	c = cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdCheck(c), "HostName gone does not exist")
+}
+
+func TestCmdCheckNoChecks(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdCheck(c), "There are no health checks, add one with 'hostBuilder host healthCheck'")
+}
+
+func TestCompleteCheck(t *testing.T) {
+	configFileName, set := setupFailoverConfigFile(t, config.Check{Command: "true"})
+	defer removeFile(t, configFileName)
+
+	os.Args = []string{"check", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteCheck(c)
+	assert.Equal(t, "api.example.com\n", writer.String())
+}
@@ -1,22 +1,80 @@
 package command
 
 import (
+	"time"
+
 	"github.com/guywithnose/hostBuilder/awsUtil"
+	"github.com/guywithnose/hostBuilder/dockerUtil"
+	"github.com/guywithnose/hostBuilder/kubeUtil"
+	"github.com/guywithnose/hostBuilder/provider"
 	"github.com/urfave/cli"
 )
 
-var profileFlag = cli.StringFlag{
-	Name:   "profile, p",
-	Usage:  "The AWS credentials profile to use",
-	EnvVar: "HOST_BUILDER_AWS_PROFILE",
-	Value:  "default",
-}
-
 var forceFlag = cli.BoolFlag{
 	Name:  "force",
 	Usage: "Overwrite existing",
 }
 
+var templateFlag = cli.StringFlag{
+	Name:  "template, t",
+	Usage: "The template to use for naming imported IPs",
+	Value: "{{.Name}}",
+}
+
+var hostFlag = cli.StringFlag{
+	Name:  "host",
+	Usage: "Add the imported IPs as options on this hostname instead of as global IPs",
+}
+
+var sourceFlag = cli.StringFlag{
+	Name:  "source",
+	Usage: "The name recorded as the source of the imported IPs",
+}
+
+var pruneFlag = cli.BoolFlag{
+	Name:  "prune",
+	Usage: "Remove IPs previously imported from the same source that are no longer present instead of marking them stale",
+}
+
+var currentFlag = cli.StringFlag{
+	Name:  "current",
+	Usage: "What to do with hosts using an IP that --prune removes (warn, keep, ignore, switch)",
+}
+
+var kubernetesFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:   "kubeconfig",
+		Usage:  "The path to your kubeconfig file",
+		EnvVar: "KUBECONFIG",
+	},
+	cli.StringFlag{
+		Name:  "context",
+		Usage: "The kubeconfig context to use instead of the current context",
+	},
+	cli.StringFlag{
+		Name:  "namespace, n",
+		Usage: "Only read from this namespace instead of all namespaces",
+	},
+	cli.StringFlag{
+		Name:  "file, f",
+		Usage: "Read 'kubectl get -o json' output from this file (- for stdin) instead of the cluster",
+	},
+}
+
+// Providers defines the providers that sources in the config can use
+var Providers = provider.NewRegistry(
+	awsUtil.NewInstancesProvider(new(awsUtil.AwsUtil)),
+	awsUtil.NewLoadBalancersProvider(new(awsUtil.AwsUtil)),
+	awsUtil.NewRoute53Provider(new(awsUtil.AwsUtil)),
+	awsUtil.NewRDSProvider(new(awsUtil.AwsUtil)),
+	awsUtil.NewElastiCacheProvider(new(awsUtil.AwsUtil)),
+	awsUtil.NewECSProvider(new(awsUtil.AwsUtil)),
+	awsUtil.NewAutoScalingProvider(new(awsUtil.AwsUtil)),
+	dockerUtil.Provider{},
+	kubeUtil.ServicesProvider{},
+	kubeUtil.IngressesProvider{},
+)
+
 // GlobalFlags defines flags that apply to all commands
 var GlobalFlags = []cli.Flag{
 	cli.StringFlag{
@@ -24,6 +82,23 @@ var GlobalFlags = []cli.Flag{
 		Usage:  "The path to your config file",
 		EnvVar: "HOST_BUILDER_CONFIG_FILE",
 	},
+	cli.BoolFlag{
+		Name:   "offline",
+		Usage:  "Only use cached discovery results, never query AWS, kubernetes or provider plugins",
+		EnvVar: "HOST_BUILDER_OFFLINE",
+	},
+	cli.StringFlag{
+		Name:   "cache",
+		Usage:  "The directory discovery results are cached in, empty disables the cache",
+		Value:  defaultCacheDir(),
+		EnvVar: "HOST_BUILDER_CACHE",
+	},
+	cli.DurationFlag{
+		Name:   "cacheTtl",
+		Usage:  "How long cached discovery results are used instead of discovering again, 0 always discovers",
+		Value:  5 * time.Minute,
+		EnvVar: "HOST_BUILDER_CACHE_TTL",
+	},
 }
 
 // Commands defines the commands that can be called on hostBuilder
@@ -60,6 +135,70 @@ var Commands = []cli.Command{
 			},
 		},
 	},
+	{
+		Name:         "watch",
+		Aliases:      []string{"w"},
+		Usage:        "Rebuilds your host file when the configuration changes",
+		Action:       CmdWatch(Providers),
+		BashComplete: CompleteWatch,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:   "output, o",
+				Usage:  "The path to write your hosts file",
+				EnvVar: "HOST_BUILDER_OUTPUT_FILE",
+			},
+			cli.BoolFlag{
+				Name:  "oneLinePerIP",
+				Usage: "Put all hosts for an IP on the same line",
+			},
+			cli.DurationFlag{
+				Name:  "debounce",
+				Usage: "How long to wait for edits to stop before rebuilding",
+				Value: 500 * time.Millisecond,
+			},
+			cli.DurationFlag{
+				Name:  "interval",
+				Usage: "How often to check the watched files for changes",
+				Value: time.Second,
+			},
+			cli.DurationFlag{
+				Name:  "checkInterval",
+				Usage: "How often to run the health checks of hosts with a failover list, 0 only checks when rebuilding",
+				Value: 30 * time.Second,
+			},
+		},
+	},
+	{
+		Name:   "api",
+		Usage:  "Serves the configuration as JSON over HTTP",
+		Action: CmdAPI,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:   "listen, l",
+				Usage:  "The localhost address or unix:{path} socket to listen on",
+				Value:  "localhost:8053",
+				EnvVar: "HOST_BUILDER_API_LISTEN",
+			},
+			cli.StringFlag{
+				Name:   "token",
+				Usage:  "The bearer token requests must send, a random token is printed when none is given",
+				EnvVar: "HOST_BUILDER_API_TOKEN",
+			},
+			cli.StringFlag{
+				Name:   "output, o",
+				Usage:  "The path POST /build writes your hosts file to",
+				EnvVar: "HOST_BUILDER_OUTPUT_FILE",
+			},
+			cli.BoolFlag{
+				Name:  "oneLinePerIP",
+				Usage: "Put all hosts for an IP on the same line",
+			},
+			cli.BoolFlag{
+				Name:  "dashboard",
+				Usage: "Serve a web dashboard for switching hosts on /",
+			},
+		},
+	},
 	{
 		Name:         "globalIP",
 		Aliases:      []string{"gl"},
@@ -131,6 +270,52 @@ var Commands = []cli.Command{
 				Usage:        "Set a hostname to a specific ip",
 				Action:       CmdHostSet,
 				BashComplete: CompleteHostSet,
+				Flags: []cli.Flag{
+					cli.DurationFlag{
+						Name:  "for",
+						Usage: "Only use the ip this long, like 2h, watch restores the previous ip after that",
+					},
+				},
+			},
+			{
+				Name:         "failover",
+				Aliases:      []string{"f"},
+				Usage:        "Set the IPs a hostname prefers in order, the first healthy one is used",
+				Action:       CmdHostFailover,
+				BashComplete: CompleteHostFailover,
+			},
+			{
+				Name:         "healthCheck",
+				Aliases:      []string{"hc"},
+				Usage:        "Set how to check that an IP of a hostname is healthy",
+				Action:       CmdHostHealthCheck,
+				BashComplete: CompleteHostHealthCheck,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "tcp",
+						Usage: "Connect to this host:port, :port connects to the IP",
+					},
+					cli.StringFlag{
+						Name:  "http",
+						Usage: "Request this URL",
+					},
+					cli.IntFlag{
+						Name:  "status",
+						Usage: "The status the URL must answer with (default any 2xx)",
+					},
+					cli.StringFlag{
+						Name:  "command",
+						Usage: "Run this command, it must exit 0",
+					},
+					cli.StringFlag{
+						Name:  "timeout",
+						Usage: "How long the check may take (default 5s)",
+					},
+					cli.BoolFlag{
+						Name:  "remove",
+						Usage: "Remove the health check",
+					},
+				},
 			},
 		},
 	},
@@ -170,6 +355,415 @@ var Commands = []cli.Command{
 			},
 		},
 	},
+	{
+		Name:         "import",
+		Aliases:      []string{"im"},
+		Usage:        "Merge external data into the configuration",
+		Category:     "Config",
+		BashComplete: RootCompletion,
+		Subcommands: []cli.Command{
+			{
+				Name:         "hosts",
+				Aliases:      []string{"h"},
+				Usage:        "Merge a hosts file into the configuration",
+				Action:       CmdImportHosts,
+				BashComplete: CompleteImportHosts,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "strategy, s",
+						Usage: "How to handle an IP name that already exists on a host (new, overwrite, skip, ask)",
+						Value: "new",
+					},
+					cli.StringFlag{
+						Name:  "template, t",
+						Usage: "The template to use for naming IPs that have no comment",
+						Value: "imported",
+					},
+					cli.StringFlag{
+						Name:  "group, g",
+						Usage: "Add the imported hostnames to this group",
+					},
+				},
+			},
+			{
+				Name:         "json",
+				Aliases:      []string{"j"},
+				Usage:        "Import addresses from a JSON document using JMESPath expressions",
+				Action:       CmdImportJSON,
+				BashComplete: CompleteImportJSON,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "items, i",
+						Usage: "The JMESPath expression selecting the list of items to import",
+						Value: "@",
+					},
+					cli.StringFlag{
+						Name:  "name, n",
+						Usage: "The JMESPath expression for the name of each item",
+					},
+					cli.StringFlag{
+						Name:  "address, a",
+						Usage: "The JMESPath expression for the address of each item",
+					},
+					templateFlag,
+					hostFlag,
+					sourceFlag,
+					pruneFlag,
+					currentFlag,
+				},
+			},
+			{
+				Name:         "terraform",
+				Aliases:      []string{"t"},
+				Usage:        "Import resource addresses from a terraform state file",
+				Action:       CmdImportTerraform,
+				BashComplete: CompleteImportTerraform,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "template, t",
+						Usage: "The template to use for naming resource ips",
+						Value: "{{.Label}}",
+					},
+					cli.BoolFlag{
+						Name:  "private",
+						Usage: "Import private addresses instead of public ones, load balancers always use the IP their DNS name resolves to",
+					},
+					hostFlag,
+					sourceFlag,
+					pruneFlag,
+					currentFlag,
+				},
+			},
+			{
+				Name:         "ansible",
+				Aliases:      []string{"a"},
+				Usage:        "Import hosts and groups from an ansible inventory",
+				Action:       CmdImportAnsible,
+				BashComplete: CompleteImportAnsible,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "template, t",
+						Usage: "The template to use for naming inventory host ips",
+						Value: "{{.Name}}",
+					},
+					cli.StringFlag{
+						Name:  "option",
+						Usage: "Add inventory hosts as this option on the matching hostnames instead of as global IPs",
+					},
+					sourceFlag,
+					pruneFlag,
+					currentFlag,
+				},
+			},
+		},
+	},
+	{
+		Name:         "export",
+		Aliases:      []string{"ex"},
+		Usage:        "Write the current selection in other formats",
+		Category:     "Config",
+		BashComplete: RootCompletion,
+		Subcommands: []cli.Command{
+			{
+				Name:         "ansible",
+				Aliases:      []string{"a"},
+				Usage:        "Write an ansible inventory of the current selection",
+				Action:       CmdExportAnsible,
+				BashComplete: CompleteExportAnsible,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "format, f",
+						Usage: "The inventory format to write (ini, yaml)",
+						Value: "ini",
+					},
+					cli.StringFlag{
+						Name:  "output, o",
+						Usage: "The path to write the inventory to instead of stdout",
+					},
+				},
+			},
+		},
+	},
+	{
+		Name:         "docker",
+		Aliases:      []string{"d"},
+		Usage:        "Add information from docker to the configuration",
+		Category:     "Config",
+		BashComplete: RootCompletion,
+		Subcommands: []cli.Command{
+			{
+				Name:         "containers",
+				Aliases:      []string{"c"},
+				Usage:        "Add running container addresses to the configuration",
+				Action:       CmdDockerContainers,
+				BashComplete: CompleteDockerContainers,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:   "socket, s",
+						Usage:  "The docker engine socket to read containers from",
+						EnvVar: "HOST_BUILDER_DOCKER_SOCKET",
+						Value:  dockerUtil.DefaultSocket,
+					},
+					cli.StringFlag{
+						Name:  "inspect, i",
+						Usage: "Read containers from 'docker inspect' output in this file (- for stdin) instead of the socket",
+					},
+					cli.StringFlag{
+						Name:  "network, n",
+						Usage: "Only import addresses on this docker network",
+					},
+					cli.StringFlag{
+						Name:   "template, t",
+						Usage:  "The template to use for naming container ips",
+						EnvVar: "HOST_BUILDER_DOCKER_TEMPLATE",
+						Value:  "{{.Name}}",
+					},
+					sourceFlag,
+					pruneFlag,
+					currentFlag,
+				},
+			},
+		},
+	},
+	{
+		Name:         "kubernetes",
+		Aliases:      []string{"k"},
+		Usage:        "Add information from kubernetes to the configuration",
+		Category:     "Config",
+		BashComplete: RootCompletion,
+		Subcommands: []cli.Command{
+			{
+				Name:         "services",
+				Aliases:      []string{"s"},
+				Usage:        "Add service addresses to the configuration",
+				Action:       CmdKubernetesServices,
+				BashComplete: CompleteKubernetesServices,
+				Flags: append(
+					kubernetesFlags,
+					cli.StringFlag{
+						Name:  "types",
+						Usage: "The comma separated service address types to use (LoadBalancer, ExternalIP, ClusterIP)",
+					},
+					cli.StringFlag{
+						Name:  "template, t",
+						Usage: "The template to use for naming service ips",
+						Value: "{{.Name}}.{{.Namespace}}",
+					},
+					sourceFlag,
+					pruneFlag,
+					currentFlag,
+				),
+			},
+			{
+				Name:         "ingresses",
+				Aliases:      []string{"i"},
+				Usage:        "Add ingress addresses as options on the hostnames they serve",
+				Action:       CmdKubernetesIngresses,
+				BashComplete: CompleteKubernetesIngresses,
+				Flags: append(
+					kubernetesFlags,
+					cli.StringFlag{
+						Name:  "template, t",
+						Usage: "The template to use for naming the option added to each hostname",
+						Value: "{{.Context}}",
+					},
+					sourceFlag,
+					pruneFlag,
+					currentFlag,
+				),
+			},
+		},
+	},
+	{
+		Name:         "source",
+		Aliases:      []string{"so"},
+		Usage:        "Modify the sources refreshed by sync",
+		Category:     "Config",
+		BashComplete: RootCompletion,
+		Subcommands: []cli.Command{
+			{
+				Name:         "add",
+				Aliases:      []string{"a"},
+				Usage:        "Add a source using a provider to the configuration",
+				Action:       CmdSourceAdd(Providers),
+				BashComplete: CompleteSourceAdd(Providers),
+				Flags: []cli.Flag{
+					forceFlag,
+					cli.BoolFlag{
+						Name:  "prune",
+						Usage: "Remove IPs previously imported from the source that are no longer present when syncing",
+					},
+					cli.StringFlag{
+						Name:  "current",
+						Usage: "What to do with hosts using an IP that pruning removes when syncing (warn, keep, ignore, switch)",
+					},
+					cli.StringFlag{
+						Name:  "every",
+						Usage: "How often the watch command syncs the source, like 15m",
+					},
+				},
+			},
+			{
+				Name:         "remove",
+				Aliases:      []string{"r"},
+				Usage:        "Remove a source from the configuration",
+				Action:       CmdSourceRemove,
+				BashComplete: CompleteSourceRemove,
+			},
+			{
+				Name:    "list",
+				Aliases: []string{"l"},
+				Usage:   "List the sources in the configuration",
+				Action:  CmdSourceList,
+			},
+		},
+	},
+	{
+		Name:         "hook",
+		Usage:        "Modify the commands run around builds and when hosts are switched",
+		Category:     "Config",
+		BashComplete: RootCompletion,
+		Subcommands: []cli.Command{
+			{
+				Name:         "add",
+				Aliases:      []string{"a"},
+				Usage:        "Add a hook to the configuration",
+				Action:       CmdHookAdd,
+				BashComplete: CompleteHookAdd,
+				Flags: []cli.Flag{
+					forceFlag,
+					cli.StringFlag{
+						Name:  "timeout",
+						Usage: "How long the hook may run (default 30s)",
+					},
+					cli.StringFlag{
+						Name:  "onFailure",
+						Usage: "What to do when the hook fails (abort, warn)",
+					},
+				},
+			},
+			{
+				Name:         "remove",
+				Aliases:      []string{"r"},
+				Usage:        "Remove a hook from the configuration",
+				Action:       CmdHookRemove,
+				BashComplete: CompleteHookRemove,
+			},
+			{
+				Name:    "list",
+				Aliases: []string{"l"},
+				Usage:   "List the hooks in the configuration",
+				Action:  CmdHookList,
+			},
+		},
+	},
+	{
+		Name:         "rule",
+		Usage:        "Modify the rules that pick options for the network you are on",
+		Category:     "Config",
+		BashComplete: RootCompletion,
+		Subcommands: []cli.Command{
+			{
+				Name:         "add",
+				Aliases:      []string{"a"},
+				Usage:        "Add a rule to the configuration",
+				Action:       CmdRuleAdd,
+				BashComplete: CompleteRuleAdd,
+				Flags: []cli.Flag{
+					forceFlag,
+					cli.StringFlag{
+						Name:  "cidr",
+						Usage: "Match when an interface has an address in this CIDR",
+					},
+					cli.StringFlag{
+						Name:  "gateway",
+						Usage: "Match when this is the default gateway",
+					},
+					cli.StringFlag{
+						Name:  "interface",
+						Usage: "Match when an interface with this name, like tun*, is up",
+					},
+					cli.StringFlag{
+						Name:  "env",
+						Usage: "Match when this environment variable is set, as NAME or NAME=value",
+					},
+					cli.StringSliceFlag{
+						Name:  "group",
+						Usage: "Set a group to a global IP, as groupName=globalIP",
+					},
+				},
+			},
+			{
+				Name:         "remove",
+				Aliases:      []string{"r"},
+				Usage:        "Remove a rule from the configuration",
+				Action:       CmdRuleRemove,
+				BashComplete: CompleteRuleRemove,
+			},
+			{
+				Name:    "list",
+				Aliases: []string{"l"},
+				Usage:   "List the rules in the configuration",
+				Action:  CmdRuleList,
+			},
+		},
+	},
+	{
+		Name:         "sync",
+		Aliases:      []string{"sy"},
+		Usage:        "Refresh the addresses from the sources in the configuration",
+		Action:       CmdSync(Providers),
+		BashComplete: CompleteSync,
+		Flags: []cli.Flag{
+			pruneFlag,
+			currentFlag,
+			cli.DurationFlag{
+				Name:  "maxAge",
+				Usage: "Only sync sources that were last synced longer ago than this",
+			},
+		},
+	},
+	{
+		Name:         "auto",
+		Usage:        "Switch hosts with the first rule that matches the network you are on",
+		Action:       CmdAuto(systemNetwork{routeFile: "/proc/net/route"}),
+		BashComplete: CompleteAuto,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "dryRun",
+				Usage: "Report what the rule would switch without changing the config",
+			},
+		},
+	},
+	{
+		Name:         "check",
+		Usage:        "Run the health checks of the hostnames with failover IPs",
+		Action:       CmdCheck,
+		BashComplete: CompleteCheck,
+	},
+	{
+		Name:         "verify",
+		Usage:        "Check that the system resolver answers with the selected IPs",
+		Action:       CmdVerify(newResolver),
+		BashComplete: CompleteVerify,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:   "output, o",
+				Usage:  "The hosts file that build writes",
+				Value:  "/etc/hosts",
+				EnvVar: "HOST_BUILDER_OUTPUT_FILE",
+			},
+			cli.StringFlag{
+				Name:  "server",
+				Usage: "Also ask this DNS server, as host or host:port",
+			},
+			cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "How long each lookup may take",
+				Value: 2 * time.Second,
+			},
+		},
+	},
 	{
 		Name:         "aws",
 		Aliases:      []string{"a"},
@@ -183,7 +777,7 @@ var Commands = []cli.Command{
 				Usage:        "Add load balancer information to the configuration",
 				Action:       CmdAwsLoadBalancer(new(awsUtil.AwsUtil)),
 				BashComplete: CompleteAwsLoadBalancer(new(awsUtil.AwsUtil)),
-				Flags:        []cli.Flag{profileFlag},
+				Flags:        providerFlags(awsUtil.NewLoadBalancersProvider(nil)),
 			},
 			{
 				Name:         "instances",
@@ -191,13 +785,81 @@ var Commands = []cli.Command{
 				Usage:        "Add instance information to the configuration",
 				Action:       CmdAwsInstances(new(awsUtil.AwsUtil)),
 				BashComplete: CompleteAwsInstances(new(awsUtil.AwsUtil)),
-				Flags: []cli.Flag{
-					profileFlag,
-					cli.StringFlag{
-						Name:   "template, t",
-						Usage:  "The template to use for naming instance ips",
-						EnvVar: "HOST_BUILDER_INSTANCE_TEMPLATE",
-						Value:  "{{.InstanceId}}",
+				Flags:        providerFlags(awsUtil.NewInstancesProvider(nil)),
+			},
+			{
+				Name:         "rds",
+				Usage:        "Add the addresses RDS instance endpoints resolve to",
+				Action:       CmdAwsRds(new(awsUtil.AwsUtil)),
+				BashComplete: CompleteAwsRds(new(awsUtil.AwsUtil)),
+				Flags:        providerFlags(awsUtil.NewRDSProvider(nil)),
+			},
+			{
+				Name:         "elasticache",
+				Aliases:      []string{"ec"},
+				Usage:        "Add the addresses ElastiCache node endpoints resolve to",
+				Action:       CmdAwsElastiCache(new(awsUtil.AwsUtil)),
+				BashComplete: CompleteAwsElastiCache(new(awsUtil.AwsUtil)),
+				Flags:        providerFlags(awsUtil.NewElastiCacheProvider(nil)),
+			},
+			{
+				Name:         "ecs",
+				Usage:        "Add the addresses of running ECS tasks",
+				Action:       CmdAwsEcs(new(awsUtil.AwsUtil)),
+				BashComplete: CompleteAwsEcs(new(awsUtil.AwsUtil)),
+				Flags:        providerFlags(awsUtil.NewECSProvider(nil)),
+			},
+			{
+				Name:         "asg",
+				Usage:        "Add the addresses of the instances in Auto Scaling groups",
+				Action:       CmdAwsAutoScaling(new(awsUtil.AwsUtil)),
+				BashComplete: CompleteAwsAutoScaling(new(awsUtil.AwsUtil)),
+				Flags:        providerFlags(awsUtil.NewAutoScalingProvider(nil)),
+			},
+			{
+				Name:         "route53",
+				Aliases:      []string{"r"},
+				Usage:        "Import and export Route53 hosted zone records",
+				BashComplete: RootCompletion,
+				Subcommands: []cli.Command{
+					{
+						Name:         "import",
+						Aliases:      []string{"i"},
+						Usage:        "Add the A and AAAA records of a hosted zone as options on their hostnames",
+						Action:       CmdAwsRoute53Import(new(awsUtil.AwsUtil)),
+						BashComplete: CompleteAwsRoute53Import(new(awsUtil.AwsUtil)),
+						Flags:        providerFlags(awsUtil.NewRoute53Provider(nil), "zone"),
+					},
+					{
+						Name:         "export",
+						Aliases:      []string{"e"},
+						Usage:        "Write a change batch that publishes the current IPs of a group as records",
+						Action:       CmdAwsRoute53Export,
+						BashComplete: CompleteAwsRoute53Export,
+						Flags: []cli.Flag{
+							cli.StringFlag{
+								Name:  "zone, z",
+								Usage: "Only export hostnames in this zone",
+							},
+							cli.Int64Flag{
+								Name:  "ttl",
+								Usage: "The TTL of the exported records",
+								Value: defaultRecordTTL,
+							},
+							cli.StringFlag{
+								Name:  "action, a",
+								Usage: "The change to make to each record (UPSERT, CREATE, DELETE)",
+								Value: "UPSERT",
+							},
+							cli.StringFlag{
+								Name:  "comment",
+								Usage: "The comment to include in the change batch",
+							},
+							cli.StringFlag{
+								Name:  "output, o",
+								Usage: "The path to write the change batch to instead of stdout",
+							},
+						},
 					},
 				},
 			},
@@ -23,11 +23,27 @@ func TestRootCompletion(t *testing.T) {
 		[]string{
 			"createConfig:Create a config file from an existing hosts file",
 			"build:Builds your host file",
+			"watch:Rebuilds your host file when the configuration changes",
+			"api:Serves the configuration as JSON over HTTP",
 			"globalIP:Add things to the configuration",
 			"host:Modify hosts",
 			"group:Modify groups",
+			"import:Merge external data into the configuration",
+			"export:Write the current selection in other formats",
+			"docker:Add information from docker to the configuration",
+			"kubernetes:Add information from kubernetes to the configuration",
+			"source:Modify the sources refreshed by sync",
+			"hook:Modify the commands run around builds and when hosts are switched",
+			"rule:Modify the rules that pick options for the network you are on",
+			"sync:Refresh the addresses from the sources in the configuration",
+			"auto:Switch hosts with the first rule that matches the network you are on",
+			"check:Run the health checks of the hostnames with failover IPs",
+			"verify:Check that the system resolver answers with the selected IPs",
 			"aws:Add information from AWS to the configuration",
 			"--config",
+			"--offline",
+			"--cache",
+			"--cacheTtl",
 			"",
 		},
 		strings.Split(writer.String(), "\n"),
@@ -0,0 +1,30 @@
+package command
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// dashboardFiles are the assets of the web dashboard, embedded so it works without network access
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// dashboardPaths are the paths the dashboard is served on, every other path belongs to the API
+var dashboardPaths = map[string]bool{"/": true, "/app.js": true, "/style.css": true}
+
+// newDashboardHandler serves the embedded dashboard, which uses the API with the token from its #token= link
+func newDashboardHandler() http.Handler {
+	files, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err)
+	}
+
+	fileServer := http.FileServer(http.FS(files))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src 'self'")
+		w.Header().Set("X-Frame-Options", "DENY")
+		fileServer.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,230 @@
+// The dashboard talks to the same JSON API as other clients, the token comes from the #token= link printed by hostBuilder api
+(function () {
+  'use strict';
+
+  var state = {token: sessionStorage.getItem('hostBuilderToken') || '', etag: '', hosts: {}, groups: {}, globalIPs: {}, output: ''};
+
+  var match = /token=([^&]+)/.exec(location.hash);
+  if (match) {
+    state.token = decodeURIComponent(match[1]);
+    sessionStorage.setItem('hostBuilderToken', state.token);
+    history.replaceState(null, '', location.pathname);
+  }
+
+  function element(tag, text, className) {
+    var node = document.createElement(tag);
+    if (text !== undefined) {
+      node.textContent = text;
+    }
+
+    if (className) {
+      node.className = className;
+    }
+
+    return node;
+  }
+
+  function showMessage(text, isError) {
+    var message = document.getElementById('message');
+    message.textContent = text;
+    message.className = isError ? 'error' : '';
+  }
+
+  function request(method, path, body) {
+    var headers = {Authorization: 'Bearer ' + state.token};
+    if (method !== 'GET') {
+      headers['If-Match'] = state.etag;
+    }
+
+    if (body !== undefined) {
+      headers['Content-Type'] = 'application/json';
+    }
+
+    return fetch(path, {method: method, headers: headers, cache: 'no-store', body: body === undefined ? undefined : JSON.stringify(body)}).then(function (response) {
+      if (response.status === 401) {
+        sessionStorage.removeItem('hostBuilderToken');
+        document.getElementById('login').hidden = false;
+        document.getElementById('dashboard').hidden = true;
+        throw new Error('Enter the token printed by hostBuilder api');
+      }
+
+      if (!response.ok) {
+        return response.json().then(function (error) {
+          error.status = response.status;
+          throw error;
+        });
+      }
+
+      return response;
+    });
+  }
+
+  function load() {
+    return Promise.all([
+      request('GET', '/hosts').then(function (response) {
+        state.etag = response.headers.get('ETag');
+        return response.json();
+      }),
+      request('GET', '/groups').then(function (response) { return response.json(); }),
+      request('GET', '/globalIPs').then(function (response) { return response.json(); }),
+      request('GET', '/build').then(function (response) { return response.text(); })
+    ]).then(function (results) {
+      state.hosts = results[0];
+      state.groups = results[1];
+      state.globalIPs = results[2];
+      state.output = results[3];
+      document.getElementById('login').hidden = true;
+      document.getElementById('dashboard').hidden = false;
+      render();
+    });
+  }
+
+  function optionButton(label, title, isCurrent, isStale, onClick) {
+    var button = element('button', label, 'option' + (isCurrent ? ' current' : '') + (isStale ? ' stale' : ''));
+    button.type = 'button';
+    button.title = title;
+    button.disabled = isCurrent;
+    button.addEventListener('click', onClick);
+    return button;
+  }
+
+  function globalIPNames() {
+    return Object.keys(state.globalIPs).sort();
+  }
+
+  function renderHosts() {
+    var filter = document.getElementById('filter').value.toLowerCase();
+    var tbody = document.getElementById('hosts');
+    tbody.textContent = '';
+    Object.keys(state.hosts).sort().forEach(function (hostName) {
+      if (filter && hostName.toLowerCase().indexOf(filter) === -1) {
+        return;
+      }
+
+      var host = state.hosts[hostName];
+      var provenance = host.provenance || {};
+      var options = element('td');
+      Object.keys(host.options).sort().forEach(function (option) {
+        options.appendChild(optionButton(option, host.options[option], option === host.current, provenance[option] && provenance[option].stale, function () {
+          change('PUT', '/hosts/' + encodeURIComponent(hostName) + '/current', {option: option}, hostName + ' is now ' + option);
+        }));
+      });
+
+      globalIPNames().concat(['ignore']).forEach(function (option) {
+        options.appendChild(optionButton(option, state.globalIPs[option] || 'Leave out of the hosts file', option === host.current, false, function () {
+          change('PUT', '/hosts/' + encodeURIComponent(hostName) + '/current', {option: option}, hostName + ' is now ' + option);
+        }));
+      });
+
+      var row = element('tr');
+      row.appendChild(element('td', hostName));
+      row.appendChild(element('td', host.ip || ''));
+      row.appendChild(options);
+      tbody.appendChild(row);
+    });
+  }
+
+  function renderGroups() {
+    var groups = document.getElementById('groups');
+    groups.textContent = '';
+    Object.keys(state.groups).sort().forEach(function (groupName) {
+      var group = element('div', undefined, 'group');
+      group.appendChild(element('h3', groupName));
+      group.appendChild(element('p', state.groups[groupName].join(', ')));
+      globalIPNames().concat(['ignore']).forEach(function (globalIP) {
+        var isCurrent = state.groups[groupName].every(function (hostName) {
+          return state.hosts[hostName] && state.hosts[hostName].current === globalIP;
+        });
+
+        group.appendChild(optionButton(globalIP, state.globalIPs[globalIP] || 'Leave out of the hosts file', isCurrent, false, function () {
+          change('PUT', '/groups/' + encodeURIComponent(groupName) + '/current', {globalIP: globalIP}, groupName + ' is now ' + globalIP);
+        }));
+      });
+
+      groups.appendChild(group);
+    });
+  }
+
+  function render() {
+    renderHosts();
+    renderGroups();
+  }
+
+  // showChanges lists the lines of the hosts file that a change removed and added
+  function showChanges(before, after) {
+    var beforeLines = before.split('\n').filter(Boolean);
+    var afterLines = after.split('\n').filter(Boolean);
+    var changes = document.getElementById('changes');
+    changes.textContent = '';
+    beforeLines.forEach(function (line) {
+      if (afterLines.indexOf(line) === -1) {
+        changes.appendChild(element('div', '- ' + line, 'removed'));
+      }
+    });
+
+    afterLines.forEach(function (line) {
+      if (beforeLines.indexOf(line) === -1) {
+        changes.appendChild(element('div', '+ ' + line, 'added'));
+      }
+    });
+
+    if (!changes.firstChild) {
+      changes.textContent = 'The hosts file did not change';
+    }
+  }
+
+  function change(method, path, body, done) {
+    var before = state.output;
+    var warning = '';
+    request(method, path, body).then(function (response) {
+      if (response.status === 200) {
+        return response.json().then(function (result) {
+          warning = result.warning;
+          return load();
+        });
+      }
+
+      return load();
+    }).then(function () {
+      showChanges(before, state.output);
+      showMessage(warning ? done + ', but ' + warning : done, !!warning);
+    }).catch(function (error) {
+      if (error.status === 412) {
+        showMessage('The configuration changed somewhere else, try again', true);
+        load();
+        return;
+      }
+
+      showMessage(error.error || error.message, true);
+    });
+  }
+
+  document.getElementById('filter').addEventListener('input', renderHosts);
+
+  document.getElementById('build').addEventListener('click', function () {
+    request('POST', '/build', {}).then(function () {
+      showMessage('Wrote the hosts file', false);
+    }).catch(function (error) {
+      showMessage(error.error || error.message, true);
+    });
+  });
+
+  document.getElementById('login').addEventListener('submit', function (event) {
+    event.preventDefault();
+    state.token = document.getElementById('token').value;
+    sessionStorage.setItem('hostBuilderToken', state.token);
+    load().then(function () {
+      showMessage('', false);
+    }).catch(function (error) {
+      showMessage(error.error || error.message, true);
+    });
+  });
+
+  if (state.token) {
+    load().catch(function (error) {
+      showMessage(error.error || error.message, true);
+    });
+  } else {
+    document.getElementById('login').hidden = false;
+  }
+}());
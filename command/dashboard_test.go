@@ -0,0 +1,59 @@
+package command
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashboard(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	handler := newAPIHandler(configFileName, "", false, testToken, new(bytes.Buffer))
+	handler.dashboard = newDashboardHandler()
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "text/html; charset=utf-8", response.Header().Get("Content-Type"))
+	assert.Equal(t, "default-src 'self'", response.Header().Get("Content-Security-Policy"))
+	assert.Contains(t, response.Body.String(), `<script src="app.js"></script>`)
+
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/app.js", nil))
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Contains(t, response.Body.String(), "/hosts/")
+
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/style.css", nil))
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "text/css; charset=utf-8", response.Header().Get("Content-Type"))
+}
+
+func TestDashboardKeepsAPIPrivate(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	handler := newAPIHandler(configFileName, "", false, testToken, new(bytes.Buffer))
+	handler.dashboard = newDashboardHandler()
+
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/hosts", nil))
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, httptest.NewRequest("GET", "/dashboard/app.js", nil))
+	assert.Equal(t, http.StatusUnauthorized, response.Code)
+}
+
+func TestDashboardDisabled(t *testing.T) {
+	configFileName, _ := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	response := apiRequestTo(t, newTestAPIHandler(configFileName, ""), "GET", "/", "", nil)
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
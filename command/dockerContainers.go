@@ -0,0 +1,93 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/dockerUtil"
+	"github.com/urfave/cli"
+)
+
+// CmdDockerContainers adds running container addresses to the configuration
+func CmdDockerContainers(c *cli.Context) error {
+	if c.NArg() != 0 {
+		return cli.NewExitError("Usage: \"hostBuilder docker containers\"", 1)
+	}
+
+	templateString := c.String("template")
+	if templateString == "" {
+		templateString = "{{.Name}}"
+	}
+
+	templ, err := template.New("").Parse(templateString)
+	if err != nil {
+		return err
+	}
+
+	policy, err := flagStalePolicy(c)
+	if err != nil {
+		return err
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	containers, source, err := readDockerContainers(c)
+	if err != nil {
+		return err
+	}
+
+	if c.String("source") != "" {
+		source = c.String("source")
+	}
+
+	addresses, err := dockerUtil.NamedAddresses(containers, c.String("network"), templ)
+	if err != nil {
+		return err
+	}
+
+	if mergeAddresses(configData, importOrigin(source, "docker"), addresses, policy, c.App.Writer, c.App.ErrWriter) == 0 {
+		fmt.Fprintln(c.App.Writer, "Nothing to import")
+	}
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+// readDockerContainers reads containers from the inspect file if one was given, otherwise from the engine socket
+// The default source for the imported addresses is returned along with the containers
+func readDockerContainers(c *cli.Context) ([]dockerUtil.Container, string, error) {
+	inspectFile := c.String("inspect")
+	if inspectFile == "" {
+		socket := c.String("socket")
+		if socket == "" {
+			socket = dockerUtil.DefaultSocket
+		}
+
+		containers, err := dockerUtil.NewClient(socket).ReadContainers()
+		return containers, fmt.Sprintf("docker:%s", strings.TrimPrefix(socket, "unix://")), err
+	}
+
+	source := fmt.Sprintf("docker:%s", inspectFile)
+	if inspectFile != "-" {
+		containers, err := dockerUtil.ReadInspectFile(inspectFile)
+		return containers, source, err
+	}
+
+	inspectJSON, err := ioutil.ReadAll(commandInput(c))
+	if err != nil {
+		return nil, source, err
+	}
+
+	containers, err := dockerUtil.ParseInspect(inspectJSON)
+	return containers, source, err
+}
+
+// CompleteDockerContainers handles bash autocompletion for the 'docker containers' command
+func CompleteDockerContainers(c *cli.Context) {
+	completeProviderCommand(c, "containers", dockerUtil.Provider{})
+}
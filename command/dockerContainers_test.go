@@ -0,0 +1,257 @@
+package command
+
+import (
+	"flag"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+const testDockerInspect = `[
+  {
+    "Id": "3f4e8a1c9b2d",
+    "Name": "/stack_web_1",
+    "Config": {"Image": "nginx", "Labels": {"com.docker.compose.project": "stack", "com.docker.compose.service": "web"}},
+    "NetworkSettings": {"Networks": {"stack_default": {"IPAddress": "172.18.0.3", "Aliases": ["web", "3f4e8a1c9b2d"]}}}
+  },
+  {
+    "Id": "9a8b7c6d5e4f",
+    "Name": "/stack_db_1",
+    "Config": {"Image": "postgres", "Labels": {"com.docker.compose.project": "stack", "com.docker.compose.service": "db"}},
+    "NetworkSettings": {"Networks": {"stack_default": {"IPAddress": "172.18.0.2", "Aliases": ["db"]}}}
+  }
+]`
+
+func setupDockerInspectFile(t *testing.T, inspectJSON string) string {
+	inspectFile, err := ioutil.TempFile("/tmp", "inspect")
+	assert.Nil(t, err)
+
+	err = ioutil.WriteFile(inspectFile.Name(), []byte(inspectJSON), 0644)
+	assert.Nil(t, err)
+
+	return inspectFile.Name()
+}
+
+func TestCmdDockerContainers(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	inspectFileName := setupDockerInspectFile(t, testDockerInspect)
+	defer removeFile(t, inspectFileName)
+	set.String("inspect", inspectFileName, "doc")
+	set.String("template", "{{.Service}}-{{.Name}}", "doc")
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdDockerContainers(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	expectedIPs := map[string]string{
+		"baz":             "10.0.0.4",
+		"db-db":           "172.18.0.2",
+		"db-stack_db_1":   "172.18.0.2",
+		"web-stack_web_1": "172.18.0.3",
+		"web-web":         "172.18.0.3",
+	}
+	assert.Equal(t, expectedIPs, configData.GlobalIPs)
+	assert.Equal(
+		t,
+		config.Provenance{
+			Source:     "docker:" + inspectFileName,
+			Provider:   "docker",
+			ResourceID: "3f4e8a1c9b2d",
+			ImportedAt: &testImportedAt,
+			FetchedAt:  &testImportedAt,
+		},
+		configData.Provenance["web-web"],
+	)
+	assert.Equal(
+		t,
+		"Added global IP db-db (172.18.0.2)\n"+
+			"Added global IP db-stack_db_1 (172.18.0.2)\n"+
+			"Added global IP web-stack_web_1 (172.18.0.3)\n"+
+			"Added global IP web-web (172.18.0.3)\n",
+		writer.String(),
+	)
+}
+
+func TestCmdDockerContainersRefresh(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	inspectFileName := setupDockerInspectFile(t, testDockerInspect)
+	defer removeFile(t, inspectFileName)
+	set.String("inspect", inspectFileName, "doc")
+	set.String("source", "stack", "doc")
+	set.Bool("prune", true, "doc")
+
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdDockerContainers(c))
+
+	restarted := strings.Replace(testDockerInspect, "172.18.0.3", "172.18.0.5", -1)
+	restarted = restarted[:strings.Index(restarted, "  },\n")] + "  }\n]"
+	assert.Nil(t, ioutil.WriteFile(inspectFileName, []byte(restarted), 0644))
+
+	app, writer := appWithWriter()
+	c = cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdDockerContainers(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]string{"baz": "10.0.0.4", "stack_web_1": "172.18.0.5", "web": "172.18.0.5"}, configData.GlobalIPs)
+	assert.Equal(
+		t,
+		"Updated global IP stack_web_1 (172.18.0.3 => 172.18.0.5)\n"+
+			"Updated global IP web (172.18.0.3 => 172.18.0.5)\n"+
+			"Removed global IP db (172.18.0.2)\n"+
+			"Removed global IP stack_db_1 (172.18.0.2)\n",
+		writer.String(),
+	)
+}
+
+func TestCmdDockerContainersSocket(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	socketFile, err := ioutil.TempFile("/tmp", "docker.sock")
+	assert.Nil(t, err)
+	assert.Nil(t, os.Remove(socketFile.Name()))
+	listener, err := net.Listen("unix", socketFile.Name())
+	assert.Nil(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"Id": "9a8b7c6d5e4f"}]`))
+	})
+	mux.HandleFunc("/containers/9a8b7c6d5e4f/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Id": "9a8b7c6d5e4f", "Name": "/db", "NetworkSettings": {"Networks": {"bridge": {"IPAddress": "172.17.0.2"}}}}`))
+	})
+	server := httptest.NewUnstartedServer(mux)
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	set.String("socket", "unix://"+socketFile.Name(), "doc")
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdDockerContainers(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "172.17.0.2", configData.GlobalIPs["db"])
+	assert.Equal(
+		t,
+		config.Provenance{
+			Source:     "docker:" + socketFile.Name(),
+			Provider:   "docker",
+			ResourceID: "9a8b7c6d5e4f",
+			ImportedAt: &testImportedAt,
+			FetchedAt:  &testImportedAt,
+		},
+		configData.Provenance["db"],
+	)
+	assert.Equal(t, "Added global IP db (172.17.0.2)\n", writer.String())
+}
+
+func TestCmdDockerContainersStdin(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	set.String("inspect", "-", "doc")
+	set.String("network", "missing", "doc")
+
+	app, writer := appWithWriter()
+	app.Metadata = map[string]interface{}{inputMetadata: strings.NewReader(testDockerInspect)}
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdDockerContainers(c))
+
+	assert.Equal(t, "Nothing to import\n", writer.String())
+}
+
+func TestCmdDockerContainersBadInspectFile(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	inspectFileName := setupDockerInspectFile(t, "{")
+	defer removeFile(t, inspectFileName)
+	set.String("inspect", inspectFileName, "doc")
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdDockerContainers(c), "unexpected end of JSON input")
+}
+
+func TestCmdDockerContainersTemplateError(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	inspectFileName := setupDockerInspectFile(t, testDockerInspect)
+	defer removeFile(t, inspectFileName)
+	set.String("inspect", inspectFileName, "doc")
+	set.String("template", "{{.Missing}}", "doc")
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(
+		t,
+		CmdDockerContainers(c),
+		"template: :1:2: executing \"\" at <.Missing>: can't evaluate field Missing in type dockerUtil.Address",
+	)
+}
+
+func TestCmdDockerContainersBadTemplate(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	set.String("template", "{{.badTemplate", "doc")
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdDockerContainers(c), "template: :1: unclosed action")
+}
+
+func TestCmdDockerContainersNoConfig(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdDockerContainers(c), "You must specify a config file")
+}
+
+func TestCmdDockerContainersUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdDockerContainers(c), "Usage: \"hostBuilder docker containers\"")
+}
+
+func TestCompleteDockerContainersSocket(t *testing.T) {
+	os.Args = []string{"hostBuilder", "docker", "containers", "--socket", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteDockerContainers(c)
+
+	assert.Equal(t, "fileCompletion\n", writer.String())
+}
+
+func TestCompleteDockerContainersFlags(t *testing.T) {
+	os.Args = []string{"hostBuilder", "docker", "containers", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	set.String("socket", "", "doc")
+	assert.Nil(t, set.Parse([]string{"--socket", "/tmp/docker.sock"}))
+	app, writer := appWithWriter()
+	app.Commands = []cli.Command{
+		{
+			Name: "containers",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "socket, s"},
+				cli.StringFlag{Name: "inspect, i"},
+			},
+		},
+	}
+	c := cli.NewContext(app, set, nil)
+	CompleteDockerContainers(c)
+
+	assert.Equal(t, "--inspect\n", writer.String())
+}
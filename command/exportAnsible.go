@@ -0,0 +1,94 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/guywithnose/hostBuilder/ansible"
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/hosts"
+	"github.com/urfave/cli"
+)
+
+// CmdExportAnsible writes an ansible inventory of the currently selected IPs
+func CmdExportAnsible(c *cli.Context) error {
+	if c.NArg() != 0 {
+		return cli.NewExitError("Usage: \"hostBuilder export ansible\"", 1)
+	}
+
+	format := c.String("format")
+	if format == "" {
+		format = "ini"
+	}
+
+	if format != "ini" && format != "yaml" {
+		return cli.NewExitError(fmt.Sprintf("Invalid format %s", format), 1)
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	inventory := buildInventory(configData)
+	var buffer bytes.Buffer
+	if format == "yaml" {
+		err = inventory.WriteYAML(&buffer)
+	} else {
+		err = inventory.WriteINI(&buffer)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if outputFile := c.String("output"); outputFile != "" {
+		return ioutil.WriteFile(outputFile, buffer.Bytes(), 0644)
+	}
+
+	_, err = c.App.Writer.Write(buffer.Bytes())
+	return err
+}
+
+func buildInventory(configData *config.HostsConfig) *ansible.Inventory {
+	inventory := ansible.NewInventory()
+	for _, hostName := range sortHostNames(configData) {
+		if IP, ok := hosts.CurrentIP(configData, hostName); ok {
+			inventory.AddHost(hostName, map[string]string{"ansible_host": IP})
+		}
+	}
+
+	for _, groupName := range sortGroupNames(configData) {
+		for _, hostName := range configData.Groups[groupName] {
+			if _, selected := inventory.Hosts[hostName]; selected {
+				inventory.AddToGroup(groupName, hostName)
+			}
+		}
+	}
+
+	return inventory
+}
+
+// CompleteExportAnsible handles bash autocompletion for the 'export ansible' command
+func CompleteExportAnsible(c *cli.Context) {
+	lastParam := os.Args[len(os.Args)-2]
+	if lastParam == "--output" {
+		fmt.Fprintln(c.App.Writer, "fileCompletion")
+		return
+	}
+
+	if lastParam == "--format" {
+		fmt.Fprintln(c.App.Writer, "ini\nyaml")
+		return
+	}
+
+	for _, flag := range c.App.Command("ansible").Flags {
+		name := strings.Split(flag.GetName(), ",")[0]
+		if !c.IsSet(name) {
+			fmt.Fprintf(c.App.Writer, "--%s\n", name)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdExportAnsible(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdExportAnsible(c))
+
+	assert.Equal(t, "[foo]\nbaz.com ansible_host=10.0.0.4\ngoo ansible_host=10.0.0.8\n", writer.String())
+}
+
+func TestCmdExportAnsibleYAMLToFile(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	outputFile, err := ioutil.TempFile("/tmp", "inventory")
+	assert.Nil(t, err)
+	defer removeFile(t, outputFile.Name())
+	set.String("format", "yaml", "doc")
+	set.String("output", outputFile.Name(), "doc")
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdExportAnsible(c))
+
+	inventory, err := ioutil.ReadFile(outputFile.Name())
+	assert.Nil(t, err)
+
+	expected := `all:
+  children:
+    foo:
+      hosts:
+        baz.com:
+          ansible_host: 10.0.0.4
+        goo:
+          ansible_host: 10.0.0.8
+`
+	assert.Equal(t, expected, string(inventory))
+	assert.Equal(t, "", writer.String())
+}
+
+func TestCmdExportAnsibleInvalidFormat(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	set.String("format", "toml", "doc")
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdExportAnsible(c), "Invalid format toml")
+}
+
+func TestCmdExportAnsibleNoConfig(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdExportAnsible(c), "You must specify a config file")
+}
+
+func TestCmdExportAnsibleUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdExportAnsible(c), "Usage: \"hostBuilder export ansible\"")
+}
+
+func TestCompleteExportAnsibleFormat(t *testing.T) {
+	os.Args = []string{"hostBuilder", "export", "ansible", "--format", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteExportAnsible(c)
+
+	assert.Equal(t, "ini\nyaml\n", writer.String())
+}
+
+func TestCompleteExportAnsibleOutput(t *testing.T) {
+	os.Args = []string{"hostBuilder", "export", "ansible", "--output", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteExportAnsible(c)
+
+	assert.Equal(t, "fileCompletion\n", writer.String())
+}
+
+func TestCompleteExportAnsibleFlags(t *testing.T) {
+	os.Args = []string{"hostBuilder", "export", "ansible", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	app.Commands = []cli.Command{
+		{
+			Name: "ansible",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "format, f"},
+				cli.StringFlag{Name: "output, o"},
+			},
+		},
+	}
+	c := cli.NewContext(app, set, nil)
+	CompleteExportAnsible(c)
+
+	assert.Equal(t, "--format\n--output\n", writer.String())
+}
@@ -2,6 +2,7 @@ package command
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/guywithnose/hostBuilder/config"
@@ -25,17 +26,29 @@ func CmdGlobalIPAdd(c *cli.Context) error {
 		return err
 	}
 
+	err = addGlobalIP(configData, name, address, c.Bool("force"), c.App.ErrWriter)
+	if err != nil {
+		return err
+	}
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+func addGlobalIP(configData *config.HostsConfig, name, address string, force bool, errWriter io.Writer) error {
 	if current, exists := configData.GlobalIPs[name]; exists {
-		if c.Bool("force") {
-			fmt.Fprintf(c.App.ErrWriter, "Warning: Overwriting %s (%s => %s)", name, current, address)
+		if force {
+			fmt.Fprintf(errWriter, "Warning: Overwriting %s (%s => %s)", name, current, address)
 		} else {
 			return cli.NewExitError(fmt.Sprintf("Global IP %s already exists", name), 1)
 		}
 	}
 
-	configData.GlobalIPs[name] = address
+	if configData.GlobalIPs == nil {
+		configData.GlobalIPs = map[string]string{}
+	}
 
-	return config.WriteConfig(c.GlobalString("config"), configData)
+	configData.GlobalIPs[name] = address
+	return nil
 }
 
 // CompleteGlobalIPAdd handles bash autocompletion for the 'globalIP add' command
@@ -39,7 +39,11 @@ func printSortedIps(configData *config.HostsConfig, writer io.Writer) error {
 	sort.Strings(ips)
 
 	for _, name := range ips {
-		fmt.Fprintf(w, "%s\t%s\n", name, configData.GlobalIPs[name])
+		if notes := provenanceNotes(configData.Provenance[name]); notes != "" {
+			fmt.Fprintf(w, "%s\t%s\t(%s)\n", name, configData.GlobalIPs[name], notes)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\n", name, configData.GlobalIPs[name])
+		}
 	}
 
 	return w.Flush()
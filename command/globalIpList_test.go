@@ -4,6 +4,7 @@ import (
 	"flag"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/guywithnose/hostBuilder/config"
 	"github.com/stretchr/testify/assert"
@@ -16,7 +17,14 @@ func TestCmdGlobalIPList(t *testing.T) {
 	defer removeFile(t, configFile.Name())
 
 	set := flag.NewFlagSet("test", 0)
-	configData := &config.HostsConfig{GlobalIPs: map[string]string{"def": "127.0.0.1", "abc": "10.0.0.2"}}
+	fetchedAt := testImportedAt.Add(-2 * time.Hour)
+	configData := &config.HostsConfig{
+		GlobalIPs: map[string]string{"def": "127.0.0.1", "abc": "10.0.0.2", "ghi": "10.0.0.3"},
+		Provenance: map[string]config.Provenance{
+			"abc": {Source: "aws", Stale: true},
+			"ghi": {Source: "aws", ImportedAt: &testImportedAt, FetchedAt: &fetchedAt},
+		},
+	}
 	err = config.WriteConfig(configFile.Name(), configData)
 	assert.Nil(t, err)
 	set.String("config", configFile.Name(), "doc")
@@ -24,7 +32,7 @@ func TestCmdGlobalIPList(t *testing.T) {
 	c := cli.NewContext(app, set, nil)
 	assert.Nil(t, CmdGlobalIPList(c))
 
-	assert.Equal(t, "abc 10.0.0.2\ndef 127.0.0.1\n", writer.String())
+	assert.Equal(t, "abc 10.0.0.2 (stale)\ndef 127.0.0.1\nghi 10.0.0.3 (fetched 2h ago)\n", writer.String())
 }
 
 func TestCmdGlobalIPListUsage(t *testing.T) {
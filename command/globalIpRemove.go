@@ -20,13 +20,21 @@ func CmdGlobalIPRemove(c *cli.Context) error {
 		return err
 	}
 
+	err = removeGlobalIP(configData, globalIPName)
+	if err != nil {
+		return err
+	}
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+func removeGlobalIP(configData *config.HostsConfig, globalIPName string) error {
 	if _, exists := configData.GlobalIPs[globalIPName]; !exists {
 		return cli.NewExitError(fmt.Sprintf("GlobalIP %s does not exist", globalIPName), 1)
 	}
 
 	delete(configData.GlobalIPs, globalIPName)
-
-	return config.WriteConfig(c.GlobalString("config"), configData)
+	return nil
 }
 
 // CompleteGlobalIPRemove handles bash autocompletion for the 'globalIP remove' command
@@ -22,6 +22,15 @@ func CmdGroupAdd(c *cli.Context) error {
 		return err
 	}
 
+	err = addGroupHost(configData, groupName, hostName)
+	if err != nil {
+		return err
+	}
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+func addGroupHost(configData *config.HostsConfig, groupName, hostName string) error {
 	if _, exists := configData.Groups[groupName]; !exists {
 		if configData.Groups == nil {
 			configData.Groups = map[string][]string{}
@@ -40,7 +49,7 @@ func CmdGroupAdd(c *cli.Context) error {
 		configData.Groups[groupName] = append(configData.Groups[groupName], hostName)
 	}
 
-	return config.WriteConfig(c.GlobalString("config"), configData)
+	return nil
 }
 
 // CompleteGroupAdd handles bash autocompletion for the 'group add' command
@@ -22,12 +22,25 @@ func CmdGroupSet(c *cli.Context) error {
 		return err
 	}
 
-	if _, exists := configData.Groups[groupName]; !exists {
-		return cli.NewExitError(fmt.Sprintf("Group %s does not exist", groupName), 1)
+	before := hostCurrents(configData)
+	err = setGroup(configData, groupName, globalIPName)
+	if err != nil {
+		return err
 	}
 
-	if _, exists := configData.GlobalIPs[globalIPName]; !exists && globalIPName != "ignore" {
-		return cli.NewExitError(fmt.Sprintf("Global IP %s does not exist", globalIPName), 1)
+	err = config.WriteConfig(c.GlobalString("config"), configData)
+	if err != nil {
+		return err
+	}
+
+	warnChangeHooks(configData, before, "", c.App.Writer, c.App.ErrWriter)
+	return nil
+}
+
+func setGroup(configData *config.HostsConfig, groupName, globalIPName string) error {
+	err := validateGroupParameters(configData, groupName, globalIPName)
+	if err != nil {
+		return err
 	}
 
 	for _, hostName := range configData.Groups[groupName] {
@@ -36,7 +49,19 @@ func CmdGroupSet(c *cli.Context) error {
 		configData.Hosts[hostName] = host
 	}
 
-	return config.WriteConfig(c.GlobalString("config"), configData)
+	return nil
+}
+
+func validateGroupParameters(configData *config.HostsConfig, groupName, globalIPName string) error {
+	if _, exists := configData.Groups[groupName]; !exists {
+		return cli.NewExitError(fmt.Sprintf("Group %s does not exist", groupName), 1)
+	}
+
+	if _, exists := configData.GlobalIPs[globalIPName]; !exists && globalIPName != "ignore" {
+		return cli.NewExitError(fmt.Sprintf("Global IP %s does not exist", globalIPName), 1)
+	}
+
+	return nil
 }
 
 // CompleteGroupSet handles bash autocompletion for the 'group set' command
@@ -14,7 +14,7 @@ func TestCmdGroupSet(t *testing.T) {
 	defer removeFile(t, configFileName)
 
 	assert.Nil(t, set.Parse([]string{"foo", "baz"}))
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	assert.Nil(t, CmdGroupSet(c))
 
 	modifiedConfigData, err := config.LoadConfigFromFile(configFileName)
@@ -28,7 +28,7 @@ func TestCmdGroupSetIgnore(t *testing.T) {
 	defer removeFile(t, configFileName)
 
 	assert.Nil(t, set.Parse([]string{"foo", "ignore"}))
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	assert.Nil(t, CmdGroupSet(c))
 
 	modifiedConfigData, err := config.LoadConfigFromFile(configFileName)
@@ -47,7 +47,7 @@ func TestCmdGroupSetNoConfigFile(t *testing.T) {
 	set := flag.NewFlagSet("test", 0)
 	assert.Nil(t, set.Parse([]string{"foo", "baz"}))
 
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	err := CmdGroupSet(c)
 	assert.EqualError(t, err, "You must specify a config file")
 }
@@ -57,7 +57,7 @@ func TestCmdGroupSetBadConfigFile(t *testing.T) {
 	assert.Nil(t, set.Parse([]string{"foo", "baz"}))
 
 	set.String("config", "/doesntexist", "doc")
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	err := CmdGroupSet(c)
 	assert.EqualError(t, err, "open /doesntexist: no such file or directory")
 }
@@ -66,7 +66,7 @@ func TestCmdGroupSetBadGroupName(t *testing.T) {
 	configFileName, set := setupBaseConfigFile(t)
 	defer removeFile(t, configFileName)
 	assert.Nil(t, set.Parse([]string{"food", "baz"}))
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	err := CmdGroupSet(c)
 	assert.EqualError(t, err, "Group food does not exist")
 }
@@ -75,7 +75,7 @@ func TestCmdGroupSetBadHostName(t *testing.T) {
 	configFileName, set := setupBaseConfigFile(t)
 	defer removeFile(t, configFileName)
 	assert.Nil(t, set.Parse([]string{"foo", "barz"}))
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	err := CmdGroupSet(c)
 	assert.EqualError(t, err, "Global IP barz does not exist")
 }
@@ -0,0 +1,254 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/guywithnose/hostBuilder/config"
+)
+
+const defaultCheckTimeout = 5 * time.Second
+
+// optionHealth is the result of checking an option, an option without a check is healthy
+type optionHealth struct {
+	Checked bool
+	Err     error
+}
+
+func (h optionHealth) healthy() bool {
+	return h.Err == nil
+}
+
+// hostHealth is the health of the options of each host that was checked
+type hostHealth map[string]map[string]optionHealth
+
+// failover checks the hosts with a failover list and returns a copy of the configuration that uses their first healthy options
+func failover(configData *config.HostsConfig, errWriter io.Writer) *config.HostsConfig {
+	selected, warnings := selectHealthy(configData, checkHealth(configData, failoverHosts(configData)))
+	for _, warning := range warnings {
+		fmt.Fprintf(errWriter, "Warning: %v\n", warning)
+	}
+
+	return selected
+}
+
+// selectHealthy sets each host with a failover list to its first healthy option in a copy of the configuration
+// A host without a healthy option keeps its current option
+func selectHealthy(configData *config.HostsConfig, health hostHealth) (*config.HostsConfig, []error) {
+	hostNames := failoverHosts(configData)
+	if len(hostNames) == 0 {
+		return configData, nil
+	}
+
+	selected := *configData
+	selected.Hosts = make(map[string]config.Host, len(configData.Hosts))
+	for hostName, host := range configData.Hosts {
+		selected.Hosts[hostName] = host
+	}
+
+	warnings := []error{}
+	for _, hostName := range hostNames {
+		host := selected.Hosts[hostName]
+		option, found := firstHealthy(host, health[hostName])
+		if !found {
+			warnings = append(warnings, fmt.Errorf("No healthy option for %s, keeping %s", hostName, host.Current))
+			continue
+		}
+
+		for _, skipped := range host.Failover {
+			if skipped == option {
+				break
+			}
+
+			warnings = append(warnings, fmt.Errorf("%s is using %s, %s is unhealthy: %v", hostName, option, skipped, health[hostName][skipped].Err))
+		}
+
+		host.Current = option
+		selected.Hosts[hostName] = host
+	}
+
+	return &selected, warnings
+}
+
+func firstHealthy(host config.Host, health map[string]optionHealth) (string, bool) {
+	for _, option := range host.Failover {
+		if health[option].healthy() {
+			return option, true
+		}
+	}
+
+	return "", false
+}
+
+// failoverHosts lists the hosts that have a failover list
+func failoverHosts(configData *config.HostsConfig) []string {
+	hostNames := []string{}
+	for _, hostName := range sortHostNames(configData) {
+		if len(configData.Hosts[hostName].Failover) != 0 {
+			hostNames = append(hostNames, hostName)
+		}
+	}
+
+	return hostNames
+}
+
+// checkedOptions lists the failover options of a host in order and then its other options that have a check
+func checkedOptions(host config.Host) []string {
+	options := append([]string{}, host.Failover...)
+	others := []string{}
+	for option := range host.Checks {
+		if !contains(host.Failover, option) {
+			others = append(others, option)
+		}
+	}
+
+	sort.Strings(others)
+	return append(options, others...)
+}
+
+// checkHealth runs the checks of the options of some hosts concurrently, each limited by its own timeout
+// Every option is recorded before the checks start, so the checks only replace their own result
+func checkHealth(configData *config.HostsConfig, hostNames []string) hostHealth {
+	type pendingCheck struct {
+		hostName, option, IP string
+		check                config.Check
+	}
+
+	health := hostHealth{}
+	pending := []pendingCheck{}
+	for _, hostName := range hostNames {
+		host := configData.Hosts[hostName]
+		health[hostName] = map[string]optionHealth{}
+		for _, option := range checkedOptions(host) {
+			health[hostName][option] = optionHealth{}
+			check, exists := host.Checks[option]
+			if !exists {
+				continue
+			}
+
+			IP, exists := host.Options[option]
+			if !exists {
+				IP = configData.GlobalIPs[option]
+			}
+
+			pending = append(pending, pendingCheck{hostName: hostName, option: option, IP: IP, check: check})
+		}
+	}
+
+	var mutex sync.Mutex
+	var wait sync.WaitGroup
+	for _, job := range pending {
+		wait.Add(1)
+		go func(job pendingCheck) {
+			defer wait.Done()
+			err := runCheck(job.check, job.hostName, job.IP)
+			mutex.Lock()
+			defer mutex.Unlock()
+			health[job.hostName][job.option] = optionHealth{Checked: true, Err: err}
+		}(job)
+	}
+
+	wait.Wait()
+	return health
+}
+
+// runCheck runs a health check against the IP of an option, nil means it is healthy
+func runCheck(check config.Check, hostName, IP string) error {
+	timeout, err := parseCheckTimeout(check.Timeout)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case check.TCP != "":
+		address := check.TCP
+		if strings.HasPrefix(address, ":") {
+			address = net.JoinHostPort(IP, address[1:])
+		}
+
+		connection, err := net.DialTimeout("tcp", address, timeout)
+		if err != nil {
+			return err
+		}
+
+		return connection.Close()
+	case check.HTTP != "":
+		return checkHTTP(check, IP, timeout)
+	case check.Command != "":
+		return checkCommand(check, hostName, IP, timeout)
+	}
+
+	return errors.New("The check has no tcp, http or command")
+}
+
+func parseCheckTimeout(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultCheckTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("Invalid timeout %s", value)
+	}
+
+	return timeout, nil
+}
+
+// checkHTTP requests the URL of a check from the IP of the option, the hostname of the URL is only sent as the Host and for TLS
+func checkHTTP(check config.Check, IP string, timeout time.Duration) error {
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			_, port, err := net.SplitHostPort(address)
+			if err != nil {
+				return nil, err
+			}
+
+			return dialer.DialContext(ctx, network, net.JoinHostPort(IP, port))
+		},
+	}
+	defer transport.CloseIdleConnections()
+
+	client := &http.Client{Timeout: timeout, Transport: transport}
+	response, err := client.Get(check.HTTP)
+	if err != nil {
+		return err
+	}
+
+	_ = response.Body.Close()
+	if check.Status == 0 && response.StatusCode >= 200 && response.StatusCode < 300 || response.StatusCode == check.Status {
+		return nil
+	}
+
+	return fmt.Errorf("%s answered %d", check.HTTP, response.StatusCode)
+}
+
+// checkCommand runs a command check with the host and IP it checks in HOSTBUILDER_HOST and HOSTBUILDER_IP
+func checkCommand(check config.Check, hostName, IP string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", check.Command)
+	cmd.Env = append(os.Environ(), "HOSTBUILDER_HOST="+hostName, "HOSTBUILDER_IP="+IP)
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("Timed out after %s", timeout)
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return fmt.Errorf("Exited with status %d", exitErr.ExitCode())
+	}
+
+	return err
+}
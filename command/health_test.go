@@ -0,0 +1,135 @@
+package command
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCheckTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer func() { _ = listener.Close() }()
+
+	assert.Nil(t, runCheck(config.Check{TCP: listener.Addr().String()}, "api.example.com", "10.0.0.9"))
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	assert.Nil(t, err)
+	assert.Nil(t, runCheck(config.Check{TCP: ":" + port}, "api.example.com", "127.0.0.1"))
+}
+
+func TestRunCheckTCPRefused(t *testing.T) {
+	address := closedAddress(t)
+	err := runCheck(config.Check{TCP: address}, "api.example.com", "127.0.0.1")
+	assert.Contains(t, fmt.Sprint(err), "connection refused")
+}
+
+func TestRunCheckHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/down" {
+			response.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	assert.Nil(t, runCheck(config.Check{HTTP: server.URL + "/health"}, "api.example.com", "127.0.0.1"))
+	assert.EqualError(t, runCheck(config.Check{HTTP: server.URL + "/down"}, "api.example.com", "127.0.0.1"), server.URL+"/down answered 503")
+	assert.Nil(t, runCheck(config.Check{HTTP: server.URL + "/down", Status: 503}, "api.example.com", "127.0.0.1"))
+	assert.EqualError(t, runCheck(config.Check{HTTP: server.URL + "/health", Status: 204}, "api.example.com", "127.0.0.1"), server.URL+"/health answered 200")
+}
+
+func TestRunCheckHTTPDialsOption(t *testing.T) {
+	hosts := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		hosts <- request.Host
+	}))
+	defer server.Close()
+
+	// The hostname only names the server, the check connects to the IP of the option
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	assert.Nil(t, err)
+	healthURL := "http://api.example.invalid:" + port + "/health"
+	assert.Nil(t, runCheck(config.Check{HTTP: healthURL}, "api.example.invalid", "127.0.0.1"))
+	assert.Equal(t, "api.example.invalid:"+port, <-hosts)
+
+	err = runCheck(config.Check{HTTP: healthURL}, "api.example.invalid", "127.0.0.2")
+	assert.Contains(t, fmt.Sprint(err), "127.0.0.2:"+port)
+}
+
+func TestRunCheckHTTPTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	err := runCheck(config.Check{HTTP: server.URL, Timeout: "100ms"}, "api.example.com", "127.0.0.1")
+	assert.Contains(t, fmt.Sprint(err), "Client.Timeout exceeded")
+}
+
+func TestRunCheckCommand(t *testing.T) {
+	assert.Nil(t, runCheck(config.Check{Command: "test \"$HOSTBUILDER_HOST $HOSTBUILDER_IP\" = \"api.example.com 127.0.0.1\""}, "api.example.com", "127.0.0.1"))
+	assert.EqualError(t, runCheck(config.Check{Command: "exit 3"}, "api.example.com", "127.0.0.1"), "Exited with status 3")
+	assert.EqualError(t, runCheck(config.Check{Command: "exec sleep 5", Timeout: "100ms"}, "api.example.com", "127.0.0.1"), "Timed out after 100ms")
+}
+
+func TestRunCheckInvalid(t *testing.T) {
+	assert.EqualError(t, runCheck(config.Check{Command: "true", Timeout: "soon"}, "api.example.com", "127.0.0.1"), "Invalid timeout soon")
+	assert.EqualError(t, runCheck(config.Check{}, "api.example.com", "127.0.0.1"), "The check has no tcp, http or command")
+}
+
+func TestCheckHealthConcurrent(t *testing.T) {
+	configData := &config.HostsConfig{Hosts: map[string]config.Host{}}
+	for _, hostName := range []string{"a", "b", "c", "d"} {
+		configData.Hosts[hostName] = config.Host{
+			Options:  map[string]string{"local": "127.0.0.1", "dev": "10.0.0.9"},
+			Failover: []string{"local", "dev"},
+			Checks:   map[string]config.Check{"local": {Command: "sleep 0.3"}, "dev": {Command: "sleep 0.3; exit 1"}},
+		}
+	}
+
+	start := time.Now()
+	health := checkHealth(configData, []string{"a", "b", "c", "d"})
+	assert.True(t, time.Since(start) < 2*time.Second, "the checks did not run concurrently")
+	for _, hostName := range []string{"a", "b", "c", "d"} {
+		assert.Equal(t, map[string]optionHealth{"local": {Checked: true}, "dev": {Checked: true, Err: fmt.Errorf("Exited with status 1")}}, health[hostName])
+	}
+}
+
+func TestSelectHealthy(t *testing.T) {
+	configData := &config.HostsConfig{Hosts: map[string]config.Host{
+		"api.example.com": {Current: "dev", Failover: []string{"local", "staging", "dev"}},
+		"db.example.com":  {Current: "dev", Failover: []string{"local"}},
+		"goo":             {Current: "foop"},
+	}}
+
+	selected, warnings := selectHealthy(configData, hostHealth{
+		"api.example.com": {"local": {Checked: true, Err: fmt.Errorf("down")}, "staging": {}, "dev": {Checked: true}},
+		"db.example.com":  {"local": {Checked: true, Err: fmt.Errorf("down")}},
+	})
+	assert.Equal(t, "staging", selected.Hosts["api.example.com"].Current)
+	assert.Equal(t, "dev", selected.Hosts["db.example.com"].Current)
+	assert.Equal(t, "foop", selected.Hosts["goo"].Current)
+	assert.Equal(t, "dev", configData.Hosts["api.example.com"].Current)
+	assert.Equal(
+		t,
+		[]error{fmt.Errorf("api.example.com is using staging, local is unhealthy: down"), fmt.Errorf("No healthy option for db.example.com, keeping dev")},
+		warnings,
+	)
+}
+
+// closedAddress is a local address that refuses connections
+func closedAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	address := listener.Addr().String()
+	assert.Nil(t, listener.Close())
+	return address
+}
@@ -0,0 +1,92 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/urfave/cli"
+)
+
+// CmdHookAdd adds a command to run before or after builds or when hosts are switched
+func CmdHookAdd(c *cli.Context) error {
+	if c.NArg() != 3 {
+		return cli.NewExitError("Usage: \"hostBuilder hook add {name} {preBuild|postBuild|change} {command}\"", 1)
+	}
+
+	hook := config.Hook{
+		Name:      c.Args().Get(0),
+		When:      c.Args().Get(1),
+		Command:   c.Args().Get(2),
+		Timeout:   c.String("timeout"),
+		OnFailure: c.String("onFailure"),
+	}
+
+	if !contains(hookEvents, hook.When) {
+		return cli.NewExitError(fmt.Sprintf("Invalid event %s, expected one of %s", hook.When, strings.Join(hookEvents, ", ")), 1)
+	}
+
+	if hook.OnFailure != "" && !contains(hookFailurePolicies, hook.OnFailure) {
+		return cli.NewExitError(fmt.Sprintf("Invalid failure policy %s, expected one of %s", hook.OnFailure, strings.Join(hookFailurePolicies, ", ")), 1)
+	}
+
+	if hook.Timeout != "" {
+		if timeout, err := time.ParseDuration(hook.Timeout); err != nil || timeout <= 0 {
+			return cli.NewExitError(fmt.Sprintf("Invalid timeout %s", hook.Timeout), 1)
+		}
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	index := hookIndex(configData, hook.Name)
+	switch {
+	case index == -1:
+		configData.Hooks = append(configData.Hooks, hook)
+	case c.Bool("force"):
+		configData.Hooks[index] = hook
+	default:
+		return cli.NewExitError(fmt.Sprintf("Hook %s already exists", hook.Name), 1)
+	}
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+// hookIndex finds a hook by name, -1 means there is no such hook
+func hookIndex(configData *config.HostsConfig, name string) int {
+	for index, hook := range configData.Hooks {
+		if hook.Name == name {
+			return index
+		}
+	}
+
+	return -1
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CompleteHookAdd handles bash autocompletion for the 'hook add' command
+func CompleteHookAdd(c *cli.Context) {
+	if c.NArg() == 1 {
+		fmt.Fprintln(c.App.Writer, strings.Join(hookEvents, "\n"))
+		return
+	}
+
+	for _, flag := range c.App.Command("add").Flags {
+		name := strings.Split(flag.GetName(), ",")[0]
+		if !c.IsSet(name) {
+			fmt.Fprintf(c.App.Writer, "--%s\n", name)
+		}
+	}
+}
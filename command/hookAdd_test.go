@@ -0,0 +1,129 @@
+package command
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdHookAdd(t *testing.T) {
+	configFileName, set := setupHookConfigFile(t, []config.Hook{{Name: "check", When: "preBuild", Command: "true"}})
+	defer removeFile(t, configFileName)
+
+	set.String("timeout", "10s", "doc")
+	set.String("onFailure", "warn", "doc")
+	assert.Nil(t, set.Parse([]string{"flush", "postBuild", "sudo systemctl restart nscd"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdHookAdd(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]config.Hook{
+			{Name: "check", When: "preBuild", Command: "true"},
+			{Name: "flush", When: "postBuild", Command: "sudo systemctl restart nscd", Timeout: "10s", OnFailure: "warn"},
+		},
+		configData.Hooks,
+	)
+}
+
+func TestCmdHookAddExists(t *testing.T) {
+	configFileName, set := setupHookConfigFile(t, []config.Hook{{Name: "flush", When: "postBuild", Command: "true"}})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"flush", "change", "false"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdHookAdd(c), "Hook flush already exists")
+}
+
+func TestCmdHookAddForce(t *testing.T) {
+	configFileName, set := setupHookConfigFile(t, []config.Hook{
+		{Name: "flush", When: "postBuild", Command: "true", OnFailure: "warn"},
+		{Name: "check", When: "preBuild", Command: "true"},
+	})
+	defer removeFile(t, configFileName)
+
+	set.Bool("force", true, "doc")
+	assert.Nil(t, set.Parse([]string{"flush", "change", "false"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdHookAdd(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]config.Hook{
+			{Name: "flush", When: "change", Command: "false"},
+			{Name: "check", When: "preBuild", Command: "true"},
+		},
+		configData.Hooks,
+	)
+}
+
+func TestCmdHookAddInvalidEvent(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"flush", "build", "true"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdHookAdd(c), "Invalid event build, expected one of preBuild, postBuild, change")
+}
+
+func TestCmdHookAddInvalidFailurePolicy(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("onFailure", "ignore", "doc")
+	assert.Nil(t, set.Parse([]string{"flush", "postBuild", "true"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdHookAdd(c), "Invalid failure policy ignore, expected one of abort, warn")
+}
+
+func TestCmdHookAddInvalidTimeout(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("timeout", "soon", "doc")
+	assert.Nil(t, set.Parse([]string{"flush", "postBuild", "true"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdHookAdd(c), "Invalid timeout soon")
+}
+
+func TestCmdHookAddUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"flush", "postBuild"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdHookAdd(c), "Usage: \"hostBuilder hook add {name} {preBuild|postBuild|change} {command}\"")
+}
+
+func TestCmdHookAddNoConfig(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"flush", "postBuild", "true"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdHookAdd(c), "You must specify a config file")
+}
+
+func TestCompleteHookAddEvent(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"flush"}))
+	os.Args = []string{"hook", "add", "flush", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteHookAdd(c)
+	assert.Equal(t, "preBuild\npostBuild\nchange\n", writer.String())
+}
+
+func TestCompleteHookAddFlags(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	os.Args = []string{"hook", "add", "--completion"}
+	app, writer := appWithWriter()
+	app.Commands = []cli.Command{{Name: "add", Flags: []cli.Flag{forceFlag, cli.StringFlag{Name: "timeout"}}}}
+	c := cli.NewContext(app, set, nil)
+	CompleteHookAdd(c)
+	assert.Equal(t, "--force\n--timeout\n", writer.String())
+}
@@ -0,0 +1,37 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+)
+
+// CmdHookList lists the hooks in the configuration in the order they run
+func CmdHookList(c *cli.Context) error {
+	if c.NArg() != 0 {
+		return cli.NewExitError("Usage: \"hostBuilder hook list\"", 1)
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.App.Writer, 0, 0, 1, ' ', 0)
+	for _, hook := range configData.Hooks {
+		line := []string{hook.Name, hook.When, hook.Command}
+		if hook.Timeout != "" {
+			line = append(line, fmt.Sprintf("(timeout %s)", hook.Timeout))
+		}
+
+		if hook.OnFailure != "" {
+			line = append(line, fmt.Sprintf("(onFailure=%s)", hook.OnFailure))
+		}
+
+		fmt.Fprintln(w, strings.Join(line, "\t"))
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,34 @@
+package command
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdHookList(t *testing.T) {
+	configFileName, set := setupHookConfigFile(t, []config.Hook{
+		{Name: "check", When: "preBuild", Command: "dig +short example.com"},
+		{Name: "flush", When: "postBuild", Command: "sudo systemctl restart nscd", Timeout: "10s", OnFailure: "warn"},
+	})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdHookList(c))
+	assert.Equal(
+		t,
+		"check preBuild  dig +short example.com\nflush postBuild sudo systemctl restart nscd (timeout 10s) (onFailure=warn)\n",
+		writer.String(),
+	)
+}
+
+func TestCmdHookListUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdHookList(c), "Usage: \"hostBuilder hook list\"")
+}
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/urfave/cli"
+)
+
+// CmdHookRemove removes a hook from the configuration
+func CmdHookRemove(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Usage: \"hostBuilder hook remove {name}\"", 1)
+	}
+
+	hookName := c.Args().Get(0)
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	index := hookIndex(configData, hookName)
+	if index == -1 {
+		return cli.NewExitError(fmt.Sprintf("Hook %s does not exist", hookName), 1)
+	}
+
+	configData.Hooks = append(configData.Hooks[:index], configData.Hooks[index+1:]...)
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+// CompleteHookRemove handles bash autocompletion for the 'hook remove' command
+func CompleteHookRemove(c *cli.Context) {
+	if c.NArg() != 0 {
+		return
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return
+	}
+
+	for _, hook := range configData.Hooks {
+		fmt.Fprintf(c.App.Writer, "%s:%s\n", hook.Name, hook.When)
+	}
+}
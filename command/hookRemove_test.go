@@ -0,0 +1,54 @@
+package command
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdHookRemove(t *testing.T) {
+	configFileName, set := setupHookConfigFile(t, []config.Hook{
+		{Name: "check", When: "preBuild", Command: "true"},
+		{Name: "flush", When: "postBuild", Command: "true"},
+	})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"check"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdHookRemove(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, []config.Hook{{Name: "flush", When: "postBuild", Command: "true"}}, configData.Hooks)
+}
+
+func TestCmdHookRemoveMissing(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"flush"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdHookRemove(c), "Hook flush does not exist")
+}
+
+func TestCmdHookRemoveUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdHookRemove(c), "Usage: \"hostBuilder hook remove {name}\"")
+}
+
+func TestCompleteHookRemove(t *testing.T) {
+	configFileName, set := setupHookConfigFile(t, []config.Hook{
+		{Name: "check", When: "preBuild", Command: "true"},
+		{Name: "flush", When: "postBuild", Command: "true"},
+	})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteHookRemove(c)
+	assert.Equal(t, "check:preBuild\nflush:postBuild\n", writer.String())
+}
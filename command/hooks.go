@@ -0,0 +1,174 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/hosts"
+	"github.com/guywithnose/hostBuilder/provider"
+)
+
+const (
+	hookPreBuild  = "preBuild"
+	hookPostBuild = "postBuild"
+	hookChange    = "change"
+	hookAbort     = "abort"
+	hookWarn      = "warn"
+)
+
+var (
+	hookEvents          = []string{hookPreBuild, hookPostBuild, hookChange}
+	hookFailurePolicies = []string{hookAbort, hookWarn}
+)
+
+// hookEvent is what a hook is told, as JSON on stdin and in the HOSTBUILDER_EVENT, HOSTBUILDER_OUTPUT and HOSTBUILDER_HOSTS environment variables
+// Hosts are the hostnames whose IP a build changes, or the hostnames that were switched to another option
+type hookEvent struct {
+	Event  string   `json:"event"`
+	Output string   `json:"output,omitempty"`
+	Hosts  []string `json:"hosts"`
+}
+
+// buildHostsFile writes the hosts file with the first healthy option of each host that has a failover list
+func buildHostsFile(configData *config.HostsConfig, outputFile string, oneLinePerIP bool, writer, errWriter io.Writer) error {
+	return writeHostsFile(failover(configData, errWriter), outputFile, oneLinePerIP, writer, errWriter)
+}
+
+// writeHostsFile writes the hosts file between the preBuild and postBuild hooks
+// A preBuild hook that aborts leaves the hosts file as it was
+func writeHostsFile(configData *config.HostsConfig, outputFile string, oneLinePerIP bool, writer, errWriter io.Writer) error {
+	before, err := ioutil.ReadFile(outputFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	output := hosts.FormatHostLines(configData, oneLinePerIP)
+	event := hookEvent{Event: hookPreBuild, Output: outputFile, Hosts: hosts.ChangedHostnames(string(before), output)}
+	err = runHooks(configData, event, writer, errWriter)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(outputFile, []byte(output), 0644)
+	if err != nil {
+		return err
+	}
+
+	event.Event = hookPostBuild
+	return runHooks(configData, event, writer, errWriter)
+}
+
+// hostCurrents records the current option of every host so switchedHosts can tell which ones a change switched
+func hostCurrents(configData *config.HostsConfig) map[string]string {
+	currents := make(map[string]string, len(configData.Hosts))
+	for hostName, host := range configData.Hosts {
+		currents[hostName] = host.Current
+	}
+
+	return currents
+}
+
+// runChangeHooks runs the change hooks when any host was switched to another option since the currents were recorded
+func runChangeHooks(configData *config.HostsConfig, before map[string]string, outputFile string, writer, errWriter io.Writer) error {
+	switched := []string{}
+	for hostName, host := range configData.Hosts {
+		if current, exists := before[hostName]; exists && current != host.Current {
+			switched = append(switched, hostName)
+		}
+	}
+
+	if len(switched) == 0 {
+		return nil
+	}
+
+	sort.Strings(switched)
+	return runHooks(configData, hookEvent{Event: hookChange, Output: outputFile, Hosts: switched}, writer, errWriter)
+}
+
+// warnChangeHooks runs the change hooks of a change that is already saved
+// A failing hook can not undo the change, so it is reported as a warning instead of failing the command
+func warnChangeHooks(configData *config.HostsConfig, before map[string]string, outputFile string, writer, errWriter io.Writer) {
+	err := runChangeHooks(configData, before, outputFile, writer, errWriter)
+	if err != nil {
+		fmt.Fprintf(errWriter, "Warning: %v\n", err)
+	}
+}
+
+// runHooks runs the hooks for an event in the order they are configured
+// A failing hook stops the hooks after it and fails the command unless it only warns
+func runHooks(configData *config.HostsConfig, event hookEvent, writer, errWriter io.Writer) error {
+	for _, hook := range configData.Hooks {
+		if hook.When != event.Event {
+			continue
+		}
+
+		err := runHook(hook, event, writer, errWriter)
+		if err == nil {
+			continue
+		}
+
+		if hook.OnFailure == hookWarn {
+			fmt.Fprintf(errWriter, "Warning: %v\n", err)
+			continue
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func runHook(hook config.Hook, event hookEvent, writer, errWriter io.Writer) error {
+	timeout := provider.DefaultTimeout
+	if hook.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(hook.Timeout)
+		if err != nil {
+			return fmt.Errorf("Invalid timeout %s for hook %s", hook.Timeout, hook.Name)
+		}
+	}
+
+	input, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", hook.Command)
+	cmd.Env = append(
+		os.Environ(),
+		"HOSTBUILDER_EVENT="+event.Event,
+		"HOSTBUILDER_OUTPUT="+event.Output,
+		"HOSTBUILDER_HOSTS="+strings.Join(event.Hosts, " "),
+	)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = writer
+	cmd.Stderr = errWriter
+	cmd.WaitDelay = time.Second
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("Hook %s timed out after %s", hook.Name, timeout)
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return fmt.Errorf("Hook %s exited with status %d", hook.Name, exitErr.ExitCode())
+	}
+
+	if err != nil {
+		return fmt.Errorf("Unable to run hook %s: %v", hook.Name, err)
+	}
+
+	return nil
+}
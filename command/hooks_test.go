@@ -0,0 +1,205 @@
+package command
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestBuildHostsFileHooks(t *testing.T) {
+	logFileName := setupOutputFile(t)
+	defer removeFile(t, logFileName)
+	configFileName, set := setupHookConfigFile(t, []config.Hook{
+		{Name: "before", When: hookPreBuild, Command: logHook(logFileName)},
+		{Name: "after", When: hookPostBuild, Command: logHook(logFileName)},
+		{Name: "switch", When: hookChange, Command: logHook(logFileName)},
+	})
+	defer removeFile(t, configFileName)
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	assert.Nil(t, ioutil.WriteFile(outputFileName, []byte("10.0.0.1 goo\n10.0.0.8 gone\n"), 0644))
+
+	set.String("output", outputFileName, "doc")
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdBuild(c))
+	assert.Equal(t, "", writer.String())
+
+	log, err := ioutil.ReadFile(logFileName)
+	assert.Nil(t, err)
+	changed := "baz.com gone goo localhost localhost.localdomain localhost4 localhost4.localdomain4"
+	changedJSON := `["baz.com","gone","goo","localhost","localhost.localdomain","localhost4","localhost4.localdomain4"]`
+	assert.Equal(
+		t,
+		"preBuild "+outputFileName+" "+changed+"\n"+
+			"{\"event\":\"preBuild\",\"output\":\""+outputFileName+"\",\"hosts\":"+changedJSON+"}\n"+
+			"postBuild "+outputFileName+" "+changed+"\n"+
+			"{\"event\":\"postBuild\",\"output\":\""+outputFileName+"\",\"hosts\":"+changedJSON+"}\n",
+		string(log),
+	)
+}
+
+func TestBuildHostsFilePreBuildAbort(t *testing.T) {
+	configFileName, set := setupHookConfigFile(t, []config.Hook{
+		{Name: "check", When: hookPreBuild, Command: "echo checking; exit 2"},
+		{Name: "never", When: hookPreBuild, Command: "echo never"},
+	})
+	defer removeFile(t, configFileName)
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	set.String("output", outputFileName, "doc")
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdBuild(c), "Hook check exited with status 2")
+	assert.Equal(t, "checking\n", writer.String())
+
+	output, err := ioutil.ReadFile(outputFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(output))
+}
+
+func TestBuildHostsFileWarn(t *testing.T) {
+	configFileName, set := setupHookConfigFile(t, []config.Hook{
+		{Name: "flush", When: hookPostBuild, Command: "echo flushing >&2; exit 1", OnFailure: hookWarn},
+		{Name: "reload", When: hookPostBuild, Command: "echo reloading"},
+	})
+	defer removeFile(t, configFileName)
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	set.String("output", outputFileName, "doc")
+	app, writer := appWithWriter()
+	errWriter := new(bytes.Buffer)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdBuild(c))
+	assert.Equal(t, "reloading\n", writer.String())
+	assert.Equal(t, "flushing\nWarning: Hook flush exited with status 1\n", errWriter.String())
+}
+
+func TestRunHooksTimeout(t *testing.T) {
+	configData := &config.HostsConfig{Hooks: []config.Hook{{Name: "slow", When: hookChange, Command: "exec sleep 5", Timeout: "100ms"}}}
+	err := runHooks(configData, hookEvent{Event: hookChange}, new(bytes.Buffer), new(bytes.Buffer))
+	assert.EqualError(t, err, "Hook slow timed out after 100ms")
+}
+
+func TestRunHooksInvalidTimeout(t *testing.T) {
+	configData := &config.HostsConfig{Hooks: []config.Hook{{Name: "slow", When: hookChange, Command: "true", Timeout: "soon"}}}
+	err := runHooks(configData, hookEvent{Event: hookChange}, new(bytes.Buffer), new(bytes.Buffer))
+	assert.EqualError(t, err, "Invalid timeout soon for hook slow")
+}
+
+func TestCmdHostSetChangeHook(t *testing.T) {
+	logFileName := setupOutputFile(t)
+	defer removeFile(t, logFileName)
+	configFileName, set := setupHookConfigFile(t, []config.Hook{{Name: "switch", When: hookChange, Command: logHook(logFileName)}})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"goo", "baz"}))
+	c := cli.NewContext(cli.NewApp(), set, nil)
+	assert.Nil(t, CmdHostSet(c))
+
+	log, err := ioutil.ReadFile(logFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "change  goo\n{\"event\":\"change\",\"hosts\":[\"goo\"]}\n", string(log))
+}
+
+func TestCmdHostSetUnchangedSkipsHook(t *testing.T) {
+	logFileName := setupOutputFile(t)
+	defer removeFile(t, logFileName)
+	configFileName, set := setupHookConfigFile(t, []config.Hook{{Name: "switch", When: hookChange, Command: logHook(logFileName)}})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"goo", "foop"}))
+	c := cli.NewContext(cli.NewApp(), set, nil)
+	assert.Nil(t, CmdHostSet(c))
+
+	log, err := ioutil.ReadFile(logFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "", string(log))
+}
+
+func TestCmdHostSetChangeHookAbortKeepsChange(t *testing.T) {
+	configFileName, set := setupHookConfigFile(t, []config.Hook{
+		{Name: "reload", When: hookChange, Command: "exit 1"},
+		{Name: "never", When: hookChange, Command: "echo never"},
+	})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"goo", "baz"}))
+	app, writer := appWithWriter()
+	errWriter := new(bytes.Buffer)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdHostSet(c))
+	assert.Equal(t, "", writer.String())
+	assert.Equal(t, "Warning: Hook reload exited with status 1\n", errWriter.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "baz", configData.Hosts["goo"].Current)
+}
+
+func TestCmdGroupSetChangeHookAbortKeepsChange(t *testing.T) {
+	configFileName, set := setupHookConfigFile(t, []config.Hook{{Name: "reload", When: hookChange, Command: "exit 1"}})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"foo", "ignore"}))
+	app, _ := appWithWriter()
+	errWriter := new(bytes.Buffer)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdGroupSet(c))
+	assert.Equal(t, "Warning: Hook reload exited with status 1\n", errWriter.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "ignore", configData.Hosts["goo"].Current)
+}
+
+func TestAPIChangeHook(t *testing.T) {
+	logFileName := setupOutputFile(t)
+	defer removeFile(t, logFileName)
+	configFileName, _ := setupHookConfigFile(t, []config.Hook{{Name: "switch", When: hookChange, Command: logHook(logFileName)}})
+	defer removeFile(t, configFileName)
+
+	handler := newTestAPIHandler(configFileName, "/etc/hosts")
+	response := apiRequestTo(t, handler, "PUT", "/groups/foo/current", `{"globalIP": "ignore"}`, ifMatch(t, configFileName))
+	assert.Equal(t, 204, response.Code)
+
+	log, err := ioutil.ReadFile(logFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "change /etc/hosts baz.com goo\n{\"event\":\"change\",\"output\":\"/etc/hosts\",\"hosts\":[\"baz.com\",\"goo\"]}\n", string(log))
+}
+
+func TestAPIChangeHookAbortKeepsChange(t *testing.T) {
+	configFileName, _ := setupHookConfigFile(t, []config.Hook{{Name: "reload", When: hookChange, Command: "exit 1"}})
+	defer removeFile(t, configFileName)
+
+	errWriter := new(bytes.Buffer)
+	handler := newAPIHandler(configFileName, "/etc/hosts", false, testToken, errWriter)
+	response := apiRequestTo(t, handler, "PUT", "/hosts/goo/current", `{"option": "baz"}`, ifMatch(t, configFileName))
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, "{\"warning\":\"Hook reload exited with status 1\"}\n", response.Body.String())
+	assert.Equal(t, "Warning: Hook reload exited with status 1\n", errWriter.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "baz", configData.Hosts["goo"].Current)
+
+	// The ETag of the response is the saved configuration, so the next change goes through
+	etag := response.Header().Get("ETag")
+	response = apiRequestTo(t, handler, "PUT", "/hosts/goo/current", `{"option": "foop"}`, map[string]string{"If-Match": etag})
+	assert.Equal(t, 200, response.Code)
+}
+
+// logHook is a hook command that appends what it is told to a file
+func logHook(logFileName string) string {
+	return "echo \"$HOSTBUILDER_EVENT $HOSTBUILDER_OUTPUT $HOSTBUILDER_HOSTS\" >> " + logFileName + "; cat >> " + logFileName + "; echo >> " + logFileName
+}
@@ -16,21 +16,24 @@ func CmdHostAdd(c *cli.Context) error {
 		return configErr
 	}
 
-	if c.NArg() == 3 {
-		return addHost(configData, c, c.Bool("force"), c.App.ErrWriter)
+	var err error
+	switch c.NArg() {
+	case 3:
+		err = addHost(configData, c.Args().Get(0), c.Args().Get(1), c.Args().Get(2), c.Bool("force"), c.App.ErrWriter)
+	case 2:
+		err = addGlobalIPHost(configData, c.Args().Get(0), c.Args().Get(1))
+	default:
+		return cli.NewExitError("Usage: \"hostBuilder host add {hostName} ({address} {IPName}|{globalIpName})\"", 1)
 	}
 
-	if c.NArg() == 2 {
-		return addGlobalIPHost(configData, c)
+	if err != nil {
+		return err
 	}
 
-	return cli.NewExitError("Usage: \"hostBuilder host add {hostName} ({address} {IPName}|{globalIpName})\"", 1)
+	return config.WriteConfig(c.GlobalString("config"), configData)
 }
 
-func addGlobalIPHost(configData *config.HostsConfig, c *cli.Context) error {
-	hostName := c.Args().Get(0)
-	globalIPName := c.Args().Get(1)
-
+func addGlobalIPHost(configData *config.HostsConfig, hostName, globalIPName string) error {
 	if _, exists := configData.Hosts[hostName]; exists {
 		return cli.NewExitError(fmt.Sprintf("IP %s already exists", hostName), 1)
 	}
@@ -40,19 +43,15 @@ func addGlobalIPHost(configData *config.HostsConfig, c *cli.Context) error {
 	}
 
 	configData.Hosts[hostName] = config.Host{Current: globalIPName}
-
-	return config.WriteConfig(c.GlobalString("config"), configData)
+	return nil
 }
 
-func addHost(configData *config.HostsConfig, c *cli.Context, force bool, errWriter io.Writer) error {
-	hostName := c.Args().Get(0)
-	address, err := resolveAddress(c.Args().Get(1))
+func addHost(configData *config.HostsConfig, hostName, address, IPName string, force bool, errWriter io.Writer) error {
+	address, err := resolveAddress(address)
 	if err != nil {
 		return err
 	}
 
-	IPName := c.Args().Get(2)
-
 	if _, exists := configData.Hosts[hostName]; !exists {
 		configData.Hosts[hostName] = config.Host{Current: IPName, Options: map[string]string{IPName: address}}
 	} else {
@@ -67,7 +66,7 @@ func addHost(configData *config.HostsConfig, c *cli.Context, force bool, errWrit
 		configData.Hosts[hostName].Options[IPName] = address
 	}
 
-	return config.WriteConfig(c.GlobalString("config"), configData)
+	return nil
 }
 
 // CompleteHostAdd handles bash autocompletion for the 'host add' command
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/urfave/cli"
+)
+
+// CmdHostFailover sets the options a host prefers in order, the hosts file uses the first healthy one
+func CmdHostFailover(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return cli.NewExitError("Usage: \"hostBuilder host failover {hostName} [IPName...]\"", 1)
+	}
+
+	hostName := c.Args().Get(0)
+	options := []string(c.Args()[1:])
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	if _, exists := configData.Hosts[hostName]; !exists {
+		return cli.NewExitError(fmt.Sprintf("HostName %s does not exist", hostName), 1)
+	}
+
+	for index, option := range options {
+		err = validateParameters(configData, hostName, option)
+		if err != nil {
+			return err
+		}
+
+		if contains(options[:index], option) {
+			return cli.NewExitError(fmt.Sprintf("IPName %s is listed twice", option), 1)
+		}
+	}
+
+	host := configData.Hosts[hostName]
+	host.Failover = nil
+	if len(options) != 0 {
+		host.Failover = options
+	}
+
+	configData.Hosts[hostName] = host
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+// CompleteHostFailover handles bash autocompletion for the 'host failover' command
+func CompleteHostFailover(c *cli.Context) {
+	configData, err := loadConfig(c)
+	if err != nil {
+		return
+	}
+
+	if c.NArg() == 0 {
+		for _, hostName := range sortHostNames(configData) {
+			fmt.Fprintln(c.App.Writer, hostName)
+		}
+
+		return
+	}
+
+	hostName := c.Args().Get(0)
+	if _, exists := configData.Hosts[hostName]; !exists {
+		return
+	}
+
+	for _, option := range append(sortOptions(configData, hostName), sortGlobalIPNames(configData)...) {
+		if !argsContain(c, option) {
+			fmt.Fprintln(c.App.Writer, option)
+		}
+	}
+}
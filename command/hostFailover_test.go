@@ -0,0 +1,86 @@
+package command
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdHostFailover(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"goo", "foop", "baz"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdHostFailover(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"foop", "baz"}, configData.Hosts["goo"].Failover)
+}
+
+func TestCmdHostFailoverClear(t *testing.T) {
+	configFileName, set := setupFailoverConfigFile(t, config.Check{TCP: ":443"})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"api.example.com"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdHostFailover(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Nil(t, configData.Hosts["api.example.com"].Failover)
+	assert.Equal(t, map[string]config.Check{"local": {TCP: ":443"}}, configData.Hosts["api.example.com"].Checks)
+}
+
+func TestCmdHostFailoverInvalid(t *testing.T) {
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"gone", "foop"}, "HostName gone does not exist"},
+		{[]string{"goo", "public"}, "IPName public does not exist"},
+		{[]string{"goo", "foop", "baz", "foop"}, "IPName foop is listed twice"},
+	}
+
+	for _, test := range tests {
+		configFileName, set := setupBaseConfigFile(t)
+		assert.Nil(t, set.Parse(test.args))
+		c := cli.NewContext(nil, set, nil)
+		assert.EqualError(t, CmdHostFailover(c), test.err)
+		removeFile(t, configFileName)
+	}
+}
+
+func TestCmdHostFailoverUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdHostFailover(c), "Usage: \"hostBuilder host failover {hostName} [IPName...]\"")
+}
+
+func TestCompleteHostFailover(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"goo", "foop"}))
+	os.Args = []string{"host", "failover", "goo", "foop", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteHostFailover(c)
+	assert.Equal(t, "baz\n", writer.String())
+}
+
+func TestCompleteHostFailoverHostName(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	os.Args = []string{"host", "failover", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteHostFailover(c)
+	assert.Equal(t, "bar\nbaz.com\ngoo\n", writer.String())
+}
@@ -0,0 +1,117 @@
+package command
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/urfave/cli"
+)
+
+// CmdHostHealthCheck sets or removes the health check of a host option
+func CmdHostHealthCheck(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return cli.NewExitError("Usage: \"hostBuilder host healthCheck {hostName} {IPName} {--tcp address|--http url|--command command|--remove}\"", 1)
+	}
+
+	hostName := c.Args().Get(0)
+	option := c.Args().Get(1)
+	check := config.Check{
+		TCP:     c.String("tcp"),
+		HTTP:    c.String("http"),
+		Status:  c.Int("status"),
+		Command: c.String("command"),
+		Timeout: c.String("timeout"),
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	err = validateParameters(configData, hostName, option)
+	if err != nil {
+		return err
+	}
+
+	host := configData.Hosts[hostName]
+	if c.Bool("remove") {
+		if _, exists := host.Checks[option]; !exists {
+			return cli.NewExitError(fmt.Sprintf("IPName %s has no health check", option), 1)
+		}
+
+		delete(host.Checks, option)
+		if len(host.Checks) == 0 {
+			host.Checks = nil
+		}
+	} else {
+		err = validateCheck(check)
+		if err != nil {
+			return err
+		}
+
+		if host.Checks == nil {
+			host.Checks = map[string]config.Check{}
+		}
+
+		host.Checks[option] = check
+	}
+
+	configData.Hosts[hostName] = host
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+func validateCheck(check config.Check) error {
+	kinds := 0
+	for _, value := range []string{check.TCP, check.HTTP, check.Command} {
+		if value != "" {
+			kinds++
+		}
+	}
+
+	if kinds != 1 {
+		return cli.NewExitError("Expected one of --tcp, --http or --command", 1)
+	}
+
+	if _, _, err := net.SplitHostPort(check.TCP); check.TCP != "" && err != nil {
+		return cli.NewExitError(fmt.Sprintf("Invalid address %s, expected host:port or :port", check.TCP), 1)
+	}
+
+	if parsed, err := url.Parse(check.HTTP); check.HTTP != "" && (err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https")) {
+		return cli.NewExitError(fmt.Sprintf("Invalid URL %s", check.HTTP), 1)
+	}
+
+	if check.Status != 0 && check.HTTP == "" {
+		return cli.NewExitError("--status only applies to --http checks", 1)
+	}
+
+	if _, err := parseCheckTimeout(check.Timeout); err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	return nil
+}
+
+// CompleteHostHealthCheck handles bash autocompletion for the 'host healthCheck' command
+func CompleteHostHealthCheck(c *cli.Context) {
+	configData, err := loadConfig(c)
+	if err != nil {
+		return
+	}
+
+	switch c.NArg() {
+	case 0:
+		fmt.Fprintln(c.App.Writer, strings.Join(sortHostNames(configData), "\n"))
+	case 1:
+		printIPs(configData, c.Args().Get(0), c.App.Writer)
+	default:
+		for _, flag := range c.App.Command("healthCheck").Flags {
+			name := strings.Split(flag.GetName(), ",")[0]
+			if !c.IsSet(name) {
+				fmt.Fprintf(c.App.Writer, "--%s\n", name)
+			}
+		}
+	}
+}
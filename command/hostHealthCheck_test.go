@@ -0,0 +1,133 @@
+package command
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdHostHealthCheck(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("http", "http://10.0.0.8/health", "doc")
+	set.Int("status", 204, "doc")
+	set.String("timeout", "2s", "doc")
+	assert.Nil(t, set.Parse([]string{"goo", "foop"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdHostHealthCheck(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]config.Check{"foop": {HTTP: "http://10.0.0.8/health", Status: 204, Timeout: "2s"}}, configData.Hosts["goo"].Checks)
+}
+
+func TestCmdHostHealthCheckReplace(t *testing.T) {
+	configFileName, set := setupFailoverConfigFile(t, config.Check{TCP: ":443"})
+	defer removeFile(t, configFileName)
+
+	set.String("command", "curl -sf http://$HOSTBUILDER_IP/", "doc")
+	assert.Nil(t, set.Parse([]string{"api.example.com", "local"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdHostHealthCheck(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]config.Check{"local": {Command: "curl -sf http://$HOSTBUILDER_IP/"}}, configData.Hosts["api.example.com"].Checks)
+}
+
+func TestCmdHostHealthCheckRemove(t *testing.T) {
+	configFileName, set := setupFailoverConfigFile(t, config.Check{TCP: ":443"})
+	defer removeFile(t, configFileName)
+
+	set.Bool("remove", true, "doc")
+	assert.Nil(t, set.Parse([]string{"api.example.com", "local"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdHostHealthCheck(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Nil(t, configData.Hosts["api.example.com"].Checks)
+
+	c = cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdHostHealthCheck(c), "IPName local has no health check")
+}
+
+func TestCmdHostHealthCheckInvalid(t *testing.T) {
+	tests := []struct {
+		flags map[string]string
+		args  []string
+		err   string
+	}{
+		{map[string]string{}, []string{"goo", "foop"}, "Expected one of --tcp, --http or --command"},
+		{map[string]string{"tcp": ":443", "command": "true"}, []string{"goo", "foop"}, "Expected one of --tcp, --http or --command"},
+		{map[string]string{"tcp": "10.0.0.8"}, []string{"goo", "foop"}, "Invalid address 10.0.0.8, expected host:port or :port"},
+		{map[string]string{"http": "10.0.0.8/health"}, []string{"goo", "foop"}, "Invalid URL 10.0.0.8/health"},
+		{map[string]string{"command": "true", "timeout": "soon"}, []string{"goo", "foop"}, "Invalid timeout soon"},
+		{map[string]string{"tcp": ":443"}, []string{"goo", "public"}, "IPName public does not exist"},
+		{map[string]string{"tcp": ":443"}, []string{"gone", "foop"}, "HostName gone does not exist"},
+	}
+
+	for _, test := range tests {
+		configFileName, set := setupBaseConfigFile(t)
+		for name, value := range test.flags {
+			set.String(name, value, "doc")
+		}
+
+		assert.Nil(t, set.Parse(test.args))
+		c := cli.NewContext(nil, set, nil)
+		assert.EqualError(t, CmdHostHealthCheck(c), test.err)
+		removeFile(t, configFileName)
+	}
+}
+
+func TestCmdHostHealthCheckStatusWithoutHTTP(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("tcp", ":443", "doc")
+	set.Int("status", 200, "doc")
+	assert.Nil(t, set.Parse([]string{"goo", "foop"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdHostHealthCheck(c), "--status only applies to --http checks")
+}
+
+func TestCmdHostHealthCheckUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"goo"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(
+		t,
+		CmdHostHealthCheck(c),
+		"Usage: \"hostBuilder host healthCheck {hostName} {IPName} {--tcp address|--http url|--command command|--remove}\"",
+	)
+}
+
+func TestCompleteHostHealthCheckIPName(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"goo"}))
+	os.Args = []string{"host", "healthCheck", "goo", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteHostHealthCheck(c)
+	assert.Equal(t, "foop:10.0.0.8\nbaz:10.0.0.4\nignore:\n", writer.String())
+}
+
+func TestCompleteHostHealthCheckFlags(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"goo", "foop"}))
+	os.Args = []string{"host", "healthCheck", "goo", "foop", "--completion"}
+	app, writer := appWithWriter()
+	app.Commands = []cli.Command{{Name: "healthCheck", Flags: []cli.Flag{cli.StringFlag{Name: "tcp"}, cli.BoolFlag{Name: "remove"}}}}
+	c := cli.NewContext(app, set, nil)
+	CompleteHostHealthCheck(c)
+	assert.Equal(t, "--tcp\n--remove\n", writer.String())
+}
@@ -22,6 +22,15 @@ func CmdHostRemove(c *cli.Context) error {
 		return err
 	}
 
+	err = removeHostOption(configData, hostName, IPName)
+	if err != nil {
+		return err
+	}
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+func removeHostOption(configData *config.HostsConfig, hostName, IPName string) error {
 	if _, exists := configData.Hosts[hostName]; !exists {
 		return cli.NewExitError(fmt.Sprintf("Host %s does not exist", hostName), 1)
 	}
@@ -32,13 +41,20 @@ func CmdHostRemove(c *cli.Context) error {
 
 	host := configData.Hosts[hostName]
 	delete(host.Options, IPName)
+	delete(host.Checks, IPName)
+	for index, option := range host.Failover {
+		if option == IPName {
+			host.Failover = append(host.Failover[:index:index], host.Failover[index+1:]...)
+			break
+		}
+	}
+
 	if len(host.Options) == 0 {
 		host.Current = hostIgnore
 	}
 
 	configData.Hosts[hostName] = host
-
-	return config.WriteConfig(c.GlobalString("config"), configData)
+	return nil
 }
 
 // CompleteHostRemove handles bash autocompletion for the 'host remove' command
@@ -25,6 +25,22 @@ func TestCmdHostRemove(t *testing.T) {
 	assert.Equal(t, expectedHost, modifiedConfigData.Hosts["goo"])
 }
 
+func TestCmdHostRemoveFailover(t *testing.T) {
+	configFileName, set := setupFailoverConfigFile(t, config.Check{TCP: ":443"})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"api.example.com", "local"}))
+
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdHostRemove(c))
+
+	modifiedConfigData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	expectedHost := config.Host{Current: "dev", Options: map[string]string{"dev": "10.0.0.9"}, Failover: []string{"dev"}}
+	assert.Equal(t, expectedHost, modifiedConfigData.Hosts["api.example.com"])
+}
+
 func TestCmdHostRemoveUsage(t *testing.T) {
 	set := flag.NewFlagSet("test", 0)
 
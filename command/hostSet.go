@@ -3,13 +3,15 @@ package command
 import (
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/guywithnose/hostBuilder/config"
 	"github.com/urfave/cli"
 )
 
-// CmdHostSet sets the current IP on a hostname
+// CmdHostSet sets the current IP on a hostname, with --for the previous IP is restored by the watch command once it expires
 func CmdHostSet(c *cli.Context) error {
 	if c.NArg() != 2 {
 		return cli.NewExitError("Usage: \"hostBuilder host set {hostName} {IPName}\"", 1)
@@ -17,22 +19,90 @@ func CmdHostSet(c *cli.Context) error {
 
 	hostName := c.Args().Get(0)
 	IPName := c.Args().Get(1)
+	duration := c.Duration("for")
+	if duration < 0 {
+		return cli.NewExitError(fmt.Sprintf("Invalid duration %s", duration), 1)
+	}
 
 	configData, err := loadConfig(c)
 	if err != nil {
 		return err
 	}
 
-	err = validateParameters(configData, hostName, IPName)
+	before := hostCurrents(configData)
+	override := configData.Hosts[hostName].Override
+	err = setHost(configData, hostName, IPName)
+	if err != nil {
+		return err
+	}
+
+	if duration != 0 {
+		// Overriding an override keeps the option that was chosen for good
+		previous := before[hostName]
+		if override != nil {
+			previous = override.Previous
+		}
+
+		host := configData.Hosts[hostName]
+		host.Override = &config.Override{Previous: previous, Until: now().Add(duration).UTC().Truncate(time.Second)}
+		configData.Hosts[hostName] = host
+		fmt.Fprintf(c.App.Writer, "%s reverts to %s at %s\n", hostName, previous, host.Override.Until.Format(time.RFC3339))
+	}
+
+	err = config.WriteConfig(c.GlobalString("config"), configData)
 	if err != nil {
 		return err
 	}
 
+	warnChangeHooks(configData, before, "", c.App.Writer, c.App.ErrWriter)
+	return nil
+}
+
+func setHost(configData *config.HostsConfig, hostName, IPName string) error {
+	err := validateParameters(configData, hostName, IPName)
+	if err != nil {
+		return err
+	}
+
+	// Choosing an option replaces a temporary one
 	host := configData.Hosts[hostName]
 	host.Current = IPName
+	host.Override = nil
 	configData.Hosts[hostName] = host
+	return nil
+}
+
+// revertExpiredOverrides restores the previous option of the hosts whose override expired at or before at
+// The hosts that were reverted are returned in order
+func revertExpiredOverrides(configData *config.HostsConfig, at time.Time) []string {
+	reverted := []string{}
+	for hostName, host := range configData.Hosts {
+		if host.Override == nil || host.Override.Until.After(at) {
+			continue
+		}
+
+		host.Current = host.Override.Previous
+		host.Override = nil
+		configData.Hosts[hostName] = host
+		reverted = append(reverted, hostName)
+	}
+
+	sort.Strings(reverted)
+	return reverted
+}
+
+// nextExpiry is when the first override expires
+func nextExpiry(configData *config.HostsConfig) (time.Time, bool) {
+	var next time.Time
+	found := false
+	for _, host := range configData.Hosts {
+		if host.Override != nil && (!found || host.Override.Until.Before(next)) {
+			next = host.Override.Until
+			found = true
+		}
+	}
 
-	return config.WriteConfig(c.GlobalString("config"), configData)
+	return next, found
 }
 
 func validateParameters(configData *config.HostsConfig, hostName, IPName string) error {
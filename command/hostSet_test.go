@@ -3,6 +3,7 @@ package command
 import (
 	"flag"
 	"testing"
+	"time"
 
 	"github.com/guywithnose/hostBuilder/config"
 	"github.com/stretchr/testify/assert"
@@ -14,7 +15,7 @@ func TestCmdHostSet(t *testing.T) {
 	defer removeFile(t, configFileName)
 	assert.Nil(t, set.Parse([]string{"baz.com", "bazz"}))
 
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	assert.Nil(t, CmdHostSet(c))
 
 	modifiedConfigData, err := config.LoadConfigFromFile(configFileName)
@@ -23,6 +24,76 @@ func TestCmdHostSet(t *testing.T) {
 	assert.Equal(t, "bazz", modifiedConfigData.Hosts["baz.com"].Current, "baz.com was not set to baz")
 }
 
+func TestCmdHostSetFor(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	set.Duration("for", 2*time.Hour, "doc")
+	assert.Nil(t, set.Parse([]string{"goo", "baz"}))
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdHostSet(c))
+	assert.Equal(t, "goo reverts to foop at 2017-11-01T14:00:00Z\n", writer.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "baz", configData.Hosts["goo"].Current)
+	assert.Equal(t, &config.Override{Previous: "foop", Until: testImportedAt.Add(2 * time.Hour)}, configData.Hosts["goo"].Override)
+
+	// Another temporary choice still reverts to the option chosen for good
+	set = flag.NewFlagSet("test", 0)
+	set.String("config", configFileName, "doc")
+	set.Duration("for", time.Hour, "doc")
+	assert.Nil(t, set.Parse([]string{"goo", "ignore"}))
+	app, writer = appWithWriter()
+	assert.Nil(t, CmdHostSet(cli.NewContext(app, set, nil)))
+	assert.Equal(t, "goo reverts to foop at 2017-11-01T13:00:00Z\n", writer.String())
+
+	// A choice without --for is kept
+	set = flag.NewFlagSet("test", 0)
+	set.String("config", configFileName, "doc")
+	assert.Nil(t, set.Parse([]string{"goo", "baz"}))
+	assert.Nil(t, CmdHostSet(cli.NewContext(cli.NewApp(), set, nil)))
+
+	configData, err = config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "baz", configData.Hosts["goo"].Current)
+	assert.Nil(t, configData.Hosts["goo"].Override)
+}
+
+func TestCmdHostSetInvalidFor(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	set.Duration("for", -time.Hour, "doc")
+	assert.Nil(t, set.Parse([]string{"goo", "baz"}))
+	assert.EqualError(t, CmdHostSet(cli.NewContext(nil, set, nil)), "Invalid duration -1h0m0s")
+}
+
+func TestRevertExpiredOverrides(t *testing.T) {
+	configData := &config.HostsConfig{
+		Hosts: map[string]config.Host{
+			"a.com": {Current: "temp", Override: &config.Override{Previous: "prod", Until: testImportedAt}},
+			"b.com": {Current: "temp", Override: &config.Override{Previous: "dev", Until: testImportedAt.Add(time.Hour)}},
+			"c.com": {Current: "prod"},
+		},
+	}
+
+	next, found := nextExpiry(configData)
+	assert.True(t, found)
+	assert.Equal(t, testImportedAt, next)
+
+	assert.Equal(t, []string{"a.com"}, revertExpiredOverrides(configData, testImportedAt))
+	assert.Equal(t, config.Host{Current: "prod"}, configData.Hosts["a.com"])
+	assert.Equal(t, "temp", configData.Hosts["b.com"].Current)
+
+	next, found = nextExpiry(configData)
+	assert.True(t, found)
+	assert.Equal(t, testImportedAt.Add(time.Hour), next)
+
+	assert.Equal(t, []string{"b.com"}, revertExpiredOverrides(configData, testImportedAt.Add(2*time.Hour)))
+	_, found = nextExpiry(configData)
+	assert.False(t, found)
+}
+
 func TestCmdHostSetUsage(t *testing.T) {
 	c := cli.NewContext(nil, flag.NewFlagSet("test", 0), nil)
 	err := CmdHostSet(c)
@@ -33,7 +104,7 @@ func TestCmdHostSetNoConfigFile(t *testing.T) {
 	set := flag.NewFlagSet("test", 0)
 	assert.Nil(t, set.Parse([]string{"baz.com", "bazz"}))
 
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	err := CmdHostSet(c)
 	assert.EqualError(t, err, "You must specify a config file")
 }
@@ -43,7 +114,7 @@ func TestCmdHostSetBadConfigFile(t *testing.T) {
 	assert.Nil(t, set.Parse([]string{"baz.com", "bazz"}))
 
 	set.String("config", "/doesntexist", "doc")
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	err := CmdHostSet(c)
 	assert.EqualError(t, err, "open /doesntexist: no such file or directory")
 }
@@ -52,7 +123,7 @@ func TestCmdHostSetBadHostName(t *testing.T) {
 	configFileName, set := setupBaseConfigFile(t)
 	defer removeFile(t, configFileName)
 	assert.Nil(t, set.Parse([]string{"food", "baz"}))
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	err := CmdHostSet(c)
 	assert.EqualError(t, err, "HostName food does not exist")
 }
@@ -61,7 +132,7 @@ func TestCmdHostSetBadIPName(t *testing.T) {
 	configFileName, set := setupBaseConfigFile(t)
 	defer removeFile(t, configFileName)
 	assert.Nil(t, set.Parse([]string{"baz.com", "bar"}))
-	c := cli.NewContext(nil, set, nil)
+	c := cli.NewContext(cli.NewApp(), set, nil)
 	err := CmdHostSet(c)
 	assert.EqualError(t, err, "IPName bar does not exist")
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"github.com/guywithnose/hostBuilder/config"
 	"github.com/urfave/cli"
@@ -32,9 +33,43 @@ func CmdHostShow(c *cli.Context) error {
 		pringtGlobalIPInfo(configData, hostName, c.App.Writer)
 	}
 
+	if override := configData.Hosts[hostName].Override; override != nil {
+		fmt.Fprintf(c.App.Writer, "Reverts to %s at %s\n", override.Previous, override.Until.Format(time.RFC3339))
+	}
+
+	printFailover(configData.Hosts[hostName], c.App.Writer)
 	return nil
 }
 
+func printFailover(host config.Host, writer io.Writer) {
+	if len(host.Failover) != 0 {
+		fmt.Fprintf(writer, "Failover: %s\n", strings.Join(host.Failover, ", "))
+	}
+
+	for _, option := range checkedOptions(host) {
+		check, exists := host.Checks[option]
+		if !exists {
+			continue
+		}
+
+		description := fmt.Sprintf("tcp %s", check.TCP)
+		if check.HTTP != "" {
+			description = fmt.Sprintf("http %s", check.HTTP)
+			if check.Status != 0 {
+				description += fmt.Sprintf(" (status %d)", check.Status)
+			}
+		} else if check.Command != "" {
+			description = fmt.Sprintf("command %s", check.Command)
+		}
+
+		if check.Timeout != "" {
+			description += fmt.Sprintf(" (timeout %s)", check.Timeout)
+		}
+
+		fmt.Fprintf(writer, "Health check %s: %s\n", option, description)
+	}
+}
+
 func pringtGlobalIPInfo(configData *config.HostsConfig, hostName string, writer io.Writer) {
 	if IP, exists := configData.GlobalIPs[configData.Hosts[hostName].Current]; exists {
 		fmt.Fprintf(writer, "Current: Global IP %s => %s\n", configData.Hosts[hostName].Current, IP)
@@ -59,6 +94,10 @@ func printOptions(configData *config.HostsConfig, hostName string, writer io.Wri
 	fmt.Fprintf(writer, "%d Option%s:\n", numOptions, pluralSuffix)
 	for _, option := range sortOptions(configData, hostName) {
 		IP := configData.Hosts[hostName].Options[option]
+		if notes := provenanceNotes(configData.Hosts[hostName].Provenance[option]); notes != "" {
+			IP += fmt.Sprintf(" (%s)", notes)
+		}
+
 		if option == configData.Hosts[hostName].Current {
 			fmt.Fprintf(writer, "*%s => %s*\n", option, IP)
 			found = true
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"testing"
 
+	"github.com/guywithnose/hostBuilder/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli"
 )
@@ -34,6 +35,39 @@ func TestCmdHostShowGlobalIP(t *testing.T) {
 	assert.Equal(t, "1 Option:\nbazz => 10.0.0.7\nCurrent: Global IP baz => 10.0.0.4\n", writer.String())
 }
 
+func TestCmdHostShowFailover(t *testing.T) {
+	configFileName, set := setupFailoverConfigFile(t, config.Check{HTTP: "http://127.0.0.1:8080/health", Status: 204, Timeout: "1s"})
+	defer removeFile(t, configFileName)
+	err := set.Parse([]string{"api.example.com"})
+	assert.Nil(t, err)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdHostShow(c))
+
+	assert.Equal(
+		t,
+		"2 Options:\n*dev => 10.0.0.9*\nlocal => 127.0.0.1\nFailover: local, dev\nHealth check local: http http://127.0.0.1:8080/health (status 204) (timeout 1s)\n",
+		writer.String(),
+	)
+}
+
+func TestCmdHostShowOverride(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	host := configData.Hosts["goo"]
+	host.Override = &config.Override{Previous: "baz", Until: testImportedAt}
+	configData.Hosts["goo"] = host
+	assert.Nil(t, config.WriteConfig(configFileName, configData))
+	assert.Nil(t, set.Parse([]string{"goo"}))
+
+	app, writer := appWithWriter()
+	assert.Nil(t, CmdHostShow(cli.NewContext(app, set, nil)))
+	assert.Equal(t, "1 Option:\n*foop => 10.0.0.8*\nReverts to baz at 2017-11-01T12:00:00Z\n", writer.String())
+}
+
 func TestCmdHostShowGlobalUnknown(t *testing.T) {
 	configFileName := setupInvalidConfigFile(t)
 	defer removeFile(t, configFileName)
@@ -0,0 +1,125 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/guywithnose/hostBuilder/ansible"
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/urfave/cli"
+)
+
+type ansibleHost struct {
+	Name    string
+	Address string
+	Vars    map[string]string
+}
+
+// CmdImportAnsible imports hosts and groups from an ansible inventory
+func CmdImportAnsible(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Usage: \"hostBuilder import ansible {inventoryFile}\"", 1)
+	}
+
+	templateString := c.String("template")
+	if templateString == "" {
+		templateString = "{{.Name}}"
+	}
+
+	templ, err := template.New("").Parse(templateString)
+	if err != nil {
+		return err
+	}
+
+	policy, err := flagStalePolicy(c)
+	if err != nil {
+		return err
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	inventoryFile := c.Args().Get(0)
+	inventory, err := ansible.ReadInventoryFile(inventoryFile)
+	if err != nil {
+		return err
+	}
+
+	source := c.String("source")
+	if source == "" {
+		source = fmt.Sprintf("ansible:%s", inventoryFile)
+	}
+
+	addresses, err := inventoryAddresses(inventory, templ, c.String("option"), c.App.ErrWriter)
+	if err != nil {
+		return err
+	}
+
+	changes := mergeAddresses(configData, importOrigin(source, "ansible"), addresses, policy, c.App.Writer, c.App.ErrWriter)
+	changes += mergeAnsibleGroups(configData, inventory, c.App.Writer, c.App.ErrWriter)
+	if changes == 0 {
+		fmt.Fprintln(c.App.Writer, "Nothing to import")
+	}
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+func inventoryAddresses(inventory *ansible.Inventory, templ *template.Template, option string, errWriter io.Writer) ([]provider.Address, error) {
+	addresses := make([]provider.Address, 0, len(inventory.Hosts))
+	for _, hostName := range inventory.HostNames() {
+		address, explicit := inventory.Address(hostName)
+		if !explicit {
+			continue
+		}
+
+		IP, err := resolveAddress(address)
+		if err != nil {
+			fmt.Fprintf(errWriter, "Warning: %v, skipping %s\n", err, hostName)
+			continue
+		}
+
+		if option != "" {
+			addresses = append(addresses, provider.Address{Host: hostName, Name: option, IP: IP})
+			continue
+		}
+
+		name, err := executeTemplate(templ, ansibleHost{Name: hostName, Address: address, Vars: inventory.Hosts[hostName]})
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, provider.Address{Name: name, IP: IP})
+	}
+
+	return addresses, nil
+}
+
+// mergeAnsibleGroups adds every configured hostname to the groups it belongs to in the inventory
+// Groups only hold hostnames, so inventory hosts imported as global IPs are reported instead of being dropped
+func mergeAnsibleGroups(configData *config.HostsConfig, inventory *ansible.Inventory, writer, errWriter io.Writer) int {
+	changes := 0
+	for _, groupName := range inventory.GroupNames() {
+		for _, hostName := range inventory.Groups[groupName] {
+			if _, exists := configData.Hosts[hostName]; !exists {
+				fmt.Fprintf(errWriter, "Warning: %s is not a configured hostname, not adding it to group %s\n", hostName, groupName)
+				continue
+			}
+
+			if addHostToGroup(configData, groupName, hostName) {
+				fmt.Fprintf(writer, "Added %s to group %s\n", hostName, groupName)
+				changes++
+			}
+		}
+	}
+
+	return changes
+}
+
+// CompleteImportAnsible handles bash autocompletion for the 'import ansible' command
+func CompleteImportAnsible(c *cli.Context) {
+	completeImportCommand(c, "ansible")
+}
@@ -0,0 +1,216 @@
+package command
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+const testAnsibleInventory = `mail.example.com ansible_host=10.0.1.1
+unmanaged.example.com
+
+[web]
+goo ansible_host=10.0.1.8
+baz.com ansible_host=10.0.0.7
+broken.example.com ansible_host=10.0.0.256
+`
+
+func setupAnsibleInventoryFile(t *testing.T) string {
+	inventoryFile, err := ioutil.TempFile("/tmp", "inventory")
+	assert.Nil(t, err)
+
+	err = ioutil.WriteFile(inventoryFile.Name(), []byte(testAnsibleInventory), 0644)
+	assert.Nil(t, err)
+
+	return inventoryFile.Name()
+}
+
+func TestCmdImportAnsibleGlobalIPs(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	inventoryFileName := setupAnsibleInventoryFile(t)
+	defer removeFile(t, inventoryFileName)
+	set.String("template", "ansible-{{.Name}}", "doc")
+	assert.Nil(t, set.Parse([]string{inventoryFileName}))
+
+	app, writer := appWithWriter()
+	errWriter := new(strings.Builder)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportAnsible(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	expectedIPs := map[string]string{
+		"baz":                      "10.0.0.4",
+		"ansible-baz.com":          "10.0.0.7",
+		"ansible-goo":              "10.0.1.8",
+		"ansible-mail.example.com": "10.0.1.1",
+	}
+	assert.Equal(t, expectedIPs, configData.GlobalIPs)
+	assert.Equal(t, []string{"goo", "baz.com"}, configData.Groups["web"])
+	assert.Equal(
+		t,
+		"Added global IP ansible-baz.com (10.0.0.7)\n"+
+			"Added global IP ansible-goo (10.0.1.8)\n"+
+			"Added global IP ansible-mail.example.com (10.0.1.1)\n"+
+			"Added goo to group web\n"+
+			"Added baz.com to group web\n",
+		writer.String(),
+	)
+	assert.Equal(
+		t,
+		"Warning: Unable to resolve 10.0.0.256, skipping broken.example.com\n"+
+			"Warning: broken.example.com is not a configured hostname, not adding it to group web\n",
+		errWriter.String(),
+	)
+}
+
+func TestCmdImportAnsibleGroupsOfGlobalIPs(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	inventoryFile, err := ioutil.TempFile("/tmp", "inventory")
+	assert.Nil(t, err)
+	defer removeFile(t, inventoryFile.Name())
+	assert.Nil(t, ioutil.WriteFile(inventoryFile.Name(), []byte("[db]\ndb1 ansible_host=10.0.2.1\ndb2 ansible_host=10.0.2.2\n"), 0644))
+	assert.Nil(t, set.Parse([]string{inventoryFile.Name()}))
+
+	app, writer := appWithWriter()
+	errWriter := new(strings.Builder)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportAnsible(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]string{"baz": "10.0.0.4", "db1": "10.0.2.1", "db2": "10.0.2.2"}, configData.GlobalIPs)
+	assert.NotContains(t, configData.Groups, "db")
+	assert.Equal(t, "Added global IP db1 (10.0.2.1)\nAdded global IP db2 (10.0.2.2)\n", writer.String())
+	assert.Equal(
+		t,
+		"Warning: db1 is not a configured hostname, not adding it to group db\n"+
+			"Warning: db2 is not a configured hostname, not adding it to group db\n",
+		errWriter.String(),
+	)
+}
+
+func TestCmdImportAnsibleHostOptions(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	inventoryFileName := setupAnsibleInventoryFile(t)
+	defer removeFile(t, inventoryFileName)
+	set.String("option", "ansible", "doc")
+	assert.Nil(t, set.Parse([]string{inventoryFileName}))
+
+	app, writer := appWithWriter()
+	app.ErrWriter = new(strings.Builder)
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportAnsible(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	source := map[string]config.Provenance{
+		"ansible": {Source: "ansible:" + inventoryFileName, Provider: "ansible", ImportedAt: &testImportedAt, FetchedAt: &testImportedAt},
+	}
+	assert.Equal(t, config.Host{Current: "ansible", Options: map[string]string{"ansible": "10.0.1.1"}, Provenance: source}, configData.Hosts["mail.example.com"])
+	assert.Equal(t, map[string]string{"foop": "10.0.0.8", "ansible": "10.0.1.8"}, configData.Hosts["goo"].Options)
+	assert.Equal(t, "foop", configData.Hosts["goo"].Current)
+	assert.Equal(t, []string{"goo", "baz.com"}, configData.Groups["web"])
+	assert.Equal(
+		t,
+		"Added option to baz.com (ansible => 10.0.0.7)\n"+
+			"Added option to goo (ansible => 10.0.1.8)\n"+
+			"Added host mail.example.com (ansible => 10.0.1.1)\n"+
+			"Added goo to group web\n"+
+			"Added baz.com to group web\n",
+		writer.String(),
+	)
+}
+
+func TestCmdImportAnsibleNothingToImport(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	inventoryFile, err := ioutil.TempFile("/tmp", "inventory")
+	assert.Nil(t, err)
+	defer removeFile(t, inventoryFile.Name())
+	assert.Nil(t, ioutil.WriteFile(inventoryFile.Name(), []byte("[web]\nunmanaged.example.com\n"), 0644))
+	assert.Nil(t, set.Parse([]string{inventoryFile.Name()}))
+
+	app, writer := appWithWriter()
+	errWriter := new(strings.Builder)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportAnsible(c))
+
+	assert.Equal(t, "Nothing to import\n", writer.String())
+	assert.Equal(t, "Warning: unmanaged.example.com is not a configured hostname, not adding it to group web\n", errWriter.String())
+}
+
+func TestCmdImportAnsibleTemplateError(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	inventoryFileName := setupAnsibleInventoryFile(t)
+	defer removeFile(t, inventoryFileName)
+	set.String("template", "{{.Missing}}", "doc")
+	assert.Nil(t, set.Parse([]string{inventoryFileName}))
+
+	app, _ := appWithWriter()
+	app.ErrWriter = new(strings.Builder)
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(
+		t,
+		CmdImportAnsible(c),
+		"template: :1:2: executing \"\" at <.Missing>: can't evaluate field Missing in type command.ansibleHost",
+	)
+}
+
+func TestCmdImportAnsibleBadTemplate(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	set.String("template", "{{.badTemplate", "doc")
+	assert.Nil(t, set.Parse([]string{"inventory"}))
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportAnsible(c), "template: :1: unclosed action")
+}
+
+func TestCmdImportAnsibleMissingInventory(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	assert.Nil(t, set.Parse([]string{"/tmp/doesntexist.ini"}))
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportAnsible(c), "open /tmp/doesntexist.ini: no such file or directory")
+}
+
+func TestCmdImportAnsibleNoConfig(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"inventory"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportAnsible(c), "You must specify a config file")
+}
+
+func TestCmdImportAnsibleUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportAnsible(c), "Usage: \"hostBuilder import ansible {inventoryFile}\"")
+}
+
+func TestCompleteImportAnsible(t *testing.T) {
+	os.Args = []string{"hostBuilder", "import", "ansible", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteImportAnsible(c)
+
+	assert.Equal(t, "fileCompletion\n", writer.String())
+}
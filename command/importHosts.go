@@ -0,0 +1,319 @@
+package command
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/hosts"
+	"github.com/urfave/cli"
+)
+
+const (
+	strategyNew       = "new"
+	strategyOverwrite = "overwrite"
+	strategySkip      = "skip"
+	strategyAsk       = "ask"
+)
+
+// inputMetadata is the app metadata key for the reader importers take answers and piped documents from
+const inputMetadata = "input"
+
+type hostsImporter struct {
+	configData *config.HostsConfig
+	strategy   string
+	templ      *template.Template
+	group      string
+	added      map[string]bool
+	input      *bufio.Reader
+	writer     io.Writer
+	summary    []string
+}
+
+// CmdImportHosts merges a hosts file into an existing configuration
+func CmdImportHosts(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Usage: \"hostBuilder import hosts {hostsFile}\"", 1)
+	}
+
+	strategy := c.String("strategy")
+	if strategy == "" {
+		strategy = strategyNew
+	}
+
+	if !isValidStrategy(strategy) {
+		return cli.NewExitError(fmt.Sprintf("Invalid strategy %s", strategy), 1)
+	}
+
+	templateString := c.String("template")
+	if templateString == "" {
+		templateString = "imported"
+	}
+
+	templ, err := template.New("").Parse(templateString)
+	if err != nil {
+		return err
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	entries, err := hosts.ReadHostsFileEntries(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	importer := &hostsImporter{
+		configData: configData,
+		strategy:   strategy,
+		templ:      templ,
+		group:      c.String("group"),
+		added:      map[string]bool{},
+		input:      bufio.NewReader(commandInput(c)),
+		writer:     c.App.Writer,
+	}
+
+	for _, entry := range entries {
+		err = importer.importEntry(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	importer.printSummary()
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+// commandInput returns the reader set in the app metadata, or stdin when there is none
+func commandInput(c *cli.Context) io.Reader {
+	if c.App != nil {
+		if input, ok := c.App.Metadata[inputMetadata].(io.Reader); ok {
+			return input
+		}
+	}
+
+	return os.Stdin
+}
+
+// importDefaultEntry records the local hostname and IPv6 entries hostBuilder writes itself
+// It reports whether the entry was one of those defaults and should not be imported as a host
+func (importer *hostsImporter) importDefaultEntry(entry hosts.Entry) bool {
+	if entry.IP == "127.0.1.1" {
+		importer.addLocalHostname(entry.Hostname)
+		return true
+	}
+
+	if strings.HasPrefix(entry.Hostname, "ip6-") {
+		importer.configData.IPv6Defaults = true
+		return true
+	}
+
+	return isLoopbackDefault(entry)
+}
+
+// isLoopbackDefault reports whether the entry is one of the localhost names every hosts file maps to a loopback address
+func isLoopbackDefault(entry hosts.Entry) bool {
+	IP := net.ParseIP(entry.IP)
+	return IP != nil && IP.IsLoopback() && strings.HasPrefix(entry.Hostname, "localhost")
+}
+
+func isValidStrategy(strategy string) bool {
+	for _, validStrategy := range []string{strategyNew, strategyOverwrite, strategySkip, strategyAsk} {
+		if strategy == validStrategy {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (importer *hostsImporter) importEntry(entry hosts.Entry) error {
+	if importer.importDefaultEntry(entry) {
+		return nil
+	}
+
+	IPName, err := importer.optionName(entry)
+	if err != nil {
+		return err
+	}
+
+	if importer.configData.Hosts == nil {
+		importer.configData.Hosts = map[string]config.Host{}
+	}
+
+	host, exists := importer.configData.Hosts[entry.Hostname]
+	if !exists {
+		importer.configData.Hosts[entry.Hostname] = config.Host{Current: IPName, Options: map[string]string{IPName: entry.IP}}
+		importer.markAdded(entry.Hostname, IPName)
+		importer.report("Added host %s (%s => %s)", entry.Hostname, IPName, entry.IP)
+		importer.addToGroup(entry.Hostname)
+		return nil
+	}
+
+	if host.Options == nil {
+		host.Options = map[string]string{}
+		importer.configData.Hosts[entry.Hostname] = host
+	}
+
+	for _, IP := range host.Options {
+		if IP == entry.IP {
+			importer.addToGroup(entry.Hostname)
+			return nil
+		}
+	}
+
+	current, conflict := host.Options[IPName]
+	if !conflict {
+		importer.addOption(entry, IPName)
+		return nil
+	}
+
+	strategy := importer.strategy
+	if importer.added[entry.Hostname+" "+IPName] {
+		strategy = strategyNew
+	} else if strategy == strategyAsk {
+		strategy, err = importer.ask(entry, IPName, current)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch strategy {
+	case strategyOverwrite:
+		host.Options[IPName] = entry.IP
+		importer.report("Overwrote %s (%s: %s => %s)", entry.Hostname, IPName, current, entry.IP)
+	case strategySkip:
+		importer.report("Skipped %s (%s => %s conflicts with %s)", entry.Hostname, IPName, entry.IP, current)
+		return nil
+	default:
+		importer.addOption(entry, uniqueOptionName(host, IPName))
+	}
+
+	importer.addToGroup(entry.Hostname)
+	return nil
+}
+
+func (importer *hostsImporter) optionName(entry hosts.Entry) (string, error) {
+	if fields := strings.Fields(entry.Comment); len(fields) > 0 {
+		return fields[0], nil
+	}
+
+	return executeTemplate(importer.templ, entry)
+}
+
+func (importer *hostsImporter) addOption(entry hosts.Entry, IPName string) {
+	importer.configData.Hosts[entry.Hostname].Options[IPName] = entry.IP
+	importer.markAdded(entry.Hostname, IPName)
+	importer.report("Added option to %s (%s => %s)", entry.Hostname, IPName, entry.IP)
+	importer.addToGroup(entry.Hostname)
+}
+
+func (importer *hostsImporter) markAdded(hostName, IPName string) {
+	importer.added[hostName+" "+IPName] = true
+}
+
+func uniqueOptionName(host config.Host, IPName string) string {
+	for i := 2; ; i++ {
+		name := fmt.Sprintf("%s%d", IPName, i)
+		if _, exists := host.Options[name]; !exists {
+			return name
+		}
+	}
+}
+
+func (importer *hostsImporter) ask(entry hosts.Entry, IPName, current string) (string, error) {
+	for {
+		fmt.Fprintf(
+			importer.writer,
+			"%s already has %s => %s, import %s as [n]ew option, [o]verwrite or [s]kip? ",
+			entry.Hostname,
+			IPName,
+			current,
+			entry.IP,
+		)
+		answer, err := importer.input.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		switch {
+		case answer == "n" || answer == strategyNew:
+			return strategyNew, nil
+		case answer == "o" || answer == strategyOverwrite:
+			return strategyOverwrite, nil
+		case answer == "s" || answer == strategySkip:
+			return strategySkip, nil
+		}
+
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+func (importer *hostsImporter) addLocalHostname(hostName string) {
+	for _, localHostname := range importer.configData.LocalHostnames {
+		if localHostname == hostName {
+			return
+		}
+	}
+
+	importer.configData.LocalHostnames = append(importer.configData.LocalHostnames, hostName)
+	importer.report("Added local hostname %s", hostName)
+}
+
+func (importer *hostsImporter) addToGroup(hostName string) {
+	if importer.group != "" && addHostToGroup(importer.configData, importer.group, hostName) {
+		importer.report("Added %s to group %s", hostName, importer.group)
+	}
+}
+
+func (importer *hostsImporter) report(format string, args ...interface{}) {
+	importer.summary = append(importer.summary, fmt.Sprintf(format, args...))
+}
+
+func (importer *hostsImporter) printSummary() {
+	if len(importer.summary) == 0 {
+		fmt.Fprintln(importer.writer, "Nothing to import")
+		return
+	}
+
+	for _, line := range importer.summary {
+		fmt.Fprintln(importer.writer, line)
+	}
+}
+
+// CompleteImportHosts handles bash autocompletion for the 'import hosts' command
+func CompleteImportHosts(c *cli.Context) {
+	lastParam := os.Args[len(os.Args)-2]
+	if lastParam == "--strategy" {
+		fmt.Fprintln(c.App.Writer, strings.Join([]string{strategyNew, strategyOverwrite, strategySkip, strategyAsk}, "\n"))
+		return
+	}
+
+	if lastParam == "--group" {
+		configData, err := loadConfig(c)
+		if err == nil {
+			fmt.Fprintln(c.App.Writer, strings.Join(sortGroupNames(configData), "\n"))
+		}
+
+		return
+	}
+
+	if c.NArg() == 0 {
+		fmt.Fprintln(c.App.Writer, "fileCompletion")
+		return
+	}
+
+	for _, flag := range c.App.Command("hosts").Flags {
+		name := strings.Split(flag.GetName(), ",")[0]
+		if !c.IsSet(name) {
+			fmt.Fprintf(c.App.Writer, "--%s\n", name)
+		}
+	}
+}
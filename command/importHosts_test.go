@@ -0,0 +1,327 @@
+package command
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func setupImportHostsFile(t *testing.T, hostsLines string) string {
+	hostsFile, err := ioutil.TempFile("/tmp", "hosts")
+	assert.Nil(t, err)
+
+	err = ioutil.WriteFile(hostsFile.Name(), []byte(hostsLines), 0644)
+	assert.Nil(t, err)
+
+	return hostsFile.Name()
+}
+
+func TestCmdImportHosts(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupImportHostsFile(t, `10.0.0.8 goo #foop
+10.0.0.9 goo #foop
+10.0.0.1 new.com #dev
+10.0.0.2 new.com
+10.0.0.3 new.com
+127.0.1.1 myhost
+127.0.0.1 localhost
+`)
+	defer removeFile(t, hostsFileName)
+	assert.Nil(t, set.Parse([]string{hostsFileName}))
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportHosts(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	expectedHosts := map[string]config.Host{
+		"bar":     {Current: hostIgnore, Options: map[string]string{}},
+		"baz.com": {Current: "baz", Options: map[string]string{"bazz": "10.0.0.7"}},
+		"goo":     {Current: "foop", Options: map[string]string{"foop": "10.0.0.8", "foop2": "10.0.0.9"}},
+		"new.com": {Current: "dev", Options: map[string]string{"dev": "10.0.0.1", "imported": "10.0.0.2", "imported2": "10.0.0.3"}},
+	}
+	assert.Equal(t, expectedHosts, configData.Hosts)
+	assert.Equal(t, []string{"myhost"}, configData.LocalHostnames)
+	assert.Equal(
+		t,
+		"Added option to goo (foop2 => 10.0.0.9)\n"+
+			"Added host new.com (dev => 10.0.0.1)\n"+
+			"Added option to new.com (imported => 10.0.0.2)\n"+
+			"Added option to new.com (imported2 => 10.0.0.3)\n"+
+			"Added local hostname myhost\n",
+		writer.String(),
+	)
+}
+
+func TestCmdImportHostsOverwrite(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupImportHostsFile(t, "10.0.0.9 goo #foop\n")
+	defer removeFile(t, hostsFileName)
+	set.String("strategy", "overwrite", "doc")
+	assert.Nil(t, set.Parse([]string{hostsFileName}))
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportHosts(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]string{"foop": "10.0.0.9"}, configData.Hosts["goo"].Options)
+	assert.Equal(t, "Overwrote goo (foop: 10.0.0.8 => 10.0.0.9)\n", writer.String())
+}
+
+func TestCmdImportHostsSkip(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupImportHostsFile(t, "10.0.0.9 goo #foop\n")
+	defer removeFile(t, hostsFileName)
+	set.String("strategy", "skip", "doc")
+	assert.Nil(t, set.Parse([]string{hostsFileName}))
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportHosts(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]string{"foop": "10.0.0.8"}, configData.Hosts["goo"].Options)
+	assert.Equal(t, "Skipped goo (foop => 10.0.0.9 conflicts with 10.0.0.8)\n", writer.String())
+}
+
+func TestCmdImportHostsAsk(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupImportHostsFile(t, "10.0.0.9 goo #foop\n10.0.0.6 baz.com #bazz\n")
+	defer removeFile(t, hostsFileName)
+	set.String("strategy", "ask", "doc")
+	assert.Nil(t, set.Parse([]string{hostsFileName}))
+
+	app, writer := appWithWriter()
+	app.Metadata = map[string]interface{}{inputMetadata: strings.NewReader("maybe\no\ns\n")}
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportHosts(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]string{"foop": "10.0.0.9"}, configData.Hosts["goo"].Options)
+	assert.Equal(t, map[string]string{"bazz": "10.0.0.7"}, configData.Hosts["baz.com"].Options)
+	prompt := "goo already has foop => 10.0.0.8, import 10.0.0.9 as [n]ew option, [o]verwrite or [s]kip? "
+	assert.Equal(
+		t,
+		prompt+prompt+
+			"baz.com already has bazz => 10.0.0.7, import 10.0.0.6 as [n]ew option, [o]verwrite or [s]kip? "+
+			"Overwrote goo (foop: 10.0.0.8 => 10.0.0.9)\n"+
+			"Skipped baz.com (bazz => 10.0.0.6 conflicts with 10.0.0.7)\n",
+		writer.String(),
+	)
+}
+
+func TestCmdImportHostsAskNoAnswer(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupImportHostsFile(t, "10.0.0.9 goo #foop\n")
+	defer removeFile(t, hostsFileName)
+	set.String("strategy", "ask", "doc")
+	assert.Nil(t, set.Parse([]string{hostsFileName}))
+
+	app, _ := appWithWriter()
+	app.Metadata = map[string]interface{}{inputMetadata: strings.NewReader("")}
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdImportHosts(c), "EOF")
+}
+
+func TestCmdImportHostsGroupAndTemplate(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupImportHostsFile(t, "10.0.0.1 new.com\n10.0.0.8 goo\n")
+	defer removeFile(t, hostsFileName)
+	set.String("group", "teamB", "doc")
+	set.String("template", "teamB-{{.IP}}", "doc")
+	assert.Nil(t, set.Parse([]string{hostsFileName}))
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportHosts(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	expectedHost := config.Host{Current: "teamB-10.0.0.1", Options: map[string]string{"teamB-10.0.0.1": "10.0.0.1"}}
+	assert.Equal(t, expectedHost, configData.Hosts["new.com"])
+	assert.Equal(t, []string{"new.com", "goo"}, configData.Groups["teamB"])
+	assert.Equal(
+		t,
+		"Added host new.com (teamB-10.0.0.1 => 10.0.0.1)\nAdded new.com to group teamB\nAdded goo to group teamB\n",
+		writer.String(),
+	)
+}
+
+func TestCmdImportHostsNothingToImport(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupImportHostsFile(t, "10.0.0.8 goo\n127.0.0.1 localhost\n")
+	defer removeFile(t, hostsFileName)
+	assert.Nil(t, set.Parse([]string{hostsFileName}))
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportHosts(c))
+
+	assert.Equal(t, "Nothing to import\n", writer.String())
+}
+
+func TestCmdImportHostsStockHostsFile(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupImportHostsFile(t, `127.0.0.1	localhost localhost.localdomain
+127.0.1.1	myhost
+127.0.0.1	www.example.com
+
+# The following lines are desirable for IPv6 capable hosts
+::1     localhost ip6-localhost ip6-loopback
+fe00::0 ip6-localnet
+ff00::0 ip6-mcastprefix
+ff02::1 ip6-allnodes
+ff02::2 ip6-allrouters
+`)
+	defer removeFile(t, hostsFileName)
+	assert.Nil(t, set.Parse([]string{hostsFileName}))
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportHosts(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	assert.Equal(t, config.Host{Current: "imported", Options: map[string]string{"imported": "127.0.0.1"}}, configData.Hosts["www.example.com"])
+	assert.Equal(t, 4, len(configData.Hosts))
+	assert.Equal(t, []string{"myhost"}, configData.LocalHostnames)
+	assert.True(t, configData.IPv6Defaults)
+	assert.Equal(t, "Added local hostname myhost\nAdded host www.example.com (imported => 127.0.0.1)\n", writer.String())
+}
+
+func TestCmdImportHostsInvalidStrategy(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	set.String("strategy", "merge", "doc")
+	assert.Nil(t, set.Parse([]string{"hosts"}))
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportHosts(c), "Invalid strategy merge")
+}
+
+func TestCmdImportHostsBadTemplate(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	set.String("template", "{{.badTemplate", "doc")
+	assert.Nil(t, set.Parse([]string{"hosts"}))
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportHosts(c), "template: :1: unclosed action")
+}
+
+func TestCmdImportHostsTemplateError(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupImportHostsFile(t, "10.0.0.1 new.com\n")
+	defer removeFile(t, hostsFileName)
+	set.String("template", "{{.Missing}}", "doc")
+	assert.Nil(t, set.Parse([]string{hostsFileName}))
+
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(
+		t,
+		CmdImportHosts(c),
+		"template: :1:2: executing \"\" at <.Missing>: can't evaluate field Missing in type hosts.Entry",
+	)
+}
+
+func TestCmdImportHostsBadHostsFile(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	assert.Nil(t, set.Parse([]string{"/doesntexist"}))
+
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdImportHosts(c), "open /doesntexist: no such file or directory")
+}
+
+func TestCmdImportHostsNoConfig(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"hosts"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportHosts(c), "You must specify a config file")
+}
+
+func TestCmdImportHostsUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportHosts(c), "Usage: \"hostBuilder import hosts {hostsFile}\"")
+}
+
+func TestCompleteImportHostsFile(t *testing.T) {
+	os.Args = []string{"hostBuilder", "import", "hosts", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteImportHosts(c)
+
+	assert.Equal(t, "fileCompletion\n", writer.String())
+}
+
+func TestCompleteImportHostsStrategy(t *testing.T) {
+	os.Args = []string{"hostBuilder", "import", "hosts", "--strategy", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteImportHosts(c)
+
+	assert.Equal(t, "new\noverwrite\nskip\nask\n", writer.String())
+}
+
+func TestCompleteImportHostsGroup(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	os.Args = []string{"hostBuilder", "import", "hosts", "--group", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteImportHosts(c)
+
+	assert.Equal(t, "foo\n", writer.String())
+}
+
+func TestCompleteImportHostsFlags(t *testing.T) {
+	os.Args = []string{"hostBuilder", "import", "hosts", "/etc/hosts", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"/etc/hosts"}))
+	app, writer := appWithWriter()
+	app.Commands = []cli.Command{
+		{
+			Name: "hosts",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "strategy, s"},
+				cli.StringFlag{Name: "template, t"},
+				cli.StringFlag{Name: "group, g"},
+			},
+		},
+	}
+	c := cli.NewContext(app, set, nil)
+	CompleteImportHosts(c)
+
+	assert.Equal(t, "--strategy\n--template\n--group\n", writer.String())
+}
@@ -0,0 +1,220 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/jmespath/go-jmespath"
+	"github.com/urfave/cli"
+)
+
+type jsonImportItem struct {
+	Name    string
+	Address string
+	Index   int
+	Source  string
+}
+
+// CmdImportJSON imports addresses from a JSON document using JMESPath expressions
+func CmdImportJSON(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Usage: \"hostBuilder import json {file|-} --name {expression} --address {expression}\"", 1)
+	}
+
+	if c.String("name") == "" || c.String("address") == "" {
+		return cli.NewExitError("You must specify a name and an address expression", 1)
+	}
+
+	expressions, err := compileJSONExpressions(c)
+	if err != nil {
+		return err
+	}
+
+	templateString := c.String("template")
+	if templateString == "" {
+		templateString = "{{.Name}}"
+	}
+
+	templ, err := template.New("").Parse(templateString)
+	if err != nil {
+		return err
+	}
+
+	policy, err := flagStalePolicy(c)
+	if err != nil {
+		return err
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	fileName := c.Args().Get(0)
+	document, err := readJSONDocument(fileName, commandInput(c))
+	if err != nil {
+		return err
+	}
+
+	source := c.String("source")
+	if source == "" {
+		source = fmt.Sprintf("json:%s", fileName)
+	}
+
+	items, err := searchJSONItems(document, expressions, source)
+	if err != nil {
+		return err
+	}
+
+	addresses := make([]provider.Address, 0, len(items))
+	for _, item := range items {
+		name, err := executeTemplate(templ, item)
+		if err != nil {
+			return err
+		}
+
+		IP, err := resolveAddress(item.Address)
+		if err != nil {
+			return err
+		}
+
+		addresses = append(addresses, provider.Address{Host: c.String("host"), Name: name, IP: IP})
+	}
+
+	if mergeAddresses(configData, importOrigin(source, "json"), addresses, policy, c.App.Writer, c.App.ErrWriter) == 0 {
+		fmt.Fprintln(c.App.Writer, "Nothing to import")
+	}
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+func compileJSONExpressions(c *cli.Context) ([]*jmespath.JMESPath, error) {
+	itemsExpression := c.String("items")
+	if itemsExpression == "" {
+		itemsExpression = "@"
+	}
+
+	expressions := make([]*jmespath.JMESPath, 0, 3)
+	for _, expression := range []string{itemsExpression, c.String("name"), c.String("address")} {
+		compiled, err := jmespath.Compile(expression)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid expression %s: %v", expression, err)
+		}
+
+		expressions = append(expressions, compiled)
+	}
+
+	return expressions, nil
+}
+
+func readJSONDocument(fileName string, input io.Reader) (interface{}, error) {
+	var documentJSON []byte
+	var err error
+	if fileName == "-" {
+		documentJSON, err = ioutil.ReadAll(input)
+	} else {
+		documentJSON, err = ioutil.ReadFile(fileName)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var document interface{}
+	err = json.Unmarshal(documentJSON, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+func searchJSONItems(document interface{}, expressions []*jmespath.JMESPath, source string) ([]jsonImportItem, error) {
+	result, err := expressions[0].Search(document)
+	if err != nil {
+		return nil, err
+	}
+
+	rawItems, isList := result.([]interface{})
+	if !isList {
+		rawItems = []interface{}{result}
+	}
+
+	items := make([]jsonImportItem, 0, len(rawItems))
+	for index, rawItem := range rawItems {
+		name, err := searchJSONString(expressions[1], rawItem)
+		if err != nil {
+			return nil, err
+		}
+
+		address, err := searchJSONString(expressions[2], rawItem)
+		if err != nil {
+			return nil, err
+		}
+
+		if name == "" || address == "" {
+			continue
+		}
+
+		items = append(items, jsonImportItem{Name: name, Address: address, Index: index, Source: source})
+	}
+
+	return items, nil
+}
+
+func searchJSONString(expression *jmespath.JMESPath, data interface{}) (string, error) {
+	result, err := expression.Search(data)
+	if err != nil {
+		return "", err
+	}
+
+	if list, isList := result.([]interface{}); isList {
+		if len(list) == 0 {
+			return "", nil
+		}
+
+		result = list[0]
+	}
+
+	if result == nil {
+		return "", nil
+	}
+
+	return fmt.Sprint(result), nil
+}
+
+// CompleteImportJSON handles bash autocompletion for the 'import json' command
+func CompleteImportJSON(c *cli.Context) {
+	completeImportCommand(c, "json")
+}
+
+func completeImportCommand(c *cli.Context, commandName string) {
+	lastParam := os.Args[len(os.Args)-2]
+	if lastParam == "--host" {
+		configData, err := loadConfig(c)
+		if err == nil {
+			fmt.Fprintln(c.App.Writer, strings.Join(sortHostNames(configData), "\n"))
+		}
+
+		return
+	}
+
+	if c.NArg() == 0 {
+		fmt.Fprintln(c.App.Writer, "fileCompletion")
+		return
+	}
+
+	for _, flag := range c.App.Command(commandName).Flags {
+		name := strings.Split(flag.GetName(), ",")[0]
+		if !c.IsSet(name) {
+			fmt.Fprintf(c.App.Writer, "--%s\n", name)
+		}
+	}
+}
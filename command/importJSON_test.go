@@ -0,0 +1,301 @@
+package command
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+const testInventoryJSON = `{
+  "instances": [
+    {"name": "web1", "networkInterfaces": [{"networkIP": "10.1.0.1"}]},
+    {"name": "web2", "networkInterfaces": [{"networkIP": "10.1.0.2"}]},
+    {"name": "web2", "networkInterfaces": [{"networkIP": "10.1.0.3"}]},
+    {"name": "noip", "networkInterfaces": []}
+  ]
+}`
+
+func setupJSONFile(t *testing.T, document string) string {
+	jsonFile, err := ioutil.TempFile("/tmp", "json")
+	assert.Nil(t, err)
+
+	err = ioutil.WriteFile(jsonFile.Name(), []byte(document), 0644)
+	assert.Nil(t, err)
+
+	return jsonFile.Name()
+}
+
+func setupImportJSONFlags(set *flag.FlagSet) {
+	set.String("items", "instances", "doc")
+	set.String("name", "name", "doc")
+	set.String("address", "networkInterfaces[].networkIP", "doc")
+}
+
+func TestCmdImportJSON(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	jsonFileName := setupJSONFile(t, testInventoryJSON)
+	defer removeFile(t, jsonFileName)
+	setupImportJSONFlags(set)
+	set.String("template", "gce-{{.Name}}", "doc")
+	assert.Nil(t, set.Parse([]string{jsonFileName}))
+
+	app, writer := appWithWriter()
+	errWriter := new(strings.Builder)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportJSON(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]string{"baz": "10.0.0.4", "gce-web1": "10.1.0.1", "gce-web2": "10.1.0.2"}, configData.GlobalIPs)
+	source := config.Provenance{Source: "json:" + jsonFileName, Provider: "json", ImportedAt: &testImportedAt, FetchedAt: &testImportedAt}
+	assert.Equal(t, map[string]config.Provenance{"gce-web1": source, "gce-web2": source}, configData.Provenance)
+	assert.Equal(t, "Added global IP gce-web1 (10.1.0.1)\nAdded global IP gce-web2 (10.1.0.2)\n", writer.String())
+	assert.Equal(t, "Warning: global IP gce-web2 was found more than once, keeping 10.1.0.2 and ignoring 10.1.0.3\n", errWriter.String())
+}
+
+func TestCmdImportJSONHostOptionsAndPrune(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	configData.Hosts["goo"] = config.Host{
+		Current:    "foop",
+		Options:    map[string]string{"foop": "10.0.0.8", "web1": "10.1.0.9", "old": "10.1.0.5"},
+		Provenance: map[string]config.Provenance{"web1": {Source: "inventory"}, "old": {Source: "inventory"}},
+	}
+	assert.Nil(t, config.WriteConfig(configFileName, configData))
+
+	setupImportJSONFlags(set)
+	set.String("host", "goo", "doc")
+	set.String("source", "inventory", "doc")
+	set.Bool("prune", true, "doc")
+	assert.Nil(t, set.Parse([]string{"-"}))
+
+	app, writer := appWithWriter()
+	app.Metadata = map[string]interface{}{inputMetadata: strings.NewReader(testInventoryJSON)}
+	app.ErrWriter = new(strings.Builder)
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportJSON(c))
+
+	configData, err = config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	expectedHost := config.Host{
+		Current: "foop",
+		Options: map[string]string{"foop": "10.0.0.8", "web1": "10.1.0.1", "web2": "10.1.0.2"},
+		Provenance: map[string]config.Provenance{
+			"web1": {Source: "inventory", Provider: "json", ImportedAt: &testImportedAt, FetchedAt: &testImportedAt},
+			"web2": {Source: "inventory", Provider: "json", ImportedAt: &testImportedAt, FetchedAt: &testImportedAt},
+		},
+	}
+	assert.Equal(t, expectedHost, configData.Hosts["goo"])
+	assert.Equal(
+		t,
+		"Updated goo (web1: 10.1.0.9 => 10.1.0.1)\nAdded option to goo (web2 => 10.1.0.2)\nRemoved old from goo (10.1.0.5)\n",
+		writer.String(),
+	)
+}
+
+func TestCmdImportJSONNewHost(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	jsonFileName := setupJSONFile(t, `{"name": "web1", "ip": "10.1.0.1"}`)
+	defer removeFile(t, jsonFileName)
+	set.String("name", "name", "doc")
+	set.String("address", "ip", "doc")
+	set.String("host", "new.com", "doc")
+	assert.Nil(t, set.Parse([]string{jsonFileName}))
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportJSON(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	expectedHost := config.Host{
+		Current:    "web1",
+		Options:    map[string]string{"web1": "10.1.0.1"},
+		Provenance: map[string]config.Provenance{"web1": {Source: "json:" + jsonFileName, Provider: "json", ImportedAt: &testImportedAt, FetchedAt: &testImportedAt}},
+	}
+	assert.Equal(t, expectedHost, configData.Hosts["new.com"])
+	assert.Equal(t, "Added host new.com (web1 => 10.1.0.1)\n", writer.String())
+}
+
+func TestCmdImportJSONPruneGlobalIPs(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	configData.GlobalIPs["web1"] = "10.1.0.1"
+	configData.GlobalIPs["gone"] = "10.1.0.7"
+	configData.Provenance = map[string]config.Provenance{"web1": {Source: "inventory"}, "gone": {Source: "inventory"}}
+	assert.Nil(t, config.WriteConfig(configFileName, configData))
+
+	jsonFileName := setupJSONFile(t, `[{"name": "web1", "ip": "10.1.0.1"}]`)
+	defer removeFile(t, jsonFileName)
+	set.String("name", "name", "doc")
+	set.String("address", "ip", "doc")
+	set.String("source", "inventory", "doc")
+	set.Bool("prune", true, "doc")
+	assert.Nil(t, set.Parse([]string{jsonFileName}))
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportJSON(c))
+
+	configData, err = config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]string{"baz": "10.0.0.4", "web1": "10.1.0.1"}, configData.GlobalIPs)
+	assert.Equal(t, "Removed global IP gone (10.1.0.7)\n", writer.String())
+}
+
+func TestCmdImportJSONNothingToImport(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	jsonFileName := setupJSONFile(t, `[{"name": "baz", "ip": "10.0.0.4"}]`)
+	defer removeFile(t, jsonFileName)
+	set.String("name", "name", "doc")
+	set.String("address", "ip", "doc")
+	assert.Nil(t, set.Parse([]string{jsonFileName}))
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportJSON(c))
+
+	assert.Equal(t, "Nothing to import\n", writer.String())
+}
+
+func TestCmdImportJSONBadAddress(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	jsonFileName := setupJSONFile(t, `[{"name": "web1", "ip": "10.0.0.256"}]`)
+	defer removeFile(t, jsonFileName)
+	set.String("name", "name", "doc")
+	set.String("address", "ip", "doc")
+	assert.Nil(t, set.Parse([]string{jsonFileName}))
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportJSON(c), "Unable to resolve 10.0.0.256")
+}
+
+func TestCmdImportJSONInvalidJSON(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	jsonFileName := setupJSONFile(t, `{`)
+	defer removeFile(t, jsonFileName)
+	set.String("name", "name", "doc")
+	set.String("address", "ip", "doc")
+	assert.Nil(t, set.Parse([]string{jsonFileName}))
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportJSON(c), "unexpected end of JSON input")
+}
+
+func TestCmdImportJSONInvalidExpression(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	set.String("name", "name[", "doc")
+	set.String("address", "ip", "doc")
+	assert.Nil(t, set.Parse([]string{"file.json"}))
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportJSON(c), "Invalid expression name[: SyntaxError: Expected tStar, received: tEOF")
+}
+
+func TestCmdImportJSONBadTemplate(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	set.String("name", "name", "doc")
+	set.String("address", "ip", "doc")
+	set.String("template", "{{.badTemplate", "doc")
+	assert.Nil(t, set.Parse([]string{"file.json"}))
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportJSON(c), "template: :1: unclosed action")
+}
+
+func TestCmdImportJSONMissingFile(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	set.String("name", "name", "doc")
+	set.String("address", "ip", "doc")
+	assert.Nil(t, set.Parse([]string{"/tmp/doesntexist.json"}))
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportJSON(c), "open /tmp/doesntexist.json: no such file or directory")
+}
+
+func TestCmdImportJSONNoExpressions(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"file.json"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportJSON(c), "You must specify a name and an address expression")
+}
+
+func TestCmdImportJSONNoConfig(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	set.String("name", "name", "doc")
+	set.String("address", "ip", "doc")
+	assert.Nil(t, set.Parse([]string{"file.json"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportJSON(c), "You must specify a config file")
+}
+
+func TestCmdImportJSONUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportJSON(c), "Usage: \"hostBuilder import json {file|-} --name {expression} --address {expression}\"")
+}
+
+func TestCompleteImportJSONFile(t *testing.T) {
+	os.Args = []string{"hostBuilder", "import", "json", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteImportJSON(c)
+
+	assert.Equal(t, "fileCompletion\n", writer.String())
+}
+
+func TestCompleteImportJSONHost(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	os.Args = []string{"hostBuilder", "import", "json", "--host", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteImportJSON(c)
+
+	assert.Equal(t, "bar\nbaz.com\ngoo\n", writer.String())
+}
+
+func TestCompleteImportJSONFlags(t *testing.T) {
+	os.Args = []string{"hostBuilder", "import", "json", "file.json", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"file.json"}))
+	app, writer := appWithWriter()
+	app.Commands = []cli.Command{
+		{
+			Name: "json",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "name, n"},
+				cli.StringFlag{Name: "address, a"},
+				cli.BoolFlag{Name: "prune"},
+			},
+		},
+	}
+	c := cli.NewContext(app, set, nil)
+	CompleteImportJSON(c)
+
+	assert.Equal(t, "--name\n--address\n--prune\n", writer.String())
+}
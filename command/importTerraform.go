@@ -0,0 +1,78 @@
+package command
+
+import (
+	"fmt"
+	"text/template"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/guywithnose/hostBuilder/terraform"
+	"github.com/urfave/cli"
+)
+
+// CmdImportTerraform imports resource addresses from a terraform state file
+func CmdImportTerraform(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Usage: \"hostBuilder import terraform {stateFile}\"", 1)
+	}
+
+	templateString := c.String("template")
+	if templateString == "" {
+		templateString = "{{.Label}}"
+	}
+
+	templ, err := template.New("").Parse(templateString)
+	if err != nil {
+		return err
+	}
+
+	policy, err := flagStalePolicy(c)
+	if err != nil {
+		return err
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	stateFile := c.Args().Get(0)
+	state, err := terraform.ReadStateFile(stateFile)
+	if err != nil {
+		return err
+	}
+
+	source := c.String("source")
+	if source == "" {
+		source = fmt.Sprintf("terraform:%s", stateFile)
+	}
+
+	resourceAddresses := state.Addresses(c.Bool("private"))
+	addresses := make([]provider.Address, 0, len(resourceAddresses))
+	for _, resourceAddress := range resourceAddresses {
+		name, err := executeTemplate(templ, resourceAddress)
+		if err != nil {
+			return err
+		}
+
+		// Load balancers only have a DNS name, one that does not resolve from here must not stop the import of the rest
+		IP, err := resolveAddress(resourceAddress.IP)
+		if err != nil {
+			fmt.Fprintf(c.App.ErrWriter, "Warning: %v, skipping %s\n", err, resourceAddress.Address)
+			continue
+		}
+
+		addresses = append(addresses, provider.Address{Host: c.String("host"), Name: name, IP: IP})
+	}
+
+	if mergeAddresses(configData, importOrigin(source, "terraform"), addresses, policy, c.App.Writer, c.App.ErrWriter) == 0 {
+		fmt.Fprintln(c.App.Writer, "Nothing to import")
+	}
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+// CompleteImportTerraform handles bash autocompletion for the 'import terraform' command
+func CompleteImportTerraform(c *cli.Context) {
+	completeImportCommand(c, "terraform")
+}
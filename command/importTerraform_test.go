@@ -0,0 +1,176 @@
+package command
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+const testTerraformState = `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "instances": [
+        {"index_key": 0, "attributes": {"public_ip": "54.0.0.1", "private_ip": "10.0.0.1"}},
+        {"index_key": 1, "attributes": {"public_ip": "54.0.0.2", "private_ip": "10.0.0.2"}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "google_compute_instance",
+      "name": "worker",
+      "instances": [
+        {"index_key": "blue", "attributes": {"network_interface": [{"network_ip": "10.2.0.1"}]}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_lb",
+      "name": "internal",
+      "instances": [
+        {"attributes": {"dns_name": "internal-api.invalid"}}
+      ]
+    }
+  ]
+}`
+
+func setupTerraformStateFile(t *testing.T) string {
+	stateFile, err := ioutil.TempFile("/tmp", "tfstate")
+	assert.Nil(t, err)
+
+	err = ioutil.WriteFile(stateFile.Name(), []byte(testTerraformState), 0644)
+	assert.Nil(t, err)
+
+	return stateFile.Name()
+}
+
+func TestCmdImportTerraform(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	stateFileName := setupTerraformStateFile(t)
+	defer removeFile(t, stateFileName)
+	assert.Nil(t, set.Parse([]string{stateFileName}))
+
+	app, writer := appWithWriter()
+	errWriter := new(strings.Builder)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportTerraform(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	expectedIPs := map[string]string{"baz": "10.0.0.4", "aws_instance.web.0": "54.0.0.1", "aws_instance.web.1": "54.0.0.2"}
+	assert.Equal(t, expectedIPs, configData.GlobalIPs)
+	assert.Equal(
+		t,
+		config.Provenance{Source: "terraform:" + stateFileName, Provider: "terraform", ImportedAt: &testImportedAt, FetchedAt: &testImportedAt},
+		configData.Provenance["aws_instance.web.0"],
+	)
+	assert.Equal(t, "Added global IP aws_instance.web.0 (54.0.0.1)\nAdded global IP aws_instance.web.1 (54.0.0.2)\n", writer.String())
+	assert.Equal(t, "Warning: Unable to resolve internal-api.invalid, skipping aws_lb.internal\n", errWriter.String())
+}
+
+func TestCmdImportTerraformPrivateWithTemplate(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	stateFileName := setupTerraformStateFile(t)
+	defer removeFile(t, stateFileName)
+	set.Bool("private", true, "doc")
+	set.String("template", "{{.Name}}-{{.Index}}", "doc")
+	set.String("source", "prod", "doc")
+	assert.Nil(t, set.Parse([]string{stateFileName}))
+
+	app, _ := appWithWriter()
+	app.ErrWriter = new(strings.Builder)
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdImportTerraform(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	expectedIPs := map[string]string{"baz": "10.0.0.4", "web-0": "10.0.0.1", "web-1": "10.0.0.2", "worker-blue": "10.2.0.1"}
+	assert.Equal(t, expectedIPs, configData.GlobalIPs)
+	assert.Equal(
+		t,
+		config.Provenance{Source: "prod", Provider: "terraform", ImportedAt: &testImportedAt, FetchedAt: &testImportedAt},
+		configData.Provenance["worker-blue"],
+	)
+}
+
+func TestCmdImportTerraformTemplateError(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	stateFileName := setupTerraformStateFile(t)
+	defer removeFile(t, stateFileName)
+	set.String("template", "{{.Missing}}", "doc")
+	assert.Nil(t, set.Parse([]string{stateFileName}))
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(
+		t,
+		CmdImportTerraform(c),
+		"template: :1:2: executing \"\" at <.Missing>: can't evaluate field Missing in type terraform.Address",
+	)
+}
+
+func TestCmdImportTerraformBadTemplate(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	set.String("template", "{{.badTemplate", "doc")
+	assert.Nil(t, set.Parse([]string{"terraform.tfstate"}))
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportTerraform(c), "template: :1: unclosed action")
+}
+
+func TestCmdImportTerraformMissingStateFile(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	assert.Nil(t, set.Parse([]string{"/tmp/doesntexist.tfstate"}))
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportTerraform(c), "open /tmp/doesntexist.tfstate: no such file or directory")
+}
+
+func TestCmdImportTerraformNoConfig(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"terraform.tfstate"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportTerraform(c), "You must specify a config file")
+}
+
+func TestCmdImportTerraformUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdImportTerraform(c), "Usage: \"hostBuilder import terraform {stateFile}\"")
+}
+
+func TestCompleteImportTerraformFlags(t *testing.T) {
+	os.Args = []string{"hostBuilder", "import", "terraform", "terraform.tfstate", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"terraform.tfstate"}))
+	app, writer := appWithWriter()
+	app.Commands = []cli.Command{
+		{
+			Name: "terraform",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "template, t"},
+				cli.BoolFlag{Name: "private"},
+			},
+		},
+	}
+	c := cli.NewContext(app, set, nil)
+	CompleteImportTerraform(c)
+
+	assert.Equal(t, "--template\n--private\n", writer.String())
+}
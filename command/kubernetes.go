@@ -0,0 +1,62 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"text/template"
+	"time"
+
+	"github.com/guywithnose/hostBuilder/kubeUtil"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/urfave/cli"
+)
+
+func parseKubernetesTemplate(c *cli.Context, defaultTemplate string) (*template.Template, error) {
+	templateString := c.String("template")
+	if templateString == "" {
+		templateString = defaultTemplate
+	}
+
+	return template.New("").Parse(templateString)
+}
+
+// openKubernetesSource connects to the cluster for the selected context, or reads kubectl output if a file was given
+// The default source for the imported addresses is returned along with it
+func openKubernetesSource(c *cli.Context, resource string) (*kubeUtil.Source, string, error) {
+	outputFile := c.String("file")
+	if outputFile == "" {
+		kubeSource, err := kubeUtil.OpenCluster(c.String("kubeconfig"), c.String("context"))
+		if err != nil {
+			return nil, "", err
+		}
+
+		return kubeSource, fmt.Sprintf("kubernetes:%s/%s", kubeSource.Context, resource), nil
+	}
+
+	source := fmt.Sprintf("kubernetes:%s", outputFile)
+	if outputFile != "-" {
+		kubeSource, err := kubeUtil.OpenOutputFile(outputFile, c.String("context"))
+		return kubeSource, source, err
+	}
+
+	listJSON, err := ioutil.ReadAll(commandInput(c))
+	if err != nil {
+		return nil, "", err
+	}
+
+	kubeSource, err := kubeUtil.OpenOutput(listJSON, c.String("context"))
+	return kubeSource, source, err
+}
+
+// discoverKubernetes runs discover, caching the results when they come from a cluster rather than kubectl output
+// defaultSource names the cluster and context so each context is cached separately
+func discoverKubernetes(c *cli.Context, name, defaultSource string, discover func() ([]provider.Address, error)) ([]provider.Address, time.Time, error) {
+	if c.String("file") != "" {
+		addresses, err := discover()
+		return addresses, now().UTC().Truncate(time.Second), err
+	}
+
+	settings := commandSettings(c)
+	settings["cluster"] = defaultSource
+	return cachedDiscovery(c, name, settings, c.GlobalDuration("cacheTtl"), discover)
+}
@@ -0,0 +1,64 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/kubeUtil"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/urfave/cli"
+)
+
+// CmdKubernetesIngresses adds an option for the cluster to every hostname served by a kubernetes ingress
+func CmdKubernetesIngresses(c *cli.Context) error {
+	if c.NArg() != 0 {
+		return cli.NewExitError("Usage: \"hostBuilder kubernetes ingresses\"", 1)
+	}
+
+	templ, err := parseKubernetesTemplate(c, "{{.Context}}")
+	if err != nil {
+		return err
+	}
+
+	policy, err := flagStalePolicy(c)
+	if err != nil {
+		return err
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	kubeSource, source, err := openKubernetesSource(c, "ingresses")
+	if err != nil {
+		return err
+	}
+
+	addresses, fetchedAt, err := discoverKubernetes(c, "kubernetesIngresses", source, func() ([]provider.Address, error) {
+		ingresses, err := kubeSource.Ingresses(c.String("namespace"))
+		if err != nil {
+			return nil, err
+		}
+
+		return kubeSource.IngressAddresses(ingresses, templ, c.App.ErrWriter)
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.String("source") != "" {
+		source = c.String("source")
+	}
+
+	if mergeAddresses(configData, fetchedOrigin(source, "kubernetesIngresses", fetchedAt), addresses, policy, c.App.Writer, c.App.ErrWriter) == 0 {
+		fmt.Fprintln(c.App.Writer, "Nothing to import")
+	}
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+// CompleteKubernetesIngresses handles bash autocompletion for the 'kubernetes ingresses' command
+func CompleteKubernetesIngresses(c *cli.Context) {
+	completeProviderCommand(c, "ingresses", kubeUtil.IngressesProvider{})
+}
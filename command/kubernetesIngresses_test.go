@@ -0,0 +1,150 @@
+package command
+
+import (
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdKubernetesIngresses(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	server, kubeconfigFileName := setupFakeKubernetesCluster(t)
+	defer server.Close()
+	defer removeFile(t, kubeconfigFileName)
+	set.String("kubeconfig", kubeconfigFileName, "doc")
+
+	app, writer := appWithWriter()
+	errWriter := new(strings.Builder)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdKubernetesIngresses(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	source := config.Provenance{
+		Source:     "kubernetes:staging/ingresses",
+		Provider:   "kubernetesIngresses",
+		ResourceID: "shop/shop",
+		ImportedAt: &testImportedAt,
+		FetchedAt:  &testImportedAt,
+	}
+	assert.Equal(t, map[string]string{"foop": "10.0.0.8", "staging": "34.2.2.2"}, configData.Hosts["goo"].Options)
+	assert.Equal(t, "foop", configData.Hosts["goo"].Current)
+	assert.Equal(
+		t,
+		config.Host{Current: "staging", Options: map[string]string{"staging": "34.2.2.2"}, Provenance: map[string]config.Provenance{"staging": source}},
+		configData.Hosts["shop.example.com"],
+	)
+	assert.Equal(t, "Added option to goo (staging => 34.2.2.2)\nAdded host shop.example.com (staging => 34.2.2.2)\n", writer.String())
+	assert.Equal(t, "Warning: Ingress default/pending has no address, skipping\n", errWriter.String())
+}
+
+func TestCmdKubernetesIngressesFile(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	objectsFileName := setupKubernetesFile(t)
+	defer removeFile(t, objectsFileName)
+	set.String("file", objectsFileName, "doc")
+	set.String("namespace", "shop", "doc")
+	set.String("template", "{{.Namespace}}-{{.Context}}", "doc")
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdKubernetesIngresses(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "34.2.2.2", configData.Hosts["shop.example.com"].Options["shop-kubectl"])
+	assert.Equal(t, "Added option to goo (shop-kubectl => 34.2.2.2)\nAdded host shop.example.com (shop-kubectl => 34.2.2.2)\n", writer.String())
+}
+
+func TestCmdKubernetesIngressesPrune(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	objectsFileName := setupKubernetesFile(t)
+	defer removeFile(t, objectsFileName)
+	set.String("file", objectsFileName, "doc")
+	set.String("context", "prod", "doc")
+	set.String("source", "prod", "doc")
+	set.Bool("prune", true, "doc")
+
+	app, _ := appWithWriter()
+	app.ErrWriter = new(strings.Builder)
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdKubernetesIngresses(c))
+
+	assert.Nil(t, set.Set("file", "-"))
+
+	app, writer := appWithWriter()
+	app.Metadata = map[string]interface{}{inputMetadata: strings.NewReader(`{"kind": "List", "items": []}`)}
+	errWriter := new(strings.Builder)
+	app.ErrWriter = errWriter
+	c = cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdKubernetesIngresses(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]string{"foop": "10.0.0.8"}, configData.Hosts["goo"].Options)
+	assert.Equal(t, "prod", configData.Hosts["shop.example.com"].Current)
+	assert.Equal(t, "Removed prod from goo (34.2.2.2)\nRemoved prod from shop.example.com (34.2.2.2)\n", writer.String())
+	assert.Equal(t, "Warning: shop.example.com was using prod which was removed, it has no IP until another is chosen\n", errWriter.String())
+}
+
+func TestCmdKubernetesIngressesTemplateError(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	objectsFileName := setupKubernetesFile(t)
+	defer removeFile(t, objectsFileName)
+	set.String("file", objectsFileName, "doc")
+	set.String("template", "{{.Missing}}", "doc")
+
+	app, _ := appWithWriter()
+	app.ErrWriter = new(strings.Builder)
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(
+		t,
+		CmdKubernetesIngresses(c),
+		"template: :1:2: executing \"\" at <.Missing>: can't evaluate field Missing in type kubeUtil.IngressAddress",
+	)
+}
+
+func TestCmdKubernetesIngressesBadFile(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	set.String("file", "/tmp/missing.json", "doc")
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdKubernetesIngresses(c), "open /tmp/missing.json: no such file or directory")
+}
+
+func TestCmdKubernetesIngressesNoConfig(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdKubernetesIngresses(c), "You must specify a config file")
+}
+
+func TestCmdKubernetesIngressesUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdKubernetesIngresses(c), "Usage: \"hostBuilder kubernetes ingresses\"")
+}
+
+func TestCompleteKubernetesIngressesFile(t *testing.T) {
+	os.Args = []string{"hostBuilder", "kubernetes", "ingresses", "--file", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteKubernetesIngresses(c)
+
+	assert.Equal(t, "fileCompletion\n", writer.String())
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/kubeUtil"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/urfave/cli"
+)
+
+// CmdKubernetesServices adds kubernetes service addresses to the configuration as global IPs
+func CmdKubernetesServices(c *cli.Context) error {
+	if c.NArg() != 0 {
+		return cli.NewExitError("Usage: \"hostBuilder kubernetes services\"", 1)
+	}
+
+	templ, err := parseKubernetesTemplate(c, "{{.Name}}.{{.Namespace}}")
+	if err != nil {
+		return err
+	}
+
+	kinds, err := kubeUtil.ParseServiceKinds(c.String("types"))
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	policy, err := flagStalePolicy(c)
+	if err != nil {
+		return err
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	kubeSource, source, err := openKubernetesSource(c, "services")
+	if err != nil {
+		return err
+	}
+
+	addresses, fetchedAt, err := discoverKubernetes(c, "kubernetesServices", source, func() ([]provider.Address, error) {
+		services, err := kubeSource.Services(c.String("namespace"))
+		if err != nil {
+			return nil, err
+		}
+
+		return kubeSource.ServiceAddresses(services, kinds, templ, c.App.ErrWriter)
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.String("source") != "" {
+		source = c.String("source")
+	}
+
+	if mergeAddresses(configData, fetchedOrigin(source, "kubernetesServices", fetchedAt), addresses, policy, c.App.Writer, c.App.ErrWriter) == 0 {
+		fmt.Fprintln(c.App.Writer, "Nothing to import")
+	}
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+// CompleteKubernetesServices handles bash autocompletion for the 'kubernetes services' command
+func CompleteKubernetesServices(c *cli.Context) {
+	completeProviderCommand(c, "services", kubeUtil.ServicesProvider{})
+}
@@ -0,0 +1,268 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+const testKubernetesObjects = `{
+  "kind": "List",
+  "items": [
+    {
+      "kind": "Service",
+      "metadata": {"name": "web", "namespace": "shop"},
+      "spec": {"type": "LoadBalancer", "clusterIP": "10.96.0.10"},
+      "status": {"loadBalancer": {"ingress": [{"ip": "34.1.1.1"}]}}
+    },
+    {
+      "kind": "Service",
+      "metadata": {"name": "db", "namespace": "shop"},
+      "spec": {"type": "ClusterIP", "clusterIP": "None"}
+    },
+    {
+      "kind": "Service",
+      "metadata": {"name": "api", "namespace": "default"},
+      "spec": {"type": "ClusterIP", "clusterIP": "10.96.0.20"}
+    },
+    {
+      "kind": "Ingress",
+      "metadata": {"name": "shop", "namespace": "shop"},
+      "spec": {"rules": [{"host": "goo"}, {"host": "shop.example.com"}]},
+      "status": {"loadBalancer": {"ingress": [{"ip": "34.2.2.2"}]}}
+    },
+    {
+      "kind": "Ingress",
+      "metadata": {"name": "pending", "namespace": "default"},
+      "spec": {"rules": [{"host": "pending.example.com"}]}
+    }
+  ]
+}`
+
+func setupKubernetesFile(t *testing.T) string {
+	objectsFile, err := ioutil.TempFile("/tmp", "kubectl")
+	assert.Nil(t, err)
+
+	err = ioutil.WriteFile(objectsFile.Name(), []byte(testKubernetesObjects), 0644)
+	assert.Nil(t, err)
+
+	return objectsFile.Name()
+}
+
+// setupFakeKubernetesCluster starts an API server serving testKubernetesObjects and writes a kubeconfig for it
+func setupFakeKubernetesCluster(t *testing.T) (*httptest.Server, string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		_, _ = w.Write([]byte(testKubernetesObjects))
+	}))
+
+	kubeconfigFile, err := ioutil.TempFile("/tmp", "kubeconfig")
+	assert.Nil(t, err)
+
+	kubeconfig := fmt.Sprintf(`current-context: staging
+clusters:
+- name: staging
+  cluster:
+    server: %s
+users:
+- name: admin
+  user:
+    token: secret
+contexts:
+- name: staging
+  context:
+    cluster: staging
+    user: admin
+- name: prod
+  context:
+    cluster: staging
+`, server.URL)
+	assert.Nil(t, ioutil.WriteFile(kubeconfigFile.Name(), []byte(kubeconfig), 0600))
+
+	return server, kubeconfigFile.Name()
+}
+
+func TestCmdKubernetesServices(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	server, kubeconfigFileName := setupFakeKubernetesCluster(t)
+	defer server.Close()
+	defer removeFile(t, kubeconfigFileName)
+	set.String("kubeconfig", kubeconfigFileName, "doc")
+	set.String("template", "{{.Context}}-{{.Name}}", "doc")
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdKubernetesServices(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]string{"baz": "10.0.0.4", "staging-api": "10.96.0.20", "staging-web": "34.1.1.1"}, configData.GlobalIPs)
+	assert.Equal(
+		t,
+		config.Provenance{
+			Source:     "kubernetes:staging/services",
+			Provider:   "kubernetesServices",
+			ResourceID: "shop/web",
+			ImportedAt: &testImportedAt,
+			FetchedAt:  &testImportedAt,
+		},
+		configData.Provenance["staging-web"],
+	)
+	assert.Equal(t, "Added global IP staging-api (10.96.0.20)\nAdded global IP staging-web (34.1.1.1)\n", writer.String())
+}
+
+func TestCmdKubernetesServicesFile(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	objectsFileName := setupKubernetesFile(t)
+	defer removeFile(t, objectsFileName)
+	set.String("file", objectsFileName, "doc")
+	set.String("namespace", "shop", "doc")
+	set.String("types", "clusterip", "doc")
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdKubernetesServices(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]string{"baz": "10.0.0.4", "web.shop": "10.96.0.10"}, configData.GlobalIPs)
+	assert.Equal(
+		t,
+		config.Provenance{
+			Source:     "kubernetes:" + objectsFileName,
+			Provider:   "kubernetesServices",
+			ResourceID: "shop/web",
+			ImportedAt: &testImportedAt,
+			FetchedAt:  &testImportedAt,
+		},
+		configData.Provenance["web.shop"],
+	)
+	assert.Equal(t, "Added global IP web.shop (10.96.0.10)\n", writer.String())
+}
+
+func TestCmdKubernetesServicesStdin(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	set.String("file", "-", "doc")
+	set.String("namespace", "missing", "doc")
+
+	app, writer := appWithWriter()
+	app.Metadata = map[string]interface{}{inputMetadata: strings.NewReader(testKubernetesObjects)}
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdKubernetesServices(c))
+
+	assert.Equal(t, "Nothing to import\n", writer.String())
+}
+
+func TestCmdKubernetesServicesUnauthorized(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	server, kubeconfigFileName := setupFakeKubernetesCluster(t)
+	defer server.Close()
+	defer removeFile(t, kubeconfigFileName)
+	set.String("kubeconfig", kubeconfigFileName, "doc")
+	set.String("context", "prod", "doc")
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdKubernetesServices(c), "Kubernetes request /api/v1/services failed with status 401: Unauthorized")
+}
+
+func TestCmdKubernetesServicesMissingContext(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	server, kubeconfigFileName := setupFakeKubernetesCluster(t)
+	defer server.Close()
+	defer removeFile(t, kubeconfigFileName)
+	set.String("kubeconfig", kubeconfigFileName+":/tmp/other", "doc")
+	set.String("context", "dev", "doc")
+
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdKubernetesServices(c), fmt.Sprintf("Context dev not found in %s", kubeconfigFileName))
+}
+
+func TestCmdKubernetesServicesInvalidType(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	set.String("types", "LoadBalancer,NodePort", "doc")
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdKubernetesServices(c), "Invalid service type NodePort")
+}
+
+func TestCmdKubernetesServicesBadTemplate(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	set.String("template", "{{.badTemplate", "doc")
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdKubernetesServices(c), "template: :1: unclosed action")
+}
+
+func TestCmdKubernetesServicesNoConfig(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdKubernetesServices(c), "You must specify a config file")
+}
+
+func TestCmdKubernetesServicesUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdKubernetesServices(c), "Usage: \"hostBuilder kubernetes services\"")
+}
+
+func TestCompleteKubernetesServicesContext(t *testing.T) {
+	server, kubeconfigFileName := setupFakeKubernetesCluster(t)
+	defer server.Close()
+	defer removeFile(t, kubeconfigFileName)
+	os.Args = []string{"hostBuilder", "kubernetes", "services", "--context", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	set.String("kubeconfig", kubeconfigFileName, "doc")
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteKubernetesServices(c)
+
+	assert.Equal(t, "staging\nprod\n", writer.String())
+}
+
+func TestCompleteKubernetesServicesTypes(t *testing.T) {
+	os.Args = []string{"hostBuilder", "kubernetes", "services", "--types", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteKubernetesServices(c)
+
+	assert.Equal(t, "LoadBalancer\nExternalIP\nClusterIP\n", writer.String())
+}
+
+func TestCompleteKubernetesServicesFlags(t *testing.T) {
+	os.Args = []string{"hostBuilder", "kubernetes", "services", "--completion"}
+	set := flag.NewFlagSet("test", 0)
+	app, writer := appWithWriter()
+	app.Commands = []cli.Command{
+		{
+			Name: "services",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "kubeconfig"},
+				cli.StringFlag{Name: "file, f"},
+			},
+		},
+	}
+	c := cli.NewContext(app, set, nil)
+	CompleteKubernetesServices(c)
+
+	assert.Equal(t, "--kubeconfig\n--file\n", writer.String())
+}
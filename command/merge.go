@@ -0,0 +1,391 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/urfave/cli"
+)
+
+// The policies for hosts using an entry that prune removes
+const (
+	// currentWarn removes the entry and reports the hosts left without an IP
+	currentWarn = "warn"
+	// currentKeep keeps entries that hosts are using, flagging them as stale instead
+	currentKeep = "keep"
+	// currentIgnore removes the entry and sets the hosts using it to ignore
+	currentIgnore = "ignore"
+	// currentSwitch removes the entry and switches the hosts using it to their first remaining option
+	currentSwitch = "switch"
+)
+
+var currentPolicies = []string{currentWarn, currentKeep, currentIgnore, currentSwitch}
+
+// now is when addresses are imported, tests replace it to get stable provenance
+var now = time.Now
+
+// stalePolicy decides what happens to entries a source no longer returns
+// Without prune they are flagged as stale, with prune they are removed and current chooses what happens to the hosts using them
+type stalePolicy struct {
+	prune   bool
+	current string
+}
+
+// newStalePolicy checks that current is one of the currentPolicies, an empty current means warn
+func newStalePolicy(prune bool, current string) (stalePolicy, error) {
+	if current == "" {
+		current = currentWarn
+	}
+
+	for _, policy := range currentPolicies {
+		if current == policy {
+			return stalePolicy{prune: prune, current: current}, nil
+		}
+	}
+
+	return stalePolicy{}, cli.NewExitError(fmt.Sprintf("Invalid current policy %s, expected one of %s", current, strings.Join(currentPolicies, ", ")), 1)
+}
+
+// flagStalePolicy reads the policy from the --prune and --current flags
+func flagStalePolicy(c *cli.Context) (stalePolicy, error) {
+	return newStalePolicy(c.Bool("prune"), c.String("current"))
+}
+
+// importOrigin is the provenance shared by every address imported from source in one run
+func importOrigin(source, providerName string) config.Provenance {
+	importedAt := now().UTC().Truncate(time.Second)
+	return config.Provenance{Source: source, Provider: providerName, ImportedAt: &importedAt, FetchedAt: &importedAt}
+}
+
+// fetchedOrigin is the origin of addresses fetched at fetchedAt, which can come from the cache
+func fetchedOrigin(source, providerName string, fetchedAt time.Time) config.Provenance {
+	origin := importOrigin(source, providerName)
+	origin.FetchedAt = &fetchedAt
+	return origin
+}
+
+// addressProvenance records the profile, region and resource ID the provider reported for the address
+// ImportedAt is kept from the existing provenance when the IP has not changed
+func addressProvenance(origin config.Provenance, address provider.Address, existing config.Provenance, changed bool) config.Provenance {
+	provenance := origin
+	provenance.Profile = address.Metadata[provider.ProfileMetadata]
+	provenance.Region = address.Metadata[provider.RegionMetadata]
+	provenance.ResourceID = address.Metadata[provider.ResourceMetadata]
+	if !changed && !existing.Stale && existing.Source == origin.Source && existing.ImportedAt != nil {
+		provenance.ImportedAt = existing.ImportedAt
+	}
+
+	return provenance
+}
+
+func describeAddress(address provider.Address) string {
+	if address.Host == "" {
+		return fmt.Sprintf("global IP %s", address.Name)
+	}
+
+	return fmt.Sprintf("%s on %s", address.Name, address.Host)
+}
+
+func dedupeAddresses(addresses []provider.Address, errWriter io.Writer) []provider.Address {
+	seen := make(map[string]provider.Address, len(addresses))
+	deduped := make([]provider.Address, 0, len(addresses))
+	for _, address := range addresses {
+		key := address.Host + " " + address.Name
+		if existing, exists := seen[key]; exists {
+			if existing.IP != address.IP {
+				fmt.Fprintf(errWriter, "Warning: %s was found more than once, keeping %s and ignoring %s\n", describeAddress(existing), existing.IP, address.IP)
+			}
+
+			continue
+		}
+
+		seen[key] = address
+		deduped = append(deduped, address)
+	}
+
+	sort.Slice(deduped, func(i, j int) bool {
+		if deduped[i].Host != deduped[j].Host {
+			return deduped[i].Host < deduped[j].Host
+		}
+
+		return deduped[i].Name < deduped[j].Name
+	})
+
+	return deduped
+}
+
+// mergeAddresses adds the addresses to the configuration and records their provenance
+// Anything previously imported from the same source that was not returned this time is handled by the policy
+// The number of changes made is returned
+func mergeAddresses(
+	configData *config.HostsConfig,
+	origin config.Provenance,
+	addresses []provider.Address,
+	policy stalePolicy,
+	writer,
+	errWriter io.Writer,
+) int {
+	addresses = dedupeAddresses(addresses, errWriter)
+	returned := make(map[string]bool, len(addresses))
+	changes := 0
+	for _, address := range addresses {
+		returned[address.Host+" "+address.Name] = true
+		if address.Host == "" {
+			changes += mergeGlobalIP(configData, origin, address, writer, errWriter)
+		} else {
+			changes += mergeHostOption(configData, origin, address, writer, errWriter)
+		}
+	}
+
+	changes += staleGlobalIPs(configData, origin.Source, returned, policy, writer, errWriter)
+	changes += staleHostOptions(configData, origin.Source, returned, policy, writer, errWriter)
+	return changes
+}
+
+// conflictingOwner describes who owns an existing entry that the source importing it does not
+// An entry without a source was added by hand, neither kind is overwritten so a later prune can not remove it
+func conflictingOwner(existing config.Provenance, source string) (string, bool) {
+	if existing.Source == source {
+		return "", false
+	}
+
+	if existing.Source == "" {
+		return "was added by hand", true
+	}
+
+	return fmt.Sprintf("belongs to source %s", existing.Source), true
+}
+
+// reportConflict warns that an address was not imported because another owner has the entry, unless it has the same IP
+func reportConflict(address provider.Address, current, owner string, errWriter io.Writer) {
+	if current != address.IP {
+		fmt.Fprintf(errWriter, "Warning: %s %s, keeping %s and ignoring %s\n", describeAddress(address), owner, current, address.IP)
+	}
+}
+
+func mergeGlobalIP(configData *config.HostsConfig, origin config.Provenance, address provider.Address, writer, errWriter io.Writer) int {
+	if configData.GlobalIPs == nil {
+		configData.GlobalIPs = map[string]string{}
+	}
+
+	if configData.Provenance == nil {
+		configData.Provenance = map[string]config.Provenance{}
+	}
+
+	current, exists := configData.GlobalIPs[address.Name]
+	existing := configData.Provenance[address.Name]
+	if owner, conflicts := conflictingOwner(existing, origin.Source); exists && conflicts {
+		reportConflict(address, current, owner, errWriter)
+		return 0
+	}
+
+	configData.Provenance[address.Name] = addressProvenance(origin, address, existing, current != address.IP)
+	configData.GlobalIPs[address.Name] = address.IP
+	if !exists {
+		fmt.Fprintf(writer, "Added global IP %s (%s)\n", address.Name, address.IP)
+		return 1
+	}
+
+	if current != address.IP {
+		fmt.Fprintf(writer, "Updated global IP %s (%s => %s)\n", address.Name, current, address.IP)
+		return 1
+	}
+
+	if existing.Stale {
+		fmt.Fprintf(writer, "Restored global IP %s (%s)\n", address.Name, address.IP)
+		return 1
+	}
+
+	return 0
+}
+
+func mergeHostOption(configData *config.HostsConfig, origin config.Provenance, address provider.Address, writer, errWriter io.Writer) int {
+	if configData.Hosts == nil {
+		configData.Hosts = map[string]config.Host{}
+	}
+
+	host, exists := configData.Hosts[address.Host]
+	if !exists {
+		host = config.Host{Current: address.Name, Options: map[string]string{}}
+	}
+
+	if host.Options == nil {
+		host.Options = map[string]string{}
+	}
+
+	if host.Provenance == nil {
+		host.Provenance = map[string]config.Provenance{}
+	}
+
+	current, optionExists := host.Options[address.Name]
+	existing := host.Provenance[address.Name]
+	if owner, conflicts := conflictingOwner(existing, origin.Source); optionExists && conflicts {
+		reportConflict(address, current, owner, errWriter)
+		return 0
+	}
+
+	host.Options[address.Name] = address.IP
+	host.Provenance[address.Name] = addressProvenance(origin, address, existing, current != address.IP)
+	makeCurrent := exists && address.Metadata[provider.CurrentMetadata] == "true" && host.Current != address.Name
+	if makeCurrent {
+		host.Current = address.Name
+	}
+
+	configData.Hosts[address.Host] = host
+	if !exists {
+		fmt.Fprintf(writer, "Added host %s (%s => %s)\n", address.Host, address.Name, address.IP)
+		return 1
+	}
+
+	changes := 0
+	switch {
+	case !optionExists:
+		fmt.Fprintf(writer, "Added option to %s (%s => %s)\n", address.Host, address.Name, address.IP)
+		changes = 1
+	case current != address.IP:
+		fmt.Fprintf(writer, "Updated %s (%s: %s => %s)\n", address.Host, address.Name, current, address.IP)
+		changes = 1
+	case existing.Stale:
+		fmt.Fprintf(writer, "Restored %s on %s (%s)\n", address.Name, address.Host, address.IP)
+		changes = 1
+	}
+
+	if makeCurrent {
+		fmt.Fprintf(writer, "Switched %s to %s\n", address.Host, address.Name)
+		changes = 1
+	}
+
+	return changes
+}
+
+// staleGlobalIPs flags or removes the global IPs from source that were not returned
+func staleGlobalIPs(configData *config.HostsConfig, source string, returned map[string]bool, policy stalePolicy, writer, errWriter io.Writer) int {
+	changes := 0
+	for _, name := range sortGlobalIPNames(configData) {
+		provenance := configData.Provenance[name]
+		if provenance.Source != source || returned[" "+name] {
+			continue
+		}
+
+		users := globalIPUsers(configData, name)
+		if !policy.prune || (policy.current == currentKeep && len(users) != 0) {
+			if provenance.Stale {
+				continue
+			}
+
+			provenance.Stale = true
+			configData.Provenance[name] = provenance
+			fmt.Fprintf(writer, "Marked global IP %s stale (%s)\n", name, configData.GlobalIPs[name])
+			reportStaleUsers(users, "global IP "+name, policy, errWriter)
+			changes++
+			continue
+		}
+
+		fmt.Fprintf(writer, "Removed global IP %s (%s)\n", name, configData.GlobalIPs[name])
+		delete(configData.GlobalIPs, name)
+		delete(configData.Provenance, name)
+		for _, hostName := range users {
+			replaceCurrent(configData, hostName, "global IP "+name, policy, errWriter)
+		}
+
+		changes++
+	}
+
+	return changes
+}
+
+// staleHostOptions flags or removes the host options from source that were not returned
+func staleHostOptions(configData *config.HostsConfig, source string, returned map[string]bool, policy stalePolicy, writer, errWriter io.Writer) int {
+	changes := 0
+	for _, hostName := range sortHostNames(configData) {
+		for _, option := range sortOptions(configData, hostName) {
+			host := configData.Hosts[hostName]
+			provenance := host.Provenance[option]
+			if provenance.Source != source || returned[hostName+" "+option] {
+				continue
+			}
+
+			users := []string{}
+			if host.Current == option {
+				users = append(users, hostName)
+			}
+
+			if !policy.prune || (policy.current == currentKeep && len(users) != 0) {
+				if provenance.Stale {
+					continue
+				}
+
+				provenance.Stale = true
+				host.Provenance[option] = provenance
+				fmt.Fprintf(writer, "Marked %s on %s stale (%s)\n", option, hostName, host.Options[option])
+				reportStaleUsers(users, option, policy, errWriter)
+				changes++
+				continue
+			}
+
+			fmt.Fprintf(writer, "Removed %s from %s (%s)\n", option, hostName, host.Options[option])
+			delete(host.Options, option)
+			delete(host.Provenance, option)
+			if len(users) != 0 {
+				replaceCurrent(configData, hostName, option, policy, errWriter)
+			}
+
+			changes++
+		}
+	}
+
+	return changes
+}
+
+// globalIPUsers lists the hosts whose current selection is the global IP
+func globalIPUsers(configData *config.HostsConfig, name string) []string {
+	users := []string{}
+	for _, hostName := range sortHostNames(configData) {
+		host := configData.Hosts[hostName]
+		if _, shadowed := host.Options[name]; host.Current == name && !shadowed {
+			users = append(users, hostName)
+		}
+	}
+
+	return users
+}
+
+// reportStaleUsers warns about the hosts kept on a stale entry when pruning
+func reportStaleUsers(users []string, entry string, policy stalePolicy, errWriter io.Writer) {
+	if !policy.prune {
+		return
+	}
+
+	for _, hostName := range users {
+		fmt.Fprintf(errWriter, "Warning: %s is still using %s, keeping it as stale\n", hostName, entry)
+	}
+}
+
+// replaceCurrent applies the policy to a host whose current selection was removed
+func replaceCurrent(configData *config.HostsConfig, hostName, entry string, policy stalePolicy, errWriter io.Writer) {
+	host := configData.Hosts[hostName]
+	switch policy.current {
+	case currentIgnore:
+		host.Current = hostIgnore
+		fmt.Fprintf(errWriter, "Warning: %s was using %s which was removed, it is now ignored\n", hostName, entry)
+	case currentSwitch:
+		host.Current = hostIgnore
+		for _, option := range sortOptions(configData, hostName) {
+			if !host.Provenance[option].Stale {
+				host.Current = option
+				break
+			}
+		}
+
+		fmt.Fprintf(errWriter, "Warning: %s was using %s which was removed, switched to %s\n", hostName, entry, host.Current)
+	default:
+		fmt.Fprintf(errWriter, "Warning: %s was using %s which was removed, it has no IP until another is chosen\n", hostName, entry)
+	}
+
+	configData.Hosts[hostName] = host
+}
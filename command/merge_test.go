@@ -0,0 +1,199 @@
+package command
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func staleTestConfig() *config.HostsConfig {
+	return &config.HostsConfig{
+		Hosts: map[string]config.Host{
+			"web.com": {
+				Current:    "aws",
+				Options:    map[string]string{"aws": "10.0.0.1", "local": "127.0.0.1"},
+				Provenance: map[string]config.Provenance{"aws": {Source: "aws"}},
+			},
+			"api.com": {Current: "api", Options: map[string]string{}},
+			"db.com":  {Current: "db", Options: map[string]string{}},
+		},
+		GlobalIPs:  map[string]string{"api": "10.0.0.2", "db": "10.0.0.3"},
+		Provenance: map[string]config.Provenance{"api": {Source: "aws"}, "db": {Source: "aws"}},
+	}
+}
+
+func TestMergeAddressesProvenance(t *testing.T) {
+	configData := staleTestConfig()
+	earlier := testImportedAt.Add(-time.Hour)
+	configData.Provenance["api"] = config.Provenance{Source: "aws", ImportedAt: &earlier}
+	addresses := []provider.Address{
+		{Name: "api", IP: "10.0.0.2", Metadata: map[string]string{"profile": "prod", "region": "us-east-1", "resourceId": "i-1"}},
+		{Name: "db", IP: "10.0.0.4", Metadata: map[string]string{"region": "us-west-2", "resourceId": "i-2"}},
+		{Host: "web.com", Name: "aws", IP: "10.0.0.1"},
+	}
+
+	writer := new(bytes.Buffer)
+	changes := mergeAddresses(configData, importOrigin("aws", "awsInstances"), addresses, stalePolicy{current: currentWarn}, writer, new(bytes.Buffer))
+	assert.Equal(t, 1, changes)
+	assert.Equal(t, "Updated global IP db (10.0.0.3 => 10.0.0.4)\n", writer.String())
+	assert.Equal(
+		t,
+		map[string]config.Provenance{
+			"api": {Source: "aws", Provider: "awsInstances", Profile: "prod", Region: "us-east-1", ResourceID: "i-1", ImportedAt: &earlier, FetchedAt: &testImportedAt},
+			"db":  {Source: "aws", Provider: "awsInstances", Region: "us-west-2", ResourceID: "i-2", ImportedAt: &testImportedAt, FetchedAt: &testImportedAt},
+		},
+		configData.Provenance,
+	)
+}
+
+func TestMergeAddressesMarksStale(t *testing.T) {
+	configData := staleTestConfig()
+	writer := new(bytes.Buffer)
+	errWriter := new(bytes.Buffer)
+	addresses := []provider.Address{{Name: "db", IP: "10.0.0.3"}}
+	changes := mergeAddresses(configData, importOrigin("aws", "awsInstances"), addresses, stalePolicy{current: currentWarn}, writer, errWriter)
+	assert.Equal(t, 2, changes)
+	assert.Equal(t, "Marked global IP api stale (10.0.0.2)\nMarked aws on web.com stale (10.0.0.1)\n", writer.String())
+	assert.Equal(t, "", errWriter.String())
+	assert.True(t, configData.Provenance["api"].Stale)
+	assert.True(t, configData.Hosts["web.com"].Provenance["aws"].Stale)
+	assert.Equal(t, "10.0.0.2", configData.GlobalIPs["api"])
+	assert.Equal(t, "aws", configData.Hosts["web.com"].Current)
+
+	writer.Reset()
+	assert.Equal(t, 0, mergeAddresses(configData, importOrigin("aws", "awsInstances"), addresses, stalePolicy{current: currentWarn}, writer, errWriter))
+	assert.Equal(t, "", writer.String())
+
+	addresses = append(addresses, provider.Address{Name: "api", IP: "10.0.0.2"})
+	assert.Equal(t, 1, mergeAddresses(configData, importOrigin("aws", "awsInstances"), addresses, stalePolicy{current: currentWarn}, writer, errWriter))
+	assert.Equal(t, "Restored global IP api (10.0.0.2)\n", writer.String())
+	assert.False(t, configData.Provenance["api"].Stale)
+}
+
+func TestMergeAddressesPruneWarn(t *testing.T) {
+	configData := staleTestConfig()
+	writer := new(bytes.Buffer)
+	errWriter := new(bytes.Buffer)
+	policy, err := newStalePolicy(true, "")
+	assert.Nil(t, err)
+
+	changes := mergeAddresses(configData, importOrigin("aws", "awsInstances"), []provider.Address{{Name: "db", IP: "10.0.0.3"}}, policy, writer, errWriter)
+	assert.Equal(t, 2, changes)
+	assert.Equal(t, "Removed global IP api (10.0.0.2)\nRemoved aws from web.com (10.0.0.1)\n", writer.String())
+	assert.Equal(
+		t,
+		"Warning: api.com was using global IP api which was removed, it has no IP until another is chosen\n"+
+			"Warning: web.com was using aws which was removed, it has no IP until another is chosen\n",
+		errWriter.String(),
+	)
+	assert.Equal(t, "api", configData.Hosts["api.com"].Current)
+	assert.Equal(t, "aws", configData.Hosts["web.com"].Current)
+}
+
+func TestMergeAddressesPruneKeep(t *testing.T) {
+	configData := staleTestConfig()
+	configData.Hosts["api.com"] = config.Host{Current: "other", Options: map[string]string{}}
+	writer := new(bytes.Buffer)
+	errWriter := new(bytes.Buffer)
+	addresses := []provider.Address{{Name: "db", IP: "10.0.0.3"}}
+	changes := mergeAddresses(configData, importOrigin("aws", "awsInstances"), addresses, stalePolicy{prune: true, current: currentKeep}, writer, errWriter)
+	assert.Equal(t, 2, changes)
+	assert.Equal(t, "Removed global IP api (10.0.0.2)\nMarked aws on web.com stale (10.0.0.1)\n", writer.String())
+	assert.Equal(t, "Warning: web.com is still using aws, keeping it as stale\n", errWriter.String())
+	assert.Equal(t, map[string]string{"db": "10.0.0.3"}, configData.GlobalIPs)
+	assert.True(t, configData.Hosts["web.com"].Provenance["aws"].Stale)
+}
+
+func TestMergeAddressesPruneIgnore(t *testing.T) {
+	configData := staleTestConfig()
+	errWriter := new(bytes.Buffer)
+	addresses := []provider.Address{{Name: "api", IP: "10.0.0.2"}}
+	mergeAddresses(configData, importOrigin("aws", "awsInstances"), addresses, stalePolicy{prune: true, current: currentIgnore}, new(bytes.Buffer), errWriter)
+	assert.Equal(
+		t,
+		"Warning: db.com was using global IP db which was removed, it is now ignored\n"+
+			"Warning: web.com was using aws which was removed, it is now ignored\n",
+		errWriter.String(),
+	)
+	assert.Equal(t, hostIgnore, configData.Hosts["db.com"].Current)
+	assert.Equal(t, hostIgnore, configData.Hosts["web.com"].Current)
+}
+
+func TestMergeAddressesPruneSwitch(t *testing.T) {
+	configData := staleTestConfig()
+	errWriter := new(bytes.Buffer)
+	addresses := []provider.Address{{Name: "api", IP: "10.0.0.2"}}
+	mergeAddresses(configData, importOrigin("aws", "awsInstances"), addresses, stalePolicy{prune: true, current: currentSwitch}, new(bytes.Buffer), errWriter)
+	assert.Equal(
+		t,
+		"Warning: db.com was using global IP db which was removed, switched to ignore\n"+
+			"Warning: web.com was using aws which was removed, switched to local\n",
+		errWriter.String(),
+	)
+	assert.Equal(t, hostIgnore, configData.Hosts["db.com"].Current)
+	assert.Equal(t, "local", configData.Hosts["web.com"].Current)
+}
+
+func TestMergeAddressesMakeCurrent(t *testing.T) {
+	configData := staleTestConfig()
+	configData.Hosts["web.com"].Provenance["local"] = config.Provenance{Source: "tags"}
+	addresses := []provider.Address{
+		{Host: "web.com", Name: "local", IP: "127.0.0.1", Metadata: map[string]string{provider.CurrentMetadata: "true"}},
+		{Host: "api.com", Name: "staging", IP: "10.0.0.5", Metadata: map[string]string{provider.CurrentMetadata: "true"}},
+		{Host: "db.com", Name: "staging", IP: "10.0.0.6", Metadata: map[string]string{provider.CurrentMetadata: "false"}},
+		{Host: "new.com", Name: "staging", IP: "10.0.0.7", Metadata: map[string]string{provider.CurrentMetadata: "true"}},
+	}
+
+	writer := new(bytes.Buffer)
+	changes := mergeAddresses(configData, importOrigin("tags", "awsInstances"), addresses, stalePolicy{current: currentWarn}, writer, new(bytes.Buffer))
+	assert.Equal(t, 4, changes)
+	assert.Equal(
+		t,
+		"Added option to api.com (staging => 10.0.0.5)\n"+
+			"Switched api.com to staging\n"+
+			"Added option to db.com (staging => 10.0.0.6)\n"+
+			"Added host new.com (staging => 10.0.0.7)\n"+
+			"Switched web.com to local\n",
+		writer.String(),
+	)
+	assert.Equal(t, "local", configData.Hosts["web.com"].Current)
+	assert.Equal(t, "staging", configData.Hosts["api.com"].Current)
+	assert.Equal(t, "db", configData.Hosts["db.com"].Current)
+	assert.Equal(t, "staging", configData.Hosts["new.com"].Current)
+}
+
+func TestMergeAddressesConflicts(t *testing.T) {
+	configData := staleTestConfig()
+	configData.GlobalIPs["manual"] = "10.0.0.9"
+	addresses := []provider.Address{
+		{Name: "api", IP: "10.0.0.5"},
+		{Name: "manual", IP: "10.0.0.6"},
+		{Host: "web.com", Name: "aws", IP: "10.0.0.1"},
+		{Host: "web.com", Name: "local", IP: "127.0.0.2", Metadata: map[string]string{provider.CurrentMetadata: "true"}},
+	}
+
+	writer := new(bytes.Buffer)
+	errWriter := new(bytes.Buffer)
+	changes := mergeAddresses(configData, importOrigin("tags", "awsInstances"), addresses, stalePolicy{current: currentWarn}, writer, errWriter)
+	assert.Equal(t, 0, changes)
+	assert.Equal(t, "", writer.String())
+	assert.Equal(
+		t,
+		"Warning: global IP api belongs to source aws, keeping 10.0.0.2 and ignoring 10.0.0.5\n"+
+			"Warning: global IP manual was added by hand, keeping 10.0.0.9 and ignoring 10.0.0.6\n"+
+			"Warning: local on web.com was added by hand, keeping 127.0.0.1 and ignoring 127.0.0.2\n",
+		errWriter.String(),
+	)
+	assert.Equal(t, staleTestConfig().Provenance, configData.Provenance)
+	assert.Equal(t, "10.0.0.9", configData.GlobalIPs["manual"])
+	assert.Equal(t, staleTestConfig().Hosts["web.com"], configData.Hosts["web.com"])
+}
+
+func TestNewStalePolicyInvalid(t *testing.T) {
+	_, err := newStalePolicy(true, "drop")
+	assert.EqualError(t, err, "Invalid current policy drop, expected one of warn, keep, ignore, switch")
+}
@@ -0,0 +1,107 @@
+package command
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// networkLookup tells the auto rules where this machine is, tests replace it with a fixed network
+type networkLookup interface {
+	Interfaces() ([]networkInterface, error)
+	DefaultGateway() (net.IP, error)
+	LookupEnv(name string) (string, bool)
+}
+
+// networkInterface is an interface that is up and the addresses it has
+type networkInterface struct {
+	Name  string
+	Addrs []net.IP
+}
+
+// systemNetwork looks up the interfaces and default gateway of this machine
+type systemNetwork struct {
+	routeFile string
+}
+
+func (systemNetwork) Interfaces() ([]networkInterface, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	up := []networkInterface{}
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+
+		IPs := make([]net.IP, 0, len(addrs))
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				IPs = append(IPs, ipNet.IP)
+			}
+		}
+
+		up = append(up, networkInterface{Name: iface.Name, Addrs: IPs})
+	}
+
+	return up, nil
+}
+
+// DefaultGateway reads the default route from the kernel routing table, which only Linux has
+func (n systemNetwork) DefaultGateway() (net.IP, error) {
+	routes, err := os.Open(n.routeFile)
+	if err != nil {
+		return nil, errors.New("Unable to find the default gateway")
+	}
+
+	defer func() { _ = routes.Close() }()
+	return parseDefaultGateway(routes)
+}
+
+func (systemNetwork) LookupEnv(name string) (string, bool) {
+	return os.LookupEnv(name)
+}
+
+// parseDefaultGateway finds the gateway of the default route in /proc/net/route, where addresses are little endian hex
+func parseDefaultGateway(routes io.Reader) (net.IP, error) {
+	scanner := bufio.NewScanner(routes)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[1] != "00000000" {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[3], 16, 16)
+		if err != nil || flags&0x2 == 0 {
+			continue
+		}
+
+		gateway, err := hex.DecodeString(fields[2])
+		if err != nil || len(gateway) != net.IPv4len {
+			continue
+		}
+
+		IP := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(IP, binary.LittleEndian.Uint32(gateway))
+		return IP, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, errors.New("Unable to find the default gateway")
+}
@@ -0,0 +1,66 @@
+package command
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRoutes = `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	0001A8C0	00000000	0001	0	0	100	00FFFFFF	0	0	0
+eth0	00000000	0101A8C0	0003	0	0	100	00000000	0	0	0
+`
+
+func TestParseDefaultGateway(t *testing.T) {
+	gateway, err := parseDefaultGateway(strings.NewReader(testRoutes))
+	assert.Nil(t, err)
+	assert.Equal(t, net.IPv4(192, 168, 1, 1).To4(), gateway)
+}
+
+func TestParseDefaultGatewayMissing(t *testing.T) {
+	_, err := parseDefaultGateway(strings.NewReader(strings.Split(testRoutes, "\n")[0]))
+	assert.EqualError(t, err, "Unable to find the default gateway")
+}
+
+func TestSystemNetworkNoRouteFile(t *testing.T) {
+	_, err := systemNetwork{routeFile: "/notafile"}.DefaultGateway()
+	assert.EqualError(t, err, "Unable to find the default gateway")
+}
+
+// fakeNetwork is a fixed network for the auto rules, a nil gateway can not be looked up
+type fakeNetwork struct {
+	interfaces []networkInterface
+	gateway    net.IP
+	env        map[string]string
+}
+
+func (n fakeNetwork) Interfaces() ([]networkInterface, error) {
+	return n.interfaces, nil
+}
+
+func (n fakeNetwork) DefaultGateway() (net.IP, error) {
+	if n.gateway == nil {
+		return nil, errors.New("no default route")
+	}
+
+	return n.gateway, nil
+}
+
+func (n fakeNetwork) LookupEnv(name string) (string, bool) {
+	value, set := n.env[name]
+	return value, set
+}
+
+// officeNetwork is on the office LAN with the VPN up
+var officeNetwork = fakeNetwork{
+	interfaces: []networkInterface{
+		{Name: "lo", Addrs: []net.IP{net.ParseIP("127.0.0.1")}},
+		{Name: "eth0", Addrs: []net.IP{net.ParseIP("10.1.2.3"), net.ParseIP("fe80::1")}},
+		{Name: "tun0", Addrs: []net.IP{net.ParseIP("172.16.0.5")}},
+	},
+	gateway: net.ParseIP("10.1.0.1"),
+	env:     map[string]string{"LOCATION": "office"},
+}
@@ -0,0 +1,138 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/urfave/cli"
+)
+
+// providerFlags builds a flag for every provider setting, along with --source, --prune and --current
+// Settings the command takes as arguments don't get a flag
+func providerFlags(commandProvider provider.Provider, arguments ...string) []cli.Flag {
+	isArgument := make(map[string]bool, len(arguments))
+	for _, argument := range arguments {
+		isArgument[argument] = true
+	}
+
+	schema := commandProvider.Schema()
+	flags := make([]cli.Flag, 0, len(schema)+3)
+	for _, setting := range schema {
+		if isArgument[setting.Name] {
+			continue
+		}
+
+		name := setting.Name
+		if setting.Alias != "" {
+			name = fmt.Sprintf("%s, %s", setting.Name, setting.Alias)
+		}
+
+		flags = append(flags, cli.StringFlag{Name: name, Usage: setting.Usage, Value: setting.Default, EnvVar: setting.EnvVar})
+	}
+
+	return append(flags, sourceFlag, pruneFlag, currentFlag)
+}
+
+// providerSettings reads the provider settings from the command flags
+func providerSettings(c *cli.Context, commandProvider provider.Provider) (map[string]string, error) {
+	settings := map[string]string{}
+	for _, setting := range commandProvider.Schema() {
+		settings[setting.Name] = c.String(setting.Name)
+	}
+
+	return provider.ApplyDefaults(commandProvider, settings)
+}
+
+// CmdProvider imports the addresses the provider discovers into the configuration
+// usageError is returned when arguments are given
+func CmdProvider(commandProvider provider.Provider, usageError string) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		if c.NArg() != 0 {
+			return cli.NewExitError(usageError, 1)
+		}
+
+		settings, err := providerSettings(c, commandProvider)
+		if err != nil {
+			return err
+		}
+
+		return importProvider(c, commandProvider, settings, commandProvider.Name())
+	}
+}
+
+// importProvider merges the addresses the provider discovers with the settings into the configuration
+// defaultSource is recorded as their source unless --source is given
+func importProvider(c *cli.Context, commandProvider provider.Provider, settings map[string]string, defaultSource string) error {
+	policy, err := flagStalePolicy(c)
+	if err != nil {
+		return err
+	}
+
+	addresses, fetchedAt, err := discoverProvider(c, commandProvider, settings)
+	if err != nil {
+		return err
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	source := c.String("source")
+	if source == "" {
+		source = defaultSource
+	}
+
+	if mergeAddresses(configData, fetchedOrigin(source, commandProvider.Name(), fetchedAt), addresses, policy, c.App.Writer, c.App.ErrWriter) == 0 {
+		fmt.Fprintln(c.App.Writer, "Nothing to import")
+	}
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+// CompleteProvider handles bash autocompletion for a command built from a provider
+func CompleteProvider(commandProvider provider.Provider, commandName string) func(c *cli.Context) {
+	return func(c *cli.Context) {
+		completeProviderCommand(c, commandName, commandProvider)
+	}
+}
+
+func completeProviderCommand(c *cli.Context, commandName string, commandProvider provider.Provider) {
+	lastParam := os.Args[len(os.Args)-2]
+	if lastParam == "--"+currentFlag.Name {
+		fmt.Fprintln(c.App.Writer, strings.Join(currentPolicies, "\n"))
+		return
+	}
+
+	for _, setting := range commandProvider.Schema() {
+		if lastParam != "--"+setting.Name {
+			continue
+		}
+
+		if setting.File {
+			fmt.Fprintln(c.App.Writer, "fileCompletion")
+			return
+		}
+
+		settings := map[string]string{}
+		for _, other := range commandProvider.Schema() {
+			settings[other.Name] = c.String(other.Name)
+		}
+
+		for _, value := range commandProvider.Complete(setting.Name, settings) {
+			fmt.Fprintln(c.App.Writer, value)
+		}
+
+		return
+	}
+
+	for _, flag := range c.App.Command(commandName).Flags {
+		name := strings.Split(flag.GetName(), ",")[0]
+		if !c.IsSet(name) {
+			fmt.Fprintf(c.App.Writer, "--%s\n", name)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+const (
+	dnsTypeA    = 1
+	dnsTypeAAAA = 28
+)
+
+var errInvalidDNSResponse = errors.New("Invalid DNS response")
+
+// resolver looks up the addresses of a hostname, tests replace it with fixed answers
+type resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// newResolver is the system resolver, or a resolver that only asks a DNS server when one is given as host or host:port
+func newResolver(server string) resolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+
+	return dnsResolver{server: server}
+}
+
+// dnsResolver asks one DNS server directly, the system resolver would answer from the hosts file first
+type dnsResolver struct {
+	server string
+}
+
+func (r dnsResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	addresses := []string{}
+	for _, queryType := range []uint16{dnsTypeA, dnsTypeAAAA} {
+		answers, err := r.query(ctx, host, queryType)
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, answers...)
+	}
+
+	if len(addresses) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, Server: r.server, IsNotFound: true}
+	}
+
+	return addresses, nil
+}
+
+// query asks over UDP and again over TCP when the answer did not fit in a UDP response
+func (r dnsResolver) query(ctx context.Context, host string, queryType uint16) ([]string, error) {
+	id := make([]byte, 2)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := encodeDNSQuery(binary.BigEndian.Uint16(id), host, queryType)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := r.exchange(ctx, "udp", query)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response) > 2 && response[2]&0x02 != 0 {
+		response, err = r.exchange(ctx, "tcp", query)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return parseDNSAnswers(query, response)
+}
+
+// exchange sends a query and reads the response, over TCP both are prefixed with their length
+func (r dnsResolver) exchange(ctx context.Context, network string, query []byte) ([]byte, error) {
+	var dialer net.Dialer
+	connection, err := dialer.DialContext(ctx, network, r.server)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = connection.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = connection.SetDeadline(deadline)
+	}
+
+	if network == "udp" {
+		_, err = connection.Write(query)
+		if err != nil {
+			return nil, err
+		}
+
+		response := make([]byte, 4096)
+		length, err := connection.Read(response)
+		if err != nil {
+			return nil, err
+		}
+
+		return response[:length], nil
+	}
+
+	_, err = connection.Write(append([]byte{byte(len(query) >> 8), byte(len(query))}, query...))
+	if err != nil {
+		return nil, err
+	}
+
+	length := make([]byte, 2)
+	_, err = io.ReadFull(connection, length)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]byte, binary.BigEndian.Uint16(length))
+	_, err = io.ReadFull(connection, response)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// encodeDNSQuery builds a recursive query for one record type of a hostname
+func encodeDNSQuery(id uint16, host string, queryType uint16) ([]byte, error) {
+	query := []byte{byte(id >> 8), byte(id), 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0}
+	for _, label := range strings.Split(strings.TrimSuffix(host, "."), ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return nil, fmt.Errorf("Invalid hostname %s", host)
+		}
+
+		query = append(query, byte(len(label)))
+		query = append(query, label...)
+	}
+
+	return append(query, 0, byte(queryType>>8), byte(queryType), 0, 1), nil
+}
+
+// parseDNSAnswers reads the addresses of the queried record type from a response, a name that does not exist has none
+func parseDNSAnswers(query, response []byte) ([]string, error) {
+	if !isDNSResponseTo(query, response) {
+		return nil, errInvalidDNSResponse
+	}
+
+	switch rcode := response[3] & 0x0f; rcode {
+	case 0:
+	case 3:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("DNS server failed with rcode %d", rcode)
+	}
+
+	queryType := binary.BigEndian.Uint16(query[len(query)-4:])
+	answers := int(binary.BigEndian.Uint16(response[6:]))
+	offset := len(query)
+	var err error
+	addresses := []string{}
+	for index := 0; index < answers; index++ {
+		offset, err = skipDNSName(response, offset)
+		if err != nil || offset+10 > len(response) {
+			return nil, errInvalidDNSResponse
+		}
+
+		recordType := binary.BigEndian.Uint16(response[offset:])
+		dataLength := int(binary.BigEndian.Uint16(response[offset+8:]))
+		offset += 10
+		if offset+dataLength > len(response) {
+			return nil, errInvalidDNSResponse
+		}
+
+		if recordType == queryType && (dataLength == net.IPv4len || dataLength == net.IPv6len) {
+			addresses = append(addresses, net.IP(response[offset:offset+dataLength]).String())
+		}
+
+		offset += dataLength
+	}
+
+	return addresses, nil
+}
+
+// isDNSResponseTo checks the response has the id of the query, is marked as a response and repeats the question that was asked
+// Servers may change the case of the name, so it is compared case insensitively
+func isDNSResponseTo(query, response []byte) bool {
+	return len(response) >= len(query) &&
+		bytes.Equal(response[:2], query[:2]) &&
+		response[2]&0x80 != 0 &&
+		binary.BigEndian.Uint16(response[4:]) == 1 &&
+		bytes.EqualFold(response[12:len(query)], query[12:])
+}
+
+func skipDNSName(message []byte, offset int) (int, error) {
+	for offset < len(message) {
+		length := int(message[offset])
+		switch {
+		case length == 0:
+			return offset + 1, nil
+		case length&0xc0 == 0xc0:
+			return offset + 2, nil
+		default:
+			offset += length + 1
+		}
+	}
+
+	return 0, errInvalidDNSResponse
+}
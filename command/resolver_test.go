@@ -0,0 +1,228 @@
+package command
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDNSResolver(t *testing.T) {
+	server := startDNSServer(t, map[string][]net.IP{
+		"api.example.com.": {net.ParseIP("10.0.0.9"), net.ParseIP("fd00::9")},
+	})
+	defer func() { _ = server.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	addresses, err := newResolver(server.LocalAddr().String()).LookupHost(ctx, "api.example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.9", "fd00::9"}, addresses)
+
+	_, err = newResolver(server.LocalAddr().String()).LookupHost(ctx, "gone.example.com")
+	assert.EqualError(t, err, "lookup gone.example.com on "+server.LocalAddr().String()+": no such host")
+}
+
+func TestDNSResolverTruncated(t *testing.T) {
+	IPs := []net.IP{}
+	expected := []string{}
+	for index := 1; index <= 40; index++ {
+		IP := net.IPv4(10, 0, 1, byte(index))
+		IPs = append(IPs, IP)
+		expected = append(expected, IP.String())
+	}
+
+	udp, tcp := startTruncatingDNSServer(t, map[string][]net.IP{"api.example.com.": IPs})
+	defer func() { _ = udp.Close() }()
+	defer func() { _ = tcp.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	addresses, err := newResolver(udp.LocalAddr().String()).LookupHost(ctx, "api.example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, expected, addresses)
+}
+
+func TestNewResolver(t *testing.T) {
+	assert.Equal(t, net.DefaultResolver, newResolver(""))
+	assert.Equal(t, dnsResolver{server: "10.0.0.53:53"}, newResolver("10.0.0.53"))
+	assert.Equal(t, dnsResolver{server: "10.0.0.53:5353"}, newResolver("10.0.0.53:5353"))
+}
+
+func TestEncodeDNSQueryInvalid(t *testing.T) {
+	_, err := encodeDNSQuery(1, "api..example.com", dnsTypeA)
+	assert.EqualError(t, err, "Invalid hostname api..example.com")
+}
+
+func TestParseDNSAnswersInvalid(t *testing.T) {
+	query, err := encodeDNSQuery(1, "api.example.com", dnsTypeA)
+	assert.Nil(t, err)
+
+	_, err = parseDNSAnswers(query, []byte{0, 1})
+	assert.Equal(t, errInvalidDNSResponse, err)
+
+	response := dnsResponse(query, nil)
+	response[1] = 2
+	_, err = parseDNSAnswers(query, response)
+	assert.Equal(t, errInvalidDNSResponse, err)
+
+	_, err = parseDNSAnswers(query, dnsResponse(query, nil)[:len(query)-1])
+	assert.Equal(t, errInvalidDNSResponse, err)
+
+	response = dnsResponse(query, nil)
+	response[3] = 0x82
+	_, err = parseDNSAnswers(query, response)
+	assert.EqualError(t, err, "DNS server failed with rcode 2")
+
+	response = dnsResponse(query, map[string][]net.IP{"api.example.com.": {net.ParseIP("10.0.0.9")}})
+	_, err = parseDNSAnswers(query, response[:len(response)-2])
+	assert.Equal(t, errInvalidDNSResponse, err)
+}
+
+func TestParseDNSAnswersNotAResponse(t *testing.T) {
+	query, err := encodeDNSQuery(1, "api.example.com", dnsTypeA)
+	assert.Nil(t, err)
+
+	response := dnsResponse(query, map[string][]net.IP{"api.example.com.": {net.ParseIP("10.0.0.9")}})
+	response[2] &^= 0x80
+	_, err = parseDNSAnswers(query, response)
+	assert.Equal(t, errInvalidDNSResponse, err)
+}
+
+func TestParseDNSAnswersOtherQuestion(t *testing.T) {
+	query, err := encodeDNSQuery(1, "api.example.com", dnsTypeA)
+	assert.Nil(t, err)
+	otherQuery, err := encodeDNSQuery(1, "www.example.com", dnsTypeA)
+	assert.Nil(t, err)
+
+	_, err = parseDNSAnswers(query, dnsResponse(otherQuery, map[string][]net.IP{"www.example.com.": {net.ParseIP("10.0.0.9")}}))
+	assert.Equal(t, errInvalidDNSResponse, err)
+
+	AAAAQuery, err := encodeDNSQuery(1, "api.example.com", dnsTypeAAAA)
+	assert.Nil(t, err)
+	_, err = parseDNSAnswers(query, dnsResponse(AAAAQuery, map[string][]net.IP{"api.example.com.": {net.ParseIP("fd00::9")}}))
+	assert.Equal(t, errInvalidDNSResponse, err)
+}
+
+func TestParseDNSAnswersMixedCase(t *testing.T) {
+	query, err := encodeDNSQuery(1, "api.example.com", dnsTypeA)
+	assert.Nil(t, err)
+	mixedQuery, err := encodeDNSQuery(1, "API.Example.com", dnsTypeA)
+	assert.Nil(t, err)
+
+	addresses, err := parseDNSAnswers(query, dnsResponse(mixedQuery, map[string][]net.IP{"API.Example.com.": {net.ParseIP("10.0.0.9")}}))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.9"}, addresses)
+}
+
+// startDNSServer answers A and AAAA queries from fixed records and NXDOMAIN for anything else
+func startDNSServer(t *testing.T, records map[string][]net.IP) net.PacketConn {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go func() {
+		query := make([]byte, 512)
+		for {
+			length, address, err := server.ReadFrom(query)
+			if err != nil {
+				return
+			}
+
+			_, _ = server.WriteTo(dnsResponse(query[:length], records), address)
+		}
+	}()
+
+	return server
+}
+
+// startTruncatingDNSServer only answers with the truncated bit over UDP, the answers are served over TCP on the same port
+func startTruncatingDNSServer(t *testing.T, records map[string][]net.IP) (net.PacketConn, net.Listener) {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	assert.Nil(t, err)
+	go func() {
+		query := make([]byte, 512)
+		for {
+			length, address, err := udp.ReadFrom(query)
+			if err != nil {
+				return
+			}
+
+			truncated := dnsResponse(query[:length], nil)
+			truncated[2], truncated[3] = truncated[2]|0x02, 0x80
+			_, _ = udp.WriteTo(truncated, address)
+		}
+	}()
+
+	go func() {
+		for {
+			connection, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+
+			go func(connection net.Conn) {
+				defer func() { _ = connection.Close() }()
+				for {
+					length := make([]byte, 2)
+					if _, err := io.ReadFull(connection, length); err != nil {
+						return
+					}
+
+					query := make([]byte, binary.BigEndian.Uint16(length))
+					if _, err := io.ReadFull(connection, query); err != nil {
+						return
+					}
+
+					response := dnsResponse(query, records)
+					_, _ = connection.Write(append([]byte{byte(len(response) >> 8), byte(len(response))}, response...))
+				}
+			}(connection)
+		}
+	}()
+
+	return udp, tcp
+}
+
+func dnsResponse(query []byte, records map[string][]net.IP) []byte {
+	name, labelsEnd := "", 12
+	for query[labelsEnd] != 0 {
+		length := int(query[labelsEnd])
+		name += string(query[labelsEnd+1:labelsEnd+1+length]) + "."
+		labelsEnd += length + 1
+	}
+
+	queryType := binary.BigEndian.Uint16(query[labelsEnd+1:])
+	response := append([]byte{}, query[:labelsEnd+5]...)
+	response[2], response[3] = 0x81, 0x80
+	IPs, exists := records[name]
+	if !exists {
+		response[3] |= 3
+		return response
+	}
+
+	answers := 0
+	for _, IP := range IPs {
+		data := IP.To4()
+		if queryType == dnsTypeAAAA {
+			if data != nil {
+				continue
+			}
+
+			data = IP.To16()
+		} else if data == nil {
+			continue
+		}
+
+		response = append(response, 0xc0, 12, 0, byte(queryType), 0, 1, 0, 0, 0, 60, 0, byte(len(data)))
+		response = append(response, data...)
+		answers++
+	}
+
+	binary.BigEndian.PutUint16(response[6:], uint16(answers))
+	return response
+}
@@ -0,0 +1,131 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/urfave/cli"
+)
+
+// CmdRuleAdd adds a rule that the auto command uses to switch hosts and groups on a network
+func CmdRuleAdd(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return cli.NewExitError("Usage: \"hostBuilder rule add {name} [hostName=option...]\"", 1)
+	}
+
+	hosts, err := parseAssignments(c.Args()[1:], "hostName=option")
+	if err != nil {
+		return err
+	}
+
+	groups, err := parseAssignments(c.StringSlice("group"), "groupName=globalIP")
+	if err != nil {
+		return err
+	}
+
+	rule := config.Rule{
+		Name:      c.Args().Get(0),
+		CIDR:      c.String("cidr"),
+		Gateway:   c.String("gateway"),
+		Interface: c.String("interface"),
+		Env:       c.String("env"),
+		Hosts:     hosts,
+		Groups:    groups,
+	}
+
+	if len(hosts) == 0 && len(groups) == 0 {
+		return cli.NewExitError(fmt.Sprintf("Rule %s does not switch any hosts or groups", rule.Name), 1)
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	err = validateRule(configData, rule)
+	if err != nil {
+		return err
+	}
+
+	index := ruleIndex(configData, rule.Name)
+	switch {
+	case index == -1:
+		configData.Rules = append(configData.Rules, rule)
+	case c.Bool("force"):
+		configData.Rules[index] = rule
+	default:
+		return cli.NewExitError(fmt.Sprintf("Rule %s already exists", rule.Name), 1)
+	}
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+// parseAssignments parses name=value arguments, nil means there were none
+func parseAssignments(args []string, expected string) (map[string]string, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	assignments := make(map[string]string, len(args))
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, cli.NewExitError(fmt.Sprintf("Invalid assignment %s, expected %s", arg, expected), 1)
+		}
+
+		assignments[parts[0]] = parts[1]
+	}
+
+	return assignments, nil
+}
+
+// CompleteRuleAdd handles bash autocompletion for the 'rule add' command
+func CompleteRuleAdd(c *cli.Context) {
+	configData, err := loadConfig(c)
+	if err != nil {
+		return
+	}
+
+	current := os.Args[len(os.Args)-2]
+	if current == "--group" {
+		for _, groupName := range sortGroupNames(configData) {
+			fmt.Fprintf(c.App.Writer, "%s=\n", groupName)
+		}
+
+		return
+	}
+
+	if c.NArg() == 0 {
+		for _, flag := range c.App.Command("add").Flags {
+			name := strings.Split(flag.GetName(), ",")[0]
+			if !c.IsSet(name) {
+				fmt.Fprintf(c.App.Writer, "--%s\n", name)
+			}
+		}
+
+		return
+	}
+
+	if strings.HasSuffix(current, "=") {
+		hostName := strings.TrimSuffix(current, "=")
+		if _, exists := configData.Hosts[hostName]; !exists {
+			return
+		}
+
+		options := append(sortOptions(configData, hostName), sortGlobalIPNames(configData)...)
+		for _, option := range append(options, hostIgnore) {
+			fmt.Fprintf(c.App.Writer, "%s=%s\n", hostName, option)
+		}
+
+		return
+	}
+
+	hosts, _ := parseAssignments(c.Args()[1:], "")
+	for _, hostName := range sortHostNames(configData) {
+		if _, given := hosts[hostName]; !given {
+			fmt.Fprintf(c.App.Writer, "%s=\n", hostName)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package command
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdRuleAdd(t *testing.T) {
+	configFileName, set := setupRuleConfigFile(t, []config.Rule{{Name: "home", Hosts: map[string]string{"goo": "foop"}}})
+	defer removeFile(t, configFileName)
+
+	set.String("cidr", "10.1.0.0/16", "doc")
+	set.String("interface", "tun*", "doc")
+	groups := cli.StringSlice{"foo=baz"}
+	set.Var(&groups, "group", "doc")
+	assert.Nil(t, set.Parse([]string{"office", "baz.com=bazz"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdRuleAdd(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]config.Rule{
+			{Name: "home", Hosts: map[string]string{"goo": "foop"}},
+			{Name: "office", CIDR: "10.1.0.0/16", Interface: "tun*", Hosts: map[string]string{"baz.com": "bazz"}, Groups: map[string]string{"foo": "baz"}},
+		},
+		configData.Rules,
+	)
+}
+
+func TestCmdRuleAddExists(t *testing.T) {
+	configFileName, set := setupRuleConfigFile(t, []config.Rule{{Name: "home", Hosts: map[string]string{"goo": "foop"}}})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"home", "goo=ignore"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdRuleAdd(c), "Rule home already exists")
+}
+
+func TestCmdRuleAddForce(t *testing.T) {
+	configFileName, set := setupRuleConfigFile(t, []config.Rule{{Name: "home", CIDR: "192.168.0.0/16", Hosts: map[string]string{"goo": "foop"}}})
+	defer removeFile(t, configFileName)
+
+	set.Bool("force", true, "doc")
+	set.String("env", "LOCATION=home", "doc")
+	assert.Nil(t, set.Parse([]string{"home", "goo=ignore"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdRuleAdd(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, []config.Rule{{Name: "home", Env: "LOCATION=home", Hosts: map[string]string{"goo": "ignore"}}}, configData.Rules)
+}
+
+func TestCmdRuleAddInvalid(t *testing.T) {
+	tests := []struct {
+		flag  string
+		value string
+		args  []string
+		err   string
+	}{
+		{"cidr", "10.1.0.0", []string{"office", "goo=ignore"}, "Invalid CIDR 10.1.0.0"},
+		{"gateway", "router", []string{"office", "goo=ignore"}, "Invalid gateway router, expected an IP"},
+		{"interface", "tun[", []string{"office", "goo=ignore"}, "Invalid interface pattern tun["},
+		{"env", "=office", []string{"office", "goo=ignore"}, "Invalid environment variable =office, expected NAME or NAME=value"},
+		{"env", "CI", []string{"office", "goo"}, "Invalid assignment goo, expected hostName=option"},
+		{"env", "CI", []string{"office"}, "Rule office does not switch any hosts or groups"},
+		{"env", "CI", []string{"office", "gone=ignore"}, "HostName gone does not exist"},
+		{"env", "CI", []string{"office", "goo=public"}, "IPName public does not exist"},
+	}
+
+	for _, test := range tests {
+		configFileName, set := setupBaseConfigFile(t)
+		set.String(test.flag, test.value, "doc")
+		assert.Nil(t, set.Parse(test.args))
+		c := cli.NewContext(nil, set, nil)
+		assert.EqualError(t, CmdRuleAdd(c), test.err)
+		removeFile(t, configFileName)
+	}
+}
+
+func TestCmdRuleAddInvalidGroup(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	groups := cli.StringSlice{"foo=public"}
+	set.Var(&groups, "group", "doc")
+	assert.Nil(t, set.Parse([]string{"office"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdRuleAdd(c), "Global IP public does not exist")
+}
+
+func TestCmdRuleAddUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdRuleAdd(c), "Usage: \"hostBuilder rule add {name} [hostName=option...]\"")
+}
+
+func TestCompleteRuleAddHosts(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"office", "goo=ignore"}))
+	os.Args = []string{"rule", "add", "office", "goo=ignore", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteRuleAdd(c)
+	assert.Equal(t, "bar=\nbaz.com=\n", writer.String())
+}
+
+func TestCompleteRuleAddOptions(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"office", "goo="}))
+	os.Args = []string{"rule", "add", "office", "goo=", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteRuleAdd(c)
+	assert.Equal(t, "goo=foop\ngoo=baz\ngoo=ignore\n", writer.String())
+}
+
+func TestCompleteRuleAddGroup(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"office"}))
+	os.Args = []string{"rule", "add", "office", "--group", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteRuleAdd(c)
+	assert.Equal(t, "foo=\n", writer.String())
+}
+
+func TestCompleteRuleAddFlags(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	os.Args = []string{"rule", "add", "--completion"}
+	app, writer := appWithWriter()
+	app.Commands = []cli.Command{{Name: "add", Flags: []cli.Flag{forceFlag, cli.StringFlag{Name: "cidr"}}}}
+	c := cli.NewContext(app, set, nil)
+	CompleteRuleAdd(c)
+	assert.Equal(t, "--force\n--cidr\n", writer.String())
+}
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+)
+
+// CmdRuleList lists the rules in the configuration in the order the auto command checks them
+func CmdRuleList(c *cli.Context) error {
+	if c.NArg() != 0 {
+		return cli.NewExitError("Usage: \"hostBuilder rule list\"", 1)
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.App.Writer, 0, 0, 1, ' ', 0)
+	for _, rule := range configData.Rules {
+		conditions := []string{}
+		for _, condition := range [][2]string{{"cidr", rule.CIDR}, {"gateway", rule.Gateway}, {"interface", rule.Interface}, {"env", rule.Env}} {
+			if condition[1] != "" {
+				conditions = append(conditions, fmt.Sprintf("%s=%s", condition[0], condition[1]))
+			}
+		}
+
+		if len(conditions) == 0 {
+			conditions = append(conditions, "(always)")
+		}
+
+		switches := []string{}
+		for _, groupName := range sortedKeys(rule.Groups) {
+			switches = append(switches, fmt.Sprintf("group %s=%s", groupName, rule.Groups[groupName]))
+		}
+
+		for _, hostName := range sortedKeys(rule.Hosts) {
+			switches = append(switches, fmt.Sprintf("%s=%s", hostName, rule.Hosts[hostName]))
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t-> %s\n", rule.Name, strings.Join(conditions, " "), strings.Join(switches, " "))
+	}
+
+	return w.Flush()
+}
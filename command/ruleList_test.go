@@ -0,0 +1,34 @@
+package command
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdRuleList(t *testing.T) {
+	configFileName, set := setupRuleConfigFile(t, []config.Rule{
+		{Name: "office", CIDR: "10.1.0.0/16", Interface: "tun*", Groups: map[string]string{"foo": "baz"}, Hosts: map[string]string{"baz.com": "bazz"}},
+		{Name: "home", Hosts: map[string]string{"goo": "foop"}},
+	})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdRuleList(c))
+	assert.Equal(
+		t,
+		"office cidr=10.1.0.0/16 interface=tun* -> group foo=baz baz.com=bazz\nhome   (always)                        -> goo=foop\n",
+		writer.String(),
+	)
+}
+
+func TestCmdRuleListUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdRuleList(c), "Usage: \"hostBuilder rule list\"")
+}
@@ -0,0 +1,47 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/urfave/cli"
+)
+
+// CmdRuleRemove removes a rule from the configuration
+func CmdRuleRemove(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Usage: \"hostBuilder rule remove {name}\"", 1)
+	}
+
+	ruleName := c.Args().Get(0)
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	index := ruleIndex(configData, ruleName)
+	if index == -1 {
+		return cli.NewExitError(fmt.Sprintf("Rule %s does not exist", ruleName), 1)
+	}
+
+	configData.Rules = append(configData.Rules[:index], configData.Rules[index+1:]...)
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+// CompleteRuleRemove handles bash autocompletion for the 'rule remove' command
+func CompleteRuleRemove(c *cli.Context) {
+	if c.NArg() != 0 {
+		return
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return
+	}
+
+	for _, rule := range configData.Rules {
+		fmt.Fprintln(c.App.Writer, rule.Name)
+	}
+}
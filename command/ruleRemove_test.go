@@ -0,0 +1,54 @@
+package command
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdRuleRemove(t *testing.T) {
+	configFileName, set := setupRuleConfigFile(t, []config.Rule{
+		{Name: "office", Hosts: map[string]string{"goo": "ignore"}},
+		{Name: "home", Hosts: map[string]string{"goo": "foop"}},
+	})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"office"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdRuleRemove(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, []config.Rule{{Name: "home", Hosts: map[string]string{"goo": "foop"}}}, configData.Rules)
+}
+
+func TestCmdRuleRemoveMissing(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"office"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdRuleRemove(c), "Rule office does not exist")
+}
+
+func TestCmdRuleRemoveUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdRuleRemove(c), "Usage: \"hostBuilder rule remove {name}\"")
+}
+
+func TestCompleteRuleRemove(t *testing.T) {
+	configFileName, set := setupRuleConfigFile(t, []config.Rule{
+		{Name: "office", Hosts: map[string]string{"goo": "ignore"}},
+		{Name: "home", Hosts: map[string]string{"goo": "foop"}},
+	})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteRuleRemove(c)
+	assert.Equal(t, "office\nhome\n", writer.String())
+}
@@ -0,0 +1,191 @@
+package command
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/urfave/cli"
+)
+
+// firstMatchingRule finds the first rule in the configuration that matches the network and why it matched
+// Rules that can not be checked, like a gateway on a system without a routing table, are skipped with a warning
+func firstMatchingRule(configData *config.HostsConfig, lookup networkLookup, warn func(error)) (*config.Rule, []string) {
+	for index, rule := range configData.Rules {
+		reasons, matched, err := matchRule(rule, lookup)
+		if err != nil {
+			warn(fmt.Errorf("Unable to check rule %s: %v", rule.Name, err))
+			continue
+		}
+
+		if matched {
+			return &configData.Rules[index], reasons
+		}
+	}
+
+	return nil, nil
+}
+
+// matchRule checks every condition a rule sets and describes the ones that matched
+func matchRule(rule config.Rule, lookup networkLookup) ([]string, bool, error) {
+	reasons := []string{}
+	if rule.CIDR != "" {
+		_, cidr, err := net.ParseCIDR(rule.CIDR)
+		if err != nil {
+			return nil, false, fmt.Errorf("Invalid CIDR %s", rule.CIDR)
+		}
+
+		reason, err := matchInterface(lookup, func(iface networkInterface) string {
+			for _, IP := range iface.Addrs {
+				if cidr.Contains(IP) {
+					return fmt.Sprintf("%s on %s is in %s", IP, iface.Name, rule.CIDR)
+				}
+			}
+
+			return ""
+		})
+		if err != nil || reason == "" {
+			return nil, false, err
+		}
+
+		reasons = append(reasons, reason)
+	}
+
+	if rule.Gateway != "" {
+		gateway, err := lookup.DefaultGateway()
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !gateway.Equal(net.ParseIP(rule.Gateway)) {
+			return nil, false, nil
+		}
+
+		reasons = append(reasons, fmt.Sprintf("the default gateway is %s", rule.Gateway))
+	}
+
+	if rule.Interface != "" {
+		reason, err := matchInterface(lookup, func(iface networkInterface) string {
+			if matched, _ := path.Match(rule.Interface, iface.Name); matched {
+				return fmt.Sprintf("%s is up", iface.Name)
+			}
+
+			return ""
+		})
+		if err != nil || reason == "" {
+			return nil, false, err
+		}
+
+		reasons = append(reasons, reason)
+	}
+
+	if rule.Env != "" {
+		parts := strings.SplitN(rule.Env, "=", 2)
+		value, set := lookup.LookupEnv(parts[0])
+		if !set || (len(parts) == 2 && value != parts[1]) {
+			return nil, false, nil
+		}
+
+		reasons = append(reasons, fmt.Sprintf("%s=%s", parts[0], value))
+	}
+
+	if len(reasons) == 0 {
+		reasons = append(reasons, "it has no conditions")
+	}
+
+	return reasons, true, nil
+}
+
+// matchInterface returns the reason given for the first interface that is up and matches
+func matchInterface(lookup networkLookup, match func(networkInterface) string) (string, error) {
+	interfaces, err := lookup.Interfaces()
+	if err != nil {
+		return "", err
+	}
+
+	for _, iface := range interfaces {
+		if reason := match(iface); reason != "" {
+			return reason, nil
+		}
+	}
+
+	return "", nil
+}
+
+// validateRule checks the conditions of a rule and that the hosts and groups it switches exist
+func validateRule(configData *config.HostsConfig, rule config.Rule) error {
+	if _, _, err := net.ParseCIDR(rule.CIDR); rule.CIDR != "" && err != nil {
+		return cli.NewExitError(fmt.Sprintf("Invalid CIDR %s", rule.CIDR), 1)
+	}
+
+	if rule.Gateway != "" && net.ParseIP(rule.Gateway) == nil {
+		return cli.NewExitError(fmt.Sprintf("Invalid gateway %s, expected an IP", rule.Gateway), 1)
+	}
+
+	if _, err := path.Match(rule.Interface, ""); err != nil {
+		return cli.NewExitError(fmt.Sprintf("Invalid interface pattern %s", rule.Interface), 1)
+	}
+
+	if strings.HasPrefix(rule.Env, "=") {
+		return cli.NewExitError(fmt.Sprintf("Invalid environment variable %s, expected NAME or NAME=value", rule.Env), 1)
+	}
+
+	for _, groupName := range sortedKeys(rule.Groups) {
+		err := validateGroupParameters(configData, groupName, rule.Groups[groupName])
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, hostName := range sortedKeys(rule.Hosts) {
+		err := validateParameters(configData, hostName, rule.Hosts[hostName])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyRule switches the groups of a rule and then its hosts, so a host can be set apart from its group
+func applyRule(configData *config.HostsConfig, rule config.Rule) error {
+	for _, groupName := range sortedKeys(rule.Groups) {
+		err := setGroup(configData, groupName, rule.Groups[groupName])
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, hostName := range sortedKeys(rule.Hosts) {
+		err := setHost(configData, hostName, rule.Hosts[hostName])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ruleIndex finds a rule by name, -1 means there is no such rule
+func ruleIndex(configData *config.HostsConfig, name string) int {
+	for index, rule := range configData.Rules {
+		if rule.Name == name {
+			return index
+		}
+	}
+
+	return -1
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
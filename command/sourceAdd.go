@@ -0,0 +1,127 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/urfave/cli"
+)
+
+// CmdSourceAdd declares a provider instance for the sync command to refresh
+func CmdSourceAdd(registry *provider.Registry) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		if c.NArg() < 2 {
+			return cli.NewExitError("Usage: \"hostBuilder source add {name} {provider} [setting=value...]\"", 1)
+		}
+
+		configData, err := loadConfig(c)
+		if err != nil {
+			return err
+		}
+
+		sourceName := c.Args().Get(0)
+		sourceProvider, err := withPlugins(registry, configData).Get(c.Args().Get(1))
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+
+		settings, err := provider.ParseSettings(c.Args()[2:])
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+
+		_, err = provider.ApplyDefaults(sourceProvider, settings)
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+
+		_, err = newStalePolicy(c.Bool("prune"), c.String("current"))
+		if err != nil {
+			return err
+		}
+
+		if every := c.String("every"); every != "" {
+			if _, err = parseEvery(every); err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+		}
+
+		if _, exists := configData.Sources[sourceName]; exists && !c.Bool("force") {
+			return cli.NewExitError(fmt.Sprintf("Source %s already exists", sourceName), 1)
+		}
+
+		if configData.Sources == nil {
+			configData.Sources = map[string]config.Source{}
+		}
+
+		configData.Sources[sourceName] = config.Source{
+			Provider: sourceProvider.Name(),
+			Settings: settings,
+			Prune:    c.Bool("prune"),
+			Current:  c.String("current"),
+			Every:    c.String("every"),
+		}
+
+		return config.WriteConfig(c.GlobalString("config"), configData)
+	}
+}
+
+// CompleteSourceAdd handles bash autocompletion for the 'source add' command
+func CompleteSourceAdd(registry *provider.Registry) func(c *cli.Context) {
+	return func(c *cli.Context) {
+		sourceRegistry := registry
+		if configData, err := loadConfig(c); err == nil {
+			sourceRegistry = withPlugins(registry, configData)
+		}
+
+		if c.NArg() == 1 {
+			fmt.Fprintln(c.App.Writer, strings.Join(sourceRegistry.Names(), "\n"))
+			return
+		}
+
+		if c.NArg() >= 2 {
+			completeSourceSettings(c, sourceRegistry)
+			return
+		}
+
+		for _, flag := range c.App.Command("add").Flags {
+			name := strings.Split(flag.GetName(), ",")[0]
+			if !c.IsSet(name) {
+				fmt.Fprintf(c.App.Writer, "--%s\n", name)
+			}
+		}
+	}
+}
+
+// completeSourceSettings suggests the settings that have not been given yet, or values for a setting being typed
+func completeSourceSettings(c *cli.Context, registry *provider.Registry) {
+	sourceProvider, err := registry.Get(c.Args().Get(1))
+	if err != nil {
+		return
+	}
+
+	settings, err := provider.ParseSettings(c.Args()[2:])
+	if err != nil {
+		return
+	}
+
+	current := os.Args[len(os.Args)-2]
+	for _, setting := range sourceProvider.Schema() {
+		if current == setting.Name+"=" {
+			for _, value := range sourceProvider.Complete(setting.Name, settings) {
+				fmt.Fprintf(c.App.Writer, "%s=%s\n", setting.Name, value)
+			}
+
+			return
+		}
+	}
+
+	for _, setting := range sourceProvider.Schema() {
+		if _, given := settings[setting.Name]; !given {
+			fmt.Fprintf(c.App.Writer, "%s=\n", setting.Name)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package command
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdSourceAdd(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.Bool("prune", true, "doc")
+	assert.Nil(t, set.Parse([]string{"east", "test", "region=us-west-2"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdSourceAdd(provider.NewRegistry(&testProvider{}))(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]config.Source{"east": {Provider: "test", Settings: map[string]string{"region": "us-west-2"}, Prune: true}}, configData.Sources)
+}
+
+func TestCmdSourceAddExists(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test"}})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"east", "test"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdSourceAdd(provider.NewRegistry(&testProvider{}))(c), "Source east already exists")
+}
+
+func TestCmdSourceAddForce(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test", Prune: true}})
+	defer removeFile(t, configFileName)
+
+	set.Bool("force", true, "doc")
+	assert.Nil(t, set.Parse([]string{"east", "test", "file=foo"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdSourceAdd(provider.NewRegistry(&testProvider{}))(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]config.Source{"east": {Provider: "test", Settings: map[string]string{"file": "foo"}}}, configData.Sources)
+}
+
+func TestCmdSourceAddUnknownProvider(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"east", "missing"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdSourceAdd(provider.NewRegistry(&testProvider{}))(c), "Unknown provider missing")
+}
+
+func TestCmdSourceAddInvalidSetting(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"east", "test", "region"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdSourceAdd(provider.NewRegistry(&testProvider{}))(c), "Invalid setting region, expected name=value")
+}
+
+func TestCmdSourceAddUnknownSetting(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"east", "test", "zone=a"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdSourceAdd(provider.NewRegistry(&testProvider{}))(c), "Unknown setting zone for provider test")
+}
+
+func TestCmdSourceAddEvery(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("every", "15m", "doc")
+	assert.Nil(t, set.Parse([]string{"east", "test"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdSourceAdd(provider.NewRegistry(&testProvider{}))(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]config.Source{"east": {Provider: "test", Every: "15m"}}, configData.Sources)
+}
+
+func TestCmdSourceAddInvalidEvery(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.String("every", "0s", "doc")
+	assert.Nil(t, set.Parse([]string{"east", "test"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdSourceAdd(provider.NewRegistry(&testProvider{}))(c), "Invalid period 0s, expected a duration like 15m")
+}
+
+func TestCmdSourceAddUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"east"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdSourceAdd(provider.NewRegistry(&testProvider{}))(c), "Usage: \"hostBuilder source add {name} {provider} [setting=value...]\"")
+}
+
+func TestCmdSourceAddNoConfig(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"east", "test"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdSourceAdd(provider.NewRegistry(&testProvider{}))(c), "You must specify a config file")
+}
+
+func TestCompleteSourceAddProvider(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"east"}))
+	os.Args = []string{"source", "add", "east", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteSourceAdd(provider.NewRegistry(&testProvider{}))(c)
+	assert.Equal(t, "test\n", writer.String())
+}
+
+func TestCompleteSourceAddSettings(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"east", "test", "file=foo"}))
+	os.Args = []string{"source", "add", "east", "test", "file=foo", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteSourceAdd(provider.NewRegistry(&testProvider{}))(c)
+	assert.Equal(t, "region=\n", writer.String())
+}
+
+func TestCompleteSourceAddSettingValue(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"east", "test", "region="}))
+	os.Args = []string{"source", "add", "east", "test", "region=", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteSourceAdd(provider.NewRegistry(&testProvider{}))(c)
+	assert.Equal(t, "region=us-east-1\nregion=us-west-2\n", writer.String())
+}
+
+func TestCompleteSourceAddFlags(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	os.Args = []string{"source", "add", "--completion"}
+	app, writer := appWithWriter()
+	app.Commands = []cli.Command{{Name: "add", Flags: []cli.Flag{forceFlag, pruneFlag}}}
+	c := cli.NewContext(app, set, nil)
+	CompleteSourceAdd(provider.NewRegistry(&testProvider{}))(c)
+	assert.Equal(t, "--force\n--prune\n", writer.String())
+}
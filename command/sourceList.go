@@ -0,0 +1,48 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/urfave/cli"
+)
+
+// CmdSourceList lists the sources in the configuration
+func CmdSourceList(c *cli.Context) error {
+	if c.NArg() != 0 {
+		return cli.NewExitError("Usage: \"hostBuilder source list\"", 1)
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.App.Writer, 0, 0, 1, ' ', 0)
+	for _, sourceName := range sortSourceNames(configData) {
+		source := configData.Sources[sourceName]
+		settings := make([]string, 0, len(source.Settings))
+		for name, value := range source.Settings {
+			settings = append(settings, fmt.Sprintf("%s=%s", name, value))
+		}
+
+		sort.Strings(settings)
+		if source.Prune {
+			settings = append(settings, "(prune)")
+		}
+
+		if source.Current != "" {
+			settings = append(settings, fmt.Sprintf("(current=%s)", source.Current))
+		}
+
+		if source.Every != "" {
+			settings = append(settings, fmt.Sprintf("(every %s)", source.Every))
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", sourceName, source.Provider, strings.Join(settings, " "))
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,30 @@
+package command
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdSourceList(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{
+		"east":       {Provider: "test", Settings: map[string]string{"region": "us-east-1", "file": "foo"}, Prune: true},
+		"containers": {Provider: "docker", Current: "keep", Every: "15m"},
+	})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdSourceList(c))
+	assert.Equal(t, "containers docker (current=keep) (every 15m)\neast       test   file=foo region=us-east-1 (prune)\n", writer.String())
+}
+
+func TestCmdSourceListUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdSourceList(c), "Usage: \"hostBuilder source list\"")
+}
@@ -0,0 +1,46 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/urfave/cli"
+)
+
+// CmdSourceRemove removes a source from the configuration
+func CmdSourceRemove(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("Usage: \"hostBuilder source remove {name}\"", 1)
+	}
+
+	sourceName := c.Args().Get(0)
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return err
+	}
+
+	if _, exists := configData.Sources[sourceName]; !exists {
+		return cli.NewExitError(fmt.Sprintf("Source %s does not exist", sourceName), 1)
+	}
+
+	delete(configData.Sources, sourceName)
+
+	return config.WriteConfig(c.GlobalString("config"), configData)
+}
+
+// CompleteSourceRemove handles bash autocompletion for the 'source remove' command
+func CompleteSourceRemove(c *cli.Context) {
+	if c.NArg() != 0 {
+		return
+	}
+
+	configData, err := loadConfig(c)
+	if err != nil {
+		return
+	}
+
+	for _, sourceName := range sortSourceNames(configData) {
+		fmt.Fprintf(c.App.Writer, "%s:%s\n", sourceName, configData.Sources[sourceName].Provider)
+	}
+}
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdSourceRemove(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test"}, "west": {Provider: "test"}})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"east"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.Nil(t, CmdSourceRemove(c))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]config.Source{"west": {Provider: "test"}}, configData.Sources)
+}
+
+func TestCmdSourceRemoveMissing(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"east"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdSourceRemove(c), "Source east does not exist")
+}
+
+func TestCmdSourceRemoveUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdSourceRemove(c), "Usage: \"hostBuilder source remove {name}\"")
+}
+
+func TestCompleteSourceRemove(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test"}, "west": {Provider: "docker"}})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteSourceRemove(c)
+	assert.Equal(t, "east:test\nwest:docker\n", writer.String())
+}
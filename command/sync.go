@@ -0,0 +1,136 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/urfave/cli"
+)
+
+// CmdSync refreshes the addresses from the sources declared in the configuration
+func CmdSync(registry *provider.Registry) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		configData, err := loadConfig(c)
+		if err != nil {
+			return err
+		}
+
+		sourceNames := []string(c.Args())
+		if len(sourceNames) == 0 {
+			sourceNames = sortSourceNames(configData)
+		}
+
+		if len(sourceNames) == 0 {
+			return cli.NewExitError("There are no sources to sync, add one with 'hostBuilder source add'", 1)
+		}
+
+		for _, sourceName := range sourceNames {
+			if _, exists := configData.Sources[sourceName]; !exists {
+				return cli.NewExitError(fmt.Sprintf("Source %s does not exist", sourceName), 1)
+			}
+		}
+
+		_, err = newStalePolicy(false, c.String("current"))
+		if err != nil {
+			return err
+		}
+
+		sourceRegistry := withPlugins(registry, configData)
+		failures := 0
+		for _, sourceName := range sourceNames {
+			if syncedAt := configData.Sources[sourceName].SyncedAt; c.IsSet("maxAge") && syncedAt != nil && now().Sub(*syncedAt) < c.Duration("maxAge") {
+				fmt.Fprintf(c.App.Writer, "%s was synced %s, skipping\n", sourceName, formatAge(*syncedAt))
+				continue
+			}
+
+			err = syncSource(c, sourceRegistry, configData, sourceName)
+			if err != nil {
+				fmt.Fprintf(c.App.ErrWriter, "Failed to sync %s: %v\n", sourceName, err)
+				failures++
+			}
+		}
+
+		err = config.WriteConfig(c.GlobalString("config"), configData)
+		if err != nil {
+			return err
+		}
+
+		if failures != 0 {
+			return cli.NewExitError(fmt.Sprintf("%d of %d sources failed to sync", failures, len(sourceNames)), 1)
+		}
+
+		return nil
+	}
+}
+
+func syncSource(c *cli.Context, registry *provider.Registry, configData *config.HostsConfig, sourceName string) error {
+	source := configData.Sources[sourceName]
+	sourceProvider, err := registry.Get(source.Provider)
+	if err != nil {
+		return err
+	}
+
+	settings, err := provider.ApplyDefaults(sourceProvider, source.Settings)
+	if err != nil {
+		return err
+	}
+
+	current := source.Current
+	if c.IsSet("current") {
+		current = c.String("current")
+	}
+
+	policy, err := newStalePolicy(source.Prune || c.Bool("prune"), current)
+	if err != nil {
+		return err
+	}
+
+	addresses, fetchedAt, err := discoverProvider(c, sourceProvider, settings)
+	if err != nil {
+		return err
+	}
+
+	if mergeAddresses(configData, fetchedOrigin(sourceName, source.Provider, fetchedAt), addresses, policy, c.App.Writer, c.App.ErrWriter) == 0 {
+		fmt.Fprintf(c.App.Writer, "%s is up to date\n", sourceName)
+	}
+
+	source.SyncedAt = &fetchedAt
+	configData.Sources[sourceName] = source
+	return nil
+}
+
+func sortSourceNames(configData *config.HostsConfig) []string {
+	sourceNames := make([]string, 0, len(configData.Sources))
+	for sourceName := range configData.Sources {
+		sourceNames = append(sourceNames, sourceName)
+	}
+
+	sort.Strings(sourceNames)
+	return sourceNames
+}
+
+// CompleteSync handles bash autocompletion for the 'sync' command
+func CompleteSync(c *cli.Context) {
+	configData, err := loadConfig(c)
+	if err != nil {
+		return
+	}
+
+	for _, sourceName := range sortSourceNames(configData) {
+		if !argsContain(c, sourceName) {
+			fmt.Fprintln(c.App.Writer, sourceName)
+		}
+	}
+}
+
+func argsContain(c *cli.Context, value string) bool {
+	for _, arg := range c.Args() {
+		if arg == value {
+			return true
+		}
+	}
+
+	return false
+}
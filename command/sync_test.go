@@ -0,0 +1,265 @@
+package command
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestCmdSync(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{
+		"east": {Provider: "test", Settings: map[string]string{"file": "foo"}},
+	})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	testProvider := &testProvider{addresses: []provider.Address{{Name: "foo", IP: "10.0.0.1"}, {Host: "goo", Name: "east", IP: "10.0.0.2"}}}
+	assert.Nil(t, CmdSync(provider.NewRegistry(testProvider))(c))
+	assert.Equal(t, "Added global IP foo (10.0.0.1)\nAdded option to goo (east => 10.0.0.2)\n", writer.String())
+	assert.Equal(t, map[string]string{"region": "us-east-1", "file": "foo"}, testProvider.settings)
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"baz": "10.0.0.4", "foo": "10.0.0.1"}, configData.GlobalIPs)
+	assert.Equal(t, map[string]string{"foop": "10.0.0.8", "east": "10.0.0.2"}, configData.Hosts["goo"].Options)
+	assert.Equal(t, "east", configData.Provenance["foo"].Source)
+}
+
+func TestCmdSyncUpToDate(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{
+		"east": {Provider: "test"},
+		"west": {Provider: "test", Settings: map[string]string{"region": "us-west-2"}},
+	})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"west"}))
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	testProvider := &testProvider{}
+	assert.Nil(t, CmdSync(provider.NewRegistry(testProvider))(c))
+	assert.Equal(t, "west is up to date\n", writer.String())
+	assert.Equal(t, map[string]string{"region": "us-west-2", "file": ""}, testProvider.settings)
+}
+
+func TestCmdSyncPrune(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test", Prune: true}})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	testProvider := &testProvider{addresses: []provider.Address{{Name: "foo", IP: "10.0.0.1"}}}
+	assert.Nil(t, CmdSync(provider.NewRegistry(testProvider))(c))
+
+	writer.Reset()
+	testProvider.addresses = nil
+	assert.Nil(t, CmdSync(provider.NewRegistry(testProvider))(c))
+	assert.Equal(t, "Removed global IP foo (10.0.0.1)\n", writer.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"baz": "10.0.0.4"}, configData.GlobalIPs)
+}
+
+func TestCmdSyncStale(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test"}})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	testProvider := &testProvider{addresses: []provider.Address{{Name: "foo", IP: "10.0.0.1"}}}
+	assert.Nil(t, CmdSync(provider.NewRegistry(testProvider))(c))
+
+	writer.Reset()
+	testProvider.addresses = nil
+	assert.Nil(t, CmdSync(provider.NewRegistry(testProvider))(c))
+	assert.Equal(t, "Marked global IP foo stale (10.0.0.1)\n", writer.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.1", configData.GlobalIPs["foo"])
+	assert.Equal(
+		t,
+		config.Provenance{Source: "east", Provider: "test", ImportedAt: &testImportedAt, FetchedAt: &testImportedAt, Stale: true},
+		configData.Provenance["foo"],
+	)
+}
+
+func TestCmdSyncPruneCurrent(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test", Prune: true, Current: currentIgnore}})
+	defer removeFile(t, configFileName)
+
+	// baz was added by hand, so the source only owns it once it has been removed and imported
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	delete(configData.GlobalIPs, "baz")
+	assert.Nil(t, config.WriteConfig(configFileName, configData))
+
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	testProvider := &testProvider{addresses: []provider.Address{{Name: "baz", IP: "10.0.0.4"}}}
+	assert.Nil(t, CmdSync(provider.NewRegistry(testProvider))(c))
+
+	app, writer := appWithWriter()
+	errWriter := new(bytes.Buffer)
+	app.ErrWriter = errWriter
+	c = cli.NewContext(app, set, nil)
+	testProvider.addresses = nil
+	assert.Nil(t, CmdSync(provider.NewRegistry(testProvider))(c))
+	assert.Equal(t, "Removed global IP baz (10.0.0.4)\n", writer.String())
+	assert.Equal(t, "Warning: baz.com was using global IP baz which was removed, it is now ignored\n", errWriter.String())
+
+	configData, err = config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, hostIgnore, configData.Hosts["baz.com"].Current)
+}
+
+func TestCmdSyncPruneKeepsHandAdded(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test", Prune: true, Current: currentIgnore}})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	errWriter := new(bytes.Buffer)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	testProvider := &testProvider{addresses: []provider.Address{{Name: "baz", IP: "10.0.0.5"}, {Host: "goo", Name: "foop", IP: "10.0.0.6"}}}
+	assert.Nil(t, CmdSync(provider.NewRegistry(testProvider))(c))
+	assert.Equal(
+		t,
+		"Warning: global IP baz was added by hand, keeping 10.0.0.4 and ignoring 10.0.0.5\n"+
+			"Warning: foop on goo was added by hand, keeping 10.0.0.8 and ignoring 10.0.0.6\n",
+		errWriter.String(),
+	)
+
+	writer.Reset()
+	errWriter.Reset()
+	testProvider.addresses = nil
+	assert.Nil(t, CmdSync(provider.NewRegistry(testProvider))(c))
+	assert.Equal(t, "", errWriter.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.4", configData.GlobalIPs["baz"])
+	assert.Equal(t, "10.0.0.8", configData.Hosts["goo"].Options["foop"])
+	assert.Equal(t, "baz", configData.Hosts["baz.com"].Current)
+	assert.Empty(t, configData.Provenance)
+}
+
+func TestCmdSyncInvalidCurrent(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test"}})
+	defer removeFile(t, configFileName)
+
+	set.String("current", "drop", "doc")
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdSync(provider.NewRegistry(&testProvider{}))(c), "Invalid current policy drop, expected one of warn, keep, ignore, switch")
+}
+
+func TestCmdSyncFailure(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{
+		"east":    {Provider: "test"},
+		"missing": {Provider: "missing"},
+	})
+	defer removeFile(t, configFileName)
+
+	app, writer := appWithWriter()
+	errWriter := new(bytes.Buffer)
+	app.ErrWriter = errWriter
+	c := cli.NewContext(app, set, nil)
+	testProvider := &testProvider{addresses: []provider.Address{{Name: "foo", IP: "10.0.0.1"}}}
+	assert.EqualError(t, CmdSync(provider.NewRegistry(testProvider))(c), "1 of 2 sources failed to sync")
+	assert.Equal(t, "Added global IP foo (10.0.0.1)\n", writer.String())
+	assert.Equal(t, "Failed to sync missing: Unknown provider missing\n", errWriter.String())
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.1", configData.GlobalIPs["foo"])
+}
+
+func TestCmdSyncProviderError(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test"}})
+	defer removeFile(t, configFileName)
+
+	app, errWriter := appWithErrWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdSync(provider.NewRegistry(&testProvider{throwError: true}))(c), "1 of 1 sources failed to sync")
+	assert.Equal(t, "Failed to sync east: error\n", errWriter.String())
+}
+
+func TestCmdSyncInvalidSetting(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test", Settings: map[string]string{"bad": "foo"}}})
+	defer removeFile(t, configFileName)
+
+	app, errWriter := appWithErrWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdSync(provider.NewRegistry(&testProvider{}))(c), "1 of 1 sources failed to sync")
+	assert.Equal(t, "Failed to sync east: Unknown setting bad for provider test\n", errWriter.String())
+}
+
+func TestCmdSyncUnknownSource(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test"}})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"west"}))
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdSync(provider.NewRegistry(&testProvider{}))(c), "Source west does not exist")
+}
+
+func TestCmdSyncNoSources(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdSync(provider.NewRegistry(&testProvider{}))(c), "There are no sources to sync, add one with 'hostBuilder source add'")
+}
+
+func TestCmdSyncNoConfig(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	app, _ := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdSync(provider.NewRegistry(&testProvider{}))(c), "You must specify a config file")
+}
+
+func TestCompleteSync(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"east": {Provider: "test"}, "west": {Provider: "test"}})
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"east"}))
+	os.Args = []string{"sync", "east", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteSync(c)
+	assert.Equal(t, "west\n", writer.String())
+}
+
+func TestCmdSyncPlugin(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{"lab": {Provider: "cmdb", Settings: map[string]string{"timeout": "5s"}}})
+	defer removeFile(t, configFileName)
+
+	pluginFile, err := ioutil.TempFile("", "plugin")
+	assert.Nil(t, err)
+	defer removeFile(t, pluginFile.Name())
+	_, err = pluginFile.WriteString("#!/bin/sh\necho '{\"addresses\": [{\"name\": \"db\", \"address\": \"10.0.0.5\"}]}'\n")
+	assert.Nil(t, err)
+	assert.Nil(t, pluginFile.Close())
+	assert.Nil(t, os.Chmod(pluginFile.Name(), 0755))
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	configData.Plugins = map[string]string{"cmdb": pluginFile.Name()}
+	assert.Nil(t, config.WriteConfig(configFileName, configData))
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdSync(provider.NewRegistry(&testProvider{}))(c))
+	assert.Equal(t, "Added global IP db (10.0.0.5)\n", writer.String())
+}
@@ -2,11 +2,12 @@ package command
 
 import (
 	"errors"
-	"fmt"
-	"net"
+	"os"
 	"sort"
+	"text/template"
 
 	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
 	"github.com/urfave/cli"
 )
 
@@ -26,6 +27,11 @@ func loadConfig(c *cli.Context) (*config.HostsConfig, error) {
 	return configData, nil
 }
 
+// withPlugins adds the external providers on the PATH and in the configuration to the registry
+func withPlugins(registry *provider.Registry, configData *config.HostsConfig) *provider.Registry {
+	return registry.WithPlugins(os.Getenv("PATH"), configData.Plugins)
+}
+
 func sortHostNames(configData *config.HostsConfig) []string {
 	hostNames := make([]string, 0, len(configData.Hosts))
 	for hostName := range configData.Hosts {
@@ -56,6 +62,19 @@ func groupContains(configData *config.HostsConfig, groupName, hostName string) b
 	return false
 }
 
+func addHostToGroup(configData *config.HostsConfig, groupName, hostName string) bool {
+	if groupContains(configData, groupName, hostName) {
+		return false
+	}
+
+	if configData.Groups == nil {
+		configData.Groups = map[string][]string{}
+	}
+
+	configData.Groups[groupName] = append(configData.Groups[groupName], hostName)
+	return true
+}
+
 func sortGlobalIPNames(configData *config.HostsConfig) []string {
 	globalIPNames := make([]string, 0, len(configData.GlobalIPs))
 	for globalIPName := range configData.GlobalIPs {
@@ -108,14 +127,9 @@ func sortAllIPs(configData *config.HostsConfig) ([]string, map[string]string) {
 }
 
 func resolveAddress(address string) (string, error) {
-	if net.ParseIP(address) != nil {
-		return address, nil
-	}
-
-	IPs, err := net.LookupHost(address)
-	if err != nil {
-		return "", fmt.Errorf("Unable to resolve %s", address)
-	}
+	return provider.ResolveAddress(address)
+}
 
-	return IPs[0], nil
+func executeTemplate(templ *template.Template, data interface{}) (string, error) {
+	return provider.ExecuteTemplate(templ, data)
 }
@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"testing"
 	"text/template"
+	"time"
 
+	"github.com/guywithnose/hostBuilder/awsUtil"
 	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli"
 )
@@ -60,15 +65,35 @@ func setupInvalidConfigFile(t *testing.T) string {
 	return configFile.Name()
 }
 
+// testImportedAt is the import time recorded in the provenance of everything imported by the tests
+var testImportedAt = time.Date(2017, time.November, 1, 12, 0, 0, 0, time.UTC)
+
+func init() {
+	now = func() time.Time { return testImportedAt }
+}
+
 type awsTestUtil struct {
-	instances     map[string]string
-	loadBalancers map[string]string
-	profiles      []string
-	throwError    bool
+	instances      []awsUtil.InstanceAddress
+	loadBalancers  []awsUtil.LoadBalancer
+	zones          []awsUtil.HostedZone
+	dbInstances    []awsUtil.DBInstance
+	cacheClusters  []awsUtil.CacheCluster
+	tasks          []awsUtil.Task
+	groups         []awsUtil.AutoScalingGroup
+	names          []string
+	zone           string
+	filter         awsUtil.LoadBalancerFilter
+	instanceFilter awsUtil.InstanceFilter
+	kinds          []awsUtil.AddressKind
+	profiles       []string
+	throwError     bool
+	regions        awsUtil.RegionFilter
+	endpoint       string
 }
 
-// ReadAllLoadBalancers gets the load balancer information for all regions
-func (util *awsTestUtil) ReadAllLoadBalancers() (map[string]string, error) {
+// ReadAllLoadBalancers gets the load balancers matching the filter in all regions
+func (util *awsTestUtil) ReadAllLoadBalancers(filter awsUtil.LoadBalancerFilter, warnings io.Writer) ([]awsUtil.LoadBalancer, error) {
+	util.filter = filter
 	if util.throwError {
 		return nil, errors.New("error")
 	}
@@ -77,7 +102,14 @@ func (util *awsTestUtil) ReadAllLoadBalancers() (map[string]string, error) {
 }
 
 // ReadAllInstances gets the instance information for all regions
-func (util *awsTestUtil) ReadAllInstances(templ *template.Template) (map[string]string, error) {
+func (util *awsTestUtil) ReadAllInstances(
+	templ *template.Template,
+	filter awsUtil.InstanceFilter,
+	kinds []awsUtil.AddressKind,
+	warnings io.Writer,
+) ([]awsUtil.InstanceAddress, error) {
+	util.instanceFilter = filter
+	util.kinds = kinds
 	if util.throwError {
 		return nil, errors.New("error")
 	}
@@ -85,6 +117,75 @@ func (util *awsTestUtil) ReadAllInstances(templ *template.Template) (map[string]
 	return util.instances, nil
 }
 
+// ReadAllDBInstances gets the RDS instances in all regions
+func (util *awsTestUtil) ReadAllDBInstances(warnings io.Writer) ([]awsUtil.DBInstance, error) {
+	if util.throwError {
+		return nil, errors.New("error")
+	}
+
+	return util.dbInstances, nil
+}
+
+// ReadAllCacheClusters gets the ElastiCache clusters in all regions
+func (util *awsTestUtil) ReadAllCacheClusters(warnings io.Writer) ([]awsUtil.CacheCluster, error) {
+	if util.throwError {
+		return nil, errors.New("error")
+	}
+
+	return util.cacheClusters, nil
+}
+
+// ReadAllTasks gets the running ECS tasks of the clusters in all regions
+func (util *awsTestUtil) ReadAllTasks(clusters []string, warnings io.Writer) ([]awsUtil.Task, error) {
+	util.names = clusters
+	if util.throwError {
+		return nil, errors.New("error")
+	}
+
+	return util.tasks, nil
+}
+
+// ReadAllAutoScalingGroups gets the Auto Scaling groups in all regions
+func (util *awsTestUtil) ReadAllAutoScalingGroups(names []string, warnings io.Writer) ([]awsUtil.AutoScalingGroup, error) {
+	util.names = names
+	if util.throwError {
+		return nil, errors.New("error")
+	}
+
+	return util.groups, nil
+}
+
+// ListHostedZones lists the Route53 hosted zones of the account, without their records
+func (util *awsTestUtil) ListHostedZones() ([]awsUtil.HostedZone, error) {
+	if util.throwError {
+		return nil, errors.New("error")
+	}
+
+	zones := make([]awsUtil.HostedZone, 0, len(util.zones))
+	for _, zone := range util.zones {
+		zone.Records = nil
+		zones = append(zones, zone)
+	}
+
+	return zones, nil
+}
+
+// ReadHostedZone reads the A and AAAA records of the Route53 hosted zone with the given ID or name
+func (util *awsTestUtil) ReadHostedZone(zone string) (awsUtil.HostedZone, error) {
+	util.zone = zone
+	if util.throwError {
+		return awsUtil.HostedZone{}, errors.New("error")
+	}
+
+	for _, hostedZone := range util.zones {
+		if hostedZone.Name == zone || hostedZone.ID == zone {
+			return hostedZone, nil
+		}
+	}
+
+	return awsUtil.HostedZone{}, fmt.Errorf("No hosted zone matches %s", zone)
+}
+
 // ListAllProfiles lists all available aws credential profiles
 func (util *awsTestUtil) ListAllProfiles() ([]string, error) {
 	if util.throwError {
@@ -98,6 +199,16 @@ func (util *awsTestUtil) ListAllProfiles() ([]string, error) {
 func (util *awsTestUtil) SetProfile(string) {
 }
 
+// SetRegions sets which regions are scanned
+func (util *awsTestUtil) SetRegions(regions awsUtil.RegionFilter) {
+	util.regions = regions
+}
+
+// SetEndpoint sends every AWS request to endpoint instead of the AWS default
+func (util *awsTestUtil) SetEndpoint(endpoint string) {
+	util.endpoint = endpoint
+}
+
 func appWithWriter() (*cli.App, *bytes.Buffer) {
 	app := cli.NewApp()
 	writer := new(bytes.Buffer)
@@ -117,3 +228,87 @@ func appWithErrWriter() (*cli.App, *bytes.Buffer) {
 func removeFile(t *testing.T, fileName string) {
 	assert.Nil(t, os.Remove(fileName))
 }
+
+type testProvider struct {
+	addresses  []provider.Address
+	throwError bool
+	settings   map[string]string
+}
+
+// Name is the name sources use to refer to the provider
+func (p *testProvider) Name() string {
+	return "test"
+}
+
+// Schema lists the settings the provider accepts
+func (p *testProvider) Schema() []provider.Setting {
+	return []provider.Setting{
+		{Name: "region", Default: "us-east-1"},
+		{Name: "file", File: true},
+	}
+}
+
+// Discover returns the configured addresses
+func (p *testProvider) Discover(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	p.settings = settings
+	if p.throwError {
+		return nil, errors.New("error")
+	}
+
+	return p.addresses, nil
+}
+
+// Complete suggests values for a setting
+func (p *testProvider) Complete(setting string, settings map[string]string) []string {
+	if setting == "region" {
+		return []string{"us-east-1", "us-west-2"}
+	}
+
+	return nil
+}
+
+func setupSourceConfigFile(t *testing.T, sources map[string]config.Source) (string, *flag.FlagSet) {
+	configFileName, set := setupBaseConfigFile(t)
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	configData.Sources = sources
+	assert.Nil(t, config.WriteConfig(configFileName, configData))
+	return configFileName, set
+}
+
+func setupHookConfigFile(t *testing.T, hooks []config.Hook) (string, *flag.FlagSet) {
+	configFileName, set := setupBaseConfigFile(t)
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	configData.Hooks = hooks
+	assert.Nil(t, config.WriteConfig(configFileName, configData))
+	return configFileName, set
+}
+
+func setupRuleConfigFile(t *testing.T, rules []config.Rule) (string, *flag.FlagSet) {
+	configFileName, set := setupBaseConfigFile(t)
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	configData.Rules = rules
+	assert.Nil(t, config.WriteConfig(configFileName, configData))
+	return configFileName, set
+}
+
+// setupFailoverConfigFile adds api.example.com, which prefers its local option when the check of local passes and falls back to dev
+func setupFailoverConfigFile(t *testing.T, check config.Check) (string, *flag.FlagSet) {
+	configFileName, set := setupBaseConfigFile(t)
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+
+	configData.Hosts["api.example.com"] = config.Host{
+		Current:  "dev",
+		Options:  map[string]string{"local": "127.0.0.1", "dev": "10.0.0.9"},
+		Failover: []string{"local", "dev"},
+		Checks:   map[string]config.Check{"local": check},
+	}
+	assert.Nil(t, config.WriteConfig(configFileName, configData))
+	return configFileName, set
+}
@@ -0,0 +1,239 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/guywithnose/hostBuilder/hosts"
+	"github.com/urfave/cli"
+)
+
+// nsswitchFile is where the system resolver is told which sources to look up hosts in
+var nsswitchFile = "/etc/nsswitch.conf"
+
+// verification is what the system resolver, and the DNS server when one is given, answered for a hostname
+type verification struct {
+	hostName string
+	expected string
+	system   lookupResult
+	server   *lookupResult
+}
+
+type lookupResult struct {
+	addresses []string
+	err       error
+}
+
+func (result lookupResult) matches(expected string) bool {
+	return result.err == nil && containsIP(result.addresses, expected)
+}
+
+// containsIP compares addresses as IPs, so ::1 matches 0:0:0:0:0:0:0:1
+func containsIP(addresses []string, expected string) bool {
+	expectedIP := net.ParseIP(expected)
+	for _, address := range addresses {
+		if net.ParseIP(address).Equal(expectedIP) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (result lookupResult) String() string {
+	if result.err != nil {
+		return result.err.Error()
+	}
+
+	return strings.Join(result.addresses, ",")
+}
+
+// CmdVerify resolves the hostnames in the configuration and reports the ones that do not resolve to their selected IP
+func CmdVerify(newResolver func(server string) resolver) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		configData, err := loadConfig(c)
+		if err != nil {
+			return err
+		}
+
+		hostNames := []string(c.Args())
+		for _, hostName := range hostNames {
+			if _, exists := configData.Hosts[hostName]; !exists {
+				return cli.NewExitError(fmt.Sprintf("HostName %s does not exist", hostName), 1)
+			}
+		}
+
+		if len(hostNames) == 0 {
+			hostNames = sortHostNames(configData)
+		}
+
+		timeout := c.Duration("timeout")
+		if timeout <= 0 {
+			return cli.NewExitError(fmt.Sprintf("Invalid timeout %s", timeout), 1)
+		}
+
+		selected := failover(configData, c.App.ErrWriter)
+		verifications := []*verification{}
+		for _, hostName := range hostNames {
+			if IP, ok := hosts.CurrentIP(selected, hostName); ok {
+				verifications = append(verifications, &verification{hostName: hostName, expected: IP})
+			}
+		}
+
+		if len(verifications) == 0 {
+			return cli.NewExitError("There are no hostnames to verify", 1)
+		}
+
+		var server resolver
+		if c.String("server") != "" {
+			server = newResolver(c.String("server"))
+		}
+
+		resolveAll(verifications, newResolver(""), server, timeout)
+
+		outputFile := c.String("output")
+		hostsData, err := ioutil.ReadFile(outputFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		hostIPs := hosts.HostIPs(string(hostsData))
+		order := hostsLookupOrder()
+		mismatches := 0
+		w := tabwriter.NewWriter(c.App.Writer, 0, 0, 1, ' ', 0)
+		for _, result := range verifications {
+			mismatched := false
+			if result.system.matches(result.expected) {
+				fmt.Fprintf(w, "%s\t%s\tsystem\tok\n", result.hostName, result.expected)
+			} else {
+				mismatched = true
+				cause := systemMismatchCause(result, outputFile, hostIPs[result.hostName], order)
+				fmt.Fprintf(w, "%s\t%s\tsystem\t%s\tlikely cause: %s\n", result.hostName, result.expected, result.system, cause)
+			}
+
+			if result.server != nil && result.server.matches(result.expected) {
+				fmt.Fprintf(w, "%s\t%s\t%s\tok\n", result.hostName, result.expected, c.String("server"))
+			} else if result.server != nil {
+				mismatched = true
+				fmt.Fprintf(
+					w,
+					"%s\t%s\t%s\t%s\tlikely cause: the DNS server does not serve %s or caches the old address\n",
+					result.hostName,
+					result.expected,
+					c.String("server"),
+					result.server,
+					outputFile,
+				)
+			}
+
+			if mismatched {
+				mismatches++
+			}
+		}
+
+		err = w.Flush()
+		if err != nil {
+			return err
+		}
+
+		if mismatches != 0 {
+			return cli.NewExitError(fmt.Sprintf("%d of %d hostnames do not resolve to their selected IP", mismatches, len(verifications)), 1)
+		}
+
+		return nil
+	}
+}
+
+// resolveAll looks up every hostname concurrently, each lookup limited by the timeout, the server is only asked when there is one
+func resolveAll(verifications []*verification, system, server resolver, timeout time.Duration) {
+	var wait sync.WaitGroup
+	for _, result := range verifications {
+		wait.Add(1)
+		go func(result *verification) {
+			defer wait.Done()
+			result.system = lookup(system, result.hostName, timeout)
+			if server != nil {
+				serverResult := lookup(server, result.hostName, timeout)
+				result.server = &serverResult
+			}
+		}(result)
+	}
+
+	wait.Wait()
+}
+
+func lookup(r resolver, hostName string, timeout time.Duration) lookupResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	addresses, err := r.LookupHost(ctx, hostName)
+	return lookupResult{addresses: addresses, err: err}
+}
+
+// systemMismatchCause guesses why the system resolver did not answer with the selected IP
+// An out of date hosts file is the most common, then a resolver that asks DNS before reading the hosts file, then a cache
+func systemMismatchCause(result *verification, outputFile string, fileIPs, order []string) string {
+	if len(fileIPs) == 0 {
+		return fmt.Sprintf("%s does not have %s, run 'hostBuilder build'", outputFile, result.hostName)
+	}
+
+	if !containsIP(fileIPs, result.expected) {
+		return fmt.Sprintf("%s has %s, run 'hostBuilder build'", outputFile, strings.Join(fileIPs, ","))
+	}
+
+	if len(order) != 0 && !contains(order, "files") {
+		return fmt.Sprintf("%s does not look up hosts in files", nsswitchFile)
+	}
+
+	if len(order) != 0 && order[0] != "files" {
+		return fmt.Sprintf("%s looks up hosts in %s before files", nsswitchFile, order[0])
+	}
+
+	return "a cache like nscd, systemd-resolved or the browser still has the old address, flush it"
+}
+
+// hostsLookupOrder reads the sources of the hosts line in nsswitch.conf, it is empty on systems without one
+func hostsLookupOrder() []string {
+	nsswitch, err := ioutil.ReadFile(nsswitchFile)
+	if err != nil {
+		return nil
+	}
+
+	for _, line := range strings.Split(string(nsswitch), "\n") {
+		fields := strings.Fields(strings.SplitN(line, "#", 2)[0])
+		if len(fields) == 0 || fields[0] != "hosts:" {
+			continue
+		}
+
+		order := []string{}
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "[") {
+				order = append(order, field)
+			}
+		}
+
+		return order
+	}
+
+	return nil
+}
+
+// CompleteVerify handles bash autocompletion for the 'verify' command
+func CompleteVerify(c *cli.Context) {
+	configData, err := loadConfig(c)
+	if err != nil {
+		return
+	}
+
+	for _, hostName := range sortHostNames(configData) {
+		if !argsContain(c, hostName) {
+			fmt.Fprintln(c.App.Writer, hostName)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+// fakeResolver answers from fixed addresses, a hostname without any is not found
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addresses, exists := r[host]; exists {
+		return addresses, nil
+	}
+
+	return nil, errors.New("lookup " + host + ": no such host")
+}
+
+func fakeResolvers(system, server fakeResolver) func(string) resolver {
+	return func(address string) resolver {
+		if address == "" {
+			return system
+		}
+
+		return server
+	}
+}
+
+func TestCmdVerify(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupVerifyHostsFile(t, set, "10.0.0.4 baz.com\n10.0.0.8 goo\n")
+	defer removeFile(t, hostsFileName)
+	defer setupNsswitch(t, "hosts: files dns\n")()
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdVerify(fakeResolvers(fakeResolver{"baz.com": {"10.0.0.4"}, "goo": {"10.0.0.8"}}, nil))(c))
+	assert.Equal(t, "baz.com 10.0.0.4 system ok\ngoo     10.0.0.8 system ok\n", writer.String())
+}
+
+func TestCmdVerifyStaleHostsFile(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupVerifyHostsFile(t, set, "10.0.0.7 baz.com\n")
+	defer removeFile(t, hostsFileName)
+	defer setupNsswitch(t, "hosts: files dns\n")()
+
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	err := CmdVerify(fakeResolvers(fakeResolver{"baz.com": {"10.0.0.7"}}, nil))(c)
+	assert.EqualError(t, err, "2 of 2 hostnames do not resolve to their selected IP")
+	assert.Equal(
+		t,
+		"baz.com 10.0.0.4 system 10.0.0.7                 likely cause: "+hostsFileName+" has 10.0.0.7, run 'hostBuilder build'\n"+
+			"goo     10.0.0.8 system lookup goo: no such host likely cause: "+hostsFileName+" does not have goo, run 'hostBuilder build'\n",
+		writer.String(),
+	)
+}
+
+func TestCmdVerifyNsswitchOrder(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupVerifyHostsFile(t, set, "10.0.0.8 goo\n")
+	defer removeFile(t, hostsFileName)
+	defer setupNsswitch(t, "# comment\nhosts: mdns4_minimal [NOTFOUND=return] resolve files\n")()
+
+	assert.Nil(t, set.Parse([]string{"goo"}))
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.EqualError(t, CmdVerify(fakeResolvers(fakeResolver{"goo": {"93.184.216.34"}}, nil))(c), "1 of 1 hostnames do not resolve to their selected IP")
+	assert.Equal(t, "goo 10.0.0.8 system 93.184.216.34 likely cause: "+nsswitchFile+" looks up hosts in mdns4_minimal before files\n", writer.String())
+}
+
+func TestCmdVerifyNsswitchWithoutFiles(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupVerifyHostsFile(t, set, "10.0.0.8 goo\n")
+	defer removeFile(t, hostsFileName)
+	defer setupNsswitch(t, "hosts: dns\n")()
+
+	assert.Nil(t, set.Parse([]string{"goo"}))
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.NotNil(t, CmdVerify(fakeResolvers(fakeResolver{"goo": {"93.184.216.34"}}, nil))(c))
+	assert.Equal(t, "goo 10.0.0.8 system 93.184.216.34 likely cause: "+nsswitchFile+" does not look up hosts in files\n", writer.String())
+}
+
+func TestCmdVerifyCache(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupVerifyHostsFile(t, set, "10.0.0.8 goo\n")
+	defer removeFile(t, hostsFileName)
+	defaultNsswitch := nsswitchFile
+	nsswitchFile = "/notafile"
+	defer func() { nsswitchFile = defaultNsswitch }()
+
+	assert.Nil(t, set.Parse([]string{"goo"}))
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.NotNil(t, CmdVerify(fakeResolvers(fakeResolver{"goo": {"10.0.0.5"}}, nil))(c))
+	assert.Equal(
+		t,
+		"goo 10.0.0.8 system 10.0.0.5 likely cause: a cache like nscd, systemd-resolved or the browser still has the old address, flush it\n",
+		writer.String(),
+	)
+}
+
+func TestCmdVerifyServer(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	hostsFileName := setupVerifyHostsFile(t, set, "10.0.0.4 baz.com\n10.0.0.8 goo\n")
+	defer removeFile(t, hostsFileName)
+	defer setupNsswitch(t, "hosts: files dns\n")()
+
+	set.String("server", "127.0.0.1", "doc")
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	system := fakeResolver{"baz.com": {"10.0.0.4"}, "goo": {"10.0.0.8"}}
+	err := CmdVerify(fakeResolvers(system, fakeResolver{"baz.com": {"10.0.0.4"}, "goo": {"10.0.0.5"}}))(c)
+	assert.EqualError(t, err, "1 of 2 hostnames do not resolve to their selected IP")
+	assert.Equal(
+		t,
+		"baz.com 10.0.0.4 system    ok\n"+
+			"baz.com 10.0.0.4 127.0.0.1 ok\n"+
+			"goo     10.0.0.8 system    ok\n"+
+			"goo     10.0.0.8 127.0.0.1 10.0.0.5 likely cause: the DNS server does not serve "+hostsFileName+" or caches the old address\n",
+		writer.String(),
+	)
+}
+
+func TestLookupResultMatches(t *testing.T) {
+	assert.True(t, lookupResult{addresses: []string{"10.0.0.4", "0:0:0:0:0:0:0:1"}}.matches("::1"))
+	assert.True(t, lookupResult{addresses: []string{"::ffff:10.0.0.4"}}.matches("10.0.0.4"))
+	assert.False(t, lookupResult{addresses: []string{"10.0.0.4"}}.matches("10.0.0.5"))
+	assert.False(t, lookupResult{addresses: []string{"10.0.0.4"}, err: errors.New("timeout")}.matches("10.0.0.4"))
+}
+
+func TestCmdVerifyFailover(t *testing.T) {
+	configFileName, set := setupFailoverConfigFile(t, config.Check{Command: "true"})
+	defer removeFile(t, configFileName)
+	hostsFileName := setupVerifyHostsFile(t, set, "127.0.0.1 api.example.com\n")
+	defer removeFile(t, hostsFileName)
+
+	assert.Nil(t, set.Parse([]string{"api.example.com"}))
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	assert.Nil(t, CmdVerify(fakeResolvers(fakeResolver{"api.example.com": {"127.0.0.1"}}, nil))(c))
+	assert.Equal(t, "api.example.com 127.0.0.1 system ok\n", writer.String())
+}
+
+func TestCmdVerifyInvalid(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	set.Duration("timeout", time.Second, "doc")
+	assert.Nil(t, set.Parse([]string{"gone"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdVerify(fakeResolvers(nil, nil))(c), "HostName gone does not exist")
+
+	set = flag.NewFlagSet("test", 0)
+	set.String("config", configFileName, "doc")
+	assert.Nil(t, set.Parse([]string{"bar"}))
+	set.Duration("timeout", time.Second, "doc")
+	c = cli.NewContext(cli.NewApp(), set, nil)
+	assert.EqualError(t, CmdVerify(fakeResolvers(nil, nil))(c), "There are no hostnames to verify")
+
+	set = flag.NewFlagSet("test", 0)
+	set.String("config", configFileName, "doc")
+	c = cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdVerify(fakeResolvers(nil, nil))(c), "Invalid timeout 0s")
+}
+
+func TestCompleteVerify(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+
+	assert.Nil(t, set.Parse([]string{"goo"}))
+	os.Args = []string{"verify", "goo", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteVerify(c)
+	assert.Equal(t, "bar\nbaz.com\n", writer.String())
+}
+
+// setupVerifyHostsFile writes the hosts file that verify compares against and gives verify a timeout
+func setupVerifyHostsFile(t *testing.T, set *flag.FlagSet, hostsData string) string {
+	hostsFileName := setupOutputFile(t)
+	assert.Nil(t, ioutil.WriteFile(hostsFileName, []byte(hostsData), 0644))
+	set.String("output", hostsFileName, "doc")
+	set.Duration("timeout", time.Second, "doc")
+	return hostsFileName
+}
+
+// setupNsswitch points verify at an nsswitch.conf with the given contents, the returned function restores the default
+func setupNsswitch(t *testing.T, nsswitchData string) func() {
+	nsswitchFileName := setupOutputFile(t)
+	assert.Nil(t, ioutil.WriteFile(nsswitchFileName, []byte(nsswitchData), 0644))
+	defaultNsswitch := nsswitchFile
+	nsswitchFile = nsswitchFileName
+	return func() {
+		nsswitchFile = defaultNsswitch
+		removeFile(t, nsswitchFileName)
+	}
+}
@@ -0,0 +1,494 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/urfave/cli"
+)
+
+// clock tells the time and wakes the watch loop, tests replace it to control time
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// watcher rebuilds the output when the files it watches change and syncs sources on their schedules
+type watcher struct {
+	c            *cli.Context
+	registry     *provider.Registry
+	clock        clock
+	events       <-chan string
+	output       string
+	oneLinePerIP bool
+	debounce     time.Duration
+	checkEvery   time.Duration
+	checkedAt    time.Time
+	currents     map[string]string
+	synced       map[string]time.Time
+	filesMutex   sync.Mutex
+	files        []string
+	sourceFiles  map[string][]string
+}
+
+// CmdWatch rebuilds the hosts file whenever the configuration or a file a source reads changes
+// Sources with an every setting are synced on their schedule and expired overrides are reverted, SIGINT or SIGTERM stops watching
+func CmdWatch(registry *provider.Registry) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		if c.NArg() != 0 {
+			return cli.NewExitError("Usage: \"hostBuilder watch --output {file}\"", 1)
+		}
+
+		w, err := newWatcher(c, registry, realClock{}, nil)
+		if err != nil {
+			return err
+		}
+
+		interval := c.Duration("interval")
+		if interval <= 0 {
+			return cli.NewExitError(fmt.Sprintf("Invalid interval %s", interval), 1)
+		}
+
+		stop := make(chan struct{})
+		defer close(stop)
+		interrupted := make(chan struct{})
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(signals)
+		go func() {
+			select {
+			case <-signals:
+				close(interrupted)
+			case <-stop:
+			}
+		}()
+
+		events := make(chan string)
+		w.events = events
+		go pollFiles(w.clock, interval, w.watchedFiles, events, stop)
+		return w.run(interrupted)
+	}
+}
+
+func newWatcher(c *cli.Context, registry *provider.Registry, clk clock, events <-chan string) (*watcher, error) {
+	output := c.String("output")
+	if output == "" {
+		return nil, cli.NewExitError("You must specify an output file", 1)
+	}
+
+	debounce := c.Duration("debounce")
+	if debounce < 0 {
+		return nil, cli.NewExitError(fmt.Sprintf("Invalid debounce %s", debounce), 1)
+	}
+
+	checkEvery := c.Duration("checkInterval")
+	if checkEvery < 0 {
+		return nil, cli.NewExitError(fmt.Sprintf("Invalid check interval %s", checkEvery), 1)
+	}
+
+	return &watcher{
+		c:            c,
+		registry:     registry,
+		clock:        clk,
+		events:       events,
+		output:       output,
+		oneLinePerIP: c.Bool("oneLinePerIP"),
+		debounce:     debounce,
+		checkEvery:   checkEvery,
+		synced:       map[string]time.Time{},
+		sourceFiles:  map[string][]string{},
+	}, nil
+}
+
+// run builds the output and then watches until stop is closed
+// Events are collected until none arrive for the debounce period so a burst of edits causes one rebuild
+// One timer wakes the loop for the scheduled work, it is only replaced when the configuration changes or the work is done
+func (w *watcher) run(stop <-chan struct{}) error {
+	configData, err := w.rebuild()
+	if err != nil {
+		return err
+	}
+
+	changed := map[string]bool{}
+	var settled <-chan time.Time
+	wake := w.schedule(configData)
+	for {
+		select {
+		case <-stop:
+			fmt.Fprintln(w.c.App.Writer, "Stopped watching")
+			return nil
+		case fileName := <-w.events:
+			changed[fileName] = true
+			settled = w.clock.After(w.debounce)
+		case <-settled:
+			settled = nil
+			configData = w.update(configData, changed)
+			changed = map[string]bool{}
+			wake = w.schedule(configData)
+		case <-wake:
+			configData = w.syncSources(configData, w.dueSources(configData))
+			if at, due := w.nextCheck(configData); due && !at.After(w.clock.Now()) {
+				w.recheck(configData)
+			}
+
+			configData = w.revert(configData)
+			wake = w.schedule(configData)
+		}
+	}
+}
+
+// schedule wakes the loop when the next source sync, health check or override expiry is due
+// It never fires when there is nothing scheduled
+func (w *watcher) schedule(configData *config.HostsConfig) <-chan time.Time {
+	var next *time.Time
+	for _, nextAt := range []func(*config.HostsConfig) (time.Time, bool){w.nextSync, w.nextCheck, nextExpiry} {
+		if at, scheduled := nextAt(configData); scheduled && (next == nil || at.Before(*next)) {
+			next = &at
+		}
+	}
+
+	if next == nil {
+		return nil
+	}
+
+	return w.clock.After(next.Sub(w.clock.Now()))
+}
+
+// update syncs the sources that read a changed file and rebuilds the output
+func (w *watcher) update(configData *config.HostsConfig, changed map[string]bool) *config.HostsConfig {
+	if changed[w.c.GlobalString("config")] {
+		newConfig, err := w.rebuild()
+		if err != nil {
+			fmt.Fprintf(w.c.App.ErrWriter, "Failed to rebuild %s: %v\n", w.output, err)
+			return configData
+		}
+
+		configData = newConfig
+	}
+
+	sourceNames := []string{}
+	w.filesMutex.Lock()
+	for sourceName, fileNames := range w.sourceFiles {
+		for _, fileName := range fileNames {
+			if changed[fileName] {
+				sourceNames = append(sourceNames, sourceName)
+				break
+			}
+		}
+	}
+	w.filesMutex.Unlock()
+
+	sort.Strings(sourceNames)
+	return w.syncSources(configData, sourceNames)
+}
+
+// rebuild loads the configuration and writes the output from it
+func (w *watcher) rebuild() (*config.HostsConfig, error) {
+	configData, err := loadConfig(w.c)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.write(configData)
+	if err != nil {
+		return nil, err
+	}
+
+	return configData, nil
+}
+
+func (w *watcher) write(configData *config.HostsConfig) error {
+	w.watchFiles(configData)
+	for _, sourceName := range sortSourceNames(configData) {
+		if every := configData.Sources[sourceName].Every; every != "" {
+			if _, err := parseEvery(every); err != nil {
+				fmt.Fprintf(w.c.App.ErrWriter, "Warning: %v, not scheduling %s\n", err, sourceName)
+			}
+		}
+	}
+
+	w.checkedAt = w.clock.Now()
+	selected := failover(configData, w.c.App.ErrWriter)
+	w.currents = hostCurrents(selected)
+	err := writeHostsFile(selected, w.output, w.oneLinePerIP, w.c.App.Writer, w.c.App.ErrWriter)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w.c.App.Writer, "Rebuilt %s\n", w.output)
+	return nil
+}
+
+// nextCheck is when the health checks are due, they are never due when no host has a failover list
+func (w *watcher) nextCheck(configData *config.HostsConfig) (time.Time, bool) {
+	if w.checkEvery == 0 || len(failoverHosts(configData)) == 0 {
+		return time.Time{}, false
+	}
+
+	return w.checkedAt.Add(w.checkEvery), true
+}
+
+// recheck runs the health checks and rebuilds the output when they change the option a host uses
+func (w *watcher) recheck(configData *config.HostsConfig) {
+	w.checkedAt = w.clock.Now()
+	selected, warnings := selectHealthy(configData, checkHealth(configData, failoverHosts(configData)))
+	currents := hostCurrents(selected)
+	if reflect.DeepEqual(currents, w.currents) {
+		return
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintf(w.c.App.ErrWriter, "Warning: %v\n", warning)
+	}
+
+	w.currents = currents
+	err := writeHostsFile(selected, w.output, w.oneLinePerIP, w.c.App.Writer, w.c.App.ErrWriter)
+	if err != nil {
+		fmt.Fprintf(w.c.App.ErrWriter, "Failed to rebuild %s: %v\n", w.output, err)
+		return
+	}
+
+	fmt.Fprintf(w.c.App.Writer, "Rebuilt %s\n", w.output)
+}
+
+// revert restores the previous option of the hosts whose override expired, saves the configuration and rebuilds the output
+// The change hooks run after the output is rebuilt, as they do when a host is set by hand
+func (w *watcher) revert(configData *config.HostsConfig) *config.HostsConfig {
+	before := hostCurrents(configData)
+	reverted := revertExpiredOverrides(configData, w.clock.Now())
+	if len(reverted) == 0 {
+		return configData
+	}
+
+	for _, hostName := range reverted {
+		fmt.Fprintf(w.c.App.Writer, "Override of %s expired, reverted %s -> %s\n", hostName, before[hostName], configData.Hosts[hostName].Current)
+	}
+
+	err := config.WriteConfig(w.c.GlobalString("config"), configData)
+	if err != nil {
+		fmt.Fprintf(w.c.App.ErrWriter, "Failed to save %s: %v\n", w.c.GlobalString("config"), err)
+	}
+
+	err = w.write(configData)
+	if err != nil {
+		fmt.Fprintf(w.c.App.ErrWriter, "Failed to rebuild %s: %v\n", w.output, err)
+		return configData
+	}
+
+	warnChangeHooks(configData, before, w.output, w.c.App.Writer, w.c.App.ErrWriter)
+	return configData
+}
+
+// syncSources syncs sources, saves the configuration and rebuilds the output
+// A source that fails is reported and tried again on its next schedule
+func (w *watcher) syncSources(configData *config.HostsConfig, sourceNames []string) *config.HostsConfig {
+	if len(sourceNames) == 0 {
+		return configData
+	}
+
+	sourceRegistry := withPlugins(w.registry, configData)
+	for _, sourceName := range sourceNames {
+		w.synced[sourceName] = w.clock.Now()
+		err := syncSource(w.c, sourceRegistry, configData, sourceName)
+		if err != nil {
+			fmt.Fprintf(w.c.App.ErrWriter, "Failed to sync %s: %v\n", sourceName, err)
+		}
+	}
+
+	err := config.WriteConfig(w.c.GlobalString("config"), configData)
+	if err != nil {
+		fmt.Fprintf(w.c.App.ErrWriter, "Failed to save %s: %v\n", w.c.GlobalString("config"), err)
+	}
+
+	err = w.write(configData)
+	if err != nil {
+		fmt.Fprintf(w.c.App.ErrWriter, "Failed to rebuild %s: %v\n", w.output, err)
+	}
+
+	return configData
+}
+
+// nextSync is when the next scheduled source is due, there is none when no source has a schedule
+func (w *watcher) nextSync(configData *config.HostsConfig) (time.Time, bool) {
+	var next time.Time
+	found := false
+	for sourceName := range configData.Sources {
+		due, scheduled := w.dueAt(configData, sourceName)
+		if scheduled && (!found || due.Before(next)) {
+			next = due
+			found = true
+		}
+	}
+
+	return next, found
+}
+
+func (w *watcher) dueSources(configData *config.HostsConfig) []string {
+	sourceNames := []string{}
+	for _, sourceName := range sortSourceNames(configData) {
+		if due, scheduled := w.dueAt(configData, sourceName); scheduled && !due.After(w.clock.Now()) {
+			sourceNames = append(sourceNames, sourceName)
+		}
+	}
+
+	return sourceNames
+}
+
+// dueAt is when a source is next synced, one period after the last sync this watcher made or the last sync in the configuration
+func (w *watcher) dueAt(configData *config.HostsConfig, sourceName string) (time.Time, bool) {
+	source := configData.Sources[sourceName]
+	if source.Every == "" {
+		return time.Time{}, false
+	}
+
+	every, err := parseEvery(source.Every)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	if synced, exists := w.synced[sourceName]; exists {
+		return synced.Add(every), true
+	}
+
+	if source.SyncedAt != nil {
+		return source.SyncedAt.Add(every), true
+	}
+
+	return w.clock.Now(), true
+}
+
+// watchFiles records the configuration file and the files the sources read
+func (w *watcher) watchFiles(configData *config.HostsConfig) {
+	files := []string{w.c.GlobalString("config")}
+	watched := map[string]bool{w.c.GlobalString("config"): true}
+	sourceFiles := map[string][]string{}
+	sourceRegistry := withPlugins(w.registry, configData)
+	for _, sourceName := range sortSourceNames(configData) {
+		source := configData.Sources[sourceName]
+		sourceProvider, err := sourceRegistry.Get(source.Provider)
+		if err != nil {
+			continue
+		}
+
+		settings, err := provider.ApplyDefaults(sourceProvider, source.Settings)
+		if err != nil {
+			continue
+		}
+
+		for _, setting := range sourceProvider.Schema() {
+			fileName := settings[setting.Name]
+			if !setting.File || fileName == "" || fileName == "-" {
+				continue
+			}
+
+			sourceFiles[sourceName] = append(sourceFiles[sourceName], fileName)
+			if !watched[fileName] {
+				watched[fileName] = true
+				files = append(files, fileName)
+			}
+		}
+	}
+
+	w.filesMutex.Lock()
+	defer w.filesMutex.Unlock()
+	w.files = files
+	w.sourceFiles = sourceFiles
+}
+
+func (w *watcher) watchedFiles() []string {
+	w.filesMutex.Lock()
+	defer w.filesMutex.Unlock()
+	return w.files
+}
+
+// fileState is what pollFiles compares to notice a change
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime int64
+}
+
+func statFile(fileName string) fileState {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return fileState{}
+	}
+
+	return fileState{exists: true, size: info.Size(), modTime: info.ModTime().UnixNano()}
+}
+
+// pollFiles sends the name of each watched file whose size, modification time or existence changes until stop is closed
+func pollFiles(clk clock, interval time.Duration, files func() []string, events chan<- string, stop <-chan struct{}) {
+	states := map[string]fileState{}
+	for _, fileName := range files() {
+		states[fileName] = statFile(fileName)
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-clk.After(interval):
+		}
+
+		for _, fileName := range files() {
+			state := statFile(fileName)
+			previous, known := states[fileName]
+			states[fileName] = state
+			if !known || previous == state {
+				continue
+			}
+
+			select {
+			case events <- fileName:
+			case <-stop:
+				return
+			}
+		}
+	}
+}
+
+// parseEvery reads the period of a source schedule
+func parseEvery(every string) (time.Duration, error) {
+	period, err := time.ParseDuration(every)
+	if err != nil || period <= 0 {
+		return 0, fmt.Errorf("Invalid period %s, expected a duration like 15m", every)
+	}
+
+	return period, nil
+}
+
+// CompleteWatch handles bash autocompletion for the 'watch' command
+func CompleteWatch(c *cli.Context) {
+	lastParam := os.Args[len(os.Args)-2]
+	if lastParam == "--output" {
+		fmt.Fprintln(c.App.Writer, "fileCompletion")
+		return
+	}
+
+	for _, flag := range c.App.Command("watch").Flags {
+		name := strings.Split(flag.GetName(), ",")[0]
+		if !c.IsSet(name) {
+			fmt.Fprintf(c.App.Writer, "--%s\n", name)
+		}
+	}
+}
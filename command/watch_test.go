@@ -0,0 +1,433 @@
+package command
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/guywithnose/hostBuilder/config"
+	"github.com/guywithnose/hostBuilder/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestWatchDebounce(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	set.Duration("debounce", 500*time.Millisecond, "doc")
+	w, lines, clk, events := setupWatcher(t, set, outputFileName, &testProvider{})
+	stop, done := startWatcher(w)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	configData.Hosts["goo"] = config.Host{Current: "new", Options: map[string]string{"new": "10.0.0.9"}}
+	assert.Nil(t, config.WriteConfig(configFileName, configData))
+
+	events <- configFileName
+	clk.waitFor(1)
+	clk.Advance(250 * time.Millisecond)
+	events <- configFileName
+	clk.waitFor(2)
+	clk.Advance(250 * time.Millisecond)
+	clk.Advance(250 * time.Millisecond)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+
+	close(stop)
+	assert.Equal(t, "Stopped watching\n", <-lines)
+	assert.Nil(t, <-done)
+
+	output, err := ioutil.ReadFile(outputFileName)
+	assert.Nil(t, err)
+	assert.Contains(t, string(output), "10.0.0.9 goo")
+}
+
+func TestWatchRecheck(t *testing.T) {
+	upFileName := setupOutputFile(t)
+	removeFile(t, upFileName)
+	configFileName, set := setupFailoverConfigFile(t, config.Check{Command: "test -e " + upFileName})
+	defer removeFile(t, configFileName)
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	set.Duration("checkInterval", 30*time.Second, "doc")
+	w, lines, clk, _ := setupWatcher(t, set, outputFileName, &testProvider{})
+	stop, done := startWatcher(w)
+	assert.Equal(t, "Warning: api.example.com is using dev, local is unhealthy: Exited with status 1\n", <-lines)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+
+	clk.waitFor(1)
+	clk.Advance(30 * time.Second)
+	clk.waitFor(1)
+	assert.Nil(t, ioutil.WriteFile(upFileName, []byte{}, 0644))
+	defer removeFile(t, upFileName)
+	clk.Advance(30 * time.Second)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+
+	close(stop)
+	assert.Equal(t, "Stopped watching\n", <-lines)
+	assert.Nil(t, <-done)
+
+	output, err := ioutil.ReadFile(outputFileName)
+	assert.Nil(t, err)
+	assert.Contains(t, string(output), "127.0.0.1 api.example.com\n")
+}
+
+func TestWatchRevertsOverride(t *testing.T) {
+	logFileName := setupOutputFile(t)
+	defer removeFile(t, logFileName)
+	configFileName, set := setupHookConfigFile(t, []config.Hook{{Name: "switch", When: hookChange, Command: logHook(logFileName)}})
+	defer removeFile(t, configFileName)
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	host := configData.Hosts["goo"]
+	host.Override = &config.Override{Previous: "baz", Until: testImportedAt.Add(time.Hour)}
+	configData.Hosts["goo"] = host
+	assert.Nil(t, config.WriteConfig(configFileName, configData))
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	w, lines, clk, _ := setupWatcher(t, set, outputFileName, &testProvider{})
+	stop, done := startWatcher(w)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+
+	clk.waitFor(1)
+	clk.Advance(time.Hour)
+	assert.Equal(t, "Override of goo expired, reverted foop -> baz\n", <-lines)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+
+	close(stop)
+	assert.Equal(t, "Stopped watching\n", <-lines)
+	assert.Nil(t, <-done)
+
+	configData, err = config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, config.Host{Current: "baz", Options: map[string]string{"foop": "10.0.0.8"}}, configData.Hosts["goo"])
+
+	output, err := ioutil.ReadFile(outputFileName)
+	assert.Nil(t, err)
+	assert.Contains(t, string(output), "10.0.0.4 goo\n")
+
+	log, err := ioutil.ReadFile(logFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, "change "+outputFileName+" goo\n{\"event\":\"change\",\"output\":\""+outputFileName+"\",\"hosts\":[\"goo\"]}\n", string(log))
+}
+
+func TestWatchInvalidConfig(t *testing.T) {
+	configFileName, set := setupBaseConfigFile(t)
+	defer removeFile(t, configFileName)
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	w, lines, _, events := setupWatcher(t, set, outputFileName, &testProvider{})
+	stop, done := startWatcher(w)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+
+	assert.Nil(t, ioutil.WriteFile(configFileName, []byte("{"), 0644))
+	events <- configFileName
+	assert.True(t, strings.HasPrefix(<-lines, "Failed to rebuild "+outputFileName+": "))
+
+	close(stop)
+	assert.Equal(t, "Stopped watching\n", <-lines)
+	assert.Nil(t, <-done)
+}
+
+func TestWatchSchedule(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{
+		"east":   {Provider: "test", Every: "1h"},
+		"manual": {Provider: "test"},
+	})
+	defer removeFile(t, configFileName)
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	testProvider := &testProvider{addresses: []provider.Address{{Name: "foo", IP: "10.0.0.1"}}}
+	w, lines, clk, _ := setupWatcher(t, set, outputFileName, testProvider)
+	stop, done := startWatcher(w)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+	assert.Equal(t, "Added global IP foo (10.0.0.1)\n", <-lines)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+
+	clk.waitFor(1)
+	clk.Advance(time.Hour)
+	assert.Equal(t, "east is up to date\n", <-lines)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+
+	close(stop)
+	assert.Equal(t, "Stopped watching\n", <-lines)
+	assert.Nil(t, <-done)
+
+	configData, err := config.LoadConfigFromFile(configFileName)
+	assert.Nil(t, err)
+	assert.Equal(t, testImportedAt, *configData.Sources["east"].SyncedAt)
+	assert.Nil(t, configData.Sources["manual"].SyncedAt)
+}
+
+func TestWatchScheduleFromLastSync(t *testing.T) {
+	syncedAt := testImportedAt.Add(-30 * time.Minute)
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{
+		"east": {Provider: "test", Every: "1h", SyncedAt: &syncedAt},
+	})
+	defer removeFile(t, configFileName)
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	w, lines, clk, _ := setupWatcher(t, set, outputFileName, &testProvider{})
+	stop, done := startWatcher(w)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+
+	clk.waitFor(1)
+	clk.Advance(30 * time.Minute)
+	assert.Equal(t, "east is up to date\n", <-lines)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+
+	close(stop)
+	assert.Equal(t, "Stopped watching\n", <-lines)
+	assert.Nil(t, <-done)
+}
+
+func TestWatchScheduleFailure(t *testing.T) {
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{
+		"east": {Provider: "test", Every: "1h"},
+		"bad":  {Provider: "test", Every: "never"},
+	})
+	defer removeFile(t, configFileName)
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	warning := "Warning: Invalid period never, expected a duration like 15m, not scheduling bad\n"
+	w, lines, clk, _ := setupWatcher(t, set, outputFileName, &testProvider{throwError: true})
+	stop, done := startWatcher(w)
+	assert.Equal(t, warning, <-lines)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+	assert.Equal(t, "Failed to sync east: error\n", <-lines)
+	assert.Equal(t, warning, <-lines)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+
+	clk.waitFor(1)
+	clk.Advance(time.Hour)
+	assert.Equal(t, "Failed to sync east: error\n", <-lines)
+	assert.Equal(t, warning, <-lines)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+
+	close(stop)
+	assert.Equal(t, "Stopped watching\n", <-lines)
+	assert.Nil(t, <-done)
+}
+
+func TestWatchSourceFile(t *testing.T) {
+	sourceFileName := setupOutputFile(t)
+	defer removeFile(t, sourceFileName)
+	configFileName, set := setupSourceConfigFile(t, map[string]config.Source{
+		"lab":   {Provider: "test", Settings: map[string]string{"file": sourceFileName}},
+		"stdin": {Provider: "test", Settings: map[string]string{"file": "-"}},
+	})
+	defer removeFile(t, configFileName)
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	testProvider := &testProvider{addresses: []provider.Address{{Host: "goo", Name: "lab", IP: "10.0.0.2"}}}
+	w, lines, _, events := setupWatcher(t, set, outputFileName, testProvider)
+	stop, done := startWatcher(w)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+	assert.Equal(t, []string{configFileName, sourceFileName}, w.watchedFiles())
+
+	events <- sourceFileName
+	assert.Equal(t, "Added option to goo (lab => 10.0.0.2)\n", <-lines)
+	assert.Equal(t, "Rebuilt "+outputFileName+"\n", <-lines)
+
+	close(stop)
+	assert.Equal(t, "Stopped watching\n", <-lines)
+	assert.Nil(t, <-done)
+}
+
+func TestWatchBadConfigFile(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	set.String("config", "/notafile", "doc")
+	outputFileName := setupOutputFile(t)
+	defer removeFile(t, outputFileName)
+
+	w, _, _, _ := setupWatcher(t, set, outputFileName, &testProvider{})
+	assert.NotNil(t, w.run(make(chan struct{})))
+}
+
+func TestPollFiles(t *testing.T) {
+	fileName := setupOutputFile(t)
+	defer removeFile(t, fileName)
+
+	clk := newFakeClock(testImportedAt)
+	events := make(chan string)
+	stop := make(chan struct{})
+	defer close(stop)
+	go pollFiles(clk, time.Second, func() []string { return []string{fileName, "/notafile"} }, events, stop)
+
+	clk.waitFor(1)
+	assert.Nil(t, ioutil.WriteFile(fileName, []byte("changed"), 0644))
+	clk.Advance(time.Second)
+	assert.Equal(t, fileName, <-events)
+
+	clk.waitFor(1)
+	assert.Nil(t, os.Remove(fileName))
+	clk.Advance(time.Second)
+	assert.Equal(t, fileName, <-events)
+	assert.Nil(t, ioutil.WriteFile(fileName, []byte{}, 0644))
+}
+
+func TestCmdWatchUsage(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	assert.Nil(t, set.Parse([]string{"foo"}))
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdWatch(provider.NewRegistry(&testProvider{}))(c), "Usage: \"hostBuilder watch --output {file}\"")
+}
+
+func TestCmdWatchNoOutput(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdWatch(provider.NewRegistry(&testProvider{}))(c), "You must specify an output file")
+}
+
+func TestCmdWatchInvalidDebounce(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	set.String("output", "/notafile", "doc")
+	set.Duration("debounce", -time.Second, "doc")
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdWatch(provider.NewRegistry(&testProvider{}))(c), "Invalid debounce -1s")
+}
+
+func TestCmdWatchInvalidInterval(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	set.String("output", "/notafile", "doc")
+	c := cli.NewContext(nil, set, nil)
+	assert.EqualError(t, CmdWatch(provider.NewRegistry(&testProvider{}))(c), "Invalid interval 0s")
+}
+
+func TestCompleteWatch(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	os.Args = []string{"watch", "--completion"}
+	app, writer := appWithWriter()
+	app.Commands = []cli.Command{{Name: "watch", Flags: []cli.Flag{cli.StringFlag{Name: "output, o"}, cli.DurationFlag{Name: "debounce"}}}}
+	c := cli.NewContext(app, set, nil)
+	CompleteWatch(c)
+	assert.Equal(t, "--output\n--debounce\n", writer.String())
+}
+
+func TestCompleteWatchOutput(t *testing.T) {
+	set := flag.NewFlagSet("test", 0)
+	os.Args = []string{"watch", "--output", "--completion"}
+	app, writer := appWithWriter()
+	c := cli.NewContext(app, set, nil)
+	CompleteWatch(c)
+	assert.Equal(t, "fileCompletion\n", writer.String())
+}
+
+func setupOutputFile(t *testing.T) string {
+	outputFile, err := ioutil.TempFile("/tmp", "output")
+	assert.Nil(t, err)
+	assert.Nil(t, outputFile.Close())
+	return outputFile.Name()
+}
+
+// setupWatcher makes a watcher that writes each line it prints to a channel, whose clock and file events the test controls
+func setupWatcher(t *testing.T, set *flag.FlagSet, outputFileName string, testProvider *testProvider) (*watcher, <-chan string, *fakeClock, chan<- string) {
+	set.String("output", outputFileName, "doc")
+	lines := make(chan string, 10)
+	app := cli.NewApp()
+	app.Writer = lineWriter(lines)
+	app.ErrWriter = lineWriter(lines)
+	clk := newFakeClock(testImportedAt)
+	events := make(chan string)
+	w, err := newWatcher(cli.NewContext(app, set, nil), provider.NewRegistry(testProvider), clk, events)
+	assert.Nil(t, err)
+	return w, lines, clk, events
+}
+
+func startWatcher(w *watcher) (chan struct{}, <-chan error) {
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- w.run(stop)
+	}()
+
+	return stop, done
+}
+
+type lineWriter chan<- string
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	w <- string(p)
+	return len(p), nil
+}
+
+// fakeClock only moves when Advance is called, waiters whose time has come are woken by Advance
+type fakeClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at      time.Time
+	channel chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (clk *fakeClock) Now() time.Time {
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	return clk.now
+}
+
+func (clk *fakeClock) After(d time.Duration) <-chan time.Time {
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	channel := make(chan time.Time, 1)
+	if d <= 0 {
+		channel <- clk.now
+		return channel
+	}
+
+	clk.waiters = append(clk.waiters, fakeWaiter{at: clk.now.Add(d), channel: channel})
+	return channel
+}
+
+// Advance moves the clock forward and wakes the waiters that are due
+func (clk *fakeClock) Advance(d time.Duration) {
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	clk.now = clk.now.Add(d)
+	waiting := []fakeWaiter{}
+	for _, waiter := range clk.waiters {
+		if waiter.at.After(clk.now) {
+			waiting = append(waiting, waiter)
+			continue
+		}
+
+		waiter.channel <- clk.now
+	}
+
+	clk.waiters = waiting
+}
+
+// waitFor blocks until at least count waiters are waiting
+func (clk *fakeClock) waitFor(count int) {
+	for {
+		clk.mutex.Lock()
+		waiting := len(clk.waiters)
+		clk.mutex.Unlock()
+		if waiting >= count {
+			return
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
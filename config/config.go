@@ -5,21 +5,101 @@ import (
 	"fmt"
 	"io/ioutil"
 	"strings"
+	"time"
 )
 
 // HostsConfig defines the structure of the hosts config file
 type HostsConfig struct {
-	LocalHostnames []string            `json:"localHostnames,omitempty"`
-	IPv6Defaults   bool                `json:"ipV6Defaults,omitempty"`
-	Hosts          map[string]Host     `json:"hosts,omitempty"`
-	GlobalIPs      map[string]string   `json:"globalIPs,omitempty"`
-	Groups         map[string][]string `json:"groups,omitempty"`
+	LocalHostnames []string              `json:"localHostnames,omitempty"`
+	IPv6Defaults   bool                  `json:"ipV6Defaults,omitempty"`
+	Hosts          map[string]Host       `json:"hosts,omitempty"`
+	GlobalIPs      map[string]string     `json:"globalIPs,omitempty"`
+	Groups         map[string][]string   `json:"groups,omitempty"`
+	Provenance     map[string]Provenance `json:"provenance,omitempty"`
+	Sources        map[string]Source     `json:"sources,omitempty"`
+	Plugins        map[string]string     `json:"plugins,omitempty"`
+	Hooks          []Hook                `json:"hooks,omitempty"`
+	Rules          []Rule                `json:"rules,omitempty"`
 }
 
 // Host defines the data associated with a hostname
+// Failover lists options in order of preference, the hosts file uses the first healthy one instead of Current
+// Checks are the health checks of options by name, an option without a check is always healthy
 type Host struct {
-	Current string            `json:"current,omitempty"`
-	Options map[string]string `json:"options,omitempty"`
+	Current    string                `json:"current,omitempty"`
+	Options    map[string]string     `json:"options,omitempty"`
+	Provenance map[string]Provenance `json:"provenance,omitempty"`
+	Failover   []string              `json:"failover,omitempty"`
+	Checks     map[string]Check      `json:"checks,omitempty"`
+	Override   *Override             `json:"override,omitempty"`
+}
+
+// Override is a temporary selection made with 'host set --for', Previous is the option the watch command restores at Until
+type Override struct {
+	Previous string    `json:"previous"`
+	Until    time.Time `json:"until"`
+}
+
+// Check is a health check for a host option, one of TCP, HTTP or Command is set
+// TCP is an address to connect to, a missing host like :443 connects to the option's IP
+// HTTP is a URL that must answer with Status, any 2xx when it is 0, and Command is a shell command that must exit 0
+type Check struct {
+	TCP     string `json:"tcp,omitempty"`
+	HTTP    string `json:"http,omitempty"`
+	Status  int    `json:"status,omitempty"`
+	Command string `json:"command,omitempty"`
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Provenance records where an imported global IP or host option came from
+// ImportedAt is when the IP was first imported and FetchedAt is when the source last reported it, which is earlier than the import for cached results
+// Stale is set when a later import from the same source no longer returned the entry
+type Provenance struct {
+	Source     string     `json:"source"`
+	Provider   string     `json:"provider,omitempty"`
+	Profile    string     `json:"profile,omitempty"`
+	Region     string     `json:"region,omitempty"`
+	ResourceID string     `json:"resourceId,omitempty"`
+	ImportedAt *time.Time `json:"importedAt,omitempty"`
+	FetchedAt  *time.Time `json:"fetchedAt,omitempty"`
+	Stale      bool       `json:"stale,omitempty"`
+}
+
+// Source is a provider instance that the sync command refreshes
+// Current is the policy for hosts using an entry that prune removes, empty means warn
+// Every is how often the watch command syncs the source, like 15m, empty means it is only synced by hand
+// SyncedAt is when the addresses of the last sync were fetched
+type Source struct {
+	Provider string            `json:"provider"`
+	Settings map[string]string `json:"settings,omitempty"`
+	Prune    bool              `json:"prune,omitempty"`
+	Current  string            `json:"current,omitempty"`
+	Every    string            `json:"every,omitempty"`
+	SyncedAt *time.Time        `json:"syncedAt,omitempty"`
+}
+
+// Hook is a shell command run before or after the hosts file is built, or when hosts are switched to another option
+// When is preBuild, postBuild or change and OnFailure is abort or warn, abort when empty
+type Hook struct {
+	Name      string `json:"name"`
+	When      string `json:"when"`
+	Command   string `json:"command"`
+	Timeout   string `json:"timeout,omitempty"`
+	OnFailure string `json:"onFailure,omitempty"`
+}
+
+// Rule switches hosts and groups when the network this machine is on matches every condition it sets, a rule without conditions always matches
+// CIDR matches an address of an interface that is up, Gateway is the IP of the default gateway,
+// Interface is the name of an interface that is up like tun*, and Env is an environment variable as NAME or NAME=value
+// Hosts maps hostnames to options and Groups maps groups to global IPs
+type Rule struct {
+	Name      string            `json:"name"`
+	CIDR      string            `json:"cidr,omitempty"`
+	Gateway   string            `json:"gateway,omitempty"`
+	Interface string            `json:"interface,omitempty"`
+	Env       string            `json:"env,omitempty"`
+	Hosts     map[string]string `json:"hosts,omitempty"`
+	Groups    map[string]string `json:"groups,omitempty"`
 }
 
 // LoadConfigFromFile loads a HostsConfig from a file
@@ -29,8 +109,13 @@ func LoadConfigFromFile(fileName string) (*HostsConfig, error) {
 		return nil, err
 	}
 
+	return ParseConfig(configJSON)
+}
+
+// ParseConfig loads a HostsConfig from its JSON
+func ParseConfig(configJSON []byte) (*HostsConfig, error) {
 	var configData = new(HostsConfig)
-	err = json.Unmarshal(configJSON, configData)
+	err := json.Unmarshal(configJSON, configData)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,259 @@
+package dockerUtil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DefaultSocket is where the docker engine listens unless told otherwise
+const DefaultSocket = "/var/run/docker.sock"
+
+// Container is a running container and the addresses it has on each network
+type Container struct {
+	ID       string
+	Name     string
+	Image    string
+	Project  string
+	Service  string
+	Networks []Network
+}
+
+// Network is a container's membership in a single docker network
+type Network struct {
+	Name    string
+	IP      string
+	Aliases []string
+}
+
+// Address is a name a container can be reached by on a network
+type Address struct {
+	Name      string
+	Container string
+	ID        string
+	Image     string
+	Project   string
+	Service   string
+	Network   string
+	IP        string
+}
+
+type inspectResult struct {
+	ID     string `json:"Id"`
+	Name   string `json:"Name"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			IPAddress string   `json:"IPAddress"`
+			Aliases   []string `json:"Aliases"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+type listResult struct {
+	ID string `json:"Id"`
+}
+
+// Client reads containers from the docker engine API over a unix socket
+type Client struct {
+	socket     string
+	httpClient *http.Client
+}
+
+// NewClient builds a client for the docker engine listening on socket
+// A unix:// prefix, as used in DOCKER_HOST, is accepted
+func NewClient(socket string) *Client {
+	if socket == "" {
+		socket = DefaultSocket
+	}
+
+	socket = strings.TrimPrefix(socket, "unix://")
+	dialer := new(net.Dialer)
+	return &Client{
+		socket: socket,
+		httpClient: &http.Client{
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+// ReadContainers lists the running containers along with their network details
+func (client *Client) ReadContainers() ([]Container, error) {
+	var list []listResult
+	err := client.get("/containers/json", &list)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]Container, 0, len(list))
+	for _, listed := range list {
+		var result inspectResult
+		err = client.get(fmt.Sprintf("/containers/%s/json", url.PathEscape(listed.ID)), &result)
+		if requestErr, ok := err.(*requestError); ok && requestErr.status == http.StatusNotFound {
+			// The container was removed after it was listed
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		containers = append(containers, result.container())
+	}
+
+	sortContainers(containers)
+	return containers, nil
+}
+
+func (client *Client) get(path string, result interface{}) error {
+	response, err := client.httpClient.Get("http://docker" + path)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var apiError struct {
+			Message string `json:"message"`
+		}
+
+		if json.Unmarshal(body, &apiError) != nil || apiError.Message == "" {
+			apiError.Message = strings.TrimSpace(string(body))
+		}
+
+		return &requestError{path: path, status: response.StatusCode, message: apiError.Message}
+	}
+
+	return json.Unmarshal(body, result)
+}
+
+// requestError is a response from the docker engine that was not a success
+type requestError struct {
+	path    string
+	status  int
+	message string
+}
+
+func (err *requestError) Error() string {
+	return fmt.Sprintf("Docker request %s failed with status %d: %s", err.path, err.status, err.message)
+}
+
+// ParseInspect reads the output of 'docker inspect'
+func ParseInspect(inspectJSON []byte) ([]Container, error) {
+	var results []inspectResult
+	err := json.Unmarshal(inspectJSON, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]Container, 0, len(results))
+	for _, result := range results {
+		containers = append(containers, result.container())
+	}
+
+	sortContainers(containers)
+	return containers, nil
+}
+
+// ReadInspectFile reads a file containing the output of 'docker inspect'
+func ReadInspectFile(fileName string) ([]Container, error) {
+	inspectJSON, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseInspect(inspectJSON)
+}
+
+func (result inspectResult) container() Container {
+	container := Container{
+		ID:      result.ID,
+		Name:    strings.TrimPrefix(result.Name, "/"),
+		Image:   result.Config.Image,
+		Project: result.Config.Labels["com.docker.compose.project"],
+		Service: result.Config.Labels["com.docker.compose.service"],
+	}
+
+	for name, network := range result.NetworkSettings.Networks {
+		container.Networks = append(container.Networks, Network{Name: name, IP: network.IPAddress, Aliases: network.Aliases})
+	}
+
+	sort.Slice(container.Networks, func(i, j int) bool {
+		return container.Networks[i].Name < container.Networks[j].Name
+	})
+
+	return container
+}
+
+func sortContainers(containers []Container) {
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].Name < containers[j].Name
+	})
+}
+
+// Addresses lists the container name and every network alias on each network the container has an IP on
+// Aliases docker generates from the container ID are skipped
+// When network is not empty only that network is used
+func (container Container) Addresses(network string) []Address {
+	addresses := []Address{}
+	for _, containerNetwork := range container.Networks {
+		if containerNetwork.IP == "" || (network != "" && containerNetwork.Name != network) {
+			continue
+		}
+
+		names := []string{container.Name}
+		for _, alias := range containerNetwork.Aliases {
+			if alias == "" || alias == container.Name || strings.HasPrefix(container.ID, alias) || contains(names, alias) {
+				continue
+			}
+
+			names = append(names, alias)
+		}
+
+		for _, name := range names {
+			addresses = append(addresses, Address{
+				Name:      name,
+				Container: container.Name,
+				ID:        container.ID,
+				Image:     container.Image,
+				Project:   container.Project,
+				Service:   container.Service,
+				Network:   containerNetwork.Name,
+				IP:        containerNetwork.IP,
+			})
+		}
+	}
+
+	return addresses
+}
+
+func contains(haystack []string, needle string) bool {
+	for _, value := range haystack {
+		if value == needle {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,224 @@
+package dockerUtil
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testWebContainer = `{
+  "Id": "3f4e8a1c9b2d",
+  "Name": "/stack_web_1",
+  "Config": {
+    "Image": "nginx",
+    "Labels": {"com.docker.compose.project": "stack", "com.docker.compose.service": "web"}
+  },
+  "NetworkSettings": {
+    "Networks": {
+      "stack_default": {"IPAddress": "172.18.0.3", "Aliases": ["web", "3f4e8a1c9b2d", "3f4e8a1c", "stack_web_1"]},
+      "stack_backend": {"IPAddress": "172.19.0.3", "Aliases": ["frontend"]}
+    }
+  }
+}`
+
+const testDbContainer = `{
+  "Id": "9a8b7c6d5e4f",
+  "Name": "/db",
+  "Config": {"Image": "postgres", "Labels": {}},
+  "NetworkSettings": {
+    "Networks": {
+      "stack_backend": {"IPAddress": "172.19.0.2", "Aliases": null},
+      "none": {"IPAddress": "", "Aliases": null}
+    }
+  }
+}`
+
+const testInspect = "[" + testWebContainer + "," + testDbContainer + "]"
+
+func expectedContainers() []Container {
+	return []Container{
+		{
+			ID:    "9a8b7c6d5e4f",
+			Name:  "db",
+			Image: "postgres",
+			Networks: []Network{
+				{Name: "none"},
+				{Name: "stack_backend", IP: "172.19.0.2"},
+			},
+		},
+		{
+			ID:      "3f4e8a1c9b2d",
+			Name:    "stack_web_1",
+			Image:   "nginx",
+			Project: "stack",
+			Service: "web",
+			Networks: []Network{
+				{Name: "stack_backend", IP: "172.19.0.3", Aliases: []string{"frontend"}},
+				{Name: "stack_default", IP: "172.18.0.3", Aliases: []string{"web", "3f4e8a1c9b2d", "3f4e8a1c", "stack_web_1"}},
+			},
+		},
+	}
+}
+
+// startFakeDockerServer serves handler on a unix socket and returns the socket path
+func startFakeDockerServer(t *testing.T, handler http.Handler) (string, func()) {
+	socketFile, err := ioutil.TempFile("/tmp", "docker.sock")
+	assert.Nil(t, err)
+	assert.Nil(t, os.Remove(socketFile.Name()))
+
+	listener, err := net.Listen("unix", socketFile.Name())
+	assert.Nil(t, err)
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Listener = listener
+	server.Start()
+
+	return socketFile.Name(), server.Close
+}
+
+func fakeDockerHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"Id": "3f4e8a1c9b2d"}, {"Id": "9a8b7c6d5e4f"}]`))
+	})
+	mux.HandleFunc("/containers/3f4e8a1c9b2d/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testWebContainer))
+	})
+	mux.HandleFunc("/containers/9a8b7c6d5e4f/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testDbContainer))
+	})
+	return mux
+}
+
+func TestReadContainers(t *testing.T) {
+	socket, closeServer := startFakeDockerServer(t, fakeDockerHandler())
+	defer closeServer()
+
+	containers, err := NewClient("unix://" + socket).ReadContainers()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedContainers(), containers)
+}
+
+func TestReadContainersAPIError(t *testing.T) {
+	socket, closeServer := startFakeDockerServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"message": "engine is sad"}`))
+	}))
+	defer closeServer()
+
+	_, err := NewClient(socket).ReadContainers()
+	assert.EqualError(t, err, "Docker request /containers/json failed with status 500: engine is sad")
+}
+
+func TestReadContainersInspectError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"Id": "broken"}]`))
+	})
+	mux.HandleFunc("/containers/broken/json", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "engine is sad", http.StatusInternalServerError)
+	})
+	socket, closeServer := startFakeDockerServer(t, mux)
+	defer closeServer()
+
+	_, err := NewClient(socket).ReadContainers()
+	assert.EqualError(t, err, "Docker request /containers/broken/json failed with status 500: engine is sad")
+}
+
+func TestReadContainersRemovedBeforeInspect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"Id": "3f4e8a1c9b2d"}, {"Id": "gone"}, {"Id": "9a8b7c6d5e4f"}]`))
+	})
+	mux.HandleFunc("/containers/3f4e8a1c9b2d/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testWebContainer))
+	})
+	mux.HandleFunc("/containers/gone/json", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "No such container: gone"}`, http.StatusNotFound)
+	})
+	mux.HandleFunc("/containers/9a8b7c6d5e4f/json", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testDbContainer))
+	})
+	socket, closeServer := startFakeDockerServer(t, mux)
+	defer closeServer()
+
+	containers, err := NewClient(socket).ReadContainers()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedContainers(), containers)
+}
+
+func TestReadContainersMissingSocket(t *testing.T) {
+	_, err := NewClient("/tmp/doesntexist.sock").ReadContainers()
+	assert.Contains(t, err.Error(), "dial unix /tmp/doesntexist.sock: connect: no such file or directory")
+}
+
+func TestParseInspect(t *testing.T) {
+	containers, err := ParseInspect([]byte(testInspect))
+	assert.Nil(t, err)
+	assert.Equal(t, expectedContainers(), containers)
+}
+
+func TestParseInspectInvalid(t *testing.T) {
+	_, err := ParseInspect([]byte("{"))
+	assert.EqualError(t, err, "unexpected end of JSON input")
+}
+
+func TestReadInspectFile(t *testing.T) {
+	inspectFile, err := ioutil.TempFile("/tmp", "inspect")
+	assert.Nil(t, err)
+	defer func() {
+		assert.Nil(t, os.Remove(inspectFile.Name()))
+	}()
+	assert.Nil(t, ioutil.WriteFile(inspectFile.Name(), []byte(testInspect), 0644))
+
+	containers, err := ReadInspectFile(inspectFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expectedContainers(), containers)
+}
+
+func TestReadInspectFileMissingFile(t *testing.T) {
+	_, err := ReadInspectFile("/tmp/doesntexist.json")
+	assert.EqualError(t, err, "open /tmp/doesntexist.json: no such file or directory")
+}
+
+func TestAddresses(t *testing.T) {
+	web := expectedContainers()[1]
+	names := []string{}
+	for _, address := range web.Addresses("") {
+		names = append(names, address.Network+" "+address.Name+" "+address.IP)
+	}
+
+	assert.Equal(
+		t,
+		[]string{
+			"stack_backend stack_web_1 172.19.0.3",
+			"stack_backend frontend 172.19.0.3",
+			"stack_default stack_web_1 172.18.0.3",
+			"stack_default web 172.18.0.3",
+		},
+		names,
+	)
+
+	addresses := web.Addresses("stack_default")
+	assert.Equal(
+		t,
+		Address{
+			Name:      "web",
+			Container: "stack_web_1",
+			ID:        "3f4e8a1c9b2d",
+			Image:     "nginx",
+			Project:   "stack",
+			Service:   "web",
+			Network:   "stack_default",
+			IP:        "172.18.0.3",
+		},
+		addresses[1],
+	)
+	assert.Equal(t, 2, len(addresses))
+	assert.Equal(t, []Address{}, web.Addresses("missing"))
+}
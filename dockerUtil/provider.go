@@ -0,0 +1,85 @@
+package dockerUtil
+
+import (
+	"io"
+	"text/template"
+
+	"github.com/guywithnose/hostBuilder/provider"
+)
+
+// Provider discovers running containers and their network aliases
+type Provider struct{}
+
+// Name is the name sources use to refer to the provider
+func (Provider) Name() string {
+	return "docker"
+}
+
+// Schema lists the settings the provider accepts
+func (Provider) Schema() []provider.Setting {
+	return []provider.Setting{
+		{
+			Name:    "socket",
+			Alias:   "s",
+			Usage:   "The docker engine socket to read containers from",
+			Default: DefaultSocket,
+			EnvVar:  "HOST_BUILDER_DOCKER_SOCKET",
+			File:    true,
+		},
+		{Name: "inspect", Alias: "i", Usage: "Read containers from 'docker inspect' output in this file instead of the socket", File: true},
+		{Name: "network", Alias: "n", Usage: "Only import addresses on this docker network"},
+		{Name: "template", Alias: "t", Usage: "The template to use for naming container ips", Default: "{{.Name}}", EnvVar: "HOST_BUILDER_DOCKER_TEMPLATE"},
+	}
+}
+
+// Discover finds the addresses of the running containers
+func (Provider) Discover(settings map[string]string, _ io.Writer) ([]provider.Address, error) {
+	templ, err := template.New("").Parse(settings["template"])
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []Container
+	if settings["inspect"] != "" {
+		containers, err = ReadInspectFile(settings["inspect"])
+	} else {
+		containers, err = NewClient(settings["socket"]).ReadContainers()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NamedAddresses(containers, settings["network"], templ)
+}
+
+// Complete suggests values for a setting
+func (Provider) Complete(string, map[string]string) []string {
+	return nil
+}
+
+// NamedAddresses names every address of the containers as a global IP
+// When network is not empty only that network is used
+func NamedAddresses(containers []Container, network string, templ *template.Template) ([]provider.Address, error) {
+	addresses := []provider.Address{}
+	for _, container := range containers {
+		for _, containerAddress := range container.Addresses(network) {
+			name, err := provider.ExecuteTemplate(templ, containerAddress)
+			if err != nil {
+				return nil, err
+			}
+
+			addresses = append(addresses, provider.Address{
+				Name: name,
+				IP:   containerAddress.IP,
+				Metadata: map[string]string{
+					"container":               containerAddress.Container,
+					"network":                 containerAddress.Network,
+					provider.ResourceMetadata: containerAddress.ID,
+				},
+			})
+		}
+	}
+
+	return addresses, nil
+}
@@ -12,6 +12,11 @@ import (
 
 // OutputHostLines writes the hostLines array to a file
 func OutputHostLines(outputFile string, configData *config.HostsConfig, oneLinePerIP bool) error {
+	return ioutil.WriteFile(outputFile, []byte(FormatHostLines(configData, oneLinePerIP)), 0644)
+}
+
+// FormatHostLines builds the contents of the hosts file
+func FormatHostLines(configData *config.HostsConfig, oneLinePerIP bool) string {
 	hostLines := buildHostLines(configData)
 	output := ""
 	ips := make([]string, 0, len(hostLines))
@@ -34,7 +39,7 @@ func OutputHostLines(outputFile string, configData *config.HostsConfig, oneLineP
 		}
 	}
 
-	return ioutil.WriteFile(outputFile, []byte(output), 0644)
+	return output
 }
 
 func buildHostLines(configData *config.HostsConfig) map[string][]string {
@@ -51,13 +56,8 @@ func buildHostLines(configData *config.HostsConfig) map[string][]string {
 		hostLines["ff02::2"] = []string{"ip6-allrouters"}
 	}
 
-	for hostName, data := range configData.Hosts {
-		ip, ok := data.Options[data.Current]
-		if !ok {
-			ip, ok = configData.GlobalIPs[data.Current]
-		}
-
-		if ok {
+	for hostName := range configData.Hosts {
+		if ip, ok := CurrentIP(configData, hostName); ok {
 			if existingHosts, ok := hostLines[ip]; ok {
 				hostLines[ip] = append(existingHosts, hostName)
 			} else {
@@ -69,8 +69,86 @@ func buildHostLines(configData *config.HostsConfig) map[string][]string {
 	return hostLines
 }
 
+// CurrentIP returns the IP that a hostname is currently set to
+func CurrentIP(configData *config.HostsConfig, hostName string) (string, bool) {
+	host, exists := configData.Hosts[hostName]
+	if !exists {
+		return "", false
+	}
+
+	ip, ok := host.Options[host.Current]
+	if !ok {
+		ip, ok = configData.GlobalIPs[host.Current]
+	}
+
+	return ip, ok
+}
+
+// ChangedHostnames lists the hostnames whose IPs differ between two hosts files
+func ChangedHostnames(before, after string) []string {
+	beforeIPs, afterIPs := HostIPs(before), HostIPs(after)
+	changed := []string{}
+	for hostname, IPs := range afterIPs {
+		if strings.Join(IPs, " ") != strings.Join(beforeIPs[hostname], " ") {
+			changed = append(changed, hostname)
+		}
+	}
+
+	for hostname := range beforeIPs {
+		if _, exists := afterIPs[hostname]; !exists {
+			changed = append(changed, hostname)
+		}
+	}
+
+	sort.Strings(changed)
+	return changed
+}
+
+// HostIPs maps each hostname in a hosts file to its IPs
+func HostIPs(hostsData string) map[string][]string {
+	hostIPs := map[string][]string{}
+	for _, line := range strings.Split(hostsData, "\n") {
+		ip, hostnames, _ := parseHostLine(line)
+		for _, hostname := range hostnames {
+			hostIPs[hostname] = append(hostIPs[hostname], ip)
+		}
+	}
+
+	for _, IPs := range hostIPs {
+		sort.Strings(IPs)
+	}
+
+	return hostIPs
+}
+
+// Entry is a single hostname to IP mapping read from a hosts file
+type Entry struct {
+	IP       string
+	Hostname string
+	Comment  string
+}
+
 // ReadHostsFile reads a hosts file and returns the parsed hostnames and ips
 func ReadHostsFile(fileName string) (map[string][]string, error) {
+	entries, err := ReadHostsFileEntries(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make(map[string][]string)
+	for _, entry := range entries {
+		if _, exists := hosts[entry.Hostname]; exists {
+			hosts[entry.Hostname] = append(hosts[entry.Hostname], entry.IP)
+		} else {
+			hosts[entry.Hostname] = []string{entry.IP}
+		}
+	}
+
+	return hosts, nil
+}
+
+// ReadHostsFileEntries reads a hosts file and returns its entries in file order along with their trailing comments
+func ReadHostsFileEntries(fileName string) ([]Entry, error) {
 	hostsData, err := ioutil.ReadFile(fileName)
 	if err != nil {
 		return nil, err
@@ -78,36 +156,36 @@ func ReadHostsFile(fileName string) (map[string][]string, error) {
 
 	hostsLines := strings.Split(string(hostsData), "\n")
 
-	hosts := make(map[string][]string)
+	entries := make([]Entry, 0, len(hostsLines))
 	for _, line := range hostsLines {
-		ip, hostnames := parseHostLine(line)
+		ip, hostnames, comment := parseHostLine(line)
 		for _, hostname := range hostnames {
-			if _, exists := hosts[hostname]; exists {
-				hosts[hostname] = append(hosts[hostname], ip)
-			} else {
-				hosts[hostname] = []string{ip}
-			}
+			entries = append(entries, Entry{IP: ip, Hostname: hostname, Comment: comment})
 		}
 	}
 
-	return hosts, nil
+	return entries, nil
 }
 
-func parseHostLine(line string) (string, []string) {
+func parseHostLine(line string) (string, []string, string) {
 	// Clear out any comments
 	line = strings.Replace(line, "\t", " ", -1)
 	line = strings.TrimSpace(line)
 	fullLineCommentRegex := regexp.MustCompile("^#")
 	line = fullLineCommentRegex.ReplaceAllString(line, "")
-	commentRegex := regexp.MustCompile("#.*")
-	line = commentRegex.ReplaceAllString(line, "")
+	comment := ""
+	if commentStart := strings.Index(line, "#"); commentStart != -1 {
+		comment = strings.TrimSpace(line[commentStart+1:])
+		line = line[:commentStart]
+	}
+
 	line = strings.TrimSpace(line)
 	parts := strings.Split(line, " ")
 	if len(parts) >= 2 {
 		hostsnames := make([]string, 0, len(parts)-1)
 		IP := strings.TrimSpace(parts[0])
 		if net.ParseIP(IP) == nil {
-			return "", nil
+			return "", nil, ""
 		}
 
 		hostsToParse := parts[1:]
@@ -120,8 +198,8 @@ func parseHostLine(line string) (string, []string) {
 			hostsnames = append(hostsnames, hostname)
 		}
 
-		return IP, hostsnames
+		return IP, hostsnames, comment
 	}
 
-	return "", nil
+	return "", nil, ""
 }
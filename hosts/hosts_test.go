@@ -79,6 +79,67 @@ func TestReadHostsFileInvalidHostsFile(t *testing.T) {
 	assert.EqualError(t, err, "open /doesntexist: no such file or directory")
 }
 
+func TestReadHostsFileEntries(t *testing.T) {
+	hostsFile, err := ioutil.TempFile("/tmp", "hosts")
+	assert.Nil(t, err)
+	defer removeFile(t, hostsFile.Name())
+	hostsLines := `10.0.0.2 bing foo.bar #dev
+10.0.0.3	foo.bar # staging server
+ # 10.0.0.4 foo.bar #awsEast
+10.0.0.256 notip #bad
+# just a comment
+`
+	err = ioutil.WriteFile(hostsFile.Name(), []byte(hostsLines), 0644)
+	assert.Nil(t, err)
+
+	entries, err := ReadHostsFileEntries(hostsFile.Name())
+	assert.Nil(t, err)
+
+	expectedEntries := []Entry{
+		{IP: "10.0.0.2", Hostname: "bing", Comment: "dev"},
+		{IP: "10.0.0.2", Hostname: "foo.bar", Comment: "dev"},
+		{IP: "10.0.0.3", Hostname: "foo.bar", Comment: "staging server"},
+		{IP: "10.0.0.4", Hostname: "foo.bar", Comment: "awsEast"},
+	}
+	assert.Equal(t, expectedEntries, entries)
+}
+
+func TestReadHostsFileEntriesInvalidHostsFile(t *testing.T) {
+	_, err := ReadHostsFileEntries("/doesntexist")
+	assert.EqualError(t, err, "open /doesntexist: no such file or directory")
+}
+
+func TestCurrentIP(t *testing.T) {
+	configData := getTestingConfig()
+	configData.Hosts["ignored"] = config.Host{Current: "ignore"}
+
+	IP, ok := CurrentIP(configData, "foo.bar")
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.2", IP)
+
+	IP, ok = CurrentIP(configData, "bam")
+	assert.True(t, ok)
+	assert.Equal(t, "123.12.34.56", IP)
+
+	_, ok = CurrentIP(configData, "ignored")
+	assert.False(t, ok)
+
+	_, ok = CurrentIP(configData, "missing")
+	assert.False(t, ok)
+}
+
+func TestChangedHostnames(t *testing.T) {
+	before := "127.0.0.1 localhost\n10.0.0.1 foo.bar\n10.0.0.2 bam gone\n"
+	after := "127.0.0.1 localhost\n10.0.0.3 foo.bar\n10.0.0.2 bam\n10.0.0.4 new\n"
+	assert.Equal(t, []string{"foo.bar", "gone", "new"}, ChangedHostnames(before, after))
+	assert.Equal(t, []string{}, ChangedHostnames(after, after))
+}
+
+func TestHostIPs(t *testing.T) {
+	hostsData := "# comment\n127.0.0.1 localhost\n10.0.0.2 foo.bar\n::1 localhost\n"
+	assert.Equal(t, map[string][]string{"localhost": {"127.0.0.1", "::1"}, "foo.bar": {"10.0.0.2"}}, HostIPs(hostsData))
+}
+
 func getTestingConfig() *config.HostsConfig {
 	return &config.HostsConfig{
 		LocalHostnames: []string{"foo", "bar"},
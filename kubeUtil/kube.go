@@ -0,0 +1,273 @@
+package kubeUtil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Service address kinds, in the order they are preferred
+const (
+	LoadBalancer = "LoadBalancer"
+	ExternalIP   = "ExternalIP"
+	ClusterIP    = "ClusterIP"
+)
+
+// AddressKinds lists every service address kind in the order they are preferred
+var AddressKinds = []string{LoadBalancer, ExternalIP, ClusterIP}
+
+// Service is a kubernetes service and the addresses it can be reached at
+type Service struct {
+	Name         string
+	Namespace    string
+	Type         string
+	ClusterIP    string
+	ExternalIPs  []string
+	LoadBalancer []string
+}
+
+// Ingress is a kubernetes ingress, the hostnames it serves and the addresses it is exposed on
+type Ingress struct {
+	Name      string
+	Namespace string
+	Hosts     []string
+	Addresses []string
+}
+
+type object struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Type        string   `json:"type"`
+		ClusterIP   string   `json:"clusterIP"`
+		ExternalIPs []string `json:"externalIPs"`
+		Rules       []struct {
+			Host string `json:"host"`
+		} `json:"rules"`
+	} `json:"spec"`
+	Status struct {
+		LoadBalancer struct {
+			Ingress []struct {
+				IP       string `json:"ip"`
+				Hostname string `json:"hostname"`
+			} `json:"ingress"`
+		} `json:"loadBalancer"`
+	} `json:"status"`
+}
+
+type objectList struct {
+	Kind  string   `json:"kind"`
+	Items []object `json:"items"`
+}
+
+// Client reads services and ingresses from a kubernetes API server
+type Client struct {
+	Context    string
+	server     string
+	token      string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+// Services lists the services in namespace, or in every namespace when namespace is empty
+func (client *Client) Services(namespace string) ([]Service, error) {
+	list, err := client.list("/api/v1", "services", namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	services, _ := list.split("Service")
+	return services, nil
+}
+
+// Ingresses lists the ingresses in namespace, or in every namespace when namespace is empty
+func (client *Client) Ingresses(namespace string) ([]Ingress, error) {
+	list, err := client.list("/apis/networking.k8s.io/v1", "ingresses", namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	_, ingresses := list.split("Ingress")
+	return ingresses, nil
+}
+
+func (client *Client) list(apiPath, resource, namespace string) (*objectList, error) {
+	path := fmt.Sprintf("%s/%s", apiPath, resource)
+	if namespace != "" {
+		path = fmt.Sprintf("%s/namespaces/%s/%s", apiPath, url.PathEscape(namespace), resource)
+	}
+
+	request, err := http.NewRequest(http.MethodGet, client.server+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.token != "" {
+		request.Header.Set("Authorization", "Bearer "+client.token)
+	} else if client.username != "" {
+		request.SetBasicAuth(client.username, client.password)
+	}
+
+	response, err := client.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var status struct {
+			Message string `json:"message"`
+		}
+
+		if json.Unmarshal(body, &status) != nil || status.Message == "" {
+			status.Message = strings.TrimSpace(string(body))
+		}
+
+		return nil, fmt.Errorf("Kubernetes request %s failed with status %d: %s", path, response.StatusCode, status.Message)
+	}
+
+	list := new(objectList)
+	err = json.Unmarshal(body, list)
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// ParseList reads the output of 'kubectl get services,ingresses -o json'
+// A single object or a list of either kind is accepted, other kinds are ignored
+func ParseList(listJSON []byte) ([]Service, []Ingress, error) {
+	list := new(objectList)
+	err := json.Unmarshal(listJSON, list)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !strings.HasSuffix(list.Kind, "List") {
+		single := new(object)
+		err = json.Unmarshal(listJSON, single)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		list.Items = []object{*single}
+	}
+
+	services, ingresses := list.split(strings.TrimSuffix(list.Kind, "List"))
+	return services, ingresses, nil
+}
+
+// ReadListFile reads a file containing the output of 'kubectl get services,ingresses -o json'
+func ReadListFile(fileName string) ([]Service, []Ingress, error) {
+	listJSON, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ParseList(listJSON)
+}
+
+// split separates the services and ingresses in the list
+// Items from typed lists have no kind of their own, so defaultKind is used for them
+func (list *objectList) split(defaultKind string) ([]Service, []Ingress) {
+	services := []Service{}
+	ingresses := []Ingress{}
+	for _, item := range list.Items {
+		kind := item.Kind
+		if kind == "" {
+			kind = defaultKind
+		}
+
+		loadBalancer := []string{}
+		for _, ingress := range item.Status.LoadBalancer.Ingress {
+			if ingress.IP != "" {
+				loadBalancer = append(loadBalancer, ingress.IP)
+			} else if ingress.Hostname != "" {
+				loadBalancer = append(loadBalancer, ingress.Hostname)
+			}
+		}
+
+		switch kind {
+		case "Service":
+			services = append(services, Service{
+				Name:         item.Metadata.Name,
+				Namespace:    item.Metadata.Namespace,
+				Type:         item.Spec.Type,
+				ClusterIP:    item.Spec.ClusterIP,
+				ExternalIPs:  item.Spec.ExternalIPs,
+				LoadBalancer: loadBalancer,
+			})
+		case "Ingress":
+			hosts := []string{}
+			for _, rule := range item.Spec.Rules {
+				if rule.Host != "" && !strings.HasPrefix(rule.Host, "*") {
+					hosts = append(hosts, rule.Host)
+				}
+			}
+
+			ingresses = append(ingresses, Ingress{
+				Name:      item.Metadata.Name,
+				Namespace: item.Metadata.Namespace,
+				Hosts:     hosts,
+				Addresses: loadBalancer,
+			})
+		}
+	}
+
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Namespace+"/"+services[i].Name < services[j].Namespace+"/"+services[j].Name
+	})
+	sort.Slice(ingresses, func(i, j int) bool {
+		return ingresses[i].Namespace+"/"+ingresses[i].Name < ingresses[j].Namespace+"/"+ingresses[j].Name
+	})
+
+	return services, ingresses
+}
+
+// Address picks the most preferred address of one of the given kinds
+// Headless services have no cluster IP and services of none of the kinds have no address
+func (service Service) Address(kinds []string) (string, string, bool) {
+	for _, preferred := range AddressKinds {
+		if !containsKind(kinds, preferred) {
+			continue
+		}
+
+		switch {
+		case preferred == LoadBalancer && len(service.LoadBalancer) > 0:
+			return preferred, service.LoadBalancer[0], true
+		case preferred == ExternalIP && len(service.ExternalIPs) > 0:
+			return preferred, service.ExternalIPs[0], true
+		case preferred == ClusterIP && service.ClusterIP != "" && service.ClusterIP != "None":
+			return preferred, service.ClusterIP, true
+		}
+	}
+
+	return "", "", false
+}
+
+func containsKind(kinds []string, kind string) bool {
+	for _, value := range kinds {
+		if strings.EqualFold(value, kind) {
+			return true
+		}
+	}
+
+	return false
+}
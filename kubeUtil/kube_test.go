@@ -0,0 +1,347 @@
+package kubeUtil
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testServiceList = `{
+  "kind": "ServiceList",
+  "items": [
+    {
+      "metadata": {"name": "web", "namespace": "shop"},
+      "spec": {"type": "LoadBalancer", "clusterIP": "10.96.0.10"},
+      "status": {"loadBalancer": {"ingress": [{"ip": "34.1.1.1"}]}}
+    },
+    {
+      "metadata": {"name": "db", "namespace": "shop"},
+      "spec": {"type": "ClusterIP", "clusterIP": "None"}
+    },
+    {
+      "metadata": {"name": "api", "namespace": "default"},
+      "spec": {"type": "ClusterIP", "clusterIP": "10.96.0.20", "externalIPs": ["192.168.1.20"]}
+    }
+  ]
+}`
+
+const testIngressList = `{
+  "kind": "IngressList",
+  "items": [
+    {
+      "metadata": {"name": "shop", "namespace": "shop"},
+      "spec": {"rules": [{"host": "shop.example.com"}, {"host": "*.shop.example.com"}, {}]},
+      "status": {"loadBalancer": {"ingress": [{"hostname": "lb.example.com"}]}}
+    }
+  ]
+}`
+
+func expectedServices() []Service {
+	return []Service{
+		{Name: "api", Namespace: "default", Type: "ClusterIP", ClusterIP: "10.96.0.20", ExternalIPs: []string{"192.168.1.20"}, LoadBalancer: []string{}},
+		{Name: "db", Namespace: "shop", Type: "ClusterIP", ClusterIP: "None", LoadBalancer: []string{}},
+		{Name: "web", Namespace: "shop", Type: "LoadBalancer", ClusterIP: "10.96.0.10", LoadBalancer: []string{"34.1.1.1"}},
+	}
+}
+
+func expectedIngresses() []Ingress {
+	return []Ingress{{Name: "shop", Namespace: "shop", Hosts: []string{"shop.example.com"}, Addresses: []string{"lb.example.com"}}}
+}
+
+// startFakeAPIServer starts a TLS API server and writes a kubeconfig that trusts it
+func startFakeAPIServer(t *testing.T) (*httptest.Server, string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/services", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testServiceList))
+	})
+	mux.HandleFunc("/api/v1/namespaces/shop/services", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"kind": "ServiceList", "items": []}`))
+	})
+	mux.HandleFunc("/apis/networking.k8s.io/v1/ingresses", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(testIngressList))
+	})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"kind": "Status", "message": "Unauthorized"}`))
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	}))
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev-cluster
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+users:
+- name: dev-user
+  user:
+    token: secret
+- name: bad-user
+  user:
+    token: wrong
+- name: exec-user
+  user:
+    exec:
+      command: aws
+contexts:
+- name: dev
+  context:
+    cluster: dev-cluster
+    user: dev-user
+- name: bad
+  context:
+    cluster: dev-cluster
+    user: bad-user
+- name: exec
+  context:
+    cluster: dev-cluster
+    user: exec-user
+- name: orphan
+  context:
+    cluster: missing
+`, server.URL, base64.StdEncoding.EncodeToString(caPEM))
+
+	kubeconfigFile, err := ioutil.TempFile("/tmp", "kubeconfig")
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(kubeconfigFile.Name(), []byte(kubeconfig), 0600))
+
+	return server, kubeconfigFile.Name()
+}
+
+func TestClientServices(t *testing.T) {
+	server, kubeconfigFile := startFakeAPIServer(t)
+	defer server.Close()
+	defer func() {
+		assert.Nil(t, os.Remove(kubeconfigFile))
+	}()
+
+	config, err := LoadConfig(kubeconfigFile)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"dev", "bad", "exec", "orphan"}, config.ContextNames())
+
+	client, err := config.Client("")
+	assert.Nil(t, err)
+	assert.Equal(t, "dev", client.Context)
+
+	services, err := client.Services("")
+	assert.Nil(t, err)
+	assert.Equal(t, expectedServices(), services)
+
+	services, err = client.Services("shop")
+	assert.Nil(t, err)
+	assert.Equal(t, []Service{}, services)
+
+	ingresses, err := client.Ingresses("")
+	assert.Nil(t, err)
+	assert.Equal(t, expectedIngresses(), ingresses)
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	server, kubeconfigFile := startFakeAPIServer(t)
+	defer server.Close()
+	defer func() {
+		assert.Nil(t, os.Remove(kubeconfigFile))
+	}()
+
+	config, err := LoadConfig(kubeconfigFile)
+	assert.Nil(t, err)
+
+	client, err := config.Client("bad")
+	assert.Nil(t, err)
+
+	_, err = client.Services("")
+	assert.EqualError(t, err, "Kubernetes request /api/v1/services failed with status 401: Unauthorized")
+}
+
+func TestClientErrors(t *testing.T) {
+	server, kubeconfigFile := startFakeAPIServer(t)
+	defer server.Close()
+	defer func() {
+		assert.Nil(t, os.Remove(kubeconfigFile))
+	}()
+
+	config, err := LoadConfig(kubeconfigFile)
+	assert.Nil(t, err)
+
+	_, err = config.Client("missing")
+	assert.EqualError(t, err, fmt.Sprintf("Context missing not found in %s", kubeconfigFile))
+
+	_, err = config.Client("orphan")
+	assert.EqualError(t, err, fmt.Sprintf("Cluster missing not found in %s", kubeconfigFile))
+
+	_, err = config.Client("exec")
+	assert.EqualError(t, err, "User exec-user uses exec or auth-provider credentials, which are not supported")
+
+	config.CurrentContext = ""
+	_, err = config.Client("")
+	assert.EqualError(t, err, fmt.Sprintf("No context given and %s has no current-context", kubeconfigFile))
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	kubeconfigFile, err := ioutil.TempFile("/tmp", "kubeconfig")
+	assert.Nil(t, err)
+	defer func() {
+		assert.Nil(t, os.Remove(kubeconfigFile.Name()))
+	}()
+
+	kubeconfig := `{
+  "current-context": "prod",
+  "clusters": [{"name": "prod", "cluster": {"server": "https://127.0.0.1:1", "certificate-authority": "ca.crt"}}],
+  "contexts": [{"name": "prod", "context": {"cluster": "prod"}}]
+}`
+	assert.Nil(t, ioutil.WriteFile(kubeconfigFile.Name(), []byte(kubeconfig), 0600))
+
+	config, err := LoadConfig(kubeconfigFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"prod"}, config.ContextNames())
+
+	_, err = config.Client("")
+	assert.EqualError(t, err, "open /tmp/ca.crt: no such file or directory")
+}
+
+func TestLoadConfigInsecureSkipTLSVerify(t *testing.T) {
+	server, kubeconfigFile := startFakeAPIServer(t)
+	defer server.Close()
+	defer func() {
+		assert.Nil(t, os.Remove(kubeconfigFile))
+	}()
+
+	jsonConfig := fmt.Sprintf(`{
+  "current-context": "dev",
+  "clusters": [{"name": "dev", "cluster": {"server": "%s", "insecure-skip-tls-verify": true}}],
+  "users": [{"name": "dev", "user": {"token": "secret"}}],
+  "contexts": [{"name": "dev", "context": {"cluster": "dev", "user": "dev"}}]
+}`, server.URL)
+	yamlConfig := fmt.Sprintf(`current-context: dev
+clusters:
+- name: dev
+  cluster:
+    server: %s
+    insecure-skip-tls-verify: true
+users:
+- name: dev
+  user:
+    token: secret
+contexts:
+- name: dev
+  context:
+    cluster: dev
+    user: dev
+`, server.URL)
+	for _, kubeconfig := range []string{jsonConfig, yamlConfig} {
+		assert.Nil(t, ioutil.WriteFile(kubeconfigFile, []byte(kubeconfig), 0600))
+
+		config, err := LoadConfig(kubeconfigFile)
+		assert.Nil(t, err)
+
+		client, err := config.Client("")
+		assert.Nil(t, err)
+
+		services, err := client.Services("")
+		assert.Nil(t, err)
+		assert.Equal(t, expectedServices(), services)
+	}
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	kubeconfigFile, err := ioutil.TempFile("/tmp", "kubeconfig")
+	assert.Nil(t, err)
+	defer func() {
+		assert.Nil(t, os.Remove(kubeconfigFile.Name()))
+	}()
+
+	kubeconfig := `# Written by kind
+apiVersion: v1
+current-context: "kind-dev"
+clusters:
+- &cluster {name: kind-dev, cluster: {server: "https://127.0.0.1:6443"}}
+contexts:
+- name: kind-dev
+  context: {cluster: kind-dev, user: "kind\x2ddev"}
+users:
+- name: kind-dev
+  user:
+    token: |-
+      secret
+`
+	assert.Nil(t, ioutil.WriteFile(kubeconfigFile.Name(), []byte(kubeconfig), 0600))
+
+	config, err := LoadConfig(kubeconfigFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, "kind-dev", config.CurrentContext)
+	assert.Equal(t, "https://127.0.0.1:6443", config.Clusters[0].Cluster.Server)
+	assert.Equal(t, "kind-dev", config.Contexts[0].Context.User)
+	assert.Equal(t, "secret", config.Users[0].User.Token)
+
+	assert.Nil(t, ioutil.WriteFile(kubeconfigFile.Name(), []byte("clusters: [\n"), 0600))
+	_, err = LoadConfig(kubeconfigFile.Name())
+	assert.NotNil(t, err)
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	_, err := LoadConfig("/tmp/doesntexist.kubeconfig")
+	assert.EqualError(t, err, "open /tmp/doesntexist.kubeconfig: no such file or directory")
+}
+
+func TestParseList(t *testing.T) {
+	mixed := `{"kind": "List", "items": [
+		{"kind": "Service", "metadata": {"name": "web", "namespace": "shop"}, "spec": {"type": "NodePort", "clusterIP": "10.96.0.10"}},
+		{"kind": "Ingress", "metadata": {"name": "shop", "namespace": "shop"}, "spec": {"rules": [{"host": "shop.example.com"}]}},
+		{"kind": "Pod", "metadata": {"name": "web-1", "namespace": "shop"}}
+	]}`
+
+	services, ingresses, err := ParseList([]byte(mixed))
+	assert.Nil(t, err)
+	assert.Equal(t, []Service{{Name: "web", Namespace: "shop", Type: "NodePort", ClusterIP: "10.96.0.10", LoadBalancer: []string{}}}, services)
+	assert.Equal(t, []Ingress{{Name: "shop", Namespace: "shop", Hosts: []string{"shop.example.com"}, Addresses: []string{}}}, ingresses)
+
+	services, _, err = ParseList([]byte(`{"kind": "Service", "metadata": {"name": "db"}, "spec": {"clusterIP": "10.96.0.30"}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []Service{{Name: "db", ClusterIP: "10.96.0.30", LoadBalancer: []string{}}}, services)
+
+	_, _, err = ParseList([]byte("{"))
+	assert.EqualError(t, err, "unexpected end of JSON input")
+}
+
+func TestReadListFile(t *testing.T) {
+	listFile, err := ioutil.TempFile("/tmp", "services")
+	assert.Nil(t, err)
+	defer func() {
+		assert.Nil(t, os.Remove(listFile.Name()))
+	}()
+	assert.Nil(t, ioutil.WriteFile(listFile.Name(), []byte(testServiceList), 0644))
+
+	services, ingresses, err := ReadListFile(listFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, expectedServices(), services)
+	assert.Equal(t, []Ingress{}, ingresses)
+}
+
+func TestServiceAddress(t *testing.T) {
+	services := expectedServices()
+	kind, address, ok := services[0].Address(AddressKinds)
+	assert.Equal(t, []interface{}{ExternalIP, "192.168.1.20", true}, []interface{}{kind, address, ok})
+
+	kind, address, ok = services[0].Address([]string{"clusterip"})
+	assert.Equal(t, []interface{}{ClusterIP, "10.96.0.20", true}, []interface{}{kind, address, ok})
+
+	_, _, ok = services[1].Address(AddressKinds)
+	assert.False(t, ok)
+
+	kind, address, ok = services[2].Address(AddressKinds)
+	assert.Equal(t, []interface{}{LoadBalancer, "34.1.1.1", true}, []interface{}{kind, address, ok})
+}
@@ -0,0 +1,235 @@
+package kubeUtil
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Config is the part of a kubeconfig file that hostBuilder reads
+type Config struct {
+	CurrentContext string         `json:"current-context" yaml:"current-context"`
+	Clusters       []namedCluster `json:"clusters" yaml:"clusters"`
+	Users          []namedUser    `json:"users" yaml:"users"`
+	Contexts       []namedContext `json:"contexts" yaml:"contexts"`
+	path           string
+}
+
+type namedCluster struct {
+	Name    string  `json:"name" yaml:"name"`
+	Cluster cluster `json:"cluster" yaml:"cluster"`
+}
+
+type cluster struct {
+	Server                   string `json:"server" yaml:"server"`
+	CertificateAuthority     string `json:"certificate-authority" yaml:"certificate-authority"`
+	CertificateAuthorityData string `json:"certificate-authority-data" yaml:"certificate-authority-data"`
+	InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify" yaml:"insecure-skip-tls-verify"`
+}
+
+type namedUser struct {
+	Name string `json:"name" yaml:"name"`
+	User user   `json:"user" yaml:"user"`
+}
+
+type user struct {
+	Token                 string      `json:"token" yaml:"token"`
+	TokenFile             string      `json:"tokenFile" yaml:"tokenFile"`
+	ClientCertificate     string      `json:"client-certificate" yaml:"client-certificate"`
+	ClientCertificateData string      `json:"client-certificate-data" yaml:"client-certificate-data"`
+	ClientKey             string      `json:"client-key" yaml:"client-key"`
+	ClientKeyData         string      `json:"client-key-data" yaml:"client-key-data"`
+	Username              string      `json:"username" yaml:"username"`
+	Password              string      `json:"password" yaml:"password"`
+	Exec                  interface{} `json:"exec" yaml:"exec"`
+	AuthProvider          interface{} `json:"auth-provider" yaml:"auth-provider"`
+}
+
+type namedContext struct {
+	Name    string  `json:"name" yaml:"name"`
+	Context context `json:"context" yaml:"context"`
+}
+
+type context struct {
+	Cluster   string `json:"cluster" yaml:"cluster"`
+	User      string `json:"user" yaml:"user"`
+	Namespace string `json:"namespace" yaml:"namespace"`
+}
+
+// DefaultConfigPath is where kubectl looks for its configuration unless KUBECONFIG is set
+func DefaultConfigPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".kube", "config")
+}
+
+// LoadConfig reads a kubeconfig file, which may be YAML or JSON
+func LoadConfig(fileName string) (*Config, error) {
+	configBytes, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var config = new(Config)
+	if bytes.HasPrefix(bytes.TrimSpace(configBytes), []byte("{")) {
+		err = json.Unmarshal(configBytes, config)
+	} else {
+		err = yaml.Unmarshal(configBytes, config)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	config.path = fileName
+	return config, nil
+}
+
+// ContextNames lists the contexts defined in the kubeconfig
+func (config *Config) ContextNames() []string {
+	names := make([]string, 0, len(config.Contexts))
+	for _, namedContext := range config.Contexts {
+		names = append(names, namedContext.Name)
+	}
+
+	return names
+}
+
+// Client builds an API client for the named context, or the current context when contextName is empty
+func (config *Config) Client(contextName string) (*Client, error) {
+	if contextName == "" {
+		contextName = config.CurrentContext
+	}
+
+	if contextName == "" {
+		return nil, fmt.Errorf("No context given and %s has no current-context", config.path)
+	}
+
+	var selected *context
+	for i := range config.Contexts {
+		if config.Contexts[i].Name == contextName {
+			selected = &config.Contexts[i].Context
+		}
+	}
+
+	if selected == nil {
+		return nil, fmt.Errorf("Context %s not found in %s", contextName, config.path)
+	}
+
+	var clusterConfig *cluster
+	for _, namedCluster := range config.Clusters {
+		if namedCluster.Name == selected.Cluster {
+			found := namedCluster.Cluster
+			clusterConfig = &found
+		}
+	}
+
+	if clusterConfig == nil {
+		return nil, fmt.Errorf("Cluster %s not found in %s", selected.Cluster, config.path)
+	}
+
+	userConfig := user{}
+	for _, namedUser := range config.Users {
+		if namedUser.Name == selected.User {
+			userConfig = namedUser.User
+		}
+	}
+
+	if userConfig.Exec != nil || userConfig.AuthProvider != nil {
+		return nil, fmt.Errorf("User %s uses exec or auth-provider credentials, which are not supported", selected.User)
+	}
+
+	clusterConfig.CertificateAuthority = config.resolvePath(clusterConfig.CertificateAuthority)
+	userConfig.ClientCertificate = config.resolvePath(userConfig.ClientCertificate)
+	userConfig.ClientKey = config.resolvePath(userConfig.ClientKey)
+	userConfig.TokenFile = config.resolvePath(userConfig.TokenFile)
+	tlsConfig, err := buildTLSConfig(*clusterConfig, userConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	token := userConfig.Token
+	if token == "" && userConfig.TokenFile != "" {
+		tokenBytes, err := ioutil.ReadFile(userConfig.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+
+		token = strings.TrimSpace(string(tokenBytes))
+	}
+
+	return &Client{
+		Context:    contextName,
+		server:     strings.TrimSuffix(clusterConfig.Server, "/"),
+		token:      token,
+		username:   userConfig.Username,
+		password:   userConfig.Password,
+		httpClient: &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}},
+	}, nil
+}
+
+// resolvePath makes paths in the kubeconfig relative to the file they were found in, as kubectl does
+func (config *Config) resolvePath(fileName string) string {
+	if fileName == "" || filepath.IsAbs(fileName) {
+		return fileName
+	}
+
+	return filepath.Join(filepath.Dir(config.path), fileName)
+}
+
+func buildTLSConfig(clusterConfig cluster, userConfig user) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: clusterConfig.InsecureSkipTLSVerify}
+	caPEM, err := readPEM(clusterConfig.CertificateAuthorityData, clusterConfig.CertificateAuthority)
+	if err != nil {
+		return nil, err
+	}
+
+	if caPEM != nil {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("Invalid certificate authority for %s", clusterConfig.Server)
+		}
+	}
+
+	certPEM, err := readPEM(userConfig.ClientCertificateData, userConfig.ClientCertificate)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := readPEM(userConfig.ClientKeyData, userConfig.ClientKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if certPEM != nil || keyPEM != nil {
+		certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+// readPEM reads base64 encoded inline data if there is any, otherwise the named file
+func readPEM(data, fileName string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+
+	if fileName != "" {
+		return ioutil.ReadFile(fileName)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,128 @@
+package kubeUtil
+
+import (
+	"io"
+	"text/template"
+
+	"github.com/guywithnose/hostBuilder/provider"
+)
+
+var sourceSettings = []provider.Setting{
+	{Name: "kubeconfig", Usage: "The path to your kubeconfig file", EnvVar: "KUBECONFIG", File: true},
+	{Name: "context", Usage: "The kubeconfig context to use instead of the current context"},
+	{Name: "namespace", Alias: "n", Usage: "Only read from this namespace instead of all namespaces"},
+	{Name: "file", Alias: "f", Usage: "Read 'kubectl get -o json' output from this file instead of the cluster", File: true},
+}
+
+// ServicesProvider discovers the addresses of kubernetes services
+type ServicesProvider struct{}
+
+// IngressesProvider discovers the hostnames served by kubernetes ingresses
+type IngressesProvider struct{}
+
+// Name is the name sources use to refer to the provider
+func (ServicesProvider) Name() string {
+	return "kubernetesServices"
+}
+
+// Schema lists the settings the provider accepts
+func (ServicesProvider) Schema() []provider.Setting {
+	return append(
+		append([]provider.Setting{}, sourceSettings...),
+		provider.Setting{Name: "types", Usage: "The comma separated service address types to use (LoadBalancer, ExternalIP, ClusterIP)"},
+		provider.Setting{Name: "template", Alias: "t", Usage: "The template to use for naming service ips", Default: "{{.Name}}.{{.Namespace}}"},
+	)
+}
+
+// Discover finds the preferred address of every service
+func (ServicesProvider) Discover(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	templ, err := template.New("").Parse(settings["template"])
+	if err != nil {
+		return nil, err
+	}
+
+	kinds, err := ParseServiceKinds(settings["types"])
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := openSettingsSource(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	services, err := source.Services(settings["namespace"])
+	if err != nil {
+		return nil, err
+	}
+
+	return source.ServiceAddresses(services, kinds, templ, warnings)
+}
+
+// Complete suggests values for a setting
+func (ServicesProvider) Complete(setting string, settings map[string]string) []string {
+	if setting == "types" {
+		return AddressKinds
+	}
+
+	return completeSourceSetting(setting, settings)
+}
+
+// Name is the name sources use to refer to the provider
+func (IngressesProvider) Name() string {
+	return "kubernetesIngresses"
+}
+
+// Schema lists the settings the provider accepts
+func (IngressesProvider) Schema() []provider.Setting {
+	return append(
+		append([]provider.Setting{}, sourceSettings...),
+		provider.Setting{Name: "template", Alias: "t", Usage: "The template to use for naming the option added to each hostname", Default: "{{.Context}}"},
+	)
+}
+
+// Discover finds the hostnames served by every ingress
+func (IngressesProvider) Discover(settings map[string]string, warnings io.Writer) ([]provider.Address, error) {
+	templ, err := template.New("").Parse(settings["template"])
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := openSettingsSource(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	ingresses, err := source.Ingresses(settings["namespace"])
+	if err != nil {
+		return nil, err
+	}
+
+	return source.IngressAddresses(ingresses, templ, warnings)
+}
+
+// Complete suggests values for a setting
+func (IngressesProvider) Complete(setting string, settings map[string]string) []string {
+	return completeSourceSetting(setting, settings)
+}
+
+func openSettingsSource(settings map[string]string) (*Source, error) {
+	if settings["file"] != "" {
+		return OpenOutputFile(settings["file"], settings["context"])
+	}
+
+	return OpenCluster(settings["kubeconfig"], settings["context"])
+}
+
+func completeSourceSetting(setting string, settings map[string]string) []string {
+	if setting != "context" {
+		return nil
+	}
+
+	config, err := LoadConfig(configPath(settings["kubeconfig"]))
+	if err != nil {
+		return nil
+	}
+
+	return config.ContextNames()
+}
@@ -0,0 +1,225 @@
+package kubeUtil
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/guywithnose/hostBuilder/provider"
+)
+
+// Source is where services and ingresses are read from, either a cluster or 'kubectl get -o json' output
+type Source struct {
+	Context   string
+	client    *Client
+	services  []Service
+	ingresses []Ingress
+}
+
+// ServiceAddress is the information available when naming a service address
+type ServiceAddress struct {
+	Name      string
+	Namespace string
+	Type      string
+	Kind      string
+	Context   string
+	Address   string
+}
+
+// IngressAddress is the information available when naming the option an ingress adds to a hostname
+type IngressAddress struct {
+	Name      string
+	Namespace string
+	Host      string
+	Context   string
+	Address   string
+}
+
+// OpenCluster connects to the cluster for a kubeconfig context, or the current context when contextName is empty
+func OpenCluster(kubeconfig, contextName string) (*Source, error) {
+	config, err := LoadConfig(configPath(kubeconfig))
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := config.Client(contextName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Source{Context: client.Context, client: client}, nil
+}
+
+// configPath uses the first file when kubeconfig is a list of files like KUBECONFIG, or the default file when it is empty
+func configPath(kubeconfig string) string {
+	kubeconfig = strings.Split(kubeconfig, string(filepath.ListSeparator))[0]
+	if kubeconfig == "" {
+		return DefaultConfigPath()
+	}
+
+	return kubeconfig
+}
+
+// OpenOutput reads 'kubectl get -o json' output, naming the context it came from contextName or kubectl
+func OpenOutput(listJSON []byte, contextName string) (*Source, error) {
+	services, ingresses, err := ParseList(listJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	if contextName == "" {
+		contextName = "kubectl"
+	}
+
+	return &Source{Context: contextName, services: services, ingresses: ingresses}, nil
+}
+
+// OpenOutputFile reads a file containing 'kubectl get -o json' output
+func OpenOutputFile(fileName, contextName string) (*Source, error) {
+	listJSON, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return OpenOutput(listJSON, contextName)
+}
+
+// Services lists the services in namespace, or in every namespace when namespace is empty
+func (source *Source) Services(namespace string) ([]Service, error) {
+	if source.client != nil {
+		return source.client.Services(namespace)
+	}
+
+	services := []Service{}
+	for _, service := range source.services {
+		if namespace == "" || service.Namespace == namespace {
+			services = append(services, service)
+		}
+	}
+
+	return services, nil
+}
+
+// Ingresses lists the ingresses in namespace, or in every namespace when namespace is empty
+func (source *Source) Ingresses(namespace string) ([]Ingress, error) {
+	if source.client != nil {
+		return source.client.Ingresses(namespace)
+	}
+
+	ingresses := []Ingress{}
+	for _, ingress := range source.ingresses {
+		if namespace == "" || ingress.Namespace == namespace {
+			ingresses = append(ingresses, ingress)
+		}
+	}
+
+	return ingresses, nil
+}
+
+// ParseServiceKinds parses a comma separated list of service address kinds, an empty list means all of them
+func ParseServiceKinds(types string) ([]string, error) {
+	if types == "" {
+		return AddressKinds, nil
+	}
+
+	kinds := strings.Split(types, ",")
+	for _, kind := range kinds {
+		if !containsKind(AddressKinds, kind) {
+			return nil, fmt.Errorf("Invalid service type %s", kind)
+		}
+	}
+
+	return kinds, nil
+}
+
+// ServiceAddresses names the preferred address of each service as a global IP
+// Addresses that can't be resolved are skipped with a warning
+func (source *Source) ServiceAddresses(services []Service, kinds []string, templ *template.Template, warnings io.Writer) ([]provider.Address, error) {
+	addresses := []provider.Address{}
+	for _, service := range services {
+		kind, address, ok := service.Address(kinds)
+		if !ok {
+			continue
+		}
+
+		IP, err := provider.ResolveAddress(address)
+		if err != nil {
+			fmt.Fprintf(warnings, "Warning: %v, skipping service %s/%s\n", err, service.Namespace, service.Name)
+			continue
+		}
+
+		name, err := provider.ExecuteTemplate(templ, ServiceAddress{
+			Name:      service.Name,
+			Namespace: service.Namespace,
+			Type:      service.Type,
+			Kind:      kind,
+			Context:   source.Context,
+			Address:   address,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, provider.Address{
+			Name: name,
+			IP:   IP,
+			Metadata: map[string]string{
+				"context":                 source.Context,
+				"namespace":               service.Namespace,
+				"service":                 service.Name,
+				"kind":                    kind,
+				provider.ResourceMetadata: fmt.Sprintf("%s/%s", service.Namespace, service.Name),
+			},
+		})
+	}
+
+	return addresses, nil
+}
+
+// IngressAddresses adds a named option to every hostname served by each ingress
+// Ingresses without a resolvable address are skipped with a warning
+func (source *Source) IngressAddresses(ingresses []Ingress, templ *template.Template, warnings io.Writer) ([]provider.Address, error) {
+	addresses := []provider.Address{}
+	for _, ingress := range ingresses {
+		if len(ingress.Addresses) == 0 {
+			fmt.Fprintf(warnings, "Warning: Ingress %s/%s has no address, skipping\n", ingress.Namespace, ingress.Name)
+			continue
+		}
+
+		IP, err := provider.ResolveAddress(ingress.Addresses[0])
+		if err != nil {
+			fmt.Fprintf(warnings, "Warning: %v, skipping ingress %s/%s\n", err, ingress.Namespace, ingress.Name)
+			continue
+		}
+
+		for _, host := range ingress.Hosts {
+			name, err := provider.ExecuteTemplate(templ, IngressAddress{
+				Name:      ingress.Name,
+				Namespace: ingress.Namespace,
+				Host:      host,
+				Context:   source.Context,
+				Address:   ingress.Addresses[0],
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			addresses = append(addresses, provider.Address{
+				Host: host,
+				Name: name,
+				IP:   IP,
+				Metadata: map[string]string{
+					"context":                 source.Context,
+					"namespace":               ingress.Namespace,
+					"ingress":                 ingress.Name,
+					provider.ResourceMetadata: fmt.Sprintf("%s/%s", ingress.Namespace, ingress.Name),
+				},
+			})
+		}
+	}
+
+	return addresses, nil
+}
@@ -0,0 +1,101 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Cache keeps the addresses providers discovered on disk, one file for each provider and settings
+type Cache struct {
+	Dir string
+}
+
+// CacheEntry is the result of one discovery and when it was fetched
+type CacheEntry struct {
+	Provider  string            `json:"provider"`
+	Settings  map[string]string `json:"settings,omitempty"`
+	FetchedAt time.Time         `json:"fetchedAt"`
+	Addresses []cachedAddress   `json:"addresses"`
+}
+
+type cachedAddress struct {
+	Host     string            `json:"host,omitempty"`
+	Name     string            `json:"name"`
+	IP       string            `json:"ip"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// NewCacheEntry records the addresses a provider discovered with the settings at fetchedAt
+func NewCacheEntry(providerName string, settings map[string]string, fetchedAt time.Time, addresses []Address) CacheEntry {
+	entry := CacheEntry{Provider: providerName, Settings: settings, FetchedAt: fetchedAt, Addresses: make([]cachedAddress, 0, len(addresses))}
+	for _, address := range addresses {
+		entry.Addresses = append(entry.Addresses, cachedAddress(address))
+	}
+
+	return entry
+}
+
+// List gives the cached addresses
+func (entry CacheEntry) List() []Address {
+	addresses := make([]Address, 0, len(entry.Addresses))
+	for _, address := range entry.Addresses {
+		addresses = append(addresses, Address(address))
+	}
+
+	return addresses
+}
+
+// Age is how long ago the entry was fetched
+func (entry CacheEntry) Age(now time.Time) time.Duration {
+	return now.Sub(entry.FetchedAt)
+}
+
+// Load reads the entry of a provider and settings, ok is false when nothing is cached for them
+func (cache Cache) Load(providerName string, settings map[string]string) (entry CacheEntry, ok bool, err error) {
+	entryJSON, err := ioutil.ReadFile(cache.fileName(providerName, settings))
+	if os.IsNotExist(err) {
+		return CacheEntry{}, false, nil
+	}
+
+	if err != nil {
+		return CacheEntry{}, false, err
+	}
+
+	err = json.Unmarshal(entryJSON, &entry)
+	if err != nil {
+		return CacheEntry{}, false, err
+	}
+
+	return entry, true, nil
+}
+
+// Store writes the entry, replacing the previous entry of its provider and settings
+// The cache directory is only readable by the user because settings can hold credentials
+func (cache Cache) Store(entry CacheEntry) error {
+	err := os.MkdirAll(cache.Dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	entryJSON, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(cache.fileName(entry.Provider, entry.Settings), entryJSON, 0600)
+}
+
+// fileName names the file of a provider and settings after a hash of both, json sorts the settings so their order doesn't matter
+func (cache Cache) fileName(providerName string, settings map[string]string) string {
+	key, _ := json.Marshal(struct {
+		Provider string            `json:"provider"`
+		Settings map[string]string `json:"settings"`
+	}{providerName, settings})
+	hash := sha256.Sum256(key)
+	return filepath.Join(cache.Dir, providerName+"-"+hex.EncodeToString(hash[:8])+".json")
+}
@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "cache")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(dir)) }()
+
+	cache := Cache{Dir: filepath.Join(dir, "hostBuilder")}
+	settings := map[string]string{"region": "us-east-1"}
+	_, ok, err := cache.Load("test", settings)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	fetchedAt := time.Date(2017, time.November, 1, 12, 0, 0, 0, time.UTC)
+	addresses := []Address{{Host: "www.example.com", Name: "lab", IP: "10.0.0.1", Metadata: map[string]string{RegionMetadata: "us-east-1"}}}
+	assert.Nil(t, cache.Store(NewCacheEntry("test", settings, fetchedAt, addresses)))
+
+	info, err := os.Stat(cache.Dir)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+
+	entry, ok, err := cache.Load("test", map[string]string{"region": "us-east-1"})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, addresses, entry.List())
+	assert.True(t, fetchedAt.Equal(entry.FetchedAt))
+	assert.Equal(t, 2*time.Hour, entry.Age(fetchedAt.Add(2*time.Hour)))
+
+	_, ok, err = cache.Load("test", map[string]string{"region": "us-west-2"})
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestCacheInvalidEntry(t *testing.T) {
+	dir, err := ioutil.TempDir("/tmp", "cache")
+	assert.Nil(t, err)
+	defer func() { assert.Nil(t, os.RemoveAll(dir)) }()
+
+	cache := Cache{Dir: dir}
+	assert.Nil(t, ioutil.WriteFile(cache.fileName("test", nil), []byte("{"), 0600))
+	_, _, err = cache.Load("test", nil)
+	assert.EqualError(t, err, "unexpected end of JSON input")
+}
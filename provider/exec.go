@@ -0,0 +1,237 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PluginPrefix is the prefix of executables on the PATH that are used as providers
+const PluginPrefix = "hostbuilder-provider-"
+
+// DefaultTimeout is how long a plugin may run when its source does not set a timeout
+const DefaultTimeout = 30 * time.Second
+
+// timeoutSetting is accepted by every plugin and consumed by hostBuilder instead of being passed on
+var timeoutSetting = Setting{Name: "timeout", Usage: "How long the plugin may run", Default: DefaultTimeout.String()}
+
+// ExecProvider is a provider implemented by an external executable
+// The executable is run with schema, discover or complete {setting} as arguments and the settings as JSON on stdin
+// Anything written to stderr by a successful run is passed on as warnings, the README describes the protocol
+type ExecProvider struct {
+	name   string
+	Path   string
+	schema []Setting
+}
+
+type pluginSchema struct {
+	Settings []struct {
+		Name    string `json:"name"`
+		Alias   string `json:"alias"`
+		Usage   string `json:"usage"`
+		Default string `json:"default"`
+		EnvVar  string `json:"envVar"`
+		File    bool   `json:"file"`
+	} `json:"settings"`
+}
+
+type pluginAddresses struct {
+	Addresses []struct {
+		Host     string            `json:"host"`
+		Name     string            `json:"name"`
+		Address  string            `json:"address"`
+		Metadata map[string]string `json:"metadata"`
+	} `json:"addresses"`
+}
+
+// NewExecProvider builds a provider that runs the executable at path
+func NewExecProvider(name, path string) *ExecProvider {
+	return &ExecProvider{name: name, Path: path}
+}
+
+// FindPlugins finds the hostbuilder-provider-* executables in a list of directories like PATH
+// The first executable found for a name wins, the same way the shell would choose
+func FindPlugins(pathList string) map[string]string {
+	plugins := map[string]string{}
+	for _, dir := range filepath.SplitList(pathList) {
+		matches, err := filepath.Glob(filepath.Join(dir, PluginPrefix+"*"))
+		if err != nil {
+			continue
+		}
+
+		for _, match := range matches {
+			name := strings.TrimPrefix(filepath.Base(match), PluginPrefix)
+			if _, exists := plugins[name]; exists || name == "" {
+				continue
+			}
+
+			info, err := os.Stat(match)
+			if err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+				continue
+			}
+
+			plugins[name] = match
+		}
+	}
+
+	return plugins
+}
+
+// WithPlugins copies the registry, adding the plugins found on pathList and the explicitly configured plugin paths
+// Plugins never replace a built in provider, and configured paths take precedence over the PATH
+func (registry *Registry) WithPlugins(pathList string, configured map[string]string) *Registry {
+	withPlugins := NewRegistry()
+	for name, provider := range registry.providers {
+		withPlugins.providers[name] = provider
+	}
+
+	for _, plugins := range []map[string]string{configured, FindPlugins(pathList)} {
+		for name, path := range plugins {
+			if _, exists := withPlugins.providers[name]; !exists {
+				withPlugins.providers[name] = NewExecProvider(name, path)
+			}
+		}
+	}
+
+	return withPlugins
+}
+
+// Name is the name sources use to refer to the provider
+func (provider *ExecProvider) Name() string {
+	return provider.name
+}
+
+// Schema asks the plugin which settings it accepts
+// A plugin that fails to describe its settings only accepts the timeout
+func (provider *ExecProvider) Schema() []Setting {
+	if provider.schema != nil {
+		return provider.schema
+	}
+
+	provider.schema = []Setting{timeoutSetting}
+	output, err := provider.run(DefaultTimeout, map[string]string{}, new(bytes.Buffer), "schema")
+	if err != nil {
+		return provider.schema
+	}
+
+	var schema pluginSchema
+	if json.Unmarshal(output, &schema) != nil {
+		return provider.schema
+	}
+
+	for _, setting := range schema.Settings {
+		if setting.Name == timeoutSetting.Name {
+			continue
+		}
+
+		provider.schema = append(provider.schema, Setting{
+			Name:    setting.Name,
+			Alias:   setting.Alias,
+			Usage:   setting.Usage,
+			Default: setting.Default,
+			EnvVar:  setting.EnvVar,
+			File:    setting.File,
+		})
+	}
+
+	return provider.schema
+}
+
+// Discover runs the plugin and reads the addresses it prints
+// Addresses that can't be resolved are skipped with a warning
+func (provider *ExecProvider) Discover(settings map[string]string, warnings io.Writer) ([]Address, error) {
+	timeout, err := time.ParseDuration(settings[timeoutSetting.Name])
+	if err != nil {
+		return nil, fmt.Errorf("Invalid timeout %s for provider %s", settings[timeoutSetting.Name], provider.name)
+	}
+
+	output, err := provider.run(timeout, settings, warnings, "discover")
+	if err != nil {
+		return nil, err
+	}
+
+	var document pluginAddresses
+	err = json.Unmarshal(output, &document)
+	if err != nil {
+		return nil, fmt.Errorf("Provider %s printed invalid output: %v", provider.name, err)
+	}
+
+	addresses := make([]Address, 0, len(document.Addresses))
+	for _, address := range document.Addresses {
+		if address.Name == "" || address.Address == "" {
+			return nil, fmt.Errorf("Provider %s printed an address without a name or address", provider.name)
+		}
+
+		IP, err := ResolveAddress(address.Address)
+		if err != nil {
+			fmt.Fprintf(warnings, "Warning: %v, skipping %s\n", err, address.Name)
+			continue
+		}
+
+		addresses = append(addresses, Address{Host: address.Host, Name: address.Name, IP: IP, Metadata: address.Metadata})
+	}
+
+	return addresses, nil
+}
+
+// Complete asks the plugin to suggest values for a setting
+func (provider *ExecProvider) Complete(setting string, settings map[string]string) []string {
+	output, err := provider.run(DefaultTimeout, settings, new(bytes.Buffer), "complete", setting)
+	if err != nil {
+		return nil
+	}
+
+	var values []string
+	if json.Unmarshal(output, &values) != nil {
+		return nil
+	}
+
+	return values
+}
+
+// run executes the plugin with the settings on stdin, returning what it printed on stdout
+func (provider *ExecProvider) run(timeout time.Duration, settings map[string]string, warnings io.Writer, args ...string) ([]byte, error) {
+	pluginSettings := make(map[string]string, len(settings))
+	for name, value := range settings {
+		if name != timeoutSetting.Name {
+			pluginSettings[name] = value
+		}
+	}
+
+	input, err := json.Marshal(pluginSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, provider.Path, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("Provider %s timed out after %s", provider.name, timeout)
+	}
+
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return nil, fmt.Errorf("Provider %s exited with status %d: %s", provider.name, exitErr.ExitCode(), strings.TrimSpace(stderr.String()))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Unable to run provider %s: %v", provider.name, err)
+	}
+
+	_, err = io.Copy(warnings, &stderr)
+	return stdout.Bytes(), err
+}
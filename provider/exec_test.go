@@ -0,0 +1,199 @@
+package provider
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPlugin = `#!/bin/sh
+case "$1" in
+schema)
+	echo '{"settings": [{"name": "zone", "usage": "The zone to read", "default": "lab"}, {"name": "timeout"}]}'
+	;;
+discover)
+	settings=$(cat)
+	echo "read $settings" >&2
+	echo '{"addresses": [{"name": "db", "address": "10.0.0.5", "metadata": {"rack": "a"}}, {"host": "www.example.com", "name": "lab", "address": "::1"}]}'
+	;;
+complete)
+	echo "[\"$2-a\", \"$2-b\"]"
+	;;
+esac
+`
+
+func writePlugin(t *testing.T, dir, name, script string) string {
+	path := filepath.Join(dir, PluginPrefix+name)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func setupPluginDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "plugins")
+	assert.Nil(t, err)
+	return dir
+}
+
+func TestExecProviderSchema(t *testing.T) {
+	dir := setupPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	provider := NewExecProvider("cmdb", writePlugin(t, dir, "cmdb", testPlugin))
+	assert.Equal(t, "cmdb", provider.Name())
+	assert.Equal(
+		t,
+		[]Setting{timeoutSetting, {Name: "zone", Usage: "The zone to read", Default: "lab"}},
+		provider.Schema(),
+	)
+}
+
+func TestExecProviderSchemaFailure(t *testing.T) {
+	provider := NewExecProvider("cmdb", "/notafile")
+	assert.Equal(t, []Setting{timeoutSetting}, provider.Schema())
+}
+
+func TestExecProviderDiscover(t *testing.T) {
+	dir := setupPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	provider := NewExecProvider("cmdb", writePlugin(t, dir, "cmdb", testPlugin))
+	settings, err := ApplyDefaults(provider, map[string]string{})
+	assert.Nil(t, err)
+
+	warnings := new(bytes.Buffer)
+	addresses, err := provider.Discover(settings, warnings)
+	assert.Nil(t, err)
+	assert.Equal(
+		t,
+		[]Address{
+			{Name: "db", IP: "10.0.0.5", Metadata: map[string]string{"rack": "a"}},
+			{Host: "www.example.com", Name: "lab", IP: "::1"},
+		},
+		addresses,
+	)
+	assert.Equal(t, "read {\"zone\":\"lab\"}\n", warnings.String())
+}
+
+func TestExecProviderDiscoverUnresolvable(t *testing.T) {
+	dir := setupPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	provider := NewExecProvider("cmdb", writePlugin(t, dir, "cmdb", `#!/bin/sh
+echo '{"addresses": [{"name": "db", "address": "notahost.invalid"}]}'
+`))
+	warnings := new(bytes.Buffer)
+	addresses, err := provider.Discover(map[string]string{"timeout": "1s"}, warnings)
+	assert.Nil(t, err)
+	assert.Equal(t, []Address{}, addresses)
+	assert.Equal(t, "Warning: Unable to resolve notahost.invalid, skipping db\n", warnings.String())
+}
+
+func TestExecProviderDiscoverExitStatus(t *testing.T) {
+	dir := setupPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	provider := NewExecProvider("cmdb", writePlugin(t, dir, "cmdb", `#!/bin/sh
+echo 'cmdb is down' >&2
+exit 3
+`))
+	_, err := provider.Discover(map[string]string{"timeout": "1s"}, new(bytes.Buffer))
+	assert.EqualError(t, err, "Provider cmdb exited with status 3: cmdb is down")
+}
+
+func TestExecProviderDiscoverTimeout(t *testing.T) {
+	dir := setupPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	provider := NewExecProvider("cmdb", writePlugin(t, dir, "cmdb", `#!/bin/sh
+exec sleep 5
+`))
+	_, err := provider.Discover(map[string]string{"timeout": "100ms"}, new(bytes.Buffer))
+	assert.EqualError(t, err, "Provider cmdb timed out after 100ms")
+}
+
+func TestExecProviderDiscoverInvalidTimeout(t *testing.T) {
+	provider := NewExecProvider("cmdb", "/notafile")
+	_, err := provider.Discover(map[string]string{"timeout": "soon"}, new(bytes.Buffer))
+	assert.EqualError(t, err, "Invalid timeout soon for provider cmdb")
+}
+
+func TestExecProviderDiscoverInvalidOutput(t *testing.T) {
+	dir := setupPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	provider := NewExecProvider("cmdb", writePlugin(t, dir, "cmdb", `#!/bin/sh
+echo 'db 10.0.0.5'
+`))
+	_, err := provider.Discover(map[string]string{"timeout": "1s"}, new(bytes.Buffer))
+	assert.EqualError(t, err, "Provider cmdb printed invalid output: invalid character 'd' looking for beginning of value")
+}
+
+func TestExecProviderDiscoverMissingAddress(t *testing.T) {
+	dir := setupPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	provider := NewExecProvider("cmdb", writePlugin(t, dir, "cmdb", `#!/bin/sh
+echo '{"addresses": [{"name": "db"}]}'
+`))
+	_, err := provider.Discover(map[string]string{"timeout": "1s"}, new(bytes.Buffer))
+	assert.EqualError(t, err, "Provider cmdb printed an address without a name or address")
+}
+
+func TestExecProviderDiscoverMissingExecutable(t *testing.T) {
+	provider := NewExecProvider("cmdb", "/notafile")
+	_, err := provider.Discover(map[string]string{"timeout": "1s"}, new(bytes.Buffer))
+	assert.EqualError(t, err, "Unable to run provider cmdb: fork/exec /notafile: no such file or directory")
+}
+
+func TestExecProviderComplete(t *testing.T) {
+	dir := setupPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	provider := NewExecProvider("cmdb", writePlugin(t, dir, "cmdb", testPlugin))
+	assert.Equal(t, []string{"zone-a", "zone-b"}, provider.Complete("zone", map[string]string{}))
+	assert.Nil(t, NewExecProvider("cmdb", "/notafile").Complete("zone", map[string]string{}))
+}
+
+func TestFindPlugins(t *testing.T) {
+	dir := setupPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	otherDir := setupPluginDir(t)
+	defer os.RemoveAll(otherDir)
+
+	cmdb := writePlugin(t, dir, "cmdb", testPlugin)
+	writePlugin(t, otherDir, "cmdb", testPlugin)
+	vpn := writePlugin(t, otherDir, "vpn", testPlugin)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(otherDir, PluginPrefix+"notes"), []byte("notes"), 0644))
+	assert.Nil(t, os.Mkdir(filepath.Join(otherDir, PluginPrefix+"dir"), 0755))
+
+	assert.Equal(
+		t,
+		map[string]string{"cmdb": cmdb, "vpn": vpn},
+		FindPlugins(dir+string(filepath.ListSeparator)+otherDir),
+	)
+}
+
+func TestRegistryWithPlugins(t *testing.T) {
+	dir := setupPluginDir(t)
+	defer os.RemoveAll(dir)
+
+	writePlugin(t, dir, "test", testPlugin)
+	writePlugin(t, dir, "cmdb", testPlugin)
+	registry := NewRegistry(testProvider{})
+	withPlugins := registry.WithPlugins(dir, map[string]string{"vpn": "/opt/vpn", "cmdb": "/opt/cmdb"})
+	assert.Equal(t, []string{"cmdb", "test", "vpn"}, withPlugins.Names())
+	assert.Equal(t, []string{"test"}, registry.Names())
+
+	provider, err := withPlugins.Get("test")
+	assert.Nil(t, err)
+	assert.Equal(t, testProvider{}, provider)
+
+	provider, err = withPlugins.Get("cmdb")
+	assert.Nil(t, err)
+	assert.Equal(t, "/opt/cmdb", provider.(*ExecProvider).Path)
+}
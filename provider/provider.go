@@ -0,0 +1,158 @@
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Setting describes a setting a provider accepts
+// File settings are completed with file names instead of asking the provider
+type Setting struct {
+	Name    string
+	Alias   string
+	Usage   string
+	Default string
+	EnvVar  string
+	File    bool
+}
+
+// Address is a named address found by a provider
+// An empty Host means the address belongs in the global IPs
+// The profile, region and resourceId metadata are recorded in the provenance of imported addresses
+type Address struct {
+	Host     string
+	Name     string
+	IP       string
+	Metadata map[string]string
+}
+
+const (
+	// ProfileMetadata is the metadata key for the account profile an address was found with
+	ProfileMetadata = "profile"
+	// RegionMetadata is the metadata key for the region an address was found in
+	RegionMetadata = "region"
+	// ResourceMetadata is the metadata key for the ID of the resource an address belongs to
+	ResourceMetadata = "resourceId"
+	// CurrentMetadata is the metadata key that makes a host option the host's current option when it is "true"
+	CurrentMetadata = "current"
+)
+
+// Provider discovers addresses from an external source
+type Provider interface {
+	// Name is the name sources use to refer to the provider
+	Name() string
+	// Schema lists the settings the provider accepts
+	Schema() []Setting
+	// Discover finds the addresses currently available, writing any non fatal problems to warnings
+	Discover(settings map[string]string, warnings io.Writer) ([]Address, error)
+	// Complete suggests values for a setting
+	Complete(setting string, settings map[string]string) []string
+}
+
+// Registry holds the providers available to sources
+type Registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry builds a registry containing the given providers
+func NewRegistry(providers ...Provider) *Registry {
+	registry := &Registry{providers: map[string]Provider{}}
+	for _, provider := range providers {
+		registry.Register(provider)
+	}
+
+	return registry
+}
+
+// Register adds a provider to the registry, replacing any provider with the same name
+func (registry *Registry) Register(provider Provider) {
+	registry.providers[provider.Name()] = provider
+}
+
+// Get finds a provider by name
+func (registry *Registry) Get(name string) (Provider, error) {
+	provider, exists := registry.providers[name]
+	if !exists {
+		return nil, fmt.Errorf("Unknown provider %s", name)
+	}
+
+	return provider, nil
+}
+
+// Names lists the registered providers
+func (registry *Registry) Names() []string {
+	names := make([]string, 0, len(registry.providers))
+	for name := range registry.providers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// ApplyDefaults fills in the default for every setting that was not given
+// An error is returned for settings the provider does not accept
+func ApplyDefaults(provider Provider, settings map[string]string) (map[string]string, error) {
+	schema := provider.Schema()
+	complete := make(map[string]string, len(schema))
+	for _, setting := range schema {
+		complete[setting.Name] = setting.Default
+	}
+
+	for name, value := range settings {
+		if _, exists := complete[name]; !exists {
+			return nil, fmt.Errorf("Unknown setting %s for provider %s", name, provider.Name())
+		}
+
+		if value != "" {
+			complete[name] = value
+		}
+	}
+
+	return complete, nil
+}
+
+// ParseSettings parses settings given as name=value
+func ParseSettings(args []string) (map[string]string, error) {
+	settings := make(map[string]string, len(args))
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Invalid setting %s, expected name=value", arg)
+		}
+
+		settings[parts[0]] = parts[1]
+	}
+
+	return settings, nil
+}
+
+// ResolveAddress returns IP addresses unchanged and looks up the first IP of a hostname
+func ResolveAddress(address string) (string, error) {
+	if net.ParseIP(address) != nil {
+		return address, nil
+	}
+
+	IPs, err := net.LookupHost(address)
+	if err != nil {
+		return "", fmt.Errorf("Unable to resolve %s", address)
+	}
+
+	return IPs[0], nil
+}
+
+// ExecuteTemplate renders a naming template
+func ExecuteTemplate(templ *template.Template, data interface{}) (string, error) {
+	var buffer bytes.Buffer
+	err := templ.Execute(&buffer, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"io"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testProvider struct{}
+
+func (testProvider) Name() string {
+	return "test"
+}
+
+func (testProvider) Schema() []Setting {
+	return []Setting{{Name: "region", Default: "us-east-1"}, {Name: "file"}}
+}
+
+func (testProvider) Discover(map[string]string, io.Writer) ([]Address, error) {
+	return nil, nil
+}
+
+func (testProvider) Complete(string, map[string]string) []string {
+	return nil
+}
+
+type otherProvider struct {
+	testProvider
+}
+
+func (otherProvider) Name() string {
+	return "other"
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(testProvider{})
+	registry.Register(otherProvider{})
+	assert.Equal(t, []string{"other", "test"}, registry.Names())
+
+	provider, err := registry.Get("other")
+	assert.Nil(t, err)
+	assert.Equal(t, otherProvider{}, provider)
+}
+
+func TestRegistryUnknownProvider(t *testing.T) {
+	_, err := NewRegistry().Get("missing")
+	assert.EqualError(t, err, "Unknown provider missing")
+}
+
+func TestApplyDefaults(t *testing.T) {
+	settings, err := ApplyDefaults(testProvider{}, map[string]string{"file": "foo", "region": ""})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"region": "us-east-1", "file": "foo"}, settings)
+}
+
+func TestApplyDefaultsUnknownSetting(t *testing.T) {
+	_, err := ApplyDefaults(testProvider{}, map[string]string{"zone": "a"})
+	assert.EqualError(t, err, "Unknown setting zone for provider test")
+}
+
+func TestParseSettings(t *testing.T) {
+	settings, err := ParseSettings([]string{"region=us-west-2", "template={{.Name}}=x", "file="})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"region": "us-west-2", "template": "{{.Name}}=x", "file": ""}, settings)
+}
+
+func TestParseSettingsInvalid(t *testing.T) {
+	_, err := ParseSettings([]string{"=foo"})
+	assert.EqualError(t, err, "Invalid setting =foo, expected name=value")
+}
+
+func TestResolveAddress(t *testing.T) {
+	IP, err := ResolveAddress("10.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.1", IP)
+}
+
+func TestExecuteTemplate(t *testing.T) {
+	name, err := ExecuteTemplate(template.Must(template.New("").Parse("{{.}}.local")), "foo")
+	assert.Nil(t, err)
+	assert.Equal(t, "foo.local", name)
+}
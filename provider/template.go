@@ -0,0 +1,26 @@
+package provider
+
+import (
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+var nonHostnameCharacters = regexp.MustCompile(`[^a-z0-9.-]+`)
+var repeatedSeparators = regexp.MustCompile(`-{2,}`)
+
+// TemplateFuncs are the helpers available in naming templates
+// replace takes the string last so it can be used in a pipeline like {{.Name | replace "_" "-"}}
+var TemplateFuncs = template.FuncMap{
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"replace": func(old, new, value string) string { return strings.Replace(value, old, new, -1) },
+	"slug":    Slug,
+}
+
+// Slug turns a string into a valid hostname by lower casing it and replacing anything else with dashes
+func Slug(value string) string {
+	slug := nonHostnameCharacters.ReplaceAllString(strings.ToLower(value), "-")
+	slug = repeatedSeparators.ReplaceAllString(slug, "-")
+	return strings.Trim(slug, "-.")
+}
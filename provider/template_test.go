@@ -0,0 +1,20 @@
+package provider
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	templ := template.Must(template.New("").Funcs(TemplateFuncs).Parse(`{{upper .}} {{lower .}} {{. | replace " " "_"}} {{slug .}}`))
+	name, err := ExecuteTemplate(templ, "Web Server")
+	assert.Nil(t, err)
+	assert.Equal(t, "WEB SERVER web server Web_Server web-server", name)
+}
+
+func TestSlug(t *testing.T) {
+	assert.Equal(t, "web-server-1.prod", Slug("  Web_Server #1.prod!"))
+	assert.Equal(t, "", Slug("!!!"))
+}
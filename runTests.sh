@@ -1,4 +1,4 @@
 #!/bin/bash
-for test in command config hosts; do
+for test in ansible awsUtil command config dockerUtil hosts kubeUtil provider terraform; do
     go test -cover "./${test}"
 done
@@ -0,0 +1,191 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/guywithnose/hostBuilder/provider"
+)
+
+// State is the part of a terraform state file that hostBuilder reads
+type State struct {
+	Version   int        `json:"version"`
+	Resources []Resource `json:"resources"`
+}
+
+// Resource is a resource block in a terraform state file
+type Resource struct {
+	Module    string     `json:"module,omitempty"`
+	Mode      string     `json:"mode"`
+	Type      string     `json:"type"`
+	Name      string     `json:"name"`
+	Instances []Instance `json:"instances"`
+}
+
+// Instance is a single instance of a resource, one for each count or for_each entry
+type Instance struct {
+	IndexKey   interface{}            `json:"index_key,omitempty"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+// Address is an IP address (or DNS name) found on a resource instance
+// Label is the resource address with indexes as labels, aws_instance.web["a"] is aws_instance.web.a
+type Address struct {
+	Address    string
+	Label      string
+	Module     string
+	Type       string
+	Name       string
+	Index      string
+	IP         string
+	Attributes map[string]interface{}
+}
+
+type addressReader func(attributes map[string]interface{}, private bool) string
+
+var addressReaders = map[string]addressReader{
+	"aws_instance":            readAwsInstanceAddress,
+	"aws_eip":                 readAwsInstanceAddress,
+	"aws_lb":                  readAwsLoadBalancerAddress,
+	"aws_alb":                 readAwsLoadBalancerAddress,
+	"google_compute_instance": readGoogleComputeInstanceAddress,
+}
+
+// ReadStateFile reads a version 4 terraform state file
+func ReadStateFile(fileName string) (*State, error) {
+	stateJSON, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var state = new(State)
+	err = json.Unmarshal(stateJSON, state)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Version != 4 {
+		return nil, fmt.Errorf("Unsupported state version %d, only version 4 is supported", state.Version)
+	}
+
+	return state, nil
+}
+
+// Addresses lists the public (or private) addresses of every supported managed resource in the state
+func (state *State) Addresses(private bool) []Address {
+	addresses := make([]Address, 0, len(state.Resources))
+	for _, resource := range state.Resources {
+		reader, supported := addressReaders[resource.Type]
+		if !supported || resource.Mode != "managed" {
+			continue
+		}
+
+		for _, instance := range resource.Instances {
+			IP := reader(instance.Attributes, private)
+			if IP == "" {
+				continue
+			}
+
+			index := formatIndex(instance.IndexKey)
+			addresses = append(addresses, Address{
+				Address:    resourceAddress(resource, instance.IndexKey),
+				Label:      resourceLabel(resource, index),
+				Module:     resource.Module,
+				Type:       resource.Type,
+				Name:       resource.Name,
+				Index:      index,
+				IP:         IP,
+				Attributes: instance.Attributes,
+			})
+		}
+	}
+
+	return addresses
+}
+
+func resourceAddress(resource Resource, indexKey interface{}) string {
+	parts := make([]string, 0, 3)
+	if resource.Module != "" {
+		parts = append(parts, resource.Module)
+	}
+
+	parts = append(parts, resource.Type, resource.Name)
+	address := strings.Join(parts, ".")
+	switch key := indexKey.(type) {
+	case float64:
+		address = fmt.Sprintf("%s[%d]", address, int(key))
+	case string:
+		address = fmt.Sprintf("%s[%q]", address, key)
+	}
+
+	return address
+}
+
+var moduleIndexRegex = regexp.MustCompile(`\["?([^"\]]*)"?\]`)
+
+// resourceLabel joins the parts of a resource address with dots and turns every index into a hostname label
+func resourceLabel(resource Resource, index string) string {
+	parts := make([]string, 0, 4)
+	if resource.Module != "" {
+		parts = append(parts, moduleIndexRegex.ReplaceAllStringFunc(resource.Module, func(moduleIndex string) string {
+			return "." + provider.Slug(moduleIndexRegex.FindStringSubmatch(moduleIndex)[1])
+		}))
+	}
+
+	parts = append(parts, resource.Type, resource.Name)
+	if index != "" {
+		parts = append(parts, provider.Slug(index))
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func formatIndex(indexKey interface{}) string {
+	switch key := indexKey.(type) {
+	case float64:
+		return fmt.Sprintf("%d", int(key))
+	case string:
+		return key
+	}
+
+	return ""
+}
+
+func readAwsInstanceAddress(attributes map[string]interface{}, private bool) string {
+	if private {
+		return stringAttribute(attributes, "private_ip")
+	}
+
+	return stringAttribute(attributes, "public_ip")
+}
+
+func readAwsLoadBalancerAddress(attributes map[string]interface{}, _ bool) string {
+	return stringAttribute(attributes, "dns_name")
+}
+
+func readGoogleComputeInstanceAddress(attributes map[string]interface{}, private bool) string {
+	networkInterface := firstBlock(attributes, "network_interface")
+	if private {
+		return stringAttribute(networkInterface, "network_ip")
+	}
+
+	return stringAttribute(firstBlock(networkInterface, "access_config"), "nat_ip")
+}
+
+func firstBlock(attributes map[string]interface{}, name string) map[string]interface{} {
+	blocks, _ := attributes[name].([]interface{})
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	block, _ := blocks[0].(map[string]interface{})
+	return block
+}
+
+func stringAttribute(attributes map[string]interface{}, name string) string {
+	value, _ := attributes[name].(string)
+	return value
+}
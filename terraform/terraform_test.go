@@ -0,0 +1,156 @@
+package terraform
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testState = `{
+  "version": 4,
+  "terraform_version": "1.5.7",
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "instances": [
+        {"index_key": 0, "attributes": {"id": "i-1", "public_ip": "54.0.0.1", "private_ip": "10.0.0.1"}},
+        {"index_key": 1, "attributes": {"id": "i-2", "public_ip": "", "private_ip": "10.0.0.2"}}
+      ]
+    },
+    {
+      "module": "module.edge",
+      "mode": "managed",
+      "type": "aws_eip",
+      "name": "nat",
+      "instances": [
+        {"index_key": "us-east-1a", "attributes": {"public_ip": "54.0.0.3", "private_ip": "10.0.0.3"}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_lb",
+      "name": "api",
+      "instances": [
+        {"attributes": {"dns_name": "api-123.us-east-1.elb.amazonaws.com"}}
+      ]
+    },
+    {
+      "mode": "managed",
+      "type": "google_compute_instance",
+      "name": "worker",
+      "instances": [
+        {
+          "attributes": {
+            "network_interface": [{"network_ip": "10.2.0.1", "access_config": [{"nat_ip": "35.0.0.1"}]}]
+          }
+        }
+      ]
+    },
+    {
+      "mode": "data",
+      "type": "aws_instance",
+      "name": "lookup",
+      "instances": [{"attributes": {"public_ip": "54.0.0.9"}}]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_s3_bucket",
+      "name": "assets",
+      "instances": [{"attributes": {"bucket": "assets"}}]
+    }
+  ]
+}`
+
+func writeStateFile(t *testing.T, state string) string {
+	stateFile, err := ioutil.TempFile("/tmp", "tfstate")
+	assert.Nil(t, err)
+
+	err = ioutil.WriteFile(stateFile.Name(), []byte(state), 0644)
+	assert.Nil(t, err)
+
+	return stateFile.Name()
+}
+
+func TestAddressesPublic(t *testing.T) {
+	stateFileName := writeStateFile(t, testState)
+	defer removeFile(t, stateFileName)
+
+	state, err := ReadStateFile(stateFileName)
+	assert.Nil(t, err)
+
+	addresses := state.Addresses(false)
+	assert.Equal(t, 4, len(addresses))
+	assert.Equal(t, "aws_instance.web[0]", addresses[0].Address)
+	assert.Equal(t, "aws_instance.web.0", addresses[0].Label)
+	assert.Equal(t, "0", addresses[0].Index)
+	assert.Equal(t, "54.0.0.1", addresses[0].IP)
+	assert.Equal(t, "i-1", addresses[0].Attributes["id"])
+	assert.Equal(t, `module.edge.aws_eip.nat["us-east-1a"]`, addresses[1].Address)
+	assert.Equal(t, "module.edge.aws_eip.nat.us-east-1a", addresses[1].Label)
+	assert.Equal(t, "module.edge", addresses[1].Module)
+	assert.Equal(t, "us-east-1a", addresses[1].Index)
+	assert.Equal(t, "54.0.0.3", addresses[1].IP)
+	assert.Equal(t, "aws_lb.api", addresses[2].Address)
+	assert.Equal(t, "aws_lb.api", addresses[2].Label)
+	assert.Equal(t, "", addresses[2].Index)
+	assert.Equal(t, "api-123.us-east-1.elb.amazonaws.com", addresses[2].IP)
+	assert.Equal(t, "google_compute_instance.worker", addresses[3].Address)
+	assert.Equal(t, "35.0.0.1", addresses[3].IP)
+}
+
+func TestResourceLabel(t *testing.T) {
+	resource := Resource{Module: `module.app["Blue Green"].module.db[0]`, Type: "aws_instance", Name: "primary"}
+	assert.Equal(t, "module.app.blue-green.module.db.0.aws_instance.primary.us-east-1a", resourceLabel(resource, "us_east_1A"))
+	assert.Equal(t, "module.app.blue-green.module.db.0.aws_instance.primary", resourceLabel(resource, ""))
+}
+
+func TestAddressesPrivate(t *testing.T) {
+	stateFileName := writeStateFile(t, testState)
+	defer removeFile(t, stateFileName)
+
+	state, err := ReadStateFile(stateFileName)
+	assert.Nil(t, err)
+
+	IPs := map[string]string{}
+	for _, address := range state.Addresses(true) {
+		IPs[address.Address] = address.IP
+	}
+
+	expectedIPs := map[string]string{
+		"aws_instance.web[0]":                   "10.0.0.1",
+		"aws_instance.web[1]":                   "10.0.0.2",
+		`module.edge.aws_eip.nat["us-east-1a"]`: "10.0.0.3",
+		"aws_lb.api":                            "api-123.us-east-1.elb.amazonaws.com",
+		"google_compute_instance.worker":        "10.2.0.1",
+	}
+	assert.Equal(t, expectedIPs, IPs)
+}
+
+func TestReadStateFileUnsupportedVersion(t *testing.T) {
+	stateFileName := writeStateFile(t, `{"version": 3, "modules": []}`)
+	defer removeFile(t, stateFileName)
+
+	_, err := ReadStateFile(stateFileName)
+	assert.EqualError(t, err, "Unsupported state version 3, only version 4 is supported")
+}
+
+func TestReadStateFileInvalidJSON(t *testing.T) {
+	stateFileName := writeStateFile(t, `{`)
+	defer removeFile(t, stateFileName)
+
+	_, err := ReadStateFile(stateFileName)
+	assert.EqualError(t, err, "unexpected end of JSON input")
+}
+
+func TestReadStateFileMissingFile(t *testing.T) {
+	_, err := ReadStateFile("/tmp/doesntexist.tfstate")
+	assert.EqualError(t, err, "open /tmp/doesntexist.tfstate: no such file or directory")
+}
+
+func removeFile(t *testing.T, fileName string) {
+	assert.Nil(t, os.Remove(fileName))
+}